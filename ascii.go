@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// asciiWriter rewrites everything written through it to printable ASCII:
+// non-ASCII characters become \uXXXX escapes and control characters other
+// than newline and tab, including the ESC of terminal color sequences, are
+// dropped. Escapes inside JSON strings are valid JSON, so every output format
+// stays well formed.
+type asciiWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+	// partial holds the start of a multi-byte character split across writes.
+	partial []byte
+}
+
+// newASCIIWriter returns a writer that forwards ASCII-safe output to w
+func newASCIIWriter(w io.Writer) *asciiWriter {
+	return &asciiWriter{w: w}
+}
+
+func (a *asciiWriter) Write(p []byte) (int, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	data := append(a.partial, p...)
+	a.partial = nil
+
+	var b strings.Builder
+	for len(data) > 0 {
+		if !utf8.FullRune(data) {
+			a.partial = append([]byte{}, data...)
+			break
+		}
+		r, size := utf8.DecodeRune(data)
+		data = data[size:]
+		switch {
+		case r == '\n' || r == '\t' || (r >= ' ' && r < utf8.RuneSelf && r != 0x7f):
+			b.WriteRune(r)
+		case r < ' ' || r == 0x7f:
+			// drop control characters
+		case r > 0xffff:
+			// JSON only knows \uXXXX, so write a surrogate pair
+			r1, r2 := utf16.EncodeRune(r)
+			fmt.Fprintf(&b, "\\u%04x\\u%04x", r1, r2)
+		default:
+			fmt.Fprintf(&b, "\\u%04x", r)
+		}
+	}
+	if _, err := io.WriteString(a.w, b.String()); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestASCIIWriter(t *testing.T) {
+	tests := []struct {
+		name   string
+		writes []string
+		want   string
+	}{
+		{name: "ascii unchanged", writes: []string{"prod\tus-east-1\n"}, want: "prod\tus-east-1\n"},
+		{name: "non-ascii escaped", writes: []string{"café"}, want: `caf\u00e9`},
+		{name: "astral plane as surrogate pair", writes: []string{"🚀"}, want: `\ud83d\ude80`},
+		{name: "color sequences dropped", writes: []string{"\x1b[31mEOL\x1b[0m\r\x7f"}, want: "[31mEOL[0m"},
+		{name: "character split across writes", writes: []string{"€"[:1], "€"[1:2], "€"[2:] + "!"}, want: `\u20ac!`},
+		{name: "invalid utf-8", writes: []string{"a\xffb"}, want: `a\ufffdb`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			w := newASCIIWriter(&buf)
+			for _, s := range tt.writes {
+				if n, err := w.Write([]byte(s)); err != nil || n != len(s) {
+					t.Fatalf("Write(%q) = %d, %v", s, n, err)
+				}
+			}
+			if buf.String() != tt.want {
+				t.Errorf("output = %q, want %q", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestASCIIWriterKeepsJSONValid(t *testing.T) {
+	result := sampleResult()
+	result.AccountAlias = "équipe 🚀"
+	var buf bytes.Buffer
+	if err := printJSON(newASCIIWriter(&buf), result, renderOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range buf.Bytes() {
+		if c >= 0x80 {
+			t.Fatalf("output has non-ASCII byte %#x", c)
+		}
+	}
+	var decoded struct {
+		AccountAlias string `json:"accountAlias"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.AccountAlias != result.AccountAlias {
+		t.Errorf("alias = %q, want %q", decoded.AccountAlias, result.AccountAlias)
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"io"
+	"time"
+
+	"shift-left-shuffle/scanner"
+)
+
+// configResourceType is the AWS Config resource type of EKS clusters
+const configResourceType = "AWS::EKS::Cluster"
+
+// configItems is the document written by --output config, shaped like the
+// response of config:GetResourceConfigHistory
+type configItems struct {
+	ConfigurationItems []configItem `json:"configurationItems"`
+}
+
+// configItem is one cluster as an AWS Config configuration item. The
+// configuration block is the cluster as reported by the scan, so its keys
+// follow this tool's JSON output rather than the EKS API.
+type configItem struct {
+	Version                      string            `json:"version"`
+	AccountID                    string            `json:"accountId"`
+	ConfigurationItemCaptureTime time.Time         `json:"configurationItemCaptureTime"`
+	ConfigurationItemStatus      string            `json:"configurationItemStatus"`
+	ARN                          string            `json:"arn"`
+	ResourceType                 string            `json:"resourceType"`
+	ResourceID                   string            `json:"resourceId"`
+	ResourceName                 string            `json:"resourceName"`
+	AWSRegion                    string            `json:"awsRegion"`
+	AvailabilityZone             string            `json:"availabilityZone"`
+	ResourceCreationTime         *time.Time        `json:"resourceCreationTime,omitempty"`
+	Tags                         map[string]string `json:"tags"`
+	Configuration                scanner.Cluster   `json:"configuration"`
+}
+
+// printConfigItems writes the clusters of all results as a single document
+// of AWS Config configuration items. Undescribed clusters are reported as
+// ResourceNotRecorded since only their name and region are known.
+func printConfigItems(w io.Writer, results []*scanner.ScanResult, compact bool) error {
+	doc := configItems{ConfigurationItems: []configItem{}}
+	for _, result := range results {
+		for _, c := range result.Clusters {
+			status := "OK"
+			if c.Undescribed {
+				status = "ResourceNotRecorded"
+			}
+			partition := result.Partition
+			if partition == "" {
+				partition = scanner.RegionPartition(c.Region)
+			}
+			tags := c.Tags
+			if tags == nil {
+				tags = map[string]string{}
+			}
+			doc.ConfigurationItems = append(doc.ConfigurationItems, configItem{
+				Version:                      "1.3",
+				AccountID:                    result.Account,
+				ConfigurationItemCaptureTime: result.GeneratedAt.UTC(),
+				ConfigurationItemStatus:      status,
+				ARN:                          scanner.ClusterARN{Partition: partition, Region: c.Region, Account: result.Account, Name: c.Name}.String(),
+				ResourceType:                 configResourceType,
+				ResourceID:                   c.Name,
+				ResourceName:                 c.Name,
+				AWSRegion:                    c.Region,
+				AvailabilityZone:             "Regional",
+				ResourceCreationTime:         c.CreatedAt,
+				Tags:                         tags,
+				Configuration:                c,
+			})
+		}
+	}
+	return encodeJSON(w, doc, compact)
+}
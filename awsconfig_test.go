@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"shift-left-shuffle/scanner"
+)
+
+func TestPrintConfigItems(t *testing.T) {
+	described := sampleResult()
+	gov := &scanner.ScanResult{Account: "210987654321", GeneratedAt: described.GeneratedAt, Clusters: []scanner.Cluster{
+		{Name: "hidden", Region: "us-gov-west-1", Undescribed: true, DescribeError: "AccessDenied"},
+	}}
+
+	var buf bytes.Buffer
+	if err := printConfigItems(&buf, []*scanner.ScanResult{described, gov}, true); err != nil {
+		t.Fatal(err)
+	}
+	var doc configItems
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name       string
+		wantARN    string
+		wantStatus string
+		wantTags   map[string]string
+		wantCreate bool
+	}{
+		{name: "prod", wantARN: "arn:aws:eks:us-east-1:123456789012:cluster/prod", wantStatus: "OK", wantTags: map[string]string{"env": "prod"}, wantCreate: true},
+		{name: "legacy", wantARN: "arn:aws:eks:eu-west-1:123456789012:cluster/legacy", wantStatus: "OK", wantTags: map[string]string{"env": "dev"}, wantCreate: true},
+		{name: "hidden", wantARN: "arn:aws-us-gov:eks:us-gov-west-1:210987654321:cluster/hidden", wantStatus: "ResourceNotRecorded", wantTags: map[string]string{}},
+	}
+	if len(doc.ConfigurationItems) != len(tests) {
+		t.Fatalf("%d configuration items, want %d", len(doc.ConfigurationItems), len(tests))
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			item := doc.ConfigurationItems[i]
+			if item.ResourceName != tt.name || item.ResourceID != tt.name || item.Configuration.Name != tt.name {
+				t.Errorf("item names = %q, %q, %q", item.ResourceName, item.ResourceID, item.Configuration.Name)
+			}
+			if item.ARN != tt.wantARN || item.ConfigurationItemStatus != tt.wantStatus {
+				t.Errorf("item = arn %q, status %q; want %q, %q", item.ARN, item.ConfigurationItemStatus, tt.wantARN, tt.wantStatus)
+			}
+			if item.ResourceType != configResourceType || item.Version != "1.3" || item.AvailabilityZone != "Regional" {
+				t.Errorf("item = %+v", item)
+			}
+			if !item.ConfigurationItemCaptureTime.Equal(time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)) {
+				t.Errorf("capture time = %s, want the scan time", item.ConfigurationItemCaptureTime)
+			}
+			if (item.ResourceCreationTime != nil) != tt.wantCreate {
+				t.Errorf("creation time = %v", item.ResourceCreationTime)
+			}
+			if len(item.Tags) != len(tt.wantTags) || item.Tags == nil {
+				t.Errorf("tags = %v, want %v", item.Tags, tt.wantTags)
+			}
+			for k, v := range tt.wantTags {
+				if item.Tags[k] != v {
+					t.Errorf("tags = %v, want %v", item.Tags, tt.wantTags)
+				}
+			}
+		})
+	}
+	if !bytes.Contains(buf.Bytes(), []byte(`"tags":{}`)) {
+		t.Error("untagged cluster does not carry an empty tags object")
+	}
+}
+
+func TestPrintConfigItemsEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	if err := printConfigItems(&buf, []*scanner.ScanResult{{Account: "123456789012"}}, true); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); got != `{"configurationItems":[]}`+"\n" {
+		t.Errorf("empty scan = %s", got)
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"path/filepath"
+
+	"shift-left-shuffle/scanner"
+)
+
+// embedsChecksum reports whether the output is a JSON document of scan
+// results, which carry their own checksum field instead of a trailer
+func embedsChecksum(f *cliFlags) bool {
+	return f.output == "json" && f.query == nil && f.groupKeys == nil && !f.summaryOnly
+}
+
+// setChecksums stamps every result with its ResultChecksum
+func setChecksums(results []*scanner.ScanResult) error {
+	for _, result := range results {
+		sum, err := scanner.ResultChecksum(result)
+		if err != nil {
+			return err
+		}
+		result.Checksum = sum
+	}
+	return nil
+}
+
+// writeChecksumTrailer reports the SHA-256 of everything written to digest.
+// Human-readable output gets a final "sha256: <hex>" line on stdout; machine
+// output keeps stdout parseable and reports the checksum on stderr instead.
+func writeChecksumTrailer(f *cliFlags, stdout, stderr io.Writer, digest hash.Hash) error {
+	sum := hex.EncodeToString(digest.Sum(nil))
+	if (f.output == "text" || f.output == "markdown") && f.query == nil {
+		_, err := fmt.Fprintf(stdout, "sha256: %s\n", sum)
+		return err
+	}
+	_, err := fmt.Fprintf(stderr, "Output sha256: %s\n", sum)
+	return err
+}
+
+// writeChecksumFile writes the detached checksum of data next to path, as
+// path.sha256 in the format read by sha256sum -c
+func writeChecksumFile(path string, data []byte) error {
+	sum := sha256.Sum256(data)
+	line := fmt.Sprintf("%s  %s\n", hex.EncodeToString(sum[:]), filepath.Base(path))
+	return writeFileAtomic(path+".sha256", func(w io.Writer) error {
+		_, err := io.WriteString(w, line)
+		return err
+	})
+}
+
+// outputWriter returns the writer the output is rendered into on its way to
+// sink, and the digest of a checksum trailer, if any. The output is hashed
+// unless the results carry their own checksum or it goes to a file with a
+// detached checksum; the digest sits below the --ascii rewriting so it
+// covers the bytes actually written.
+func outputWriter(f *cliFlags, sink io.Writer) (io.Writer, hash.Hash) {
+	var digest hash.Hash
+	if f.checksum && f.outputDir == "" && f.outputFile == "" && !embedsChecksum(f) {
+		digest = newDigest()
+		sink = io.MultiWriter(sink, digest)
+	}
+	if f.ascii {
+		return newASCIIWriter(sink), digest
+	}
+	return sink, digest
+}
+
+// newDigest returns the hash used for output checksums
+func newDigest() hash.Hash {
+	return sha256.New()
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"shift-left-shuffle/scanner"
+)
+
+// testFlags parses args into the CLI flags, failing the test on error
+func testFlags(t *testing.T, args ...string) *cliFlags {
+	t.Helper()
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	f, err := parseFlags(fs, args)
+	if err != nil {
+		t.Fatalf("parseFlags(%q): %v", args, err)
+	}
+	return f
+}
+
+func TestOutputWriterHashesWrittenBytes(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"plain", []string{"--checksum"}},
+		{"ascii", []string{"--checksum", "--ascii"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sink bytes.Buffer
+			out, digest := outputWriter(testFlags(t, tt.args...), &sink)
+			if digest == nil {
+				t.Fatal("no digest for text output with --checksum")
+			}
+			io.WriteString(out, "cluster café in eu-west-1\n")
+			want := sha256.Sum256(sink.Bytes())
+			if got := digest.Sum(nil); !bytes.Equal(got, want[:]) {
+				t.Errorf("digest %x does not match the %q written", got, sink.String())
+			}
+		})
+	}
+}
+
+func TestOutputWriterSkipsDigest(t *testing.T) {
+	for _, args := range [][]string{
+		{"--output", "json", "--checksum"},
+		{"--checksum", "--output-file", "scan.txt"},
+		{},
+	} {
+		if _, digest := outputWriter(testFlags(t, args...), io.Discard); digest != nil {
+			t.Errorf("outputWriter(%q) returned a digest", args)
+		}
+	}
+}
+
+func TestWriteOutputFileChecksum(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scan.txt")
+	data := []byte("Cluster prod (us-east-1)\n")
+	if err := writeOutputFile(path, data, true); err != nil {
+		t.Fatal(err)
+	}
+	written, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sidecar, err := os.ReadFile(path + ".sha256")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(written)
+	if want := hex.EncodeToString(sum[:]) + "  scan.txt\n"; string(sidecar) != want {
+		t.Errorf("sidecar = %q, want %q", sidecar, want)
+	}
+}
+
+func TestSetChecksumsMatchesRecomputation(t *testing.T) {
+	result := &scanner.ScanResult{Account: "123456789012", Regions: []string{"us-east-1"}, Clusters: []scanner.Cluster{{Name: "prod", Region: "us-east-1"}}}
+	if err := setChecksums([]*scanner.ScanResult{result}); err != nil {
+		t.Fatal(err)
+	}
+	want, err := scanner.ResultChecksum(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Checksum == "" || result.Checksum != want {
+		t.Errorf("Checksum = %q, recomputed %q", result.Checksum, want)
+	}
+	result.Clusters[0].Name = "tampered"
+	if tampered, _ := scanner.ResultChecksum(result); tampered == want {
+		t.Error("checksum did not change with the results")
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"shift-left-shuffle/scanner"
+)
+
+// runDiff implements "diff <old> <new>". It returns whether the scans differ.
+func runDiff(args []string) (bool, error) {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	output := fs.String("output", "text", "Output format: text or json")
+	strictJSON := fs.Bool("strict-json", false, "Fail on fields the scanner does not know instead of ignoring them")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: shift-left-shuffle diff [--output text|json] [--strict-json] <old.json> <new.json>")
+		fmt.Fprintln(fs.Output(), "Inputs may be a saved JSON scan or an NDJSON stream.")
+		fs.PrintDefaults()
+	}
+	if err := parseSubcommand(fs, args); err != nil {
+		return false, err
+	}
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return false, fmt.Errorf("diff needs exactly two files, got %d", fs.NArg())
+	}
+	if *output != "text" && *output != "json" {
+		return false, fmt.Errorf("unsupported diff output format %q: must be text or json", *output)
+	}
+
+	old, err := readClustersFile(fs.Arg(0), *strictJSON)
+	if err != nil {
+		return false, err
+	}
+	cur, err := readClustersFile(fs.Arg(1), *strictJSON)
+	if err != nil {
+		return false, err
+	}
+
+	diff := scanner.DiffClusters(old, cur)
+	if *output == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(diff)
+	} else {
+		err = printDiff(os.Stdout, diff)
+	}
+	return !diff.Empty(), err
+}
+
+// readClustersFile reads the clusters of a saved scan, rejecting unknown
+// fields with strict
+func readClustersFile(path string, strict bool) ([]scanner.AccountCluster, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	read := scanner.ReadClusters
+	if strict {
+		read = scanner.ReadClustersStrict
+	}
+	clusters, err := read(f)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	return clusters, nil
+}
+
+// printDiff writes one line per added, removed or changed cluster
+func printDiff(w io.Writer, diff *scanner.Diff) error {
+	if diff.Empty() {
+		_, err := fmt.Fprintln(w, "No differences")
+		return err
+	}
+	for _, ref := range diff.Added {
+		if _, err := fmt.Fprintf(w, "+ %s/%s/%s\n", ref.Account, ref.Region, ref.Name); err != nil {
+			return err
+		}
+	}
+	for _, ref := range diff.Removed {
+		if _, err := fmt.Fprintf(w, "- %s/%s/%s\n", ref.Account, ref.Region, ref.Name); err != nil {
+			return err
+		}
+	}
+	for _, change := range diff.Changed {
+		if _, err := fmt.Fprintf(w, "~ %s/%s/%s: %v\n", change.Account, change.Region, change.Name, change.Fields); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"shift-left-shuffle/scanner"
+)
+
+func TestPrintDiff(t *testing.T) {
+	ref := func(name string) scanner.ClusterRef {
+		return scanner.ClusterRef{Account: "123456789012", Region: "us-east-1", Name: name}
+	}
+	tests := []struct {
+		name string
+		diff *scanner.Diff
+		want string
+	}{
+		{name: "empty", diff: &scanner.Diff{}, want: "No differences\n"},
+		{
+			name: "every kind",
+			diff: &scanner.Diff{
+				Added:   []scanner.ClusterRef{ref("new")},
+				Removed: []scanner.ClusterRef{ref("old")},
+				Changed: []scanner.ClusterChange{{ClusterRef: ref("prod"), Fields: []string{"version", "tags"}}},
+			},
+			want: "+ 123456789012/us-east-1/new\n- 123456789012/us-east-1/old\n~ 123456789012/us-east-1/prod: [version tags]\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := printDiff(&buf, tt.diff); err != nil {
+				t.Fatal(err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("printDiff =\n%s\nwant\n%s", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestReadClustersFile(t *testing.T) {
+	json := writeScan(t, sampleResult())
+	clusters, err := readClustersFile(json, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(clusters) != 2 || clusters[0].Account != "123456789012" {
+		t.Errorf("clusters = %+v, want the two sample clusters", clusters)
+	}
+	if _, err := readClustersFile(json+".missing", false); err == nil {
+		t.Error("reading a missing file succeeded")
+	}
+}
@@ -0,0 +1,20 @@
+package main
+
+import (
+	"context"
+
+	"shift-left-shuffle/scanner"
+)
+
+// exportDynamoDB upserts the clusters of results into the --dynamodb-table
+// table, using the credentials and region of --profile (or the default chain)
+// whichever profiles were scanned
+func exportDynamoDB(ctx context.Context, f *cliFlags, results []*scanner.ScanResult) (int, error) {
+	factory := scanner.NewDefaultClientFactory(&scanner.DefaultConfigLoader{Profile: f.profile, MFATokenProvider: f.mfaProvider})
+	factory.HTTPClient = f.httpClient
+	client, err := factory.DynamoDB(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return scanner.ExportDynamoDB(ctx, client, f.dynamoDBTable, results...)
+}
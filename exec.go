@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"shift-left-shuffle/scanner"
+)
+
+const (
+	// maxExecOutput bounds the command output kept on a cluster
+	maxExecOutput = 4096
+	// execTruncatedMarker ends output cut at maxExecOutput
+	execTruncatedMarker = "... [truncated]"
+	// execWaitDelay is how long output is still read after a command times out
+	execWaitDelay = time.Second
+)
+
+// runExec runs command once per cluster of results, with the cluster's NDJSON
+// record on stdin, and records the exit code and trimmed standard output, cut
+// at maxExecOutput bytes, on the cluster. The command is split on spaces and
+// run without a shell. At most concurrency commands run at once, each limited
+// to timeout; the command's standard error goes to stderr.
+func runExec(ctx context.Context, command string, concurrency int, timeout time.Duration, results []*scanner.ScanResult, stderr io.Writer) {
+	argv := strings.Fields(command)
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	for _, result := range results {
+		for i := range result.Clusters {
+			c := &result.Clusters[i]
+			record := scanner.AccountCluster{Account: result.Account, Labels: result.Labels, Cluster: *c}
+			wg.Add(1)
+			sem <- struct{}{}
+			go func() {
+				defer wg.Done()
+				defer func() { <-sem }()
+				var errOut bytes.Buffer
+				res := execCluster(ctx, argv, timeout, record, &errOut)
+				c.Exec = res
+				if errOut.Len() > 0 {
+					mu.Lock()
+					stderr.Write(errOut.Bytes())
+					mu.Unlock()
+				}
+			}()
+		}
+	}
+	wg.Wait()
+}
+
+// execCluster runs argv for one cluster record and returns the outcome
+func execCluster(ctx context.Context, argv []string, timeout time.Duration, record scanner.AccountCluster, stderr io.Writer) *scanner.ExecResult {
+	input, err := json.Marshal(record)
+	if err != nil {
+		return &scanner.ExecResult{ExitCode: -1, Error: err.Error()}
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var out bytes.Buffer
+	cmd := exec.CommandContext(ctx, argv[0], argv[1:]...)
+	cmd.Stdin = bytes.NewReader(append(input, '\n'))
+	cmd.Stdout = &out
+	cmd.Stderr = stderr
+	// Children left behind by a killed command must not hold up the scan
+	cmd.WaitDelay = execWaitDelay
+	err = cmd.Run()
+
+	res := &scanner.ExecResult{Output: truncateOutput(strings.TrimSpace(out.String()), maxExecOutput)}
+	var exitErr *exec.ExitError
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		res.ExitCode = -1
+		res.Error = fmt.Sprintf("timed out after %s", timeout)
+	case errors.As(err, &exitErr):
+		res.ExitCode = exitErr.ExitCode()
+	case err != nil:
+		res.ExitCode = -1
+		res.Error = err.Error()
+	}
+	return res
+}
+
+// truncateOutput cuts s to at most limit bytes, backing off to the start of a
+// rune so multi-byte characters are not split, and marks the cut with
+// execTruncatedMarker
+func truncateOutput(s string, limit int) string {
+	if len(s) <= limit {
+		return s
+	}
+	cut := limit
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + execTruncatedMarker
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"shift-left-shuffle/scanner"
+)
+
+func TestTruncateOutput(t *testing.T) {
+	tests := []struct {
+		name  string
+		in    string
+		limit int
+		want  string
+	}{
+		{name: "short", in: "ok", limit: 4, want: "ok"},
+		{name: "exact", in: "abcd", limit: 4, want: "abcd"},
+		{name: "ascii", in: "abcdef", limit: 4, want: "abcd" + execTruncatedMarker},
+		{name: "cut inside a rune", in: "ab€cd", limit: 3, want: "ab" + execTruncatedMarker},
+		{name: "cut after a rune", in: "ab€cd", limit: 5, want: "ab€" + execTruncatedMarker},
+		{name: "leading multi-byte rune", in: "日本語", limit: 2, want: execTruncatedMarker},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := truncateOutput(tt.in, tt.limit)
+			if got != tt.want {
+				t.Errorf("truncateOutput(%q, %d) = %q, want %q", tt.in, tt.limit, got, tt.want)
+			}
+			if !utf8.ValidString(got) {
+				t.Errorf("truncateOutput(%q, %d) = %q, not valid UTF-8", tt.in, tt.limit, got)
+			}
+		})
+	}
+}
+
+func TestRunExec(t *testing.T) {
+	dir := t.TempDir()
+	script := func(name, body string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("#!/bin/sh\n"+body+"\n"), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	tests := []struct {
+		name       string
+		command    string
+		wantCode   int
+		wantOutput string
+		wantError  string
+	}{
+		{name: "echo", command: script("echo.sh", `grep -o '"name":"[a-z]*"'`), wantOutput: `"name":"prod"`},
+		{name: "exit code", command: script("fail.sh", "echo failing; exit 3"), wantCode: 3, wantOutput: "failing"},
+		{name: "long output", command: script("long.sh", `i=0; while [ $i -lt 2000 ]; do printf '€'; i=$((i+1)); done`), wantOutput: strings.Repeat("€", maxExecOutput/3) + execTruncatedMarker},
+		{name: "timeout", command: script("slow.sh", "sleep 5"), wantCode: -1, wantError: "timed out"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := &scanner.ScanResult{Account: "123456789012", Clusters: []scanner.Cluster{{Name: "prod", Region: "us-east-1"}}}
+			runExec(context.Background(), tt.command, 1, 500*time.Millisecond, []*scanner.ScanResult{result}, io.Discard)
+			res := result.Clusters[0].Exec
+			if res == nil {
+				t.Fatal("no exec result recorded")
+			}
+			if res.ExitCode != tt.wantCode || res.Output != tt.wantOutput || !strings.Contains(res.Error, tt.wantError) {
+				t.Errorf("exec = code %d, output %.40q (%d bytes), error %q; want code %d, output %.40q, error %q",
+					res.ExitCode, res.Output, len(res.Output), res.Error, tt.wantCode, tt.wantOutput, tt.wantError)
+			}
+		})
+	}
+}
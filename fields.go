@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"shift-left-shuffle/scanner"
+)
+
+// parseFields splits the --fields value and checks every name against the
+// JSON field names of a cluster
+func parseFields(v string) ([]string, error) {
+	known := jsonFieldNames(reflect.TypeOf(scanner.Cluster{}))
+	fields := splitList(v)
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("--fields lists no fields")
+	}
+	for _, field := range fields {
+		if !slices.Contains(known, field) {
+			return nil, fmt.Errorf("unknown --fields name %q: must be one of %s", field, strings.Join(known, ", "))
+		}
+	}
+	return fields, nil
+}
+
+// jsonFieldNames returns the JSON names of the exported fields of t, in
+// declaration order, flattening embedded structs as encoding/json does
+func jsonFieldNames(t reflect.Type) []string {
+	var names []string
+	for i := range t.NumField() {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" || !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			names = append(names, jsonFieldNames(field.Type)...)
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// projectFields returns the JSON document of v with every cluster object
+// reduced to fields: the clusters of a scan, of each profile of a
+// multi-profile scan, or v itself when it is a single NDJSON cluster record,
+// which keeps its account
+func projectFields(v any, fields []string) (any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	if _, ok := v.(scanner.AccountCluster); ok {
+		return keepFields(doc, append([]string{"account"}, fields...)), nil
+	}
+	scans := []any{doc}
+	if profiles, ok := doc["profiles"].([]any); ok {
+		scans = profiles
+	}
+	for _, scan := range scans {
+		scan, _ := scan.(map[string]any)
+		clusters, _ := scan["clusters"].([]any)
+		for i, c := range clusters {
+			if c, ok := c.(map[string]any); ok {
+				clusters[i] = keepFields(c, fields)
+			}
+		}
+	}
+	return doc, nil
+}
+
+// keepFields deletes the keys of obj not in fields and returns it
+func keepFields(obj map[string]any, fields []string) map[string]any {
+	for key := range obj {
+		if !slices.Contains(fields, key) {
+			delete(obj, key)
+		}
+	}
+	return obj
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"shift-left-shuffle/scanner"
+)
+
+func TestParseFields(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    []string
+		wantErr string
+	}{
+		{value: "name,version", want: []string{"name", "version"}},
+		{value: " name , region ", want: []string{"name", "region"}},
+		{value: "", wantErr: "lists no fields"},
+		{value: "name,versoin", wantErr: `unknown --fields name "versoin"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseFields(tt.value)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("fields = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJSONFieldNames(t *testing.T) {
+	type Inner struct {
+		Depth int `json:"depth"`
+	}
+	type sample struct {
+		Inner
+		Name    string `json:"name,omitempty"`
+		Skipped string `json:"-"`
+		Plain   string
+		private string
+	}
+	if got, want := jsonFieldNames(reflect.TypeOf(sample{})), []string{"depth", "name", "Plain"}; !slices.Equal(got, want) {
+		t.Errorf("jsonFieldNames = %q, want %q", got, want)
+	}
+}
+
+func TestProjectFields(t *testing.T) {
+	fields := []string{"name", "version"}
+	tests := []struct {
+		name   string
+		format string
+		result any
+		want   []string
+	}{
+		{
+			name:   "scan",
+			format: "json",
+			result: sampleResult(),
+			want:   []string{`"schemaVersion":1`, `"clusters":[{"name":"prod","version":"1.31"},{"name":"legacy","version":"1.24"}]`},
+		},
+		{
+			name:   "profiles",
+			format: "json",
+			result: &scanner.ProfilesResult{Profiles: []*scanner.ScanResult{sampleResult()}},
+			want:   []string{`"profiles":[{`, `"clusters":[{"name":"prod","version":"1.31"},{"name":"legacy","version":"1.24"}]`},
+		},
+		{
+			name:   "ndjson keeps account",
+			format: "ndjson",
+			result: sampleResult(),
+			want:   []string{`{"account":"123456789012","name":"prod","version":"1.31"}` + "\n" + `{"account":"123456789012","name":"legacy","version":"1.24"}` + "\n"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			var err error
+			if tt.format == "ndjson" {
+				err = printNDJSON(&buf, tt.result.(*scanner.ScanResult), fields)
+			} else {
+				err = printJSON(&buf, tt.result, renderOptions{compact: true, fields: fields})
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !json.Valid(bytes.Split(buf.Bytes(), []byte("\n"))[0]) {
+				t.Fatalf("output is not JSON:\n%s", buf.String())
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(buf.String(), want) {
+					t.Errorf("output lacks %s:\n%s", want, buf.String())
+				}
+			}
+		})
+	}
+}
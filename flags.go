@@ -0,0 +1,827 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"golang.org/x/time/rate"
+
+	"shift-left-shuffle/scanner"
+)
+
+// cliFlags holds the parsed command-line flags
+type cliFlags struct {
+	output              string
+	regions             string
+	concurrency         int
+	listConcurrency     int
+	describeConcurrency int
+	adaptiveConcurrency bool
+	vpcIDs              string
+	arnPrefixes         string
+	expectAccounts      string
+	profile             string
+	allProfiles         bool
+	withAccessEntries   bool
+	accessPolicy        string
+	withHealth          bool
+	withInstanceCount   bool
+	withVpcCidr         bool
+	failOnHealthIssues  bool
+	maxAgeWarn          time.Duration
+	region              string
+	fromStdin           bool
+	baselineFile        string
+	newSince            string
+	alertOnNew          bool
+	strict              bool
+	discovery           string
+	describeTimeout     time.Duration
+	regionsTimeout      time.Duration
+	describeRetries     int
+	listRetries         int
+	listTimeout         time.Duration
+	describePhase       time.Duration
+	requireTags         string
+	includeDisabled     bool
+	rateLimit           float64
+	includeConnected    bool
+	summaryOnly         bool
+	priorityRegions     string
+	requireCMK          bool
+	requireAuditLog     bool
+	outputDir           string
+	splitBy             string
+	withUpdates         bool
+	withSGRules         bool
+	countExitCode       bool
+	caBundle            string
+	httpTimeout         time.Duration
+	httpDialTimeout     time.Duration
+	compact             bool
+	verbose             bool
+	forceAllRegions     bool
+	jsonpath            string
+	regionRetries       int
+	verifyDNS           bool
+	groupBy             string
+	sortBy              string
+	reverse             bool
+	minVersion          string
+	onlyEOL             bool
+	estimateCost        bool
+	hourlyRate          float64
+	extendedRate        float64
+	findCollisions      bool
+	profileRegionMap    string
+	syslog              bool
+	syslogAddr          string
+	dynamoDBTable       string
+	execCommand         string
+	execConcurrency     int
+	execTimeout         time.Duration
+	protectionTag       string
+	requireProtection   bool
+	withVersionsBehind  bool
+	maxVersionsBehind   int
+	ascii               bool
+	checksum            bool
+	outputFile          string
+	withNetwork         bool
+	accountConcurrency  int
+	withNodegroups      bool
+	maxRegionErrors     int
+	accountAlias        bool
+	allowedCidrs        string
+	interactive         bool
+	clusterARNs         string
+	timezone            string
+	watch               time.Duration
+	changesOnly         bool
+	withAZs             bool
+	minAZs              int
+	auditLog            string
+	stats               bool
+	skipEmptyRegions    bool
+	recheckEmpty        time.Duration
+	cacheDir            string
+	regionsCacheTTL     time.Duration
+	refreshRegions      bool
+	sampleRegions       int
+	maxDescribe         int
+	seed                uint64
+	fields              string
+	ssoSession          string
+	mfaToken            string
+	tags                multiFlag
+	excludeTags         multiFlag
+	labels              multiFlag
+
+	// httpClient is built by main from caBundle and the HTTP timeouts before scanning.
+	httpClient aws.HTTPClient
+	// query is jsonpath compiled during parsing.
+	query jsonPath
+	// fieldNames is fields parsed during parsing.
+	fieldNames []string
+	// groupKeys is groupBy parsed during parsing.
+	groupKeys []string
+	// profileRegions is loaded by main from profileRegionMap.
+	profileRegions map[string][]string
+	// location is timezone resolved during parsing.
+	location *time.Location
+	// apiStats is created by main when stats is set.
+	apiStats *scanner.Stats
+	// audit is created by main when auditLog is set.
+	audit *scanner.AuditLog
+	// arns is loaded by main from clusterARNs.
+	arns []scanner.ClusterARN
+	// allowedPrefixes is allowedCidrs parsed during parsing.
+	allowedPrefixes []netip.Prefix
+	// mfaProvider is created by main from mfaToken, or prompts on a terminal.
+	mfaProvider func() (string, error)
+	// newClusters is counted by main after dropping the clusters of newSince.
+	newClusters int
+}
+
+// parseFlags registers every flag on fs, parses args and validates the combination
+func parseFlags(fs *flag.FlagSet, args []string) (*cliFlags, error) {
+	f := &cliFlags{}
+	fs.StringVar(&f.output, "output", "text", "Output format: "+strings.Join(outputFormats, ", "))
+	fs.StringVar(&f.regions, "regions", "", "Comma-separated list of regions to scan (default: all regions)")
+	fs.IntVar(&f.concurrency, "concurrency", scanner.DefaultConcurrency, "Number of regions and clusters processed in parallel")
+	fs.IntVar(&f.listConcurrency, "list-concurrency", 0, "Number of regions listed in parallel (default: --concurrency)")
+	fs.IntVar(&f.describeConcurrency, "describe-concurrency", 0, "Number of clusters described in parallel (default: --concurrency)")
+	fs.BoolVar(&f.adaptiveConcurrency, "adaptive-concurrency", false, "Start describing at --describe-concurrency, halve it when AWS throttles calls and ramp it back up as calls succeed")
+	fs.StringVar(&f.profile, "profile", "", "Named profile from the shared AWS config files")
+	fs.StringVar(&f.mfaToken, "mfa-token", "", "MFA code for profiles that assume a role with an mfa_serial (default: prompt when stdin is a terminal)")
+	fs.StringVar(&f.ssoSession, "sso-session", "", "sso-session to suggest logging in to when the IAM Identity Center token has expired (default: the profile's sso_session)")
+	fs.BoolVar(&f.allProfiles, "all-profiles", false, "Scan once per profile found in the shared AWS config file")
+	fs.IntVar(&f.accountConcurrency, "account-concurrency", 1, "Number of profiles scanned in parallel with --all-profiles")
+	fs.StringVar(&f.profileRegionMap, "profile-region-map", "", `JSON file mapping profiles to the only regions to scan for them with --all-profiles, e.g. {"prod": ["us-east-1"]}`)
+	fs.BoolVar(&f.withAccessEntries, "with-access-entries", false, "Collect access entries (principal ARNs and access policies) for each cluster")
+	fs.StringVar(&f.accessPolicy, "access-policy", "", "Only collect access entries associated with this access policy ARN (requires --with-access-entries)")
+	fs.BoolVar(&f.withHealth, "with-health", false, "Report the health issues of each cluster")
+	fs.BoolVar(&f.failOnHealthIssues, "fail-on-health-issues", false, "Exit non-zero if any cluster reports health issues (implies --with-health)")
+	fs.BoolVar(&f.withInstanceCount, "with-instance-count", false, "Count the running EC2 instances tagged kubernetes.io/cluster/<name> for each cluster")
+	fs.BoolVar(&f.withNodegroups, "with-nodegroups", false, "Sum the desired size of each cluster's managed node groups (totalNodes)")
+	fs.BoolVar(&f.withVpcCidr, "with-vpc-cidr", false, "Look up the CIDR blocks of each cluster's VPC")
+	fs.BoolVar(&f.withAZs, "with-azs", false, "Map each cluster's subnets to availability zones and flag clusters spanning fewer than --min-azs (fails under --strict)")
+	fs.IntVar(&f.minAZs, "min-azs", 2, "Minimum number of availability zones a cluster's subnets should cover (used with --with-azs)")
+	fs.BoolVar(&f.withUpdates, "with-updates", false, "Report in-progress and recently failed cluster updates")
+	fs.BoolVar(&f.withSGRules, "with-sg-rules", false, "Look up the ingress and egress rules of each cluster's control-plane security group")
+	fs.BoolVar(&f.verifyDNS, "verify-dns", false, "Check that each cluster endpoint hostname resolves in DNS from where the tool runs")
+	fs.BoolVar(&f.withNetwork, "with-network", false, "Record the IP family (ipv4 or ipv6) and Kubernetes service CIDR of each cluster")
+	fs.DurationVar(&f.maxAgeWarn, "max-age-warn", 0, "Warn about and mark as stale clusters older than this duration (e.g. 2160h); they stay in the output")
+	fs.StringVar(&f.region, "region", "", "Region of the clusters named on stdin (used with --stdin)")
+	fs.BoolVar(&f.fromStdin, "stdin", false, "Skip discovery and describe the cluster names read from stdin, one per line (requires --region)")
+	fs.StringVar(&f.clusterARNs, "cluster-arns", "", "Skip discovery and describe these cluster ARNs, comma-separated or @file with one per line, each in its own region")
+	fs.StringVar(&f.baselineFile, "baseline", "", "JSON file listing approved clusters (account, region, name); exits non-zero on drift")
+	fs.StringVar(&f.newSince, "new-since", "", "Saved JSON or NDJSON scan; only output clusters not present in it")
+	fs.BoolVar(&f.alertOnNew, "alert-on-new", false, "Exit non-zero when --new-since finds new clusters")
+	fs.StringVar(&f.discovery, "discovery", string(scanner.DiscoveryList), "Cluster discovery backend: list (eks:ListClusters) or tagging (tag:GetResources, only sees tagged clusters)")
+	fs.Var(&f.tags, "tag", "Only keep clusters with this tag, as key=value or key (repeatable)")
+	fs.Var(&f.excludeTags, "exclude-tag", "Drop clusters with this tag, as key=value or key (repeatable; applied after --tag)")
+	fs.StringVar(&f.vpcIDs, "vpc-id", "", "Comma-separated VPC IDs; only keep clusters in one of these VPCs")
+	fs.StringVar(&f.arnPrefixes, "arn-prefix", "", "Comma-separated ARN prefixes; only keep clusters whose ARN starts with one of them, such as arn:aws:eks:eu-west-1:123456789012:cluster/prod-")
+	fs.StringVar(&f.expectAccounts, "expect-account", "", "Comma-separated account IDs; fail before scanning when the credentials belong to another account (profiles of other accounts are skipped with --all-profiles)")
+	fs.BoolVar(&f.skipEmptyRegions, "skip-empty-regions", false, "Skip regions that listed no clusters in an earlier scan of the account, as recorded under --cache-dir")
+	fs.DurationVar(&f.recheckEmpty, "recheck-empty-every", scanner.DefaultRecheckEmpty, "List a region skipped by --skip-empty-regions again once this long has passed since it was last found empty")
+	fs.IntVar(&f.maxDescribe, "max-describe-per-region", 0, "Describe at most this many clusters per region, the first by name; the rest are reported as listed only (0 means no limit)")
+	fs.IntVar(&f.sampleRegions, "sample-regions", 0, "Scan only this many regions picked at random from those that would be scanned; the sampled regions are reported")
+	fs.Uint64Var(&f.seed, "seed", 0, "Seed for --sample-regions, to repeat a sample (default: a random seed, which is reported)")
+	fs.DurationVar(&f.regionsCacheTTL, "regions-cache-ttl", scanner.DefaultRegionsCacheTTL, "Reuse the region list of an earlier scan of the account, cached under --cache-dir, for this long (0 disables the cache)")
+	fs.BoolVar(&f.refreshRegions, "refresh-regions", false, "Call DescribeRegions even when the cached region list is fresh, and update the cache")
+	fs.StringVar(&f.cacheDir, "cache-dir", "", "Directory for files kept between runs (default: the user cache directory, e.g. ~/.cache/shift-left-shuffle)")
+	fs.IntVar(&f.regionRetries, "region-retries", 0, "Relist a region from scratch up to this many times after a transient error (throttling, 5xx, network)")
+	fs.Var(&f.labels, "label", "Attach run metadata to the JSON output, as key=value (repeatable)")
+	fs.DurationVar(&f.describeTimeout, "describe-timeout", 0, "Timeout for each DescribeCluster call; clusters that time out are reported with describeError (default: no timeout)")
+	fs.IntVar(&f.describeRetries, "describe-retries", -1, "Times the SDK retries each DescribeCluster call after a retryable error (default: the SDK default)")
+	fs.IntVar(&f.listRetries, "list-retries", -1, "Times the SDK retries each cluster listing call after a retryable error (default: the SDK default)")
+	fs.DurationVar(&f.regionsTimeout, "regions-timeout", 0, "Fail the scan if listing the account's regions takes longer than this (default: no timeout)")
+	fs.DurationVar(&f.listTimeout, "list-timeout", 0, "Fail the scan if listing the clusters of all regions takes longer than this (default: no timeout)")
+	fs.DurationVar(&f.describePhase, "describe-phase-timeout", 0, "Fail the scan, keeping partial results, if describing and enriching all clusters takes longer than this (default: no timeout)")
+	fs.StringVar(&f.requireTags, "require-tags", "", "Comma-separated tag keys; only report clusters missing any of them and exit non-zero if there are any")
+	fs.BoolVar(&f.includeDisabled, "include-disabled-regions", false, "Also scan regions the account has not opted in to (default: only enabled regions)")
+	fs.BoolVar(&f.forceAllRegions, "force-all-regions", false, "Also scan regions where EKS is not known to be available (default: skip them with a warning)")
+	fs.Float64Var(&f.rateLimit, "rate-limit", 0, "Maximum AWS API calls per second across the whole scan, including retries (default: unlimited)")
+	fs.BoolVar(&f.includeConnected, "include-connected", false, "Also list clusters registered through EKS Connector and mark them as connected")
+	fs.BoolVar(&f.summaryOnly, "summary-only", false, "Print only aggregates (clusters per region, versions, EOL and error counts); text or json output")
+	fs.StringVar(&f.priorityRegions, "priority-regions", "", "Comma-separated regions to scan before all others, in order")
+	fs.BoolVar(&f.requireCMK, "require-cmk", false, "Exit non-zero if any cluster does not encrypt secrets with a customer-managed KMS key")
+	fs.BoolVar(&f.requireAuditLog, "require-audit-log", false, "List clusters without the audit control plane log type enabled and exit non-zero if there are any")
+	fs.BoolVar(&f.interactive, "interactive", false, "After the scan, pick clusters from a numbered list to print in full (text output on a terminal only; ignored when piped)")
+	fs.StringVar(&f.jsonpath, "jsonpath", "", "Print the values matching this JSONPath expression over the JSON result, one per line (e.g. '$.clusters[?(@.eol == true)].name')")
+	fs.StringVar(&f.fields, "fields", "", "Comma-separated cluster fields to keep in json and ndjson output, by JSON name (e.g. name,region,version,endpoint)")
+	fs.StringVar(&f.sortBy, "sort", "", "Order clusters by "+strings.Join(sortKeys, ", ")+" (default: scan order)")
+	fs.BoolVar(&f.reverse, "reverse", false, "Reverse the --sort order")
+	fs.StringVar(&f.groupBy, "group-by", "", "Nest text or json output by these keys, outermost first: "+strings.Join(groupKeys, ", ")+" (e.g. account,region)")
+	fs.BoolVar(&f.ascii, "ascii", false, "Write only printable ASCII: escape non-ASCII characters as \\uXXXX and drop control characters, for CI log viewers")
+	fs.BoolVar(&f.checksum, "checksum", false, "Add a SHA-256 checksum: a checksum field in JSON results (and .sha256 files with --output-dir or --output-file), a trailing line otherwise")
+	fs.DurationVar(&f.watch, "watch", 0, "Rescan at this interval until interrupted, rendering each cycle (e.g. 5m); guardrails are not checked")
+	fs.BoolVar(&f.changesOnly, "changes-only", false, "With --watch, after the first full output only print clusters added, removed or changed since the previous cycle, or a heartbeat line")
+	fs.StringVar(&f.timezone, "timezone", "local", "Zone for timestamps in text and markdown output: local, utc or an IANA name such as Europe/Paris (JSON is always UTC)")
+	fs.BoolVar(&f.verbose, "verbose", false, "Also list every scanned region with its cluster count and listing time in text output")
+	fs.BoolVar(&f.compact, "compact", false, "Write JSON output on a single line instead of indented (ndjson is always compact)")
+	fs.BoolVar(&f.accountAlias, "account-alias", false, "Resolve the IAM account alias and use it in output and --output-dir file names (needs iam:ListAccountAliases)")
+	fs.BoolVar(&f.stats, "stats", false, "Print API call statistics to stderr after the scan: calls, failures, elapsed time, retries by cause (throttling, network, server, other) and phase timings; JSON output also carries them as stats")
+	fs.StringVar(&f.auditLog, "audit-log", "", "Write a JSON audit record of the run to this file: identity, every AWS API call with its outcome, and a summary")
+	fs.StringVar(&f.outputDir, "output-dir", "", "Write one JSON file per account to this directory instead of printing to stdout")
+	fs.StringVar(&f.outputFile, "output-file", "", "Write the output to this file instead of stdout (with --checksum, also a detached <file>.sha256)")
+	fs.StringVar(&f.splitBy, "split-by", "account", "File layout for --output-dir: account (<account>.json) or region (<account>/<region>.json)")
+	fs.BoolVar(&f.countExitCode, "count-exit-code", false, "Exit with 3 plus the number of clusters found (3-127 for 0-124 clusters; 255 means 125 or more); errors and guardrails still exit 1 and flag errors 2")
+	fs.DurationVar(&f.httpTimeout, "http-timeout", 0, "Timeout for each HTTP request attempt to AWS, including reading the response (default: the SDK default)")
+	fs.DurationVar(&f.httpDialTimeout, "http-dial-timeout", 0, "Timeout for establishing each connection to AWS (default: the SDK default)")
+	fs.StringVar(&f.caBundle, "ca-bundle", "", "PEM file of extra CA certificates to trust, e.g. for a TLS-intercepting proxy (HTTPS_PROXY is always honored)")
+	fs.BoolVar(&f.withVersionsBehind, "with-versions-behind", false, "Report how many Kubernetes versions each cluster is behind the latest EKS offers")
+	fs.IntVar(&f.maxVersionsBehind, "max-versions-behind", -1, "Exit non-zero if any cluster is more than this many versions behind the latest (implies --with-versions-behind; default: off)")
+	fs.BoolVar(&f.estimateCost, "estimate-cost", false, "Estimate the monthly control plane cost of each cluster and in total")
+	fs.Float64Var(&f.hourlyRate, "hourly-rate", scanner.DefaultHourlyRate, "Hourly control plane rate in USD used by --estimate-cost")
+	fs.Float64Var(&f.extendedRate, "extended-support-rate", scanner.DefaultExtendedSupportSurcharge, "Hourly surcharge in USD for clusters in extended support, used by --estimate-cost")
+	fs.BoolVar(&f.onlyEOL, "only-eol", false, "Only keep clusters running a Kubernetes version past the end of standard support")
+	fs.StringVar(&f.minVersion, "min-version", "", "Flag clusters running a Kubernetes version older than this (e.g. 1.28); fails under --strict")
+	fs.BoolVar(&f.findCollisions, "find-name-collisions", false, "Report cluster names used in more than one region")
+	fs.BoolVar(&f.syslog, "syslog", false, "Also send the scan summary and findings to syslog, as warnings for EOL, open, unhealthy and stale clusters")
+	fs.StringVar(&f.execCommand, "exec", "", "Run this command for every cluster with its JSON record on stdin, recording the exit code and output as exec (split on spaces, no shell)")
+	fs.IntVar(&f.execConcurrency, "exec-concurrency", 4, "Maximum number of --exec commands running at once")
+	fs.DurationVar(&f.execTimeout, "exec-timeout", 30*time.Second, "Time limit for each --exec command")
+	fs.StringVar(&f.dynamoDBTable, "dynamodb-table", "", "Upsert every cluster into this DynamoDB table (partition key pk = account#region, sort key sk = cluster name)")
+	fs.StringVar(&f.syslogAddr, "syslog-addr", "", "Syslog server as [udp://|tcp://]host:port (default: the local syslog daemon)")
+	fs.StringVar(&f.protectionTag, "protection-tag", "", "Tag marking a cluster as protected from deletion, as key=value or key (reported as deletionProtected)")
+	fs.BoolVar(&f.requireProtection, "require-protection", false, "Exit non-zero if any cluster lacks --protection-tag; combine with --tag to limit it to production clusters")
+	fs.StringVar(&f.allowedCidrs, "allowed-cidrs", "", "Comma-separated CIDRs public endpoints may allow; report other publicAccessCidrs entries as disallowedCidrs (fails under --strict)")
+	fs.IntVar(&f.maxRegionErrors, "max-region-errors", -1, "Exit non-zero if more than this many regions fail to list (default: unlimited, or 0 with --strict)")
+	fs.BoolVar(&f.strict, "strict", false, "Exit non-zero on any warning of the Warnings section, such as EOL versions, health issues, open endpoints and stale clusters (implies --with-health and every --fail-on-* flag)")
+	documentEnv(fs, envPrefix)
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if err := applyEnv(fs, envPrefix, os.LookupEnv); err != nil {
+		return nil, err
+	}
+
+	if !slices.Contains(outputFormats, f.output) {
+		return nil, fmt.Errorf("unsupported output format %q: must be one of %s", f.output, strings.Join(outputFormats, ", "))
+	}
+	if f.summaryOnly && f.output != "text" && f.output != "json" {
+		return nil, fmt.Errorf("--summary-only supports text or json output, got %q", f.output)
+	}
+	if f.jsonpath != "" {
+		if f.summaryOnly || f.outputDir != "" {
+			return nil, fmt.Errorf("--jsonpath cannot be combined with --summary-only or --output-dir")
+		}
+		query, err := compileJSONPath(f.jsonpath)
+		if err != nil {
+			return nil, err
+		}
+		f.query = query
+	}
+	if (f.output == "openmetrics" || f.output == "prometheus" || f.output == "html") && f.watch > 0 {
+		return nil, fmt.Errorf("--output %s cannot be combined with --watch", f.output)
+	}
+	if f.fields != "" {
+		if f.output != "json" && f.output != "ndjson" {
+			return nil, fmt.Errorf("--fields supports json or ndjson output, got %q", f.output)
+		}
+		if f.outputDir != "" || f.jsonpath != "" || f.groupBy != "" || f.summaryOnly || f.checksum {
+			return nil, fmt.Errorf("--fields cannot be combined with --output-dir, --jsonpath, --group-by, --summary-only or --checksum")
+		}
+		names, err := parseFields(f.fields)
+		if err != nil {
+			return nil, err
+		}
+		f.fieldNames = names
+	}
+	if f.groupBy != "" {
+		if f.output != "text" && f.output != "json" {
+			return nil, fmt.Errorf("--group-by supports text or json output, got %q", f.output)
+		}
+		keys, err := parseGroupBy(f.groupBy)
+		if err != nil {
+			return nil, err
+		}
+		f.groupKeys = keys
+	}
+	if err := validSortKey(f.sortBy); err != nil {
+		return nil, err
+	}
+	if f.reverse && f.sortBy == "" {
+		return nil, fmt.Errorf("--reverse requires --sort")
+	}
+	if f.outputFile != "" && f.outputDir != "" {
+		return nil, fmt.Errorf("--output-file cannot be combined with --output-dir")
+	}
+	if f.interactive && (f.output != "text" || f.fromStdin || f.outputDir != "" || f.outputFile != "" || f.query != nil || f.groupKeys != nil || f.summaryOnly) {
+		return nil, fmt.Errorf("--interactive requires text output and cannot be combined with --stdin, --output-dir, --output-file, --jsonpath, --group-by or --summary-only")
+	}
+	if f.changesOnly && f.watch <= 0 {
+		return nil, fmt.Errorf("--changes-only requires --watch")
+	}
+	if f.alertOnNew && f.newSince == "" {
+		return nil, fmt.Errorf("--alert-on-new requires --new-since")
+	}
+	if f.watch < 0 {
+		return nil, fmt.Errorf("--watch must not be negative")
+	}
+	if f.watch > 0 && (f.fromStdin || f.outputDir != "" || f.outputFile != "" || f.query != nil || f.groupKeys != nil || f.summaryOnly || f.interactive || f.baselineFile != "" || f.newSince != "" || f.dynamoDBTable != "" || f.execCommand != "" || f.checksum || f.countExitCode || f.auditLog != "" || f.stats) {
+		return nil, fmt.Errorf("--watch cannot be combined with --stdin, --output-dir, --output-file, --jsonpath, --group-by, --summary-only, --interactive, --baseline, --new-since, --dynamodb-table, --exec, --checksum, --count-exit-code, --audit-log or --stats")
+	}
+	location, err := loadLocation(f.timezone)
+	if err != nil {
+		return nil, err
+	}
+	f.location = location
+	if !slices.Contains(splitByValues, f.splitBy) {
+		return nil, fmt.Errorf("unsupported --split-by %q: must be one of %s", f.splitBy, strings.Join(splitByValues, ", "))
+	}
+	if f.fromStdin && !validRegion(f.region) {
+		return nil, fmt.Errorf("--stdin requires a valid --region, got %q", f.region)
+	}
+	if f.allProfiles && (f.profile != "" || f.fromStdin) {
+		return nil, fmt.Errorf("--all-profiles cannot be combined with --profile or --stdin")
+	}
+	if f.clusterARNs != "" && (f.allProfiles || f.fromStdin || f.regions != "") {
+		return nil, fmt.Errorf("--cluster-arns cannot be combined with --all-profiles, --stdin or --regions")
+	}
+	if f.regionRetries < 0 {
+		return nil, fmt.Errorf("--region-retries must not be negative")
+	}
+	if f.describeRetries < -1 || f.listRetries < -1 {
+		return nil, fmt.Errorf("--describe-retries and --list-retries must not be negative")
+	}
+	if f.minVersion != "" && !scanner.ValidVersion(f.minVersion) {
+		return nil, fmt.Errorf("invalid --min-version %q: expected major.minor such as 1.28", f.minVersion)
+	}
+	if f.profileRegionMap != "" && !f.allProfiles {
+		return nil, fmt.Errorf("--profile-region-map requires --all-profiles")
+	}
+	if _, _, err := parseSyslogAddr(f.syslogAddr); err != nil {
+		return nil, err
+	}
+	if key, _, _ := strings.Cut(f.protectionTag, "="); f.protectionTag != "" && key == "" {
+		return nil, fmt.Errorf("invalid --protection-tag %q: expected key=value or key", f.protectionTag)
+	}
+	if f.requireProtection && f.protectionTag == "" {
+		return nil, fmt.Errorf("--require-protection requires --protection-tag")
+	}
+	for _, cidr := range splitList(f.allowedCidrs) {
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --allowed-cidrs entry %q: expected a CIDR such as 203.0.113.0/24", cidr)
+		}
+		f.allowedPrefixes = append(f.allowedPrefixes, prefix.Masked())
+	}
+	if err := validAccountIDs(splitList(f.expectAccounts)); err != nil {
+		return nil, err
+	}
+	if err := validVpcIDs(splitList(f.vpcIDs)); err != nil {
+		return nil, err
+	}
+	if err := validArnPrefixes(splitList(f.arnPrefixes)); err != nil {
+		return nil, err
+	}
+	if f.regionsTimeout < 0 || f.listTimeout < 0 || f.describePhase < 0 {
+		return nil, fmt.Errorf("--regions-timeout, --list-timeout and --describe-phase-timeout must not be negative")
+	}
+	if f.mfaToken != "" && !validMFAToken(f.mfaToken) {
+		return nil, fmt.Errorf("invalid --mfa-token: expected the six-digit code of the MFA device")
+	}
+	if f.hourlyRate < 0 || f.extendedRate < 0 {
+		return nil, fmt.Errorf("--hourly-rate and --extended-support-rate must not be negative")
+	}
+	if f.httpTimeout < 0 || f.httpDialTimeout < 0 {
+		return nil, fmt.Errorf("--http-timeout and --http-dial-timeout must not be negative")
+	}
+	if f.sampleRegions < 0 {
+		return nil, fmt.Errorf("--sample-regions must not be negative")
+	}
+	if f.seed != 0 && f.sampleRegions == 0 {
+		return nil, fmt.Errorf("--seed requires --sample-regions")
+	}
+	if f.sampleRegions > 0 && (f.fromStdin || f.clusterARNs != "") {
+		return nil, fmt.Errorf("--sample-regions cannot be combined with --stdin or --cluster-arns")
+	}
+	if f.execCommand != "" && strings.TrimSpace(f.execCommand) == "" {
+		return nil, fmt.Errorf("--exec needs a command")
+	}
+	if f.execConcurrency < 1 {
+		return nil, fmt.Errorf("--exec-concurrency must be at least 1")
+	}
+	if f.execTimeout <= 0 {
+		return nil, fmt.Errorf("--exec-timeout must be positive")
+	}
+	if f.maxDescribe < 0 {
+		return nil, fmt.Errorf("--max-describe-per-region must not be negative")
+	}
+	if f.maxDescribe > 0 && (f.fromStdin || f.clusterARNs != "") {
+		return nil, fmt.Errorf("--max-describe-per-region cannot be combined with --stdin or --cluster-arns")
+	}
+	if f.recheckEmpty <= 0 {
+		return nil, fmt.Errorf("--recheck-empty-every must be positive")
+	}
+	if f.regionsCacheTTL < 0 {
+		return nil, fmt.Errorf("--regions-cache-ttl must not be negative")
+	}
+	if f.refreshRegions && f.regionsCacheTTL == 0 {
+		return nil, fmt.Errorf("--refresh-regions requires the region cache (--regions-cache-ttl above 0)")
+	}
+	if f.cacheDir == "" {
+		dir, err := os.UserCacheDir()
+		if err != nil && f.skipEmptyRegions {
+			return nil, fmt.Errorf("--skip-empty-regions needs --cache-dir: %w", err)
+		}
+		// Without a cache directory the region cache is off
+		if err == nil {
+			f.cacheDir = filepath.Join(dir, "shift-left-shuffle")
+		}
+	}
+	if f.minAZs < 1 {
+		return nil, fmt.Errorf("--min-azs must be at least 1")
+	}
+	if f.accountConcurrency < 1 {
+		return nil, fmt.Errorf("--account-concurrency must be at least 1")
+	}
+	if f.rateLimit < 0 {
+		return nil, fmt.Errorf("--rate-limit must not be negative")
+	}
+	if _, err := scanner.ParseDiscovery(f.discovery); err != nil {
+		return nil, err
+	}
+	for _, tag := range f.tags {
+		if key, _, _ := strings.Cut(tag, "="); key == "" {
+			return nil, fmt.Errorf("invalid --tag %q: expected key=value or key", tag)
+		}
+	}
+	for _, tag := range f.excludeTags {
+		if key, _, _ := strings.Cut(tag, "="); key == "" {
+			return nil, fmt.Errorf("invalid --exclude-tag %q: expected key=value or key", tag)
+		}
+	}
+	for _, label := range f.labels {
+		if key, _, ok := strings.Cut(label, "="); !ok || key == "" {
+			return nil, fmt.Errorf("invalid --label %q: expected key=value", label)
+		}
+	}
+	if f.output == "wide" {
+		// Fills the NODEGROUPS column
+		f.withNodegroups = true
+	}
+	if f.maxVersionsBehind >= 0 {
+		f.withVersionsBehind = true
+	}
+	if f.strict && f.maxRegionErrors < 0 {
+		f.maxRegionErrors = 0
+	}
+	if f.strict {
+		f.failOnHealthIssues = true
+	}
+	if f.failOnHealthIssues {
+		f.withHealth = true
+	}
+	return f, nil
+}
+
+// envPrefix is prepended to the upper-cased flag name to form its environment
+// variable. Subcommand flags add the subcommand, e.g. SHUFFLE_RENDER_OUTPUT, as
+// their values may not be valid for the scan flag of the same name.
+const envPrefix = "SHUFFLE_"
+
+// envName returns the environment variable for a flag, e.g. SHUFFLE_LIST_CONCURRENCY
+func envName(prefix, flagName string) string {
+	return prefix + strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
+}
+
+// subcommandEnvPrefix returns the environment prefix of the flags of a subcommand
+func subcommandEnvPrefix(name string) string {
+	return envName(envPrefix, name) + "_"
+}
+
+// parseSubcommand parses the flags of the subcommand fs, named after it, and
+// then its environment variables, documenting them in its usage
+func parseSubcommand(fs *flag.FlagSet, args []string) error {
+	prefix := subcommandEnvPrefix(fs.Name())
+	documentEnv(fs, prefix)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	return applyEnv(fs, prefix, os.LookupEnv)
+}
+
+// documentEnv appends the environment variable of each flag to its usage
+func documentEnv(fs *flag.FlagSet, prefix string) {
+	fs.VisitAll(func(fl *flag.Flag) {
+		fl.Usage += " [$" + envName(prefix, fl.Name) + "]"
+	})
+}
+
+// applyEnv sets every flag not given on the command line from its environment
+// variable, so flags take precedence over the environment
+func applyEnv(fs *flag.FlagSet, prefix string, lookup func(string) (string, bool)) error {
+	given := make(map[string]bool)
+	fs.Visit(func(fl *flag.Flag) {
+		given[fl.Name] = true
+	})
+
+	var err error
+	fs.VisitAll(func(fl *flag.Flag) {
+		if err != nil || given[fl.Name] {
+			return
+		}
+		if value, ok := lookup(envName(prefix, fl.Name)); ok {
+			if setErr := fs.Set(fl.Name, value); setErr != nil {
+				err = fmt.Errorf("invalid value %q for %s: %w", value, envName(prefix, fl.Name), setErr)
+			}
+		}
+	})
+	return err
+}
+
+// scannerOptions translates the flags into scanner options
+func (f *cliFlags) scannerOptions(progress io.Writer) []scanner.Option {
+	opts := []scanner.Option{
+		scanner.WithConcurrency(f.concurrency),
+		scanner.WithListConcurrency(f.listConcurrency),
+		scanner.WithDescribeConcurrency(f.describeConcurrency),
+		scanner.WithProfile(f.profile),
+		scanner.WithOutput(progress),
+	}
+	if f.regions != "" {
+		opts = append(opts, scanner.WithRegions(splitList(f.regions)...))
+	}
+	if f.adaptiveConcurrency {
+		opts = append(opts, scanner.WithAdaptiveConcurrency())
+	}
+	if f.expectAccounts != "" {
+		opts = append(opts, scanner.WithExpectedAccounts(splitList(f.expectAccounts)...))
+	}
+	if f.vpcIDs != "" {
+		opts = append(opts, scanner.WithVpcIDs(splitList(f.vpcIDs)...))
+	}
+	if f.arnPrefixes != "" {
+		opts = append(opts, scanner.WithArnPrefixes(splitList(f.arnPrefixes)...))
+	}
+	if f.withAccessEntries {
+		opts = append(opts, scanner.WithAccessEntries(f.accessPolicy))
+	}
+	if f.withHealth {
+		opts = append(opts, scanner.WithHealth())
+	}
+	if f.withInstanceCount {
+		opts = append(opts, scanner.WithInstanceCount())
+	}
+	discovery, _ := scanner.ParseDiscovery(f.discovery)
+	opts = append(opts, scanner.WithDiscovery(discovery))
+	for _, tag := range f.tags {
+		if key, value, ok := strings.Cut(tag, "="); ok {
+			opts = append(opts, scanner.WithTagFilter(key, value))
+		} else {
+			opts = append(opts, scanner.WithTagFilter(key))
+		}
+	}
+	for _, tag := range f.excludeTags {
+		if key, value, ok := strings.Cut(tag, "="); ok {
+			opts = append(opts, scanner.WithExcludeTag(key, value))
+		} else {
+			opts = append(opts, scanner.WithExcludeTag(key))
+		}
+	}
+	for _, label := range f.labels {
+		key, value, _ := strings.Cut(label, "=")
+		opts = append(opts, scanner.WithLabel(key, value))
+	}
+	if f.accountConcurrency > 1 {
+		opts = append(opts, scanner.WithAccountConcurrency(f.accountConcurrency))
+	}
+	if f.profileRegions != nil {
+		opts = append(opts, scanner.WithProfileRegions(f.profileRegions))
+	}
+	if f.protectionTag != "" {
+		if key, value, ok := strings.Cut(f.protectionTag, "="); ok {
+			opts = append(opts, scanner.WithProtectionTag(key, value))
+		} else {
+			opts = append(opts, scanner.WithProtectionTag(key))
+		}
+	}
+	if f.allowedPrefixes != nil {
+		opts = append(opts, scanner.WithAllowedCidrs(f.allowedPrefixes...))
+	}
+	if f.priorityRegions != "" {
+		opts = append(opts, scanner.WithPriorityRegions(splitList(f.priorityRegions)...))
+	}
+	if f.includeConnected {
+		opts = append(opts, scanner.WithConnected())
+	}
+	if f.httpClient != nil {
+		opts = append(opts, scanner.WithHTTPClient(f.httpClient))
+	}
+	if f.mfaProvider != nil {
+		opts = append(opts, scanner.WithMFATokenProvider(f.mfaProvider))
+	}
+	if f.audit != nil {
+		opts = append(opts, scanner.WithAuditLog(f.audit))
+	}
+	if f.ssoSession != "" {
+		opts = append(opts, scanner.WithSSOSession(f.ssoSession))
+	}
+	if f.apiStats != nil {
+		opts = append(opts, scanner.WithStats(f.apiStats))
+	}
+	if f.rateLimit > 0 {
+		// One limiter for every scanner of the run, such as one per profile
+		opts = append(opts, scanner.WithLimiter(rate.NewLimiter(rate.Limit(f.rateLimit), 1)))
+	}
+	if f.forceAllRegions {
+		opts = append(opts, scanner.WithAllRegions())
+	}
+	if f.includeDisabled {
+		opts = append(opts, scanner.WithDisabledRegions())
+	}
+	if f.requireTags != "" {
+		opts = append(opts, scanner.WithRequiredTags(splitList(f.requireTags)...))
+	}
+	if f.regionRetries > 0 {
+		opts = append(opts, scanner.WithRegionRetries(f.regionRetries))
+	}
+	if f.describeRetries >= 0 {
+		opts = append(opts, scanner.WithDescribeRetries(f.describeRetries))
+	}
+	if f.listRetries >= 0 {
+		opts = append(opts, scanner.WithListRetries(f.listRetries))
+	}
+	if f.regionsCacheTTL > 0 && f.cacheDir != "" {
+		opts = append(opts, scanner.WithRegionsCache(filepath.Join(f.cacheDir, "regions.json"), f.regionsCacheTTL, f.refreshRegions))
+	}
+	if f.skipEmptyRegions {
+		opts = append(opts, scanner.WithSkipEmptyRegions(filepath.Join(f.cacheDir, "empty-regions.json"), f.recheckEmpty))
+	}
+	if f.sampleRegions > 0 {
+		opts = append(opts, scanner.WithSampleRegions(f.sampleRegions, f.seed))
+	}
+	if f.maxDescribe > 0 {
+		opts = append(opts, scanner.WithMaxDescribePerRegion(f.maxDescribe))
+	}
+	if f.describeTimeout > 0 {
+		opts = append(opts, scanner.WithDescribeTimeout(f.describeTimeout))
+	}
+	if f.regionsTimeout > 0 {
+		opts = append(opts, scanner.WithPhaseTimeout(scanner.PhaseRegions, f.regionsTimeout))
+	}
+	if f.listTimeout > 0 {
+		opts = append(opts, scanner.WithPhaseTimeout(scanner.PhaseList, f.listTimeout))
+	}
+	if f.describePhase > 0 {
+		opts = append(opts, scanner.WithPhaseTimeout(scanner.PhaseDescribe, f.describePhase))
+	}
+	if f.withVpcCidr {
+		opts = append(opts, scanner.WithVpcCidr())
+	}
+	if f.withAZs {
+		opts = append(opts, scanner.WithAvailabilityZones(f.minAZs))
+	}
+	if f.withUpdates {
+		opts = append(opts, scanner.WithUpdates())
+	}
+	if f.withSGRules {
+		opts = append(opts, scanner.WithSecurityGroupRules())
+	}
+	if f.accountAlias {
+		opts = append(opts, scanner.WithAccountAlias())
+	}
+	if f.withNodegroups {
+		opts = append(opts, scanner.WithNodegroups())
+	}
+	if f.withNetwork {
+		opts = append(opts, scanner.WithNetwork())
+	}
+	if f.verifyDNS {
+		opts = append(opts, scanner.WithVerifyDNS())
+	}
+	if f.findCollisions {
+		opts = append(opts, scanner.WithNameCollisions())
+	}
+	if f.withVersionsBehind {
+		opts = append(opts, scanner.WithVersionsBehind())
+	}
+	if f.onlyEOL {
+		opts = append(opts, scanner.WithOnlyEOL())
+	}
+	if f.estimateCost {
+		opts = append(opts, scanner.WithCostEstimate(scanner.CostRates{Hourly: f.hourlyRate, ExtendedSupportSurcharge: f.extendedRate}))
+	}
+	if f.minVersion != "" {
+		opts = append(opts, scanner.WithMinVersion(f.minVersion))
+	}
+	if f.maxAgeWarn > 0 {
+		opts = append(opts, scanner.WithMaxAgeWarn(f.maxAgeWarn))
+	}
+	return opts
+}
+
+// renderOptions returns the optional output sections selected by the flags
+func (f *cliFlags) renderOptions() renderOptions {
+	return renderOptions{
+		compact:       f.compact,
+		fields:        f.fieldNames,
+		accessEntries: f.withAccessEntries,
+		health:        f.withHealth,
+		instanceCount: f.withInstanceCount,
+		vpcCidr:       f.withVpcCidr,
+		updates:       f.withUpdates,
+		sgRules:       f.withSGRules,
+		dns:           f.verifyDNS,
+		versions:      f.withVersionsBehind,
+		network:       f.withNetwork,
+		nodegroups:    f.withNodegroups,
+		location:      f.location,
+		azs:           f.withAZs,
+		minAZs:        f.minAZs,
+		auditLog:      f.requireAuditLog,
+		cost:          f.estimateCost,
+		verbose:       f.verbose,
+	}
+}
+
+// validAccountIDs checks --expect-account values are 12-digit account IDs
+func validAccountIDs(ids []string) error {
+	for _, id := range ids {
+		if len(id) != 12 || strings.Trim(id, "0123456789") != "" {
+			return fmt.Errorf("invalid --expect-account %q: expected a 12-digit account ID", id)
+		}
+	}
+	return nil
+}
+
+// validVpcIDs checks --vpc-id values look like VPC IDs (vpc- followed by hex digits)
+func validVpcIDs(ids []string) error {
+	for _, id := range ids {
+		hex, ok := strings.CutPrefix(id, "vpc-")
+		if !ok || hex == "" || strings.Trim(hex, "0123456789abcdef") != "" {
+			return fmt.Errorf("invalid --vpc-id %q: expected an ID such as vpc-0a1b2c3d", id)
+		}
+	}
+	return nil
+}
+
+// validArnPrefixes checks --arn-prefix values start like an ARN
+func validArnPrefixes(prefixes []string) error {
+	for _, prefix := range prefixes {
+		if !strings.HasPrefix(prefix, "arn:") {
+			return fmt.Errorf("invalid --arn-prefix %q: expected the start of a cluster ARN such as arn:aws:eks:eu-west-1:", prefix)
+		}
+	}
+	return nil
+}
+
+// loadLocation resolves a --timezone value: local, utc or an IANA zone name
+func loadLocation(name string) (*time.Location, error) {
+	switch strings.ToLower(name) {
+	case "local":
+		return time.Local, nil
+	case "utc":
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --timezone %q: %w", name, err)
+	}
+	return loc, nil
+}
+
+// regionPattern matches region names such as us-east-1 or us-gov-west-1
+var regionPattern = regexp.MustCompile(`^[a-z]{2}(-[a-z]+)+-[0-9]+$`)
+
+// validRegion reports whether region looks like an AWS region name
+func validRegion(region string) bool {
+	return regionPattern.MatchString(region)
+}
+
+// splitList splits a comma-separated flag value, dropping empty items
+func splitList(v string) []string {
+	var items []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// multiFlag collects the values of a repeatable string flag
+type multiFlag []string
+
+func (m *multiFlag) String() string {
+	return strings.Join(*m, ",")
+}
+
+func (m *multiFlag) Set(v string) error {
+	*m = append(*m, v)
+	return nil
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestApplyEnv(t *testing.T) {
+	tests := []struct {
+		name       string
+		prefix     string
+		args       []string
+		env        map[string]string
+		wantOutput string
+		wantErr    string
+	}{
+		{name: "default", prefix: envPrefix, wantOutput: "text"},
+		{name: "from environment", prefix: envPrefix, env: map[string]string{"SHUFFLE_OUTPUT": "json"}, wantOutput: "json"},
+		{name: "flag wins", prefix: envPrefix, args: []string{"--output", "markdown"}, env: map[string]string{"SHUFFLE_OUTPUT": "json"}, wantOutput: "markdown"},
+		{name: "subcommand prefix", prefix: subcommandEnvPrefix("render"), env: map[string]string{"SHUFFLE_RENDER_OUTPUT": "json"}, wantOutput: "json"},
+		{name: "subcommand ignores scan variables", prefix: subcommandEnvPrefix("render"), env: map[string]string{"SHUFFLE_OUTPUT": "json"}, wantOutput: "text"},
+		{name: "invalid value", prefix: subcommandEnvPrefix("render"), env: map[string]string{"SHUFFLE_RENDER_COMPACT": "sometimes"}, wantErr: "SHUFFLE_RENDER_COMPACT"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := flag.NewFlagSet("render", flag.ContinueOnError)
+			output := fs.String("output", "text", "")
+			fs.Bool("compact", false, "")
+			if err := fs.Parse(tt.args); err != nil {
+				t.Fatal(err)
+			}
+			err := applyEnv(fs, tt.prefix, func(name string) (string, bool) {
+				value, ok := tt.env[name]
+				return value, ok
+			})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if *output != tt.wantOutput {
+				t.Errorf("output = %q, want %q", *output, tt.wantOutput)
+			}
+		})
+	}
+}
+
+// checkParseError checks that parseFlags fails on args with exactly wantErr,
+// or succeeds when wantErr is empty
+func checkParseError(t *testing.T, args []string, wantErr string) {
+	t.Helper()
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	_, err := parseFlags(fs, args)
+	if wantErr == "" && err != nil || wantErr != "" && (err == nil || err.Error() != wantErr) {
+		t.Errorf("parseFlags(%q) err = %v, want %q", args, err, wantErr)
+	}
+}
+
+func TestParseLabels(t *testing.T) {
+	tests := []struct {
+		args    []string
+		wantErr string
+	}{
+		{args: []string{"--label", "run-id=123", "--label", "env=prod"}},
+		{args: []string{"--label", "note="}},
+		{args: []string{"--label", "run-id"}, wantErr: `invalid --label "run-id": expected key=value`},
+		{args: []string{"--label", "=prod"}, wantErr: `invalid --label "=prod": expected key=value`},
+	}
+	for _, tt := range tests {
+		checkParseError(t, tt.args, tt.wantErr)
+	}
+}
+
+func TestParseProtectionTag(t *testing.T) {
+	tests := []struct {
+		args    []string
+		wantErr string
+	}{
+		{args: []string{"--protection-tag", "protected=true", "--require-protection"}},
+		{args: []string{"--protection-tag", "protected"}},
+		{args: []string{"--protection-tag", "=true"}, wantErr: `invalid --protection-tag "=true": expected key=value or key`},
+		{args: []string{"--require-protection"}, wantErr: "--require-protection requires --protection-tag"},
+	}
+	for _, tt := range tests {
+		checkParseError(t, tt.args, tt.wantErr)
+	}
+}
+
+func TestParseStdin(t *testing.T) {
+	tests := []struct {
+		args    []string
+		wantErr string
+	}{
+		{args: []string{"--stdin", "--region", "us-east-1"}},
+		{args: []string{"--stdin", "--region", "us-gov-west-1"}},
+		{args: []string{"--stdin"}, wantErr: `--stdin requires a valid --region, got ""`},
+		{args: []string{"--stdin", "--region", "US-EAST-1"}, wantErr: `--stdin requires a valid --region, got "US-EAST-1"`},
+		{args: []string{"--stdin", "--region", "us-east"}, wantErr: `--stdin requires a valid --region, got "us-east"`},
+		{args: []string{"--stdin", "--region", "us-east-1", "--all-profiles"}, wantErr: "--all-profiles cannot be combined with --profile or --stdin"},
+	}
+	for _, tt := range tests {
+		checkParseError(t, tt.args, tt.wantErr)
+	}
+}
+
+func TestParseClusterARNs(t *testing.T) {
+	const arn = "arn:aws:eks:us-east-1:123456789012:cluster/prod"
+	tests := []struct {
+		args    []string
+		wantErr string
+	}{
+		{args: []string{"--cluster-arns", arn}},
+		{args: []string{"--cluster-arns", arn, "--regions", "us-east-1"}, wantErr: "--cluster-arns cannot be combined with --all-profiles, --stdin or --regions"},
+		{args: []string{"--cluster-arns", arn, "--stdin", "--region", "us-east-1"}, wantErr: "--cluster-arns cannot be combined with --all-profiles, --stdin or --regions"},
+		{args: []string{"--cluster-arns", arn, "--sample-regions", "2"}, wantErr: "--sample-regions cannot be combined with --stdin or --cluster-arns"},
+	}
+	for _, tt := range tests {
+		checkParseError(t, tt.args, tt.wantErr)
+	}
+}
+
+func TestParseHTTPTimeouts(t *testing.T) {
+	tests := []struct {
+		args    []string
+		wantErr string
+	}{
+		{args: []string{"--http-timeout", "10s", "--http-dial-timeout", "2s"}},
+		{args: []string{"--http-timeout", "-1s"}, wantErr: "--http-timeout and --http-dial-timeout must not be negative"},
+		{args: []string{"--http-dial-timeout", "-1s"}, wantErr: "--http-timeout and --http-dial-timeout must not be negative"},
+	}
+	for _, tt := range tests {
+		checkParseError(t, tt.args, tt.wantErr)
+	}
+}
+
+func TestParseMFAToken(t *testing.T) {
+	tests := []struct {
+		args    []string
+		wantErr string
+	}{
+		{args: []string{"--mfa-token", "123456"}},
+		{args: []string{"--mfa-token", "12345"}, wantErr: "invalid --mfa-token: expected the six-digit code of the MFA device"},
+	}
+	for _, tt := range tests {
+		checkParseError(t, tt.args, tt.wantErr)
+	}
+}
+
+func TestParseNewSince(t *testing.T) {
+	tests := []struct {
+		args    []string
+		wantErr string
+	}{
+		{args: []string{"--new-since", "prior.json", "--alert-on-new"}},
+		{args: []string{"--alert-on-new"}, wantErr: "--alert-on-new requires --new-since"},
+	}
+	for _, tt := range tests {
+		checkParseError(t, tt.args, tt.wantErr)
+	}
+}
+
+func TestParseInteractive(t *testing.T) {
+	const wantErr = "--interactive requires text output and cannot be combined with --stdin, --output-dir, --output-file, --jsonpath, --group-by or --summary-only"
+	tests := []struct {
+		args    []string
+		wantErr string
+	}{
+		{args: []string{"--interactive"}},
+		{args: []string{"--interactive", "--output", "json"}, wantErr: wantErr},
+		{args: []string{"--interactive", "--summary-only"}, wantErr: wantErr},
+		{args: []string{"--interactive", "--watch", "1m"}, wantErr: "--watch cannot be combined with --stdin, --output-dir, --output-file, --jsonpath, --group-by, --summary-only, --interactive, --baseline, --new-since, --dynamodb-table, --exec, --checksum, --count-exit-code, --audit-log or --stats"},
+	}
+	for _, tt := range tests {
+		checkParseError(t, tt.args, tt.wantErr)
+	}
+}
+
+func TestParseProfileRegionMap(t *testing.T) {
+	tests := []struct {
+		args    []string
+		wantErr string
+	}{
+		{args: []string{"--all-profiles", "--profile-region-map", "regions.json"}},
+		{args: []string{"--profile-region-map", "regions.json"}, wantErr: "--profile-region-map requires --all-profiles"},
+	}
+	for _, tt := range tests {
+		checkParseError(t, tt.args, tt.wantErr)
+	}
+}
+
+func TestParseSubcommandDocumentsEnv(t *testing.T) {
+	fs := flag.NewFlagSet("diff", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.String("output", "text", "Output format")
+	fs.Bool("strict-json", false, "Fail on unknown fields")
+	if err := parseSubcommand(fs, nil); err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]string{"output": "[$SHUFFLE_DIFF_OUTPUT]", "strict-json": "[$SHUFFLE_DIFF_STRICT_JSON]"} {
+		if usage := fs.Lookup(name).Usage; !strings.HasSuffix(usage, want) {
+			t.Errorf("--%s usage = %q, want it to end with %q", name, usage, want)
+		}
+	}
+}
+
+func TestRenderReadsEnv(t *testing.T) {
+	path := writeScan(t, sampleResult())
+	t.Setenv("SHUFFLE_RENDER_OUTPUT", "ndjson")
+	var buf bytes.Buffer
+	if err := runRender(&buf, []string{path}); err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Count(buf.String(), "\n"); lines != 2 || !strings.HasPrefix(buf.String(), "{") {
+		t.Errorf("render with SHUFFLE_RENDER_OUTPUT=ndjson wrote:\n%s", buf.String())
+	}
+}
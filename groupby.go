@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"shift-left-shuffle/scanner"
+)
+
+// groupKeys lists the values accepted by --group-by
+var groupKeys = []string{"account", "region"}
+
+// clusterGroup is one level of --group-by output. Inner levels are in Groups;
+// the clusters themselves sit in the innermost level.
+type clusterGroup struct {
+	Key      string                   `json:"key"`
+	Value    string                   `json:"value"`
+	Groups   []*clusterGroup          `json:"groups,omitempty"`
+	Clusters []scanner.AccountCluster `json:"clusters,omitempty"`
+}
+
+// groupedOutput is the JSON document written with --group-by
+type groupedOutput struct {
+	GroupBy []string        `json:"groupBy"`
+	Groups  []*clusterGroup `json:"groups"`
+}
+
+// parseGroupBy validates a comma-separated --group-by value
+func parseGroupBy(v string) ([]string, error) {
+	keys := splitList(v)
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("--group-by needs at least one of %s", strings.Join(groupKeys, ", "))
+	}
+	for i, key := range keys {
+		if !slices.Contains(groupKeys, key) {
+			return nil, fmt.Errorf("unsupported --group-by key %q: must be one of %s", key, strings.Join(groupKeys, ", "))
+		}
+		if slices.Contains(keys[:i], key) {
+			return nil, fmt.Errorf("--group-by key %q given twice", key)
+		}
+	}
+	return keys, nil
+}
+
+// groupValue returns the value of a grouping key for c
+func groupValue(key string, c *scanner.AccountCluster) string {
+	if key == "account" {
+		return c.Account
+	}
+	return c.Region
+}
+
+// groupClusters nests clusters by keys, outermost first. Groups are sorted by
+// value; clusters keep their scan order.
+func groupClusters(clusters []scanner.AccountCluster, keys []string) []*clusterGroup {
+	byValue := make(map[string]int)
+	var groups []*clusterGroup
+	var members [][]scanner.AccountCluster
+	for _, c := range clusters {
+		value := groupValue(keys[0], &c)
+		i, ok := byValue[value]
+		if !ok {
+			i = len(groups)
+			byValue[value] = i
+			groups = append(groups, &clusterGroup{Key: keys[0], Value: value})
+			members = append(members, nil)
+		}
+		members[i] = append(members[i], c)
+	}
+	for i, g := range groups {
+		if len(keys) > 1 {
+			g.Groups = groupClusters(members[i], keys[1:])
+		} else {
+			g.Clusters = members[i]
+		}
+	}
+	slices.SortFunc(groups, func(a, b *clusterGroup) int { return strings.Compare(a.Value, b.Value) })
+	return groups
+}
+
+// printGrouped writes the clusters of every result nested by keys, as text or JSON
+func printGrouped(w io.Writer, format string, results []*scanner.ScanResult, keys []string, opts renderOptions) error {
+	var clusters []scanner.AccountCluster
+	for _, result := range results {
+		clusters = append(clusters, result.Flatten()...)
+	}
+	groups := groupClusters(clusters, keys)
+	if format == "json" {
+		return encodeJSON(w, groupedOutput{GroupBy: keys, Groups: groups}, opts.compact)
+	}
+	printGroups(w, groups, "")
+	return nil
+}
+
+// printGroups writes groups as an indented outline, one line per cluster endpoint
+func printGroups(w io.Writer, groups []*clusterGroup, indent string) {
+	for _, g := range groups {
+		fmt.Fprintf(w, "%s%s %s:\n", indent, g.Key, g.Value)
+		printGroups(w, g.Groups, indent+"  ")
+		for _, c := range g.Clusters {
+			switch {
+			case c.DescribeError != "":
+				fmt.Fprintf(w, "%s  %s: %s\n", indent, c.Name, c.DescribeError)
+			case c.Connected():
+				fmt.Fprintf(w, "%s  %s: connected cluster, provider %s\n", indent, c.Name, c.Connector.Provider)
+			default:
+				fmt.Fprintf(w, "%s  %s: %s\n", indent, c.Name, c.Endpoint)
+			}
+		}
+	}
+}
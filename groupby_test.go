@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+
+	"shift-left-shuffle/scanner"
+)
+
+func TestParseGroupBy(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    []string
+		wantErr string
+	}{
+		{value: "account", want: []string{"account"}},
+		{value: "region, account", want: []string{"region", "account"}},
+		{value: "", wantErr: "at least one"},
+		{value: "vpc", wantErr: `unsupported --group-by key "vpc"`},
+		{value: "region,region", wantErr: "given twice"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseGroupBy(tt.value)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("keys = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrintGrouped(t *testing.T) {
+	dev := sampleResult()
+	prod := sampleResult()
+	prod.Account = "210987654321"
+	prod.Clusters = []scanner.Cluster{
+		{Name: "hidden", Region: "us-east-1", DescribeError: "AccessDenied"},
+		{Name: "onprem", Region: "us-east-1", Connector: &scanner.Connector{Provider: "OTHER"}},
+	}
+	results := []*scanner.ScanResult{prod, dev}
+
+	tests := []struct {
+		keys []string
+		want string
+	}{
+		{
+			keys: []string{"region"},
+			want: `region eu-west-1:
+  legacy: https://legacy.example.com
+region us-east-1:
+  hidden: AccessDenied
+  onprem: connected cluster, provider OTHER
+  prod: https://prod.example.com
+`,
+		},
+		{
+			keys: []string{"account", "region"},
+			want: `account 123456789012:
+  region eu-west-1:
+    legacy: https://legacy.example.com
+  region us-east-1:
+    prod: https://prod.example.com
+account 210987654321:
+  region us-east-1:
+    hidden: AccessDenied
+    onprem: connected cluster, provider OTHER
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.keys, ","), func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := printGrouped(&buf, "text", results, tt.keys, renderOptions{}); err != nil {
+				t.Fatal(err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("grouped output:\n%s\nwant:\n%s", buf.String(), tt.want)
+			}
+		})
+	}
+
+	t.Run("json", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := printGrouped(&buf, "json", results, []string{"account", "region"}, renderOptions{compact: true}); err != nil {
+			t.Fatal(err)
+		}
+		for _, want := range []string{`{"groupBy":["account","region"],"groups":[{"key":"account","value":"123456789012","groups":[{"key":"region","value":"eu-west-1","clusters":[`, `"name":"onprem"`} {
+			if !strings.Contains(buf.String(), want) {
+				t.Errorf("grouped json lacks %q:\n%s", want, buf.String())
+			}
+		}
+	})
+}
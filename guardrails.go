@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"shift-left-shuffle/scanner"
+)
+
+// checkGuardrails returns one message per failed guardrail. Drift and the
+// --fail-on-* flags always apply; --strict additionally fails on every
+// warning-level finding.
+func checkGuardrails(f *cliFlags, results []*scanner.ScanResult) []string {
+	var failures []string
+	for _, result := range results {
+		if result.Drift != nil && result.Drift.HasDrift() {
+			failures = append(failures, fmt.Sprintf("drift from baseline in account %s: %d unexpected, %d missing clusters", result.Account, len(result.Drift.Unexpected), len(result.Drift.Missing)))
+		}
+	}
+	if f.maxRegionErrors >= 0 {
+		n := 0
+		for _, result := range results {
+			n += len(result.RegionErrors)
+		}
+		if n > f.maxRegionErrors {
+			failures = append(failures, fmt.Sprintf("%d regions failed to list, more than --max-region-errors %d", n, f.maxRegionErrors))
+		}
+	}
+	warnings := warningNames(results)
+	if f.failOnHealthIssues {
+		if names := warnings[scanner.WarningHealthIssues]; len(names) > 0 {
+			failures = append(failures, fmt.Sprintf("%d clusters report health issues: %s", len(names), strings.Join(names, ", ")))
+		}
+	}
+	if f.requireTags != "" {
+		if names := warnings[scanner.WarningMissingTags]; len(names) > 0 {
+			failures = append(failures, fmt.Sprintf("%d clusters are missing required tags: %s", len(names), strings.Join(names, ", ")))
+		}
+	}
+	if f.requireCMK {
+		if n := countClusters(results, func(c *scanner.Cluster) bool { return c.DescribeError == "" && !c.Connected() && !c.UsesCMK() }); n > 0 {
+			failures = append(failures, fmt.Sprintf("%d clusters do not encrypt secrets with a customer-managed KMS key", n))
+		}
+	}
+	if f.requireAuditLog {
+		if names := clusterNames(results, missingAuditLog); len(names) > 0 {
+			failures = append(failures, fmt.Sprintf("%d clusters do not have audit logging enabled: %s", len(names), strings.Join(names, ", ")))
+		}
+	}
+	if f.alertOnNew && f.newClusters > 0 {
+		names := clusterNames(results, func(*scanner.Cluster) bool { return true })
+		failures = append(failures, fmt.Sprintf("%d new clusters since %s: %s", len(names), f.newSince, strings.Join(names, ", ")))
+	}
+	if f.requireProtection {
+		if n := countClusters(results, func(c *scanner.Cluster) bool { return c.DeletionProtected != nil && !*c.DeletionProtected }); n > 0 {
+			failures = append(failures, fmt.Sprintf("%d clusters are not tagged %s", n, f.protectionTag))
+		}
+	}
+	if f.maxVersionsBehind >= 0 {
+		if n := countClusters(results, func(c *scanner.Cluster) bool {
+			return c.VersionsBehind != nil && *c.VersionsBehind > f.maxVersionsBehind
+		}); n > 0 {
+			failures = append(failures, fmt.Sprintf("%d clusters are more than %d versions behind the latest", n, f.maxVersionsBehind))
+		}
+	}
+	if f.strict {
+		for _, code := range strictWarnings {
+			if names := warnings[code]; len(names) > 0 {
+				failures = append(failures, fmt.Sprintf("%d clusters with %s warnings: %s", len(names), code, strings.Join(names, ", ")))
+			}
+		}
+	}
+	return failures
+}
+
+// strictWarnings are the warning codes --strict fails on, in report order;
+// health issues and missing tags have guardrails of their own
+var strictWarnings = []string{
+	scanner.WarningEOL,
+	scanner.WarningBelowMinVersion,
+	scanner.WarningOpenEndpoint,
+	scanner.WarningDisallowedCidrs,
+	scanner.WarningBelowMinAZs,
+	scanner.WarningStale,
+	scanner.WarningUndescribed,
+}
+
+// warningNames maps each warning code to the "name (region)" of the clusters
+// across results with that warning, as reported by scanner.CollectWarnings
+func warningNames(results []*scanner.ScanResult) map[string][]string {
+	names := make(map[string][]string)
+	for _, result := range results {
+		for _, warning := range scanner.CollectWarnings(result.Clusters) {
+			names[warning.Code] = append(names[warning.Code], fmt.Sprintf("%s (%s)", warning.Cluster, warning.Region))
+		}
+	}
+	return names
+}
+
+// missingAuditLog reports whether a described EKS cluster lacks audit logging;
+// connected clusters have no EKS control plane to log
+func missingAuditLog(c *scanner.Cluster) bool {
+	return c.DescribeError == "" && !c.Connected() && !c.AuditLogging()
+}
+
+// clusterNames returns "name (region)" for the clusters across results for
+// which match is true
+func clusterNames(results []*scanner.ScanResult, match func(*scanner.Cluster) bool) []string {
+	var names []string
+	for _, result := range results {
+		for i := range result.Clusters {
+			if c := &result.Clusters[i]; match(c) {
+				names = append(names, fmt.Sprintf("%s (%s)", c.Name, c.Region))
+			}
+		}
+	}
+	return names
+}
+
+// countClusters returns the number of clusters across results for which match is true
+func countClusters(results []*scanner.ScanResult, match func(*scanner.Cluster) bool) int {
+	n := 0
+	for _, result := range results {
+		for i := range result.Clusters {
+			if match(&result.Clusters[i]) {
+				n++
+			}
+		}
+	}
+	return n
+}
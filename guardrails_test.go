@@ -0,0 +1,155 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+
+	"shift-left-shuffle/scanner"
+)
+
+func TestCheckGuardrails(t *testing.T) {
+	behind := 3
+	clusters := func(cs ...scanner.Cluster) []*scanner.ScanResult {
+		return []*scanner.ScanResult{{Account: "123456789012", Clusters: cs}}
+	}
+	tests := []struct {
+		name    string
+		args    []string
+		results []*scanner.ScanResult
+		want    []string
+	}{
+		{name: "nothing enabled", results: clusters(scanner.Cluster{Name: "old", Region: "us-east-1", EOL: true})},
+		{
+			name:    "drift always applies",
+			results: []*scanner.ScanResult{{Account: "123456789012", Drift: &scanner.Drift{Missing: []scanner.ClusterRef{{Name: "gone"}}}}},
+			want:    []string{"drift from baseline in account 123456789012: 0 unexpected, 1 missing clusters"},
+		},
+		{
+			name:    "region errors unlimited by default",
+			results: []*scanner.ScanResult{{RegionErrors: []scanner.RegionError{{Region: "eu-west-1"}, {Region: "us-east-1"}}}},
+		},
+		{
+			name:    "region errors under the limit",
+			args:    []string{"--max-region-errors", "1"},
+			results: clusters(scanner.Cluster{Name: "fine", Region: "us-east-1"}),
+		},
+		{
+			name:    "region errors at the limit",
+			args:    []string{"--max-region-errors", "1"},
+			results: []*scanner.ScanResult{{RegionErrors: []scanner.RegionError{{Region: "eu-west-1"}}}},
+		},
+		{
+			name:    "region errors over the limit",
+			args:    []string{"--max-region-errors", "1"},
+			results: []*scanner.ScanResult{{RegionErrors: []scanner.RegionError{{Region: "eu-west-1"}}}, {RegionErrors: []scanner.RegionError{{Region: "us-east-1"}}}},
+			want:    []string{"2 regions failed to list, more than --max-region-errors 1"},
+		},
+		{
+			name:    "health issues",
+			args:    []string{"--fail-on-health-issues"},
+			results: clusters(scanner.Cluster{Name: "sick", Region: "us-east-1", HealthIssues: []scanner.HealthIssue{{Code: "SubnetNotFound"}}}),
+			want:    []string{"1 clusters report health issues: sick (us-east-1)"},
+		},
+		{
+			name:    "missing tags",
+			args:    []string{"--require-tags", "owner"},
+			results: clusters(scanner.Cluster{Name: "untagged", Region: "us-east-1", MissingTags: []string{"owner"}}),
+			want:    []string{"1 clusters are missing required tags: untagged (us-east-1)"},
+		},
+		{
+			name: "customer-managed keys",
+			args: []string{"--require-cmk"},
+			results: clusters(
+				scanner.Cluster{Name: "aws-key", Region: "us-east-1", EncryptionKeyManager: "AWS"},
+				scanner.Cluster{Name: "cmk", Region: "us-east-1", EncryptionKeyManager: scanner.KeyManagerCustomer},
+				scanner.Cluster{Name: "undescribed", Region: "us-east-1", DescribeError: "timeout"},
+			),
+			want: []string{"1 clusters do not encrypt secrets with a customer-managed KMS key"},
+		},
+		{
+			name: "audit logging",
+			args: []string{"--require-audit-log"},
+			results: clusters(
+				scanner.Cluster{Name: "quiet", Region: "us-east-1", EnabledLogTypes: []string{"api"}},
+				scanner.Cluster{Name: "audited", Region: "us-east-1", EnabledLogTypes: []string{scanner.LogTypeAudit}},
+				scanner.Cluster{Name: "connected", Region: "us-east-1", Connector: &scanner.Connector{Provider: "EKS_ANYWHERE"}},
+			),
+			want: []string{"1 clusters do not have audit logging enabled: quiet (us-east-1)"},
+		},
+		{
+			name: "deletion protection",
+			args: []string{"--protection-tag", "protected=true", "--require-protection"},
+			results: clusters(
+				scanner.Cluster{Name: "locked", Region: "us-east-1", DeletionProtected: aws.Bool(true)},
+				scanner.Cluster{Name: "unlocked", Region: "us-east-1", DeletionProtected: aws.Bool(false)},
+				scanner.Cluster{Name: "undescribed", Region: "us-east-1", DescribeError: "timeout"},
+			),
+			want: []string{"1 clusters are not tagged protected=true"},
+		},
+		{
+			name:    "versions behind",
+			args:    []string{"--max-versions-behind", "2"},
+			results: clusters(scanner.Cluster{Name: "lagging", Region: "us-east-1", VersionsBehind: &behind}),
+			want:    []string{"1 clusters are more than 2 versions behind the latest"},
+		},
+		{
+			name: "strict fails on every warning",
+			args: []string{"--strict"},
+			results: clusters(
+				scanner.Cluster{Name: "old", Region: "us-east-1", Version: "1.24", EOL: true},
+				scanner.Cluster{Name: "open", Region: "eu-west-1", EndpointPublicAccess: true, PublicAccessCidrs: []string{"0.0.0.0/0"}},
+				scanner.Cluster{Name: "stale", Region: "eu-west-1", Stale: true},
+				scanner.Cluster{Name: "broken", Region: "eu-west-1", DescribeError: "AccessDenied"},
+			),
+			want: []string{
+				"1 clusters with eol warnings: old (us-east-1)",
+				"1 clusters with open-endpoint warnings: open (eu-west-1)",
+				"1 clusters with stale warnings: stale (eu-west-1)",
+				"1 clusters with undescribed warnings: broken (eu-west-1)",
+			},
+		},
+		{
+			name:    "strict fails on region errors",
+			args:    []string{"--strict"},
+			results: []*scanner.ScanResult{{RegionErrors: []scanner.RegionError{{Region: "eu-west-1"}}}},
+			want:    []string{"1 regions failed to list, more than --max-region-errors 0"},
+		},
+		{
+			name:    "strict keeps an explicit region error limit",
+			args:    []string{"--strict", "--max-region-errors", "1"},
+			results: []*scanner.ScanResult{{RegionErrors: []scanner.RegionError{{Region: "eu-west-1"}}}},
+		},
+		{
+			name:    "strict with clean clusters",
+			args:    []string{"--strict"},
+			results: clusters(scanner.Cluster{Name: "fine", Region: "us-east-1", Version: "1.31"}),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := checkGuardrails(testFlags(t, tt.args...), tt.results)
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("failures =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestWarningNames(t *testing.T) {
+	results := []*scanner.ScanResult{
+		{Clusters: []scanner.Cluster{{Name: "a", Region: "us-east-1", EOL: true, Stale: true}}},
+		{Clusters: []scanner.Cluster{{Name: "b", Region: "eu-west-1", EOL: true}}},
+	}
+	names := warningNames(results)
+	if got := strings.Join(names[scanner.WarningEOL], ", "); got != "a (us-east-1), b (eu-west-1)" {
+		t.Errorf("eol = %q", got)
+	}
+	if got := strings.Join(names[scanner.WarningStale], ", "); got != "a (us-east-1)" {
+		t.Errorf("stale = %q", got)
+	}
+	if len(names[scanner.WarningOpenEndpoint]) != 0 {
+		t.Errorf("open-endpoint = %q, want none", names[scanner.WarningOpenEndpoint])
+	}
+}
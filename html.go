@@ -0,0 +1,154 @@
+package main
+
+import (
+	"html/template"
+	"io"
+	"slices"
+	"time"
+
+	"shift-left-shuffle/scanner"
+)
+
+// htmlReport is the data of the HTML report template
+type htmlReport struct {
+	GeneratedAt string
+	Accounts    []string
+	Clusters    int
+	EOL         int
+	Open        int
+	Undescribed int
+	Regions     int
+	Incomplete  bool
+	Rows        []htmlRow
+}
+
+// htmlRow is one cluster of the HTML report
+type htmlRow struct {
+	Account  string
+	Region   string
+	Name     string
+	Version  string
+	Public   string
+	Created  string
+	Status   string
+	Severity string
+	Detail   string
+}
+
+// htmlTemplate renders a self-contained page: styles and the sorting script
+// are inline and every value is escaped by html/template
+var htmlTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>EKS cluster report</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2em; color: #1f2328; }
+h1 { font-size: 1.5em; margin-bottom: 0.2em; }
+.meta { color: #59636e; margin-bottom: 1.5em; }
+.cards { display: flex; flex-wrap: wrap; gap: 1em; margin-bottom: 1.5em; }
+.card { border: 1px solid #d1d9e0; border-radius: 6px; padding: 0.8em 1.2em; min-width: 8em; }
+.card .value { font-size: 1.8em; font-weight: 600; }
+.card .label { color: #59636e; }
+.warning { background: #fff8c5; border: 1px solid #d4a72c; border-radius: 6px; padding: 0.6em 1em; margin-bottom: 1em; }
+table { border-collapse: collapse; width: 100%; }
+th, td { border-bottom: 1px solid #d1d9e0; padding: 0.4em 0.6em; text-align: left; }
+th { cursor: pointer; background: #f6f8fa; user-select: none; }
+th::after { content: " \2195"; color: #8c959f; }
+.status { border-radius: 1em; padding: 0.1em 0.7em; font-size: 0.9em; white-space: nowrap; }
+.ok { background: #dafbe1; color: #116329; }
+.warn { background: #fff1e5; color: #953800; }
+.bad { background: #ffebe9; color: #a40e26; }
+.unknown { background: #eaeef2; color: #59636e; }
+</style>
+</head>
+<body>
+<h1>EKS cluster report</h1>
+<div class="meta">{{with .Accounts}}Account{{if gt (len .) 1}}s{{end}} {{range $i, $a := .}}{{if $i}}, {{end}}{{$a}}{{end}}{{end}}{{with .GeneratedAt}}, generated {{.}}{{end}}</div>
+{{if .Incomplete}}<div class="warning">The scan did not complete; the results are partial.</div>
+{{end}}<div class="cards">
+<div class="card"><div class="value">{{.Clusters}}</div><div class="label">Clusters</div></div>
+<div class="card"><div class="value">{{.EOL}}</div><div class="label">End of life</div></div>
+<div class="card"><div class="value">{{.Open}}</div><div class="label">Open endpoints</div></div>
+<div class="card"><div class="value">{{.Undescribed}}</div><div class="label">Undescribed</div></div>
+<div class="card"><div class="value">{{.Regions}}</div><div class="label">Regions scanned</div></div>
+</div>
+<table id="clusters">
+<thead><tr><th>Account</th><th>Region</th><th>Name</th><th>Version</th><th>Public</th><th>Created</th><th>Status</th></tr></thead>
+<tbody>
+{{range .Rows}}<tr><td>{{.Account}}</td><td>{{.Region}}</td><td>{{.Name}}</td><td>{{.Version}}</td><td>{{.Public}}</td><td>{{.Created}}</td><td><span class="status {{.Severity}}"{{with .Detail}} title="{{.}}"{{end}}>{{.Status}}</span></td></tr>
+{{end}}</tbody>
+</table>
+<script>
+document.querySelectorAll("#clusters th").forEach(function (th, col) {
+  var asc = true;
+  th.addEventListener("click", function () {
+    var body = th.closest("table").tBodies[0];
+    var rows = Array.prototype.slice.call(body.rows);
+    rows.sort(function (a, b) {
+      var x = a.cells[col].textContent, y = b.cells[col].textContent;
+      return (asc ? 1 : -1) * x.localeCompare(y, undefined, {numeric: true});
+    });
+    rows.forEach(function (row) { body.appendChild(row); });
+    asc = !asc;
+  });
+});
+</script>
+</body>
+</html>
+`))
+
+// printHTML writes the scans as a single self-contained HTML page with
+// summary cards and a sortable table of every cluster
+func printHTML(w io.Writer, results []*scanner.ScanResult, loc *time.Location) error {
+	var report htmlReport
+	regions := make(map[string]bool)
+	for _, result := range results {
+		if !slices.Contains(report.Accounts, result.Account) {
+			report.Accounts = append(report.Accounts, result.Account)
+		}
+		if report.GeneratedAt == "" && !result.GeneratedAt.IsZero() {
+			report.GeneratedAt = formatTime(result.GeneratedAt, loc)
+		}
+		report.Incomplete = report.Incomplete || result.Incomplete
+		for _, region := range result.Regions {
+			regions[result.Account+"/"+region] = true
+		}
+		for _, c := range result.Clusters {
+			row := htmlRow{Account: result.Account, Region: c.Region, Name: c.Name, Version: orDash(c.Version), Public: publicAccess(&c), Created: "-"}
+			if c.CreatedAt != nil {
+				row.Created = formatTime(*c.CreatedAt, loc)
+			}
+			row.Status, row.Severity, row.Detail = htmlStatus(&c)
+			report.Rows = append(report.Rows, row)
+			report.Clusters++
+			switch {
+			case c.DescribeError != "":
+				report.Undescribed++
+			case c.EOL:
+				report.EOL++
+			}
+			if c.OpenEndpoint() {
+				report.Open++
+			}
+		}
+	}
+	report.Regions = len(regions)
+	return htmlTemplate.Execute(w, report)
+}
+
+// htmlStatus returns the status label of c, its CSS class and a tooltip
+func htmlStatus(c *scanner.Cluster) (status, severity, detail string) {
+	switch {
+	case c.DescribeError != "":
+		return "undescribed", "unknown", c.DescribeError
+	case c.EOL:
+		return "EOL", "bad", "Kubernetes " + c.Version + " is past the end of standard support"
+	case c.OpenEndpoint():
+		return "open endpoint", "warn", "The public endpoint accepts 0.0.0.0/0"
+	case len(c.HealthIssues) > 0 || c.Stale || len(c.MissingTags) > 0:
+		return "attention", "warn", ""
+	default:
+		return "ok", "ok", ""
+	}
+}
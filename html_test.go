@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"shift-left-shuffle/scanner"
+)
+
+func TestHTMLStatus(t *testing.T) {
+	tests := []struct {
+		name         string
+		cluster      scanner.Cluster
+		wantStatus   string
+		wantSeverity string
+	}{
+		{name: "ok", wantStatus: "ok", wantSeverity: "ok"},
+		{name: "undescribed wins", cluster: scanner.Cluster{DescribeError: "AccessDenied", EOL: true}, wantStatus: "undescribed", wantSeverity: "unknown"},
+		{name: "eol", cluster: scanner.Cluster{Version: "1.24", EOL: true}, wantStatus: "EOL", wantSeverity: "bad"},
+		{name: "open endpoint", cluster: scanner.Cluster{EndpointPublicAccess: true, PublicAccessCidrs: []string{"0.0.0.0/0"}}, wantStatus: "open endpoint", wantSeverity: "warn"},
+		{name: "stale", cluster: scanner.Cluster{Stale: true}, wantStatus: "attention", wantSeverity: "warn"},
+		{name: "missing tags", cluster: scanner.Cluster{MissingTags: []string{"owner"}}, wantStatus: "attention", wantSeverity: "warn"},
+		{name: "health issues", cluster: scanner.Cluster{HealthIssues: []scanner.HealthIssue{{Code: "SubnetNotFound"}}}, wantStatus: "attention", wantSeverity: "warn"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, severity, _ := htmlStatus(&tt.cluster)
+			if status != tt.wantStatus || severity != tt.wantSeverity {
+				t.Errorf("htmlStatus = %q, %q; want %q, %q", status, severity, tt.wantStatus, tt.wantSeverity)
+			}
+		})
+	}
+}
+
+func TestPrintHTML(t *testing.T) {
+	dev := sampleResult()
+	prod := sampleResult()
+	prod.Account = "210987654321"
+	prod.Incomplete = true
+	prod.Clusters = []scanner.Cluster{
+		{Name: "<script>alert(1)</script>", Region: "us-east-1", Version: "1.31", EndpointPublicAccess: true, PublicAccessCidrs: []string{"0.0.0.0/0"}},
+		{Name: "hidden", Region: "us-east-1", DescribeError: "AccessDenied"},
+	}
+
+	var buf bytes.Buffer
+	if err := printHTML(&buf, []*scanner.ScanResult{dev, prod}, time.UTC); err != nil {
+		t.Fatal(err)
+	}
+	page := buf.String()
+	for _, want := range []string{
+		"Accounts 123456789012, 210987654321, generated 2025-01-02T03:04:05Z",
+		"The scan did not complete",
+		`<div class="value">4</div><div class="label">Clusters</div>`,
+		`<div class="value">1</div><div class="label">End of life</div>`,
+		`<div class="value">1</div><div class="label">Open endpoints</div>`,
+		`<div class="value">1</div><div class="label">Undescribed</div>`,
+		`<div class="value">4</div><div class="label">Regions scanned</div>`,
+		"&lt;script&gt;alert(1)&lt;/script&gt;",
+		`<span class="status unknown" title="AccessDenied">undescribed</span>`,
+		"<td>2024-03-01T12:00:00Z</td>",
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("page lacks %q", want)
+		}
+	}
+	if strings.Contains(page, "<script>alert") {
+		t.Error("cluster name is not escaped")
+	}
+}
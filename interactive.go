@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"shift-left-shuffle/scanner"
+)
+
+// isTerminal reports whether f is a character device such as a terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// runPicker lists the clusters of results numbered and, for every number read
+// from in, writes the full JSON of that cluster. It stops on q, an empty line
+// or the end of input.
+func runPicker(in io.Reader, out io.Writer, results []*scanner.ScanResult) error {
+	var clusters []scanner.AccountCluster
+	for _, result := range results {
+		clusters = append(clusters, result.Flatten()...)
+	}
+	if len(clusters) == 0 {
+		_, err := fmt.Fprintln(out, "No clusters found")
+		return err
+	}
+	for i, c := range clusters {
+		fmt.Fprintf(out, "%3d) %s (%s, account %s)\n", i+1, c.Name, c.Region, c.Account)
+	}
+
+	sc := bufio.NewScanner(in)
+	for {
+		fmt.Fprintf(out, "Cluster to describe [1-%d, q to quit]: ", len(clusters))
+		if !sc.Scan() {
+			fmt.Fprintln(out)
+			return sc.Err()
+		}
+		answer := strings.TrimSpace(sc.Text())
+		if answer == "" || answer == "q" {
+			return nil
+		}
+		n, err := strconv.Atoi(answer)
+		if err != nil || n < 1 || n > len(clusters) {
+			fmt.Fprintf(out, "Not a cluster number: %q\n", answer)
+			continue
+		}
+		data, err := json.MarshalIndent(clusters[n-1], "", "  ")
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(out, "%s\n", data); err != nil {
+			return err
+		}
+	}
+}
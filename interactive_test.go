@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"shift-left-shuffle/scanner"
+)
+
+func TestRunPicker(t *testing.T) {
+	const list = "  1) prod (us-east-1, account 123456789012)\n  2) legacy (eu-west-1, account 123456789012)\n"
+	tests := []struct {
+		name          string
+		results       []*scanner.ScanResult
+		input         string
+		wantPicked    []string
+		wantPrompts   int
+		wantRejected  []string
+		wantTrailerNL bool
+	}{
+		{name: "no clusters", results: []*scanner.ScanResult{{Account: "123456789012"}}},
+		{name: "quit", input: "q\n", wantPrompts: 1},
+		{name: "empty line quits", input: "\n1\n", wantPrompts: 1},
+		{name: "pick in turn", input: "2\n 1 \nq\n", wantPicked: []string{"legacy", "prod"}, wantPrompts: 3},
+		{name: "invalid answers", input: "0\n3\nprod\n1\nq\n", wantPicked: []string{"prod"}, wantRejected: []string{`"0"`, `"3"`, `"prod"`}, wantPrompts: 5},
+		{name: "end of input", input: "1", wantPicked: []string{"prod"}, wantPrompts: 2, wantTrailerNL: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := tt.results
+			if results == nil {
+				results = []*scanner.ScanResult{sampleResult()}
+			}
+			var out bytes.Buffer
+			if err := runPicker(strings.NewReader(tt.input), &out, results); err != nil {
+				t.Fatal(err)
+			}
+			got := out.String()
+			if tt.results != nil {
+				if got != "No clusters found\n" {
+					t.Errorf("output = %q, want no clusters found", got)
+				}
+				return
+			}
+			if !strings.HasPrefix(got, list) {
+				t.Fatalf("output =\n%s\nwant it to start with the numbered list", got)
+			}
+			if n := strings.Count(got, "Cluster to describe [1-2, q to quit]: "); n != tt.wantPrompts {
+				t.Errorf("%d prompts, want %d:\n%s", n, tt.wantPrompts, got)
+			}
+			for _, answer := range tt.wantRejected {
+				if !strings.Contains(got, "Not a cluster number: "+answer+"\n") {
+					t.Errorf("output lacks the rejection of %s:\n%s", answer, got)
+				}
+			}
+
+			// Every picked cluster is printed as an indented JSON object after its prompt
+			var picked []string
+			for _, chunk := range strings.Split(got, "quit]: ")[1:] {
+				if !strings.HasPrefix(chunk, "{\n") {
+					continue
+				}
+				var c scanner.AccountCluster
+				if err := json.NewDecoder(strings.NewReader(chunk)).Decode(&c); err != nil {
+					t.Fatalf("decoding a picked cluster: %v\n%s", err, got)
+				}
+				if c.Account != "123456789012" || c.Endpoint == "" {
+					t.Errorf("picked cluster %+v, want the full record", c)
+				}
+				picked = append(picked, c.Name)
+			}
+			if strings.Join(picked, ",") != strings.Join(tt.wantPicked, ",") {
+				t.Errorf("picked %q, want %q", picked, tt.wantPicked)
+			}
+			if strings.HasSuffix(got, ": \n") != tt.wantTrailerNL {
+				t.Errorf("output ends %q, want a final newline only at the end of input", got[max(0, len(got)-10):])
+			}
+		})
+	}
+}
+
+func TestIsTerminal(t *testing.T) {
+	f, err := os.Create(filepath.Join(t.TempDir(), "out"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if isTerminal(f) {
+		t.Error("isTerminal(regular file) = true")
+	}
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+	if isTerminal(r) {
+		t.Error("isTerminal(pipe) = true")
+	}
+}
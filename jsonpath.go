@@ -0,0 +1,300 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// jsonPath is a compiled JSONPath expression. It supports the subset used to
+// pull fields out of a scan result:
+//
+//	$.clusters[*].endpoint
+//	$.clusters[0].name
+//	$..name
+//	$.clusters[?(@.eol == true)].name
+//	$.clusters[?(@.describeError)].name
+//	$['clusters'][*]['region']
+type jsonPath []pathStep
+
+// pathStep selects child values of each node. Exactly one of its forms is set.
+type pathStep struct {
+	recursive bool   // search every descendant, not only direct children
+	name      string // object member, when not wildcard, index or filter
+	wildcard  bool
+	index     *int
+	filter    *pathFilter
+}
+
+// pathFilter keeps the array elements (or object members) for which path
+// exists or, when op is set, compares equal or unequal to value
+type pathFilter struct {
+	path  []string
+	op    string
+	value any
+}
+
+// compileJSONPath parses expr, which must start at the root $
+func compileJSONPath(expr string) (jsonPath, error) {
+	rest, ok := strings.CutPrefix(strings.TrimSpace(expr), "$")
+	if !ok {
+		return nil, fmt.Errorf("invalid JSONPath %q: must start with $", expr)
+	}
+	var path jsonPath
+	for rest != "" {
+		var step pathStep
+		var err error
+		switch {
+		case strings.HasPrefix(rest, ".."):
+			step.recursive = true
+			rest = rest[2:]
+			if strings.HasPrefix(rest, "[") {
+				step, rest, err = parseBracket(rest, step)
+			} else {
+				step, rest, err = parseDotName(rest, step)
+			}
+		case strings.HasPrefix(rest, "."):
+			step, rest, err = parseDotName(rest[1:], step)
+		case strings.HasPrefix(rest, "["):
+			step, rest, err = parseBracket(rest, step)
+		default:
+			err = fmt.Errorf("unexpected %q", rest)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid JSONPath %q: %w", expr, err)
+		}
+		path = append(path, step)
+	}
+	return path, nil
+}
+
+// parseDotName parses the member name or * following a dot
+func parseDotName(s string, step pathStep) (pathStep, string, error) {
+	if strings.HasPrefix(s, "*") {
+		step.wildcard = true
+		return step, s[1:], nil
+	}
+	end := strings.IndexAny(s, ".[")
+	if end < 0 {
+		end = len(s)
+	}
+	if end == 0 {
+		return step, s, fmt.Errorf("missing member name before %q", s)
+	}
+	step.name = s[:end]
+	return step, s[end:], nil
+}
+
+// parseBracket parses [*], [n], ['name'] or [?(filter)]
+func parseBracket(s string, step pathStep) (pathStep, string, error) {
+	if strings.HasPrefix(s, "[?(") {
+		end := strings.Index(s, ")]")
+		if end < 0 {
+			return step, s, fmt.Errorf("unterminated filter %q", s)
+		}
+		filter, err := parseFilter(s[3:end])
+		if err != nil {
+			return step, s, err
+		}
+		step.filter = filter
+		return step, s[end+2:], nil
+	}
+	end := strings.Index(s, "]")
+	if end < 0 {
+		return step, s, fmt.Errorf("unterminated bracket %q", s)
+	}
+	inner := strings.TrimSpace(s[1:end])
+	switch {
+	case inner == "*":
+		step.wildcard = true
+	case len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0]:
+		step.name = inner[1 : len(inner)-1]
+	default:
+		n, err := strconv.Atoi(inner)
+		if err != nil {
+			return step, s, fmt.Errorf("invalid subscript [%s]", inner)
+		}
+		step.index = &n
+	}
+	return step, s[end+1:], nil
+}
+
+// parseFilter parses @.a.b, @.a.b == literal or @.a.b != literal
+func parseFilter(s string) (*pathFilter, error) {
+	s = strings.TrimSpace(s)
+	filter := &pathFilter{}
+	lhs := s
+	for _, op := range []string{"==", "!="} {
+		if l, r, ok := strings.Cut(s, op); ok {
+			value, err := parseLiteral(strings.TrimSpace(r))
+			if err != nil {
+				return nil, err
+			}
+			lhs, filter.op, filter.value = strings.TrimSpace(l), op, value
+			break
+		}
+	}
+	rel, ok := strings.CutPrefix(lhs, "@.")
+	if !ok || rel == "" {
+		return nil, fmt.Errorf("filter %q must test a member of @", s)
+	}
+	filter.path = strings.Split(rel, ".")
+	return filter, nil
+}
+
+// parseLiteral parses a number, true, false, null or a quoted string
+func parseLiteral(s string) (any, error) {
+	if len(s) >= 2 && s[0] == '\'' && s[len(s)-1] == '\'' {
+		return s[1 : len(s)-1], nil
+	}
+	var value any
+	if err := json.Unmarshal([]byte(s), &value); err != nil {
+		return nil, fmt.Errorf("invalid literal %q in filter", s)
+	}
+	return value, nil
+}
+
+// eval returns the values selected by the path from doc, a decoded JSON value
+func (p jsonPath) eval(doc any) []any {
+	nodes := []any{doc}
+	for _, step := range p {
+		var next []any
+		for _, node := range nodes {
+			if step.recursive {
+				for _, d := range descendants(node) {
+					next = append(next, step.apply(d)...)
+				}
+				continue
+			}
+			next = append(next, step.apply(node)...)
+		}
+		nodes = next
+	}
+	return nodes
+}
+
+// apply returns the children of node selected by the step
+func (step pathStep) apply(node any) []any {
+	switch {
+	case step.wildcard:
+		return children(node)
+	case step.index != nil:
+		arr, ok := node.([]any)
+		if !ok {
+			return nil
+		}
+		i := *step.index
+		if i < 0 {
+			i += len(arr)
+		}
+		if i < 0 || i >= len(arr) {
+			return nil
+		}
+		return []any{arr[i]}
+	case step.filter != nil:
+		var kept []any
+		for _, child := range children(node) {
+			if step.filter.match(child) {
+				kept = append(kept, child)
+			}
+		}
+		return kept
+	default:
+		obj, ok := node.(map[string]any)
+		if !ok {
+			return nil
+		}
+		if value, ok := obj[step.name]; ok {
+			return []any{value}
+		}
+		return nil
+	}
+}
+
+// match reports whether node passes the filter
+func (f *pathFilter) match(node any) bool {
+	value := node
+	for _, name := range f.path {
+		obj, ok := value.(map[string]any)
+		if !ok {
+			return false
+		}
+		if value, ok = obj[name]; !ok {
+			return false
+		}
+	}
+	switch f.op {
+	case "==":
+		return jsonValueEqual(value, f.value)
+	case "!=":
+		return !jsonValueEqual(value, f.value)
+	default:
+		return value != nil && value != false
+	}
+}
+
+// jsonValueEqual compares two decoded JSON values
+func jsonValueEqual(a, b any) bool {
+	ja, errA := json.Marshal(a)
+	jb, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(ja) == string(jb)
+}
+
+// children returns the elements of an array or the members of an object,
+// the latter in key order so results are deterministic
+func children(node any) []any {
+	switch v := node.(type) {
+	case []any:
+		return v
+	case map[string]any:
+		values := make([]any, 0, len(v))
+		for _, key := range slices.Sorted(maps.Keys(v)) {
+			values = append(values, v[key])
+		}
+		return values
+	default:
+		return nil
+	}
+}
+
+// descendants returns node and everything nested in it, depth first
+func descendants(node any) []any {
+	all := []any{node}
+	for _, child := range children(node) {
+		all = append(all, descendants(child)...)
+	}
+	return all
+}
+
+// printJSONPath evaluates path against the JSON encoding of v and writes one
+// match per line: strings as is, anything else as compact JSON
+func printJSONPath(w io.Writer, path jsonPath, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	var doc any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	for _, match := range path.eval(doc) {
+		if s, ok := match.(string); ok {
+			if _, err := fmt.Fprintln(w, s); err != nil {
+				return err
+			}
+			continue
+		}
+		line, err := json.Marshal(match)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintln(w, string(line)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
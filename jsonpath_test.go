@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestJSONPath(t *testing.T) {
+	result := sampleResult()
+	result.Clusters[1].DescribeError = "throttled"
+	tests := []struct {
+		expr string
+		want []string
+	}{
+		{expr: "$.clusters[*].endpoint", want: []string{"https://prod.example.com", "https://legacy.example.com"}},
+		{expr: "$.clusters[0].name", want: []string{"prod"}},
+		{expr: "$.clusters[-1].name", want: []string{"legacy"}},
+		{expr: "$.clusters[5].name"},
+		{expr: "$..name", want: []string{"prod", "legacy"}},
+		{expr: "$.clusters[?(@.eol == true)].name", want: []string{"legacy"}},
+		{expr: "$.clusters[?(@.version != '1.24')].name", want: []string{"prod"}},
+		{expr: "$.clusters[?(@.describeError)].name", want: []string{"legacy"}},
+		{expr: "$.clusters[?(@.tags.env == 'prod')].region", want: []string{"us-east-1"}},
+		{expr: "$['clusters'][*]['region']", want: []string{"us-east-1", "eu-west-1"}},
+		{expr: "$.clusters[0].tags", want: []string{`{"env":"prod"}`}},
+		{expr: "$.clusters[0].tags.*", want: []string{"prod"}},
+		{expr: "$.account", want: []string{"123456789012"}},
+		{expr: "$.missing"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			path, err := compileJSONPath(tt.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var buf bytes.Buffer
+			if err := printJSONPath(&buf, path, result); err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			if out := strings.TrimSuffix(buf.String(), "\n"); out != "" {
+				got = strings.Split(out, "\n")
+			}
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("%s = %q, want %q", tt.expr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompileJSONPathErrors(t *testing.T) {
+	tests := []struct {
+		expr    string
+		wantErr string
+	}{
+		{expr: "clusters", wantErr: "must start with $"},
+		{expr: "$.clusters[", wantErr: "unterminated bracket"},
+		{expr: "$.clusters[x]", wantErr: "invalid subscript"},
+		{expr: "$.clusters[?(@.eol == true]", wantErr: "unterminated filter"},
+		{expr: "$.clusters[?(eol)]", wantErr: "must test a member of @"},
+		{expr: "$.clusters[?(@.eol == yes)]", wantErr: "invalid literal"},
+		{expr: "$..", wantErr: "missing member name"},
+		{expr: "$clusters", wantErr: "unexpected"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			if _, err := compileJSONPath(tt.expr); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
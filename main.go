@@ -1,208 +1,411 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
+	"cmp"
 	"context"
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"log"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"time"
 
-	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/config"
-	"github.com/aws/aws-sdk-go-v2/service/ec2"
-	"github.com/aws/aws-sdk-go-v2/service/eks"
-	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"shift-left-shuffle/scanner"
 )
 
-// ConfigLoader defines an interface for loading AWS configuration.
-// This interface creation is necessary for mocking.
-type ConfigLoader interface {
-	LoadDefaultConfigMethod(ctx context.Context) (aws.Config, error)
-}
+func main() {
+	// Subcommands
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "diff":
+			differ, err := runDiff(os.Args[2:])
+			if err != nil {
+				log.Fatal(err)
+			}
+			if differ {
+				os.Exit(1)
+			}
+			return
+		case "render":
+			if err := runRender(os.Stdout, os.Args[2:]); err != nil {
+				log.Fatal(err)
+			}
+			return
+		case "schema":
+			if err := runSchema(os.Args[2:]); err != nil {
+				log.Fatal(err)
+			}
+			return
+		case "preflight":
+			passed, err := runPreflight(os.Args[2:])
+			if err != nil {
+				log.Fatal(err)
+			}
+			if !passed {
+				log.Fatal("Preflight failed: a required permission is not allowed")
+			}
+			return
+		}
+	}
 
-// DefaultConfigLoader is the type upon which we call the LoadDefaultConfigMethod Method.
-// This type creation is necessary for mocking.
-type DefaultConfigLoader struct{}
+	f, err := parseFlags(flag.CommandLine, os.Args[1:])
+	if err != nil {
+		log.Fatal(err)
+	}
 
-// LoadDefaultConfigMethod implements the ConfigLoader interface using the AWS SDK.
-// LoadDefaultConfigMethod is the func converted to method necessary for mocking.
-func (l *DefaultConfigLoader) LoadDefaultConfigMethod(ctx context.Context) (aws.Config, error) {
-	return config.LoadDefaultConfig(ctx)
-}
+	// Progress goes to stderr unless stdout carries plain text
+	var stdout, stderr io.Writer = os.Stdout, os.Stderr
+	if f.ascii {
+		stdout, stderr = newASCIIWriter(stdout), newASCIIWriter(stderr)
+	}
+	progress := stdout
+	if f.output != "text" {
+		progress = stderr
+	}
 
-// This is the STSClient interface for STS operations.
-type STSClient interface {
-	GetCallerIdentity(ctx context.Context, params *sts.GetCallerIdentityInput, optFns ...func(*sts.Options)) (*sts.GetCallerIdentityOutput, error)
-}
+	// Load the baseline before scanning so a bad file fails fast
+	var baseline []scanner.ClusterRef
+	if f.baselineFile != "" {
+		baseline, err = loadBaselineFile(f.baselineFile)
+		if err != nil {
+			log.Fatalf("Error loading baseline: %v", err)
+		}
+	}
+	var prior []scanner.AccountCluster
+	if f.newSince != "" {
+		if prior, err = readClustersFile(f.newSince, false); err != nil {
+			log.Fatalf("Error loading --new-since scan: %v", err)
+		}
+	}
 
-// EC2Client interface for EC2 operations
-type EC2Client interface {
-	DescribeRegions(ctx context.Context, params *ec2.DescribeRegionsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeRegionsOutput, error)
-}
+	if f.caBundle != "" || f.httpTimeout > 0 || f.httpDialTimeout > 0 {
+		var pem []byte
+		if f.caBundle != "" {
+			if pem, err = os.ReadFile(f.caBundle); err != nil {
+				log.Fatalf("Error reading CA bundle: %v", err)
+			}
+		}
+		timeouts := scanner.HTTPTimeouts{Request: f.httpTimeout, Dial: f.httpDialTimeout}
+		if f.httpClient, err = scanner.NewHTTPClient(pem, timeouts); err != nil {
+			log.Fatalf("Error loading CA bundle %s: %v", f.caBundle, err)
+		}
+	}
 
-// EKSClient interface for EKS operations
-type EKSClient interface {
-	ListClusters(ctx context.Context, params *eks.ListClustersInput, optFns ...func(*eks.Options)) (*eks.ListClustersOutput, error)
-	DescribeCluster(ctx context.Context, params *eks.DescribeClusterInput, optFns ...func(*eks.Options)) (*eks.DescribeClusterOutput, error)
-}
+	if f.profileRegionMap != "" {
+		if f.profileRegions, err = loadProfileRegionMap(f.profileRegionMap); err != nil {
+			log.Fatalf("Error loading profile region map: %v", err)
+		}
+	}
+	if f.clusterARNs != "" {
+		if f.arns, err = loadClusterARNs(f.clusterARNs); err != nil {
+			log.Fatalf("Error reading --cluster-arns: %v", err)
+		}
+	}
 
-// Clusters holds information about EKS clusters
-type Clusters struct {
-	Names []string
-	Urls  []string
-}
+	// Roles with an mfa_serial need a code; with --stdin, stdin carries cluster names
+	f.mfaProvider = mfaTokenProvider(f.mfaToken, os.Stdin, stderr, !f.fromStdin && isTerminal(os.Stdin))
 
-func main() {
 	ctx := context.Background()
-	dcl := DefaultConfigLoader{}
-
-	// Create clients
-	stsClient := newSTSClient(ctx, &dcl)
-	ec2Client := newEC2Client(ctx, &dcl)
-
-	// Get account info
-	account, err := getAccountInfo(ctx, stsClient)
-	if err != nil {
-		log.Fatalf("Failed to get account info: %v", err)
+	if f.watch > 0 {
+		if err := runWatch(ctx, f, scan, progress, stdout); err != nil {
+			log.Fatalf("Error writing %s output: %v", f.output, err)
+		}
+		return
 	}
-	fmt.Printf("Analyzing EKS clusters for AWS Account: %s\n\n", *account)
 
-	// Get regions
-	regions, err := listAwsRegions(ec2Client)
-	if err != nil {
-		log.Fatalf("Failed to describe regions: %v", err)
+	if f.auditLog != "" {
+		f.audit = scanner.NewAuditLog()
+	}
+	if f.stats {
+		f.apiStats = scanner.NewStats()
 	}
 
-	// Print regions
-	printRegions(regions)
-
-	// Get EKS clusters across all regions
-	eksClient := newEKSClient(ctx, &dcl)
-	clusters, err := getAllClusters(ctx, regions, eksClient)
-	if err != nil {
-		log.Fatalf("Error getting clusters: %v", err)
+	// scanErr is a late scan failure; the partial result is rendered before exiting
+	start := time.Now()
+	results, profilesResult, scanErr := scan(ctx, f, progress)
+	if f.apiStats != nil {
+		counts := f.apiStats.Counts()
+		counts.Elapsed = time.Since(start)
+		printStats(stderr, counts)
+		// JSON output carries the stats too, on the document it writes
+		switch {
+		case profilesResult != nil:
+			profilesResult.Stats = &counts
+		case len(results) == 1:
+			results[0].Stats = &counts
+		}
+	}
+	if f.audit != nil {
+		// Written before anything else can fail, so failed runs are audited too
+		if err := writeJSONFile(f.auditLog, f.audit.Finish(results, scanErr), false); err != nil {
+			log.Fatalf("Error writing audit log: %v", err)
+		}
+	}
+	if results == nil {
+		if errors.Is(scanErr, scanner.ErrMFARequired) {
+			log.Fatalf("%v; pass --mfa-token or run in a terminal to be prompted", scanErr)
+		}
+		log.Fatal(scanErr)
 	}
 
-	fmt.Printf("Total clusters found: %d\n", len(clusters.Names))
+	if f.baselineFile != "" {
+		for _, result := range results {
+			result.Drift = scanner.CompareBaseline(result, baseline)
+		}
+	}
 
-	// Get cluster endpoints
-	err = getClusterEndpoints(ctx, eksClient, clusters)
-	if err != nil {
-		log.Fatalf("Error getting cluster endpoints: %v", err)
+	if f.newSince != "" {
+		f.newClusters = keepNewClusters(results, prior)
+	}
+	if f.sortBy != "" {
+		sortClusters(results, f.sortBy, f.reverse)
+	}
+	if f.execCommand != "" {
+		runExec(ctx, f.execCommand, f.execConcurrency, f.execTimeout, results, stderr)
 	}
 
-	// Print endpoints
-	for _, v := range clusters.Urls {
-		fmt.Println(v)
+	if f.checksum {
+		if err := setChecksums(results); err != nil {
+			log.Fatalf("Error computing checksums: %v", err)
+		}
 	}
-}
 
-// getAccountInfo retrieves the AWS account ID
-func getAccountInfo(ctx context.Context, client STSClient) (*string, error) {
-	clientDetails, err := client.GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+	// Render into a buffer for --output-file, which is written once complete
+	var sink io.Writer = os.Stdout
+	var fileData bytes.Buffer
+	if f.outputFile != "" {
+		sink = &fileData
+	}
+	out, digest := outputWriter(f, sink)
+
+	switch {
+	case f.outputDir != "":
+		var paths []string
+		paths, err = writeOutputDir(f.outputDir, f.splitBy, results, f.checksum)
+		for _, path := range paths {
+			fmt.Fprintf(progress, "Wrote %s\n", path)
+		}
+	case f.query != nil:
+		if profilesResult != nil {
+			err = printJSONPath(out, f.query, profilesResult)
+		} else {
+			err = printJSONPath(out, f.query, results[0])
+		}
+	case f.groupKeys != nil:
+		err = printGrouped(out, f.output, results, f.groupKeys, f.renderOptions())
+	case f.interactive && isTerminal(os.Stdin) && isTerminal(os.Stdout):
+		err = runPicker(os.Stdin, out, results)
+	case f.summaryOnly:
+		err = printSummary(out, f.output, results, f.compact)
+	case profilesResult != nil:
+		err = renderProfiles(out, f.output, profilesResult, f.renderOptions())
+	default:
+		err = render(out, f.output, results[0], f.renderOptions())
+	}
+	if err == nil && f.outputFile != "" {
+		err = writeOutputFile(f.outputFile, fileData.Bytes(), f.checksum)
+		if err == nil {
+			fmt.Fprintf(progress, "Wrote %s\n", f.outputFile)
+		}
+	}
+	if err == nil && digest != nil {
+		err = writeChecksumTrailer(f, stdout, stderr, digest)
+	}
 	if err != nil {
-		return nil, err
+		log.Fatalf("Error writing %s output: %v", f.output, err)
 	}
-	return clientDetails.Account, nil
-}
-
-// printRegions prints the list of AWS regions
-func printRegions(regions []string) {
-	fmt.Println("Available AWS Regions:")
-	for _, region := range regions {
-		fmt.Printf("* %s \n", region)
+	if scanErr != nil {
+		log.Fatalf("Scan incomplete, partial results written: %v", scanErr)
 	}
-}
-
-// getAllClusters gets all EKS clusters across specified regions
-func getAllClusters(ctx context.Context, regions []string, baseClient EKSClient) (*Clusters, error) {
-	clusters := &Clusters{}
 
-	// Load AWS config
-	cfg, err := config.LoadDefaultConfig(ctx)
-	if err != nil {
-		return nil, err
+	if f.dynamoDBTable != "" {
+		n, err := exportDynamoDB(ctx, f, results)
+		if err != nil {
+			log.Fatalf("Error writing to DynamoDB table %s: %v", f.dynamoDBTable, err)
+		}
+		fmt.Fprintf(progress, "Wrote %d clusters to DynamoDB table %s\n", n, f.dynamoDBTable)
 	}
 
-	// Iterate through each region
-	for _, region := range regions {
-		// Create a new EKS client for each region
-		regionCfg := cfg.Copy()
-		regionCfg.Region = region
+	if f.syslog {
+		if err := logFindings(openFindingsLogger(f.syslogAddr, stderr), results); err != nil {
+			log.Printf("Error writing findings to syslog: %v", err)
+		}
+	}
 
-		eksClient := eks.NewFromConfig(regionCfg)
+	// Guardrails are checked after output so the findings are always visible
+	if failures := checkGuardrails(f, results); len(failures) > 0 {
+		if f.strict {
+			log.Fatalf("Strict mode: %s", strings.Join(failures, "; "))
+		}
+		log.Fatalf("Guardrails failed: %s", strings.Join(failures, "; "))
+	}
 
-		fmt.Printf("Checking region: %s\n", region)
+	if f.countExitCode {
+		os.Exit(countExitCode(countClusters(results, func(*scanner.Cluster) bool { return true })))
+	}
+}
 
-		// List clusters in this region
-		clustersListOutput, err := eksClient.ListClusters(ctx, &eks.ListClustersInput{})
+// scan runs the scan selected by the flags. After a late failure it returns
+// the partial results along with the error; results are nil when the scan
+// collected nothing.
+func scan(ctx context.Context, f *cliFlags, progress io.Writer) ([]*scanner.ScanResult, *scanner.ProfilesResult, error) {
+	var result *scanner.ScanResult
+	var err error
+	switch {
+	case f.allProfiles:
+		profiles, err := scanner.ListProfiles(scanner.SharedConfigPath())
 		if err != nil {
-			fmt.Printf("Error listing clusters in region %s: %v\n", region, err)
-			continue // Skip to next region instead of fatal error
+			return nil, nil, fmt.Errorf("listing profiles: %w", err)
 		}
-
-		for _, v := range clustersListOutput.Clusters {
-			clusters.Names = append(clusters.Names, v)
-			fmt.Printf("Found cluster: %s in region: %s\n", v, region)
+		profilesResult := scanner.ScanProfiles(ctx, profiles, f.scannerOptions(progress)...)
+		return profilesResult.Profiles, profilesResult, profilesResult.Err()
+	case f.arns != nil:
+		result, err = scanner.NewScanner(f.scannerOptions(progress)...).DescribeARNs(ctx, f.arns)
+	case f.fromStdin:
+		names, readErr := readNames(os.Stdin)
+		if readErr != nil {
+			return nil, nil, fmt.Errorf("reading cluster names from stdin: %w", readErr)
 		}
+		result, err = scanner.NewScanner(f.scannerOptions(progress)...).Describe(ctx, f.region, names)
+	default:
+		result, err = scanner.NewScanner(f.scannerOptions(progress)...).Run(ctx)
 	}
-
-	return clusters, nil
+	if result == nil {
+		return nil, nil, err
+	}
+	return []*scanner.ScanResult{result}, nil, err
 }
 
-// getClusterEndpoints retrieves the endpoints for each cluster
-func getClusterEndpoints(ctx context.Context, client EKSClient, clusters *Clusters) error {
-	for _, v := range clusters.Names {
-		clusterInfo, err := client.DescribeCluster(ctx, &eks.DescribeClusterInput{Name: &v})
-		if err != nil {
-			return err
+// printStats writes the API call statistics of the scan
+func printStats(w io.Writer, counts scanner.StatsCounts) {
+	fmt.Fprintf(w, "Stats: %d API calls (%d failed) in %s\n", counts.Calls, counts.Failed, counts.Elapsed.Round(time.Millisecond))
+	retries := make([]string, 0, len(scanner.RetryCauses))
+	for _, cause := range scanner.RetryCauses {
+		retries = append(retries, fmt.Sprintf("%s %d", cause, counts.Retries[cause]))
+	}
+	fmt.Fprintf(w, "Stats: retries: %s\n", strings.Join(retries, ", "))
+	var phases []string
+	for _, phase := range scanner.Phases {
+		if d, ok := counts.Phases[phase]; ok {
+			phases = append(phases, fmt.Sprintf("%s %s", phase, d.Round(time.Millisecond)))
 		}
-		clusters.Urls = append(clusters.Urls, *clusterInfo.Cluster.Endpoint)
 	}
-	return nil
+	if len(phases) > 0 {
+		fmt.Fprintf(w, "Stats: phases: %s\n", strings.Join(phases, ", "))
+	}
+	// Slowest regions first, as they are the ones to look into
+	regions := slices.Collect(maps.Keys(counts.Regions))
+	slices.SortFunc(regions, func(a, b string) int {
+		return cmp.Or(cmp.Compare(counts.Regions[b], counts.Regions[a]), cmp.Compare(a, b))
+	})
+	for i, region := range regions {
+		regions[i] = fmt.Sprintf("%s %s", region, counts.Regions[region].Round(time.Millisecond))
+	}
+	if len(regions) > 0 {
+		fmt.Fprintf(w, "Stats: regions: %s\n", strings.Join(regions, ", "))
+	}
 }
 
-// Create a new STS client using the provided config loader
-func newSTSClient(ctx context.Context, loader ConfigLoader) *sts.Client {
-	cfg, err := loader.LoadDefaultConfigMethod(ctx)
-	if err != nil {
-		log.Fatal(err)
+const (
+	// countExitOffset is added to the cluster count so it never collides with
+	// the exit codes of errors (1) and flag errors (2)
+	countExitOffset = 3
+	// countExitOverflow is the exit code bit set when the cluster count does not fit
+	countExitOverflow = 0x80
+)
+
+// countExitCode maps a cluster count to an exit code: counts from 0 to 124
+// exit with the count plus countExitOffset (3 to 127), larger counts with 127
+// and countExitOverflow set (255)
+func countExitCode(n int) int {
+	if n+countExitOffset < countExitOverflow {
+		return n + countExitOffset
 	}
-	return sts.NewFromConfig(cfg)
+	return countExitOverflow | (countExitOverflow - 1)
 }
 
-// Create a new EKS client using the provided config loader
-func newEKSClient(ctx context.Context, loader ConfigLoader) *eks.Client {
-	cfg, err := loader.LoadDefaultConfigMethod(ctx)
+// loadBaselineFile reads the approved cluster list from path
+func loadBaselineFile(path string) ([]scanner.ClusterRef, error) {
+	f, err := os.Open(path)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
-	return eks.NewFromConfig(cfg)
+	defer f.Close()
+	return scanner.LoadBaseline(f)
 }
 
-// Create a new EC2 client using the provided config loader
-func newEC2Client(ctx context.Context, loader ConfigLoader) *ec2.Client {
-	cfg, err := loader.LoadDefaultConfigMethod(ctx)
+// loadProfileRegionMap reads a JSON object mapping profile names to region lists
+func loadProfileRegionMap(path string) (map[string][]string, error) {
+	data, err := os.ReadFile(path)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
+	}
+	var regions map[string][]string
+	if err := json.Unmarshal(data, &regions); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	for profile, rs := range regions {
+		if len(rs) == 0 {
+			return nil, fmt.Errorf("%s: profile %s lists no regions", path, profile)
+		}
+		for _, region := range rs {
+			if !validRegion(region) {
+				return nil, fmt.Errorf("%s: invalid region %q for profile %s", path, region, profile)
+			}
+		}
 	}
-	return ec2.NewFromConfig(cfg)
+	return regions, nil
 }
 
-// listAwsRegions gets all available AWS regions
-func listAwsRegions(ec2Client EC2Client) ([]string, error) {
-	var regionsSlice []string
-	regionsOutput, err := ec2Client.DescribeRegions(context.TODO(), &ec2.DescribeRegionsInput{
-		// Optional: Set to true to include disabled regions
-		AllRegions: aws.Bool(true),
-	})
-
-	if err != nil {
-		fmt.Println(err)
-		return []string{}, err
+// loadClusterARNs parses the --cluster-arns value: a comma-separated list, or
+// @path to read one ARN per line (blank lines and # comments are skipped)
+func loadClusterARNs(v string) ([]scanner.ClusterARN, error) {
+	values := splitList(v)
+	if path, ok := strings.CutPrefix(v, "@"); ok {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		if values, err = readNames(file); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	if len(values) == 0 {
+		return nil, fmt.Errorf("no cluster ARNs given")
 	}
 
-	for _, region := range regionsOutput.Regions {
-		regionsSlice = append(regionsSlice, string(*region.RegionName))
+	arns := make([]scanner.ClusterARN, 0, len(values))
+	for _, value := range values {
+		arn, err := scanner.ParseClusterARN(value)
+		if err != nil {
+			return nil, err
+		}
+		if !validRegion(arn.Region) {
+			return nil, fmt.Errorf("invalid region %q in cluster ARN %q", arn.Region, value)
+		}
+		arns = append(arns, arn)
 	}
+	return arns, nil
+}
 
-	return regionsSlice, err
+// readNames reads one cluster name per line, skipping blank lines and # comments
+func readNames(r io.Reader) ([]string, error) {
+	var names []string
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		names = append(names, line)
+	}
+	return names, sc.Err()
 }
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"shift-left-shuffle/scanner"
+)
+
+func TestReadNames(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{name: "empty"},
+		{name: "one per line", input: "prod\ndev\n", want: []string{"prod", "dev"}},
+		{name: "no trailing newline", input: "prod\ndev", want: []string{"prod", "dev"}},
+		{name: "blank lines, comments and spaces", input: "# clusters\n\n  prod  \r\n\t\n#dev\nstaging\n", want: []string{"prod", "staging"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readNames(strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("readNames() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadClusterARNs(t *testing.T) {
+	const prod, eu = "arn:aws:eks:us-east-1:123456789012:cluster/prod", "arn:aws:eks:eu-west-1:123456789012:cluster/eu"
+	path := filepath.Join(t.TempDir(), "arns.txt")
+	if err := os.WriteFile(path, []byte("# production\n"+prod+"\n\n"+eu+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		value   string
+		want    []string
+		wantErr string
+	}{
+		{name: "comma-separated", value: prod + ", " + eu + ",", want: []string{prod, eu}},
+		{name: "file", value: "@" + path, want: []string{prod, eu}},
+		{name: "missing file", value: "@" + path + ".missing", wantErr: "no such file"},
+		{name: "empty", value: ",", wantErr: "no cluster ARNs given"},
+		{name: "malformed", value: prod + ",arn:aws:eks:us-east-1:123456789012:prod", wantErr: `invalid EKS cluster ARN "arn:aws:eks:us-east-1:123456789012:prod"`},
+		{name: "invalid region", value: "arn:aws:eks:useast1:123456789012:cluster/prod", wantErr: `invalid region "useast1" in cluster ARN`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			arns, err := loadClusterARNs(tt.value)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, arn := range arns {
+				got = append(got, arn.String())
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("ARNs = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadProfileRegionMap(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    map[string][]string
+		wantErr string
+	}{
+		{name: "empty map", data: `{}`, want: map[string][]string{}},
+		{name: "profiles", data: `{"prod": ["us-east-1", "eu-west-1"], "dev": ["us-west-2"]}`, want: map[string][]string{"prod": {"us-east-1", "eu-west-1"}, "dev": {"us-west-2"}}},
+		{name: "not an object", data: `["us-east-1"]`, wantErr: "cannot unmarshal"},
+		{name: "no regions", data: `{"prod": []}`, wantErr: "profile prod lists no regions"},
+		{name: "invalid region", data: `{"prod": ["us-east"]}`, wantErr: `invalid region "us-east" for profile prod`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "regions.json")
+			if err := os.WriteFile(path, []byte(tt.data), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			got, err := loadProfileRegionMap(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("regions = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCountExitCode(t *testing.T) {
+	tests := []struct {
+		clusters int
+		want     int
+	}{
+		{0, 3},
+		{1, 4},
+		{42, 45},
+		{124, 127},
+		{125, 255},
+		{252, 255},
+		{10000, 255},
+	}
+	for _, tt := range tests {
+		if got := countExitCode(tt.clusters); got != tt.want {
+			t.Errorf("countExitCode(%d) = %d, want %d", tt.clusters, got, tt.want)
+		}
+		// Never an error or flag error code, and always a valid exit status
+		if got := countExitCode(tt.clusters); got == 1 || got == 2 || got < 0 || got > 255 {
+			t.Errorf("countExitCode(%d) = %d collides with an error exit code", tt.clusters, got)
+		}
+	}
+}
+
+func TestCountClusters(t *testing.T) {
+	results := []*scanner.ScanResult{
+		{Clusters: []scanner.Cluster{{Name: "a", EOL: true}, {Name: "b"}}},
+		{Clusters: []scanner.Cluster{{Name: "c", EOL: true}}},
+		{},
+	}
+	if n := countClusters(results, func(*scanner.Cluster) bool { return true }); n != 3 {
+		t.Errorf("all clusters = %d, want 3", n)
+	}
+	if n := countClusters(results, func(c *scanner.Cluster) bool { return c.EOL }); n != 2 {
+		t.Errorf("EOL clusters = %d, want 2", n)
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"shift-left-shuffle/scanner"
+)
+
+// markdownEscaper escapes characters that would break a GitHub-flavored Markdown table cell
+var markdownEscaper = strings.NewReplacer(`\`, `\\`, "|", `\|`, "\n", " ", "\r", "")
+
+// printMarkdown writes a summary line and one table row per cluster, with
+// creation times in loc (local time when nil)
+func printMarkdown(w io.Writer, result *scanner.ScanResult, loc *time.Location) error {
+	account := "`" + result.Account + "`"
+	if result.AccountAlias != "" {
+		account += " (" + markdownEscaper.Replace(result.AccountAlias) + ")"
+	}
+	incomplete := ""
+	if result.Incomplete {
+		incomplete = " (incomplete scan, results are partial)"
+	}
+	_, err := fmt.Fprintf(w, "**%d clusters** in account %s across %d regions%s\n\n", len(result.Clusters), account, len(result.Regions), incomplete)
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	b.WriteString("| Name | Region | Endpoint | Created | Error |\n")
+	b.WriteString("| --- | --- | --- | --- | --- |\n")
+	for _, c := range result.Clusters {
+		created := ""
+		if c.CreatedAt != nil {
+			created = formatTime(*c.CreatedAt, loc)
+		}
+		cells := []string{c.Name, c.Region, c.Endpoint, created, c.DescribeError}
+		for i, cell := range cells {
+			cells[i] = markdownEscaper.Replace(cell)
+		}
+		b.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+	}
+	if len(result.Warnings) > 0 {
+		b.WriteString("\n**Warnings**\n\n")
+		for _, warning := range result.Warnings {
+			fmt.Fprintf(&b, "- `%s` %s (%s) %s\n", warning.Code, markdownEscaper.Replace(warning.Cluster), warning.Region, markdownEscaper.Replace(warning.Message))
+		}
+	}
+	_, err = io.WriteString(w, b.String())
+	return err
+}
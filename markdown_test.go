@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"shift-left-shuffle/scanner"
+)
+
+func TestPrintMarkdown(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*scanner.ScanResult)
+		want   []string
+	}{
+		{
+			name: "summary and rows",
+			want: []string{
+				"**2 clusters** in account `123456789012` across 2 regions\n\n",
+				"| Name | Region | Endpoint | Created | Error |\n| --- | --- | --- | --- | --- |\n",
+				"| prod | us-east-1 | https://prod.example.com | 2024-03-01T12:00:00Z |  |\n",
+			},
+		},
+		{
+			name:   "alias and incomplete",
+			modify: func(r *scanner.ScanResult) { r.AccountAlias = "corp|main"; r.Incomplete = true },
+			want:   []string{"in account `123456789012` (corp\\|main) across 2 regions (incomplete scan, results are partial)"},
+		},
+		{
+			name: "cells escaped",
+			modify: func(r *scanner.ScanResult) {
+				r.Clusters[0].DescribeError = "denied | see\nlogs\r"
+				r.Clusters[0].CreatedAt = nil
+			},
+			want: []string{"| prod | us-east-1 | https://prod.example.com |  | denied \\| see logs |\n"},
+		},
+		{
+			name: "warnings",
+			modify: func(r *scanner.ScanResult) {
+				r.Warnings = []scanner.Warning{{Code: "eol", Cluster: "legacy", Region: "eu-west-1", Message: "1.24 | unsupported"}}
+			},
+			want: []string{"\n**Warnings**\n\n- `eol` legacy (eu-west-1) 1.24 \\| unsupported\n"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := sampleResult()
+			if tt.modify != nil {
+				tt.modify(result)
+			}
+			var buf bytes.Buffer
+			if err := printMarkdown(&buf, result, time.UTC); err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(buf.String(), want) {
+					t.Errorf("markdown lacks %q:\n%s", want, buf.String())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// mfaTokenProvider returns the MFA code source for profiles assuming a role
+// with an mfa_serial: the --mfa-token code when given, otherwise a prompt on
+// prompt reading a line from in, or nil when there is no terminal to prompt
+// on. Prompts are serialized, as several profiles may assume roles at once.
+func mfaTokenProvider(token string, in io.Reader, prompt io.Writer, interactive bool) func() (string, error) {
+	if token != "" {
+		return func() (string, error) { return token, nil }
+	}
+	if !interactive {
+		return nil
+	}
+	var mu sync.Mutex
+	reader := bufio.NewReader(in)
+	return func() (string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		fmt.Fprint(prompt, "MFA code: ")
+		line, err := reader.ReadString('\n')
+		if code := strings.TrimSpace(line); code != "" {
+			return code, nil
+		}
+		if err != nil {
+			return "", fmt.Errorf("reading MFA code: %w", err)
+		}
+		return "", fmt.Errorf("no MFA code entered")
+	}
+}
+
+// validMFAToken reports whether token looks like a six-digit MFA code
+func validMFAToken(token string) bool {
+	return len(token) == 6 && strings.Trim(token, "0123456789") == ""
+}
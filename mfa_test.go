@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestMFATokenProvider(t *testing.T) {
+	tests := []struct {
+		name        string
+		token       string
+		input       string
+		interactive bool
+		wantNil     bool
+		want        []string
+		wantErr     string
+	}{
+		{name: "token flag", token: "123456", want: []string{"123456", "123456"}},
+		{name: "token flag without terminal", token: "123456", input: "654321\n", want: []string{"123456"}},
+		{name: "no terminal", wantNil: true},
+		{name: "prompted per role", input: "111111\n 222222 \n", interactive: true, want: []string{"111111", "222222"}},
+		{name: "last line without newline", input: "111111", interactive: true, want: []string{"111111"}},
+		{name: "end of input", interactive: true, wantErr: "reading MFA code: EOF"},
+		{name: "empty line", input: "\n", interactive: true, wantErr: "no MFA code entered"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var prompt bytes.Buffer
+			provider := mfaTokenProvider(tt.token, strings.NewReader(tt.input), &prompt, tt.interactive)
+			if tt.wantNil {
+				if provider != nil {
+					t.Error("provider without a token or terminal, want nil")
+				}
+				return
+			}
+			for _, want := range tt.want {
+				if got, err := provider(); err != nil || got != want {
+					t.Errorf("provider() = %q, %v; want %q", got, err, want)
+				}
+			}
+			if tt.wantErr != "" {
+				if _, err := provider(); err == nil || err.Error() != tt.wantErr {
+					t.Errorf("provider() err = %v, want %q", err, tt.wantErr)
+				}
+			}
+			if prompts := strings.Count(prompt.String(), "MFA code: "); tt.interactive && prompts == 0 || tt.token != "" && prompts != 0 {
+				t.Errorf("prompted %d times", prompts)
+			}
+		})
+	}
+}
+
+func TestValidMFAToken(t *testing.T) {
+	for token, want := range map[string]bool{"123456": true, "000000": true, "12345": false, "1234567": false, "12345a": false, "": false} {
+		if got := validMFAToken(token); got != want {
+			t.Errorf("validMFAToken(%q) = %t, want %t", token, got, want)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"slices"
+
+	"shift-left-shuffle/scanner"
+)
+
+// keepNewClusters drops from results every cluster found in the prior scan,
+// matched by account, region and name, and returns how many clusters remain.
+// Warnings are collected again for the remaining clusters; per-region counts
+// and cost totals still describe the whole scan.
+func keepNewClusters(results []*scanner.ScanResult, prior []scanner.AccountCluster) int {
+	seen := make(map[scanner.ClusterRef]bool, len(prior))
+	for _, c := range prior {
+		seen[c.Ref()] = true
+	}
+	n := 0
+	for _, result := range results {
+		result.Clusters = slices.DeleteFunc(result.Clusters, func(c scanner.Cluster) bool {
+			return seen[scanner.ClusterRef{Account: result.Account, Region: c.Region, Name: c.Name}]
+		})
+		result.Warnings = scanner.CollectWarnings(result.Clusters)
+		n += len(result.Clusters)
+	}
+	return n
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"shift-left-shuffle/scanner"
+)
+
+func TestKeepNewClusters(t *testing.T) {
+	prior := func(account, region, name string) scanner.AccountCluster {
+		return scanner.AccountCluster{Account: account, Cluster: scanner.Cluster{Name: name, Region: region}}
+	}
+	tests := []struct {
+		name  string
+		prior []scanner.AccountCluster
+		want  []string
+	}{
+		{name: "empty prior scan", want: []string{"prod", "legacy"}},
+		{name: "nothing new", prior: []scanner.AccountCluster{prior("123456789012", "us-east-1", "prod"), prior("123456789012", "eu-west-1", "legacy")}},
+		{name: "one new", prior: []scanner.AccountCluster{prior("123456789012", "us-east-1", "prod")}, want: []string{"legacy"}},
+		{name: "same name in another region", prior: []scanner.AccountCluster{prior("123456789012", "eu-west-1", "prod"), prior("123456789012", "eu-west-1", "legacy")}, want: []string{"prod"}},
+		{name: "same name in another account", prior: []scanner.AccountCluster{prior("210987654321", "us-east-1", "prod"), prior("123456789012", "eu-west-1", "legacy")}, want: []string{"prod"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := sampleResult()
+			n := keepNewClusters([]*scanner.ScanResult{result}, tt.prior)
+			var names []string
+			for _, c := range result.Clusters {
+				names = append(names, c.Name)
+			}
+			if n != len(tt.want) || !slices.Equal(names, tt.want) {
+				t.Errorf("kept %d clusters %q, want %q", n, names, tt.want)
+			}
+			eol := slices.ContainsFunc(result.Warnings, func(w scanner.Warning) bool { return w.Code == scanner.WarningEOL })
+			if eol != slices.Contains(tt.want, "legacy") {
+				t.Errorf("warnings = %+v, want the EOL warning only while legacy is kept", result.Warnings)
+			}
+		})
+	}
+}
+
+func TestNewSinceSavedScan(t *testing.T) {
+	saved := sampleResult()
+	saved.Clusters = saved.Clusters[:1]
+	prior, err := readClustersFile(writeScan(t, saved), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{name: "reported only", args: []string{"--new-since", "prior.json"}},
+		{name: "alert on new", args: []string{"--new-since", "prior.json", "--alert-on-new"}, want: []string{"1 new clusters since prior.json: legacy (eu-west-1)"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := []*scanner.ScanResult{sampleResult()}
+			f := testFlags(t, tt.args...)
+			f.newClusters = keepNewClusters(results, prior)
+			if f.newClusters != 1 || results[0].Clusters[0].Name != "legacy" {
+				t.Fatalf("kept %d clusters %+v, want only legacy", f.newClusters, results[0].Clusters)
+			}
+			if got := checkGuardrails(f, results); strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("failures = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("nothing new", func(t *testing.T) {
+		results := []*scanner.ScanResult{saved}
+		f := testFlags(t, "--new-since", "prior.json", "--alert-on-new")
+		f.newClusters = keepNewClusters(results, prior)
+		if got := checkGuardrails(f, results); len(got) != 0 {
+			t.Errorf("failures = %q, want none", got)
+		}
+	})
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"shift-left-shuffle/scanner"
+)
+
+// openMetricsEscaper escapes label values as the OpenMetrics text format requires
+var openMetricsEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// metricFamily collects the samples of one OpenMetrics metric family. The
+// exposition format requires all samples of a family to be written together.
+type metricFamily struct {
+	name    string
+	kind    string
+	unit    string
+	help    string
+	samples []string
+}
+
+// add records a sample of the family; suffix is appended to the family name,
+// as counters require ("_total"), and labels alternate names and values
+func (m *metricFamily) add(suffix string, value int64, labels ...string) {
+	var b strings.Builder
+	b.WriteString(m.name + suffix)
+	if len(labels) > 0 {
+		b.WriteByte('{')
+		for i := 0; i+1 < len(labels); i += 2 {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			b.WriteString(labels[i] + `="` + openMetricsEscaper.Replace(labels[i+1]) + `"`)
+		}
+		b.WriteByte('}')
+	}
+	b.WriteString(" " + strconv.FormatInt(value, 10))
+	m.samples = append(m.samples, b.String())
+}
+
+// write writes the family metadata and samples in the OpenMetrics format;
+// families without samples are omitted
+func (m *metricFamily) write(b *strings.Builder) {
+	if len(m.samples) == 0 {
+		return
+	}
+	b.WriteString("# TYPE " + m.name + " " + m.kind + "\n")
+	if m.unit != "" {
+		b.WriteString("# UNIT " + m.name + " " + m.unit + "\n")
+	}
+	b.WriteString("# HELP " + m.name + " " + m.help + "\n")
+	for _, sample := range m.samples {
+		b.WriteString(sample + "\n")
+	}
+}
+
+// writePrometheus writes the family in the Prometheus text format, which
+// names a family after its samples, suffix included, has no unit metadata
+// and no info type, so info families are written as gauges
+func (m *metricFamily) writePrometheus(b *strings.Builder) {
+	if len(m.samples) == 0 {
+		return
+	}
+	name, kind := m.name, m.kind
+	switch kind {
+	case "counter":
+		name += "_total"
+	case "info":
+		name, kind = name+"_info", "gauge"
+	}
+	b.WriteString("# HELP " + name + " " + m.help + "\n")
+	b.WriteString("# TYPE " + name + " " + kind + "\n")
+	for _, sample := range m.samples {
+		b.WriteString(sample + "\n")
+	}
+}
+
+// printOpenMetrics writes the scans in the OpenMetrics text exposition format,
+// terminated by # EOF
+func printOpenMetrics(w io.Writer, results []*scanner.ScanResult) error {
+	var b strings.Builder
+	for _, family := range metricFamilies(results) {
+		family.write(&b)
+	}
+	b.WriteString("# EOF\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// printPrometheus writes the scans in the Prometheus text exposition format
+// (version 0.0.4), with the same series as printOpenMetrics
+func printPrometheus(w io.Writer, results []*scanner.ScanResult) error {
+	var b strings.Builder
+	for _, family := range metricFamilies(results) {
+		family.writePrometheus(&b)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// metricFamilies returns the metric families of the scans. Series are labeled
+// with the account, and with the profile when several profiles were scanned,
+// so one exposition can hold every scan of a run.
+func metricFamilies(results []*scanner.ScanResult) []*metricFamily {
+	scanTime := &metricFamily{name: "eks_scan_timestamp_seconds", kind: "gauge", unit: "seconds", help: "Time the scan was generated."}
+	regions := &metricFamily{name: "eks_scan_regions", kind: "gauge", help: "Number of regions scanned."}
+	regionErrors := &metricFamily{name: "eks_scan_region_errors", kind: "counter", help: "Number of regions whose clusters could not be listed."}
+	incomplete := &metricFamily{name: "eks_scan_incomplete", kind: "gauge", help: "Whether the scan failed after listing clusters and is partial (1) or not (0)."}
+	clusters := &metricFamily{name: "eks_clusters", kind: "gauge", help: "Number of clusters found in the region."}
+	info := &metricFamily{name: "eks_cluster", kind: "info", help: "Cluster version and endpoint exposure."}
+	created := &metricFamily{name: "eks_cluster_created_timestamp_seconds", kind: "gauge", unit: "seconds", help: "Time the cluster was created."}
+	undescribed := &metricFamily{name: "eks_cluster_undescribed", kind: "gauge", help: "Whether DescribeCluster failed for the cluster (1) or not (0)."}
+
+	for _, result := range results {
+		scanLabels := []string{"account", result.Account}
+		if result.Profile != "" {
+			scanLabels = append(scanLabels, "profile", result.Profile)
+		}
+		scanTime.add("", result.GeneratedAt.Unix(), scanLabels...)
+		regions.add("", int64(len(result.Regions)), scanLabels...)
+		regionErrors.add("_total", int64(len(result.RegionErrors)), scanLabels...)
+		incomplete.add("", boolMetric(result.Incomplete), scanLabels...)
+
+		perRegion := make(map[string]int64)
+		for _, c := range result.Clusters {
+			perRegion[c.Region]++
+		}
+		for _, region := range result.Regions {
+			clusters.add("", perRegion[region], slices.Concat(scanLabels, []string{"region", region})...)
+		}
+
+		for _, c := range result.Clusters {
+			clusterLabels := slices.Concat(scanLabels, []string{"region", c.Region, "name", c.Name})
+			undescribed.add("", boolMetric(c.Undescribed), clusterLabels...)
+			if c.Undescribed {
+				continue
+			}
+			info.add("_info", 1, slices.Concat(clusterLabels, []string{"version", c.Version, "platform_version", c.PlatformVersion, "public_access", publicAccess(&c)})...)
+			if c.CreatedAt != nil {
+				created.add("", c.CreatedAt.Unix(), clusterLabels...)
+			}
+		}
+	}
+
+	return []*metricFamily{scanTime, regions, regionErrors, incomplete, clusters, info, created, undescribed}
+}
+
+// boolMetric converts a flag to a 0 or 1 sample value
+func boolMetric(v bool) int64 {
+	if v {
+		return 1
+	}
+	return 0
+}
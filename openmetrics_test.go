@@ -0,0 +1,166 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"github.com/prometheus/common/model"
+
+	"shift-left-shuffle/scanner"
+)
+
+// parseExposition parses text in the Prometheus text format with expfmt
+func parseExposition(t *testing.T, text string) map[string]*dto.MetricFamily {
+	t.Helper()
+	parser := expfmt.NewTextParser(model.UTF8Validation)
+	families, err := parser.TextToMetricFamilies(strings.NewReader(text))
+	if err != nil {
+		t.Fatalf("invalid exposition: %v\n%s", err, text)
+	}
+	return families
+}
+
+// samples flattens families into "name{label=value,...} value" strings with
+// labels in the order written
+func samples(families map[string]*dto.MetricFamily) map[string]float64 {
+	out := make(map[string]float64)
+	for name, family := range families {
+		for _, m := range family.Metric {
+			var labels []string
+			for _, l := range m.Label {
+				labels = append(labels, l.GetName()+"="+l.GetValue())
+			}
+			key := name + "{" + strings.Join(labels, ",") + "}"
+			switch {
+			case m.Counter != nil:
+				out[key] = m.Counter.GetValue()
+			case m.Gauge != nil:
+				out[key] = m.Gauge.GetValue()
+			case m.Untyped != nil:
+				out[key] = m.Untyped.GetValue()
+			}
+		}
+	}
+	return out
+}
+
+// metricsResults returns two profiles' scans, one with a label value that
+// needs escaping and an undescribed cluster
+func metricsResults() []*scanner.ScanResult {
+	first := sampleResult()
+	first.Profile = "prod"
+	second := sampleResult()
+	second.Profile = `dev "eu"`
+	second.Account = "210987654321"
+	second.RegionErrors = []scanner.RegionError{{Region: "ap-south-1", Error: "throttled"}}
+	second.Clusters = append(second.Clusters, scanner.Cluster{Name: "broken", Region: "us-east-1", Undescribed: true})
+	return []*scanner.ScanResult{first, second}
+}
+
+func TestPrometheusParses(t *testing.T) {
+	var out bytes.Buffer
+	if err := printPrometheus(&out, metricsResults()); err != nil {
+		t.Fatal(err)
+	}
+	families := parseExposition(t, out.String())
+	wantTypes := map[string]dto.MetricType{
+		"eks_scan_timestamp_seconds":            dto.MetricType_GAUGE,
+		"eks_scan_regions":                      dto.MetricType_GAUGE,
+		"eks_scan_region_errors_total":          dto.MetricType_COUNTER,
+		"eks_scan_incomplete":                   dto.MetricType_GAUGE,
+		"eks_clusters":                          dto.MetricType_GAUGE,
+		"eks_cluster_info":                      dto.MetricType_GAUGE,
+		"eks_cluster_created_timestamp_seconds": dto.MetricType_GAUGE,
+		"eks_cluster_undescribed":               dto.MetricType_GAUGE,
+	}
+	for name, want := range wantTypes {
+		family, ok := families[name]
+		if !ok {
+			t.Errorf("family %s missing", name)
+			continue
+		}
+		if family.GetType() != want {
+			t.Errorf("family %s has type %s, want %s", name, family.GetType(), want)
+		}
+	}
+	got := samples(families)
+	for key, want := range map[string]float64{
+		`eks_scan_region_errors_total{account=210987654321,profile=dev "eu"}`:                         1,
+		`eks_cluster_undescribed{account=210987654321,profile=dev "eu",region=us-east-1,name=broken}`: 1,
+		`eks_clusters{account=123456789012,profile=prod,region=us-east-1}`:                            1,
+	} {
+		if got[key] != want {
+			t.Errorf("sample %s = %v, want %v", key, got[key], want)
+		}
+	}
+	if strings.Contains(out.String(), "# EOF") || strings.Contains(out.String(), "# UNIT") {
+		t.Error("Prometheus output carries OpenMetrics-only lines")
+	}
+}
+
+func TestOpenMetricsMatchesPrometheus(t *testing.T) {
+	results := metricsResults()
+	var om, prom bytes.Buffer
+	if err := printOpenMetrics(&om, results); err != nil {
+		t.Fatal(err)
+	}
+	if err := printPrometheus(&prom, results); err != nil {
+		t.Fatal(err)
+	}
+	text := om.String()
+	if !strings.HasSuffix(text, "\n# EOF\n") || strings.Count(text, "# EOF") != 1 {
+		t.Fatalf("OpenMetrics output must end with a single # EOF:\n%s", text)
+	}
+
+	// OpenMetrics metadata: each family is declared once, before its samples,
+	// counters and info samples carry their suffix, and units match the name
+	declared := make(map[string]string)
+	var sampleLines []string
+	for _, line := range strings.Split(strings.TrimSuffix(text, "# EOF\n"), "\n") {
+		fields := strings.Fields(line)
+		switch {
+		case line == "":
+		case strings.HasPrefix(line, "# TYPE "):
+			if _, dup := declared[fields[2]]; dup {
+				t.Errorf("family %s declared twice", fields[2])
+			}
+			declared[fields[2]] = fields[3]
+		case strings.HasPrefix(line, "# UNIT "):
+			if !strings.HasSuffix(fields[2], "_"+fields[3]) {
+				t.Errorf("unit %s does not suffix family %s", fields[3], fields[2])
+			}
+		case strings.HasPrefix(line, "# HELP "):
+		default:
+			name, _, _ := strings.Cut(fields[0], "{")
+			family := name
+			for _, suffix := range []string{"_total", "_info"} {
+				if base, ok := strings.CutSuffix(name, suffix); ok && declared[base] != "" {
+					family = base
+				}
+			}
+			switch kind := declared[family]; {
+			case kind == "":
+				t.Errorf("sample %s precedes its TYPE", name)
+			case kind == "counter" && !strings.HasSuffix(name, "_total"), kind == "info" && !strings.HasSuffix(name, "_info"):
+				t.Errorf("%s sample %s lacks its suffix", kind, name)
+			}
+			sampleLines = append(sampleLines, line)
+		}
+	}
+
+	// The samples themselves parse with expfmt and are those of the
+	// Prometheus exposition
+	got := samples(parseExposition(t, strings.Join(sampleLines, "\n")+"\n"))
+	want := samples(parseExposition(t, prom.String()))
+	if len(got) != len(want) {
+		t.Errorf("OpenMetrics has %d samples, Prometheus %d", len(got), len(want))
+	}
+	for key, value := range want {
+		if v, ok := got[key]; !ok || v != value {
+			t.Errorf("OpenMetrics sample %s = %v, want %v", key, v, value)
+		}
+	}
+}
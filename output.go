@@ -0,0 +1,436 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"shift-left-shuffle/scanner"
+)
+
+// outputFormats lists the values accepted by --output
+var outputFormats = []string{"text", "json", "ndjson", "markdown", "wide", "openmetrics", "prometheus", "config", "tf-import", "upgrade-plan", "html"}
+
+// render writes result to w in the given output format
+func render(w io.Writer, format string, result *scanner.ScanResult, opts renderOptions) error {
+	switch format {
+	case "json":
+		return printJSON(w, result, opts)
+	case "ndjson":
+		return printNDJSON(w, result, opts.fields)
+	case "markdown":
+		return printMarkdown(w, result, opts.location)
+	case "wide":
+		return printWide(w, result)
+	case "openmetrics":
+		return printOpenMetrics(w, []*scanner.ScanResult{result})
+	case "prometheus":
+		return printPrometheus(w, []*scanner.ScanResult{result})
+	case "config":
+		return printConfigItems(w, []*scanner.ScanResult{result}, opts.compact)
+	case "tf-import":
+		return printTFImport(w, []*scanner.ScanResult{result})
+	case "upgrade-plan":
+		return printUpgradePlan(w, []*scanner.ScanResult{result})
+	case "html":
+		return printHTML(w, []*scanner.ScanResult{result}, opts.location)
+	default:
+		printText(w, result, opts)
+		return nil
+	}
+}
+
+// renderProfiles writes a multi-profile scan. JSON output is a single document
+// nesting each profile's result; other formats render each profile in turn.
+func renderProfiles(w io.Writer, format string, result *scanner.ProfilesResult, opts renderOptions) error {
+	switch format {
+	case "json":
+		return printJSON(w, result, opts)
+	case "ndjson":
+		// Records carry their account, so no per-profile headers are needed
+		for _, scan := range result.Profiles {
+			if err := printNDJSON(w, scan, opts.fields); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "openmetrics":
+		// A single exposition, as OpenMetrics allows only one # EOF
+		return printOpenMetrics(w, result.Profiles)
+	case "prometheus":
+		return printPrometheus(w, result.Profiles)
+	case "config":
+		return printConfigItems(w, result.Profiles, opts.compact)
+	case "tf-import":
+		// One set of resource names, deduplicated across profiles
+		return printTFImport(w, result.Profiles)
+	case "upgrade-plan":
+		return printUpgradePlan(w, result.Profiles)
+	case "html":
+		// One page covering every profile
+		return printHTML(w, result.Profiles, opts.location)
+	}
+	for _, scan := range result.Profiles {
+		account := scan.Account
+		if scan.AccountAlias != "" {
+			account += " " + scan.AccountAlias
+		}
+		if _, err := fmt.Fprintf(w, "Profile %s (account %s):\n", scan.Profile, account); err != nil {
+			return err
+		}
+		if err := render(w, format, scan, opts); err != nil {
+			return err
+		}
+	}
+	for _, skipped := range result.Skipped {
+		if _, err := fmt.Fprintf(w, "Skipped profile %s: %s\n", skipped.Profile, skipped.Error); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// printJSON writes a single or multi-profile scan result as a single JSON
+// document, with clusters reduced to opts.fields when set
+func printJSON(w io.Writer, result any, opts renderOptions) error {
+	if opts.fields != nil {
+		projected, err := projectFields(result, opts.fields)
+		if err != nil {
+			return err
+		}
+		return encodeJSON(w, projected, opts.compact)
+	}
+	return encodeJSON(w, result, opts.compact)
+}
+
+// encodeJSON writes v as indented JSON, or on a single line when compact is set
+func encodeJSON(w io.Writer, v any, compact bool) error {
+	enc := json.NewEncoder(w)
+	if !compact {
+		enc.SetIndent("", "  ")
+	}
+	return enc.Encode(v)
+}
+
+// printNDJSON writes one compact JSON object per cluster, each carrying its
+// account and reduced to fields when set. Lines are always compact, whatever
+// --compact says.
+func printNDJSON(w io.Writer, result *scanner.ScanResult, fields []string) error {
+	enc := json.NewEncoder(w)
+	for _, c := range result.Flatten() {
+		var record any = c
+		if fields != nil {
+			projected, err := projectFields(c, fields)
+			if err != nil {
+				return err
+			}
+			record = projected
+		}
+		if err := enc.Encode(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// renderOptions selects the JSON layout and the optional sections of the text output
+type renderOptions struct {
+	// compact selects single-line JSON instead of indented JSON.
+	compact       bool
+	accessEntries bool
+	health        bool
+	instanceCount bool
+	vpcCidr       bool
+	updates       bool
+	sgRules       bool
+	dns           bool
+	versions      bool
+	network       bool
+	nodegroups    bool
+	azs           bool
+	minAZs        int
+	auditLog      bool
+	cost          bool
+	// verbose lists the scanned regions with their cluster counts and listing times.
+	verbose bool
+	// fields restricts the cluster fields of json and ndjson output.
+	fields []string
+	// location is the zone timestamps are shown in; nil means local time.
+	// JSON output always carries UTC.
+	location *time.Location
+}
+
+// formatTime formats t as RFC 3339 in loc, or in local time when loc is nil
+func formatTime(t time.Time, loc *time.Location) string {
+	if loc == nil {
+		loc = time.Local
+	}
+	return t.In(loc).Format(time.RFC3339)
+}
+
+// printText writes the cluster endpoints followed by the optional sections
+func printText(w io.Writer, result *scanner.ScanResult, opts renderOptions) {
+	// Print endpoints
+	for _, c := range result.Clusters {
+		if c.DescribeError != "" {
+			fmt.Fprintf(w, "%s (%s): undescribed: %s\n", c.Name, c.Region, c.DescribeError)
+			continue
+		}
+		if c.Connected() {
+			fmt.Fprintf(w, "%s (%s): connected cluster, provider %s\n", c.Name, c.Region, c.Connector.Provider)
+			continue
+		}
+		fmt.Fprintln(w, c.Endpoint)
+	}
+
+	// Print access entries
+	if opts.accessEntries {
+		for _, c := range result.Clusters {
+			fmt.Fprintf(w, "Access entries for cluster %s (%s):\n", c.Name, c.Region)
+			for _, entry := range c.AccessEntries {
+				fmt.Fprintf(w, "* %s (%s)\n", entry.PrincipalArn, entry.Type)
+				for _, policy := range entry.AccessPolicies {
+					fmt.Fprintf(w, "    - %s\n", policy)
+				}
+			}
+		}
+	}
+
+	// Print health issues
+	if opts.health {
+		for _, c := range result.Clusters {
+			if len(c.HealthIssues) == 0 {
+				fmt.Fprintf(w, "Cluster %s (%s) reports no health issues\n", c.Name, c.Region)
+				continue
+			}
+			fmt.Fprintf(w, "Health issues for cluster %s (%s):\n", c.Name, c.Region)
+			for _, issue := range c.HealthIssues {
+				fmt.Fprintf(w, "* %s: %s\n", issue.Code, issue.Message)
+			}
+		}
+	}
+
+	// Print clusters without deletion protection
+	for _, c := range result.Clusters {
+		if c.DeletionProtected != nil && !*c.DeletionProtected {
+			fmt.Fprintf(w, "Cluster %s (%s) is not deletion protected\n", c.Name, c.Region)
+		}
+	}
+
+	// Print clusters without audit logging
+	if opts.auditLog {
+		for _, c := range result.Clusters {
+			if missingAuditLog(&c) {
+				fmt.Fprintf(w, "Cluster %s (%s) does not have audit logging enabled\n", c.Name, c.Region)
+			}
+		}
+	}
+
+	// Print how far behind the latest version clusters are
+	if opts.versions {
+		for _, c := range result.Clusters {
+			if c.VersionsBehind != nil {
+				fmt.Fprintf(w, "Cluster %s (%s) runs Kubernetes %s, %d versions behind %s\n", c.Name, c.Region, c.Version, *c.VersionsBehind, c.LatestVersion)
+			}
+		}
+	}
+
+	// Print instance counts
+	if opts.instanceCount {
+		for _, c := range result.Clusters {
+			if c.InstanceCount != nil {
+				fmt.Fprintf(w, "Cluster %s (%s) has %d running instances\n", c.Name, c.Region, *c.InstanceCount)
+			}
+		}
+	}
+
+	// Print managed node group sizes
+	if opts.nodegroups {
+		for _, c := range result.Clusters {
+			if c.TotalNodes != nil {
+				fmt.Fprintf(w, "Cluster %s (%s) has %d desired nodes in %d managed node groups\n", c.Name, c.Region, *c.TotalNodes, *c.NodegroupCount)
+			}
+		}
+	}
+
+	// Print estimated control plane costs
+	if opts.cost {
+		for _, c := range result.Clusters {
+			if c.EstimatedMonthlyCost == nil {
+				continue
+			}
+			extended := ""
+			if c.EOL {
+				extended = ", including extended support"
+			}
+			fmt.Fprintf(w, "Cluster %s (%s) costs an estimated $%.2f per month%s\n", c.Name, c.Region, *c.EstimatedMonthlyCost, extended)
+		}
+		if result.EstimatedMonthlyCost != nil {
+			fmt.Fprintf(w, "Estimated monthly control plane cost: $%.2f\n", *result.EstimatedMonthlyCost)
+		}
+	}
+
+	// Print VPC CIDR blocks
+	if opts.vpcCidr {
+		for _, c := range result.Clusters {
+			cidrs := append(append([]string{}, c.VpcCidrs...), c.VpcIPv6Cidrs...)
+			fmt.Fprintf(w, "Cluster %s (%s) VPC %s: %s\n", c.Name, c.Region, c.VpcID, strings.Join(cidrs, ", "))
+		}
+	}
+
+	// Print availability zone coverage
+	if opts.azs {
+		for _, c := range result.Clusters {
+			if c.AvailabilityZones == nil {
+				continue
+			}
+			line := fmt.Sprintf("Cluster %s (%s) subnets span %d availability zones: %s", c.Name, c.Region, len(c.AvailabilityZones), strings.Join(c.AvailabilityZones, ", "))
+			switch {
+			case c.BelowMinAZs && opts.minAZs > 0:
+				line += fmt.Sprintf(" (fewer than %d)", opts.minAZs)
+			case c.BelowMinAZs:
+				line += " (below the minimum)"
+			}
+			fmt.Fprintln(w, line)
+		}
+	}
+
+	// Print pending and failed updates
+	if opts.updates {
+		for _, c := range result.Clusters {
+			for _, u := range c.Updates {
+				started := ""
+				if u.CreatedAt != nil {
+					started = ", started " + formatTime(*u.CreatedAt, opts.location)
+				}
+				fmt.Fprintf(w, "Cluster %s (%s) update %s (%s%s): %s\n", c.Name, c.Region, u.ID, u.Type, started, u.Status)
+				for _, e := range u.Errors {
+					fmt.Fprintf(w, "    - %s\n", e)
+				}
+			}
+		}
+	}
+
+	// Print service networks
+	if opts.network {
+		for _, c := range result.Clusters {
+			if c.IPFamily == "" {
+				continue
+			}
+			cidrs := strings.Join(slices.DeleteFunc([]string{c.ServiceIPv4Cidr, c.ServiceIPv6Cidr}, func(s string) bool { return s == "" }), ", ")
+			bootstrap := ""
+			if c.BootstrapSelfManagedAddons != nil {
+				bootstrap = fmt.Sprintf(", self-managed add-ons bootstrapped: %t", *c.BootstrapSelfManagedAddons)
+			}
+			fmt.Fprintf(w, "Cluster %s (%s) service network %s: %s%s\n", c.Name, c.Region, c.IPFamily, cidrs, bootstrap)
+		}
+	}
+
+	// Print security group rules
+	if opts.sgRules {
+		for _, c := range result.Clusters {
+			if c.ClusterSecurityGroupID == "" {
+				continue
+			}
+			fmt.Fprintf(w, "Security group %s of cluster %s (%s):\n", c.ClusterSecurityGroupID, c.Name, c.Region)
+			for _, rule := range c.SecurityGroupRules {
+				fmt.Fprintf(w, "* %s %s %s %s\n", rule.Direction, rule.Protocol, rule.Ports, rule.Source)
+			}
+		}
+	}
+
+	// Print DNS resolution of endpoints
+	if opts.dns {
+		for _, c := range result.Clusters {
+			if c.EndpointResolves == nil {
+				continue
+			}
+			if *c.EndpointResolves {
+				fmt.Fprintf(w, "Cluster %s (%s) endpoint resolves\n", c.Name, c.Region)
+			} else {
+				fmt.Fprintf(w, "Cluster %s (%s) endpoint does not resolve: %s\n", c.Name, c.Region, c.EndpointDNSError)
+			}
+		}
+	}
+
+	// Print the clusters listed per scanned region
+	if opts.verbose {
+		for _, count := range result.ScannedRegions {
+			if count.Failed {
+				fmt.Fprintf(w, "Region %s: listing failed\n", count.Region)
+				continue
+			}
+			line := fmt.Sprintf("Region %s: %d clusters", count.Region, count.Clusters)
+			if count.Elapsed > 0 {
+				line += " in " + count.Elapsed.Round(time.Millisecond).String()
+			}
+			if count.DescribeSkipped > 0 {
+				line += fmt.Sprintf(", %d listed only", count.DescribeSkipped)
+			}
+			fmt.Fprintln(w, line)
+		}
+	}
+
+	// Print the outcome of --exec
+	for _, c := range result.Clusters {
+		if c.Exec == nil {
+			continue
+		}
+		line := fmt.Sprintf("Exec for cluster %s (%s): exit %d", c.Name, c.Region, c.Exec.ExitCode)
+		if c.Exec.Error != "" {
+			line += ": " + c.Exec.Error
+		}
+		if c.Exec.Output != "" {
+			line += ": " + c.Exec.Output
+		}
+		fmt.Fprintln(w, line)
+	}
+
+	// Print cluster names used in several regions
+	for _, collision := range result.NameCollisions {
+		fmt.Fprintf(w, "Cluster name %s is used in regions: %s\n", collision.Name, strings.Join(collision.Regions, ", "))
+	}
+
+	// Print the sampled regions
+	if result.SampledFrom > 0 {
+		fmt.Fprintf(w, "Sampled %d of %d regions (seed %d): %s\n", len(result.Regions), result.SampledFrom, result.SampleSeed, strings.Join(result.Regions, ", "))
+	}
+
+	// Print drift from baseline
+	if result.Drift != nil {
+		printDrift(w, result.Drift)
+	}
+
+	// Print the soft findings together; missing tags, disallowed CIDRs, stale
+	// clusters and old versions are only reported here
+	if len(result.Warnings) > 0 {
+		fmt.Fprintf(w, "Warnings (%d):\n", len(result.Warnings))
+		for _, warning := range result.Warnings {
+			fmt.Fprintf(w, "* [%s] cluster %s (%s) %s\n", warning.Code, warning.Cluster, warning.Region, warning.Message)
+		}
+	}
+
+	switch {
+	case result.Incomplete && result.Error != "":
+		fmt.Fprintf(w, "Scan incomplete: results are partial (%s)\n", result.Error)
+	case result.Incomplete:
+		fmt.Fprintln(w, "Scan incomplete: results are partial")
+	}
+}
+
+// printDrift lists unexpected and missing clusters relative to the baseline
+func printDrift(w io.Writer, drift *scanner.Drift) {
+	if !drift.HasDrift() {
+		fmt.Fprintln(w, "No drift from baseline")
+		return
+	}
+	for _, ref := range drift.Unexpected {
+		fmt.Fprintf(w, "Unexpected cluster: %s in region: %s (account %s)\n", ref.Name, ref.Region, ref.Account)
+	}
+	for _, ref := range drift.Missing {
+		fmt.Fprintf(w, "Missing cluster: %s in region: %s (account %s)\n", ref.Name, ref.Region, ref.Account)
+	}
+}
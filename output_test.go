@@ -0,0 +1,239 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+
+	"shift-left-shuffle/scanner"
+)
+
+func TestPrintNDJSONLabels(t *testing.T) {
+	result := sampleResult()
+	result.Labels = map[string]string{"run-id": "123", "env": "prod"}
+	var buf bytes.Buffer
+	if err := printNDJSON(&buf, result, nil); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != len(result.Clusters) {
+		t.Fatalf("%d records, want %d:\n%s", len(lines), len(result.Clusters), buf.String())
+	}
+	for _, line := range lines {
+		var record scanner.AccountCluster
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatal(err)
+		}
+		if record.Labels["run-id"] != "123" || record.Labels["env"] != "prod" {
+			t.Errorf("%s labels = %v, want the scan's", record.Name, record.Labels)
+		}
+	}
+}
+
+func TestRenderJSONCompact(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		records int // records per line; zero for an indented document
+	}{
+		{name: "json indented by default", args: []string{"--output", "json"}},
+		{name: "json compact", args: []string{"--output", "json", "--compact"}, records: 1},
+		{name: "ndjson", args: []string{"--output", "ndjson"}, records: 2},
+		{name: "ndjson ignores compact", args: []string{"--output", "ndjson", "--compact"}, records: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := testFlags(t, tt.args...)
+			var buf bytes.Buffer
+			if err := render(&buf, f.output, sampleResult(), f.renderOptions()); err != nil {
+				t.Fatal(err)
+			}
+			lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+			if tt.records == 0 {
+				var decoded scanner.ScanResult
+				if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil || len(decoded.Clusters) != 2 {
+					t.Fatalf("decoded %d clusters, err %v; want the sample result", len(decoded.Clusters), err)
+				}
+				if len(lines) < 10 || !strings.HasPrefix(lines[1], "  \"") {
+					t.Errorf("output is not indented:\n%s", buf.String())
+				}
+				return
+			}
+			if len(lines) != tt.records {
+				t.Fatalf("%d lines, want %d:\n%s", len(lines), tt.records, buf.String())
+			}
+			for _, line := range lines {
+				if !json.Valid([]byte(line)) {
+					t.Errorf("line is not valid JSON: %s", line)
+				}
+			}
+		})
+	}
+}
+
+func TestPrintTextNameCollisions(t *testing.T) {
+	result := sampleResult()
+	result.NameCollisions = []scanner.NameCollision{{Name: "prod", Regions: []string{"eu-west-1", "us-east-1"}}}
+	var buf bytes.Buffer
+	printText(&buf, result, renderOptions{})
+	if want := "Cluster name prod is used in regions: eu-west-1, us-east-1\n"; !strings.Contains(buf.String(), want) {
+		t.Errorf("output lacks %q:\n%s", want, buf.String())
+	}
+}
+
+func TestPrintTextCost(t *testing.T) {
+	result := sampleResult()
+	result.Clusters[0].EstimatedMonthlyCost = aws.Float64(73)
+	result.Clusters[1].EstimatedMonthlyCost = aws.Float64(438)
+	result.EstimatedMonthlyCost = aws.Float64(511)
+	tests := []struct {
+		name string
+		opts renderOptions
+		want string
+	}{
+		{name: "not requested"},
+		{name: "requested", opts: renderOptions{cost: true}, want: "Cluster prod (us-east-1) costs an estimated $73.00 per month\n" +
+			"Cluster legacy (eu-west-1) costs an estimated $438.00 per month, including extended support\n" +
+			"Estimated monthly control plane cost: $511.00\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			printText(&buf, result, tt.opts)
+			if tt.want == "" && strings.Contains(buf.String(), "costs an estimated") || !strings.Contains(buf.String(), tt.want) {
+				t.Errorf("output =\n%s\nwant it to contain\n%s", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestPrintTextDeletionProtection(t *testing.T) {
+	result := sampleResult()
+	result.Clusters[0].DeletionProtected = aws.Bool(true)
+	result.Clusters[1].DeletionProtected = aws.Bool(false)
+	var buf bytes.Buffer
+	printText(&buf, result, renderOptions{})
+	if got := strings.Count(buf.String(), "is not deletion protected"); got != 1 || !strings.Contains(buf.String(), "Cluster legacy (eu-west-1) is not deletion protected\n") {
+		t.Errorf("output =\n%s\nwant only legacy reported as unprotected", buf.String())
+	}
+}
+
+func TestPrintTextConnected(t *testing.T) {
+	result := sampleResult()
+	result.Clusters[1].Connector = &scanner.Connector{Provider: "EKS_ANYWHERE"}
+	result.Clusters[1].Endpoint = ""
+	var buf bytes.Buffer
+	printText(&buf, result, renderOptions{})
+	if want := "legacy (eu-west-1): connected cluster, provider EKS_ANYWHERE\n"; !strings.Contains(buf.String(), want) {
+		t.Errorf("output lacks %q:\n%s", want, buf.String())
+	}
+}
+
+func TestPrintTextHealth(t *testing.T) {
+	result := &scanner.ScanResult{Clusters: []scanner.Cluster{
+		{Name: "sick", Region: "us-east-1", HealthIssues: []scanner.HealthIssue{
+			{Code: "SubnetNotFound", Message: "subnet-1 was deleted"},
+			{Code: "IamRoleNotFound", Message: "role was deleted"},
+		}},
+		{Name: "fine", Region: "eu-west-1"},
+	}}
+	tests := []struct {
+		name    string
+		args    []string
+		want    []string
+		wantNot []string
+	}{
+		{name: "without --with-health", wantNot: []string{"health issues"}},
+		{
+			name: "with --with-health",
+			args: []string{"--with-health"},
+			want: []string{
+				"Health issues for cluster sick (us-east-1):\n* SubnetNotFound: subnet-1 was deleted\n* IamRoleNotFound: role was deleted\n",
+				"Cluster fine (eu-west-1) reports no health issues\n",
+			},
+		},
+		{name: "implied by --fail-on-health-issues", args: []string{"--fail-on-health-issues"}, want: []string{"Health issues for cluster sick (us-east-1):"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			printText(&buf, result, testFlags(t, tt.args...).renderOptions())
+			for _, want := range tt.want {
+				if !strings.Contains(buf.String(), want) {
+					t.Errorf("output lacks %q:\n%s", want, buf.String())
+				}
+			}
+			for _, unwanted := range tt.wantNot {
+				if strings.Contains(buf.String(), unwanted) {
+					t.Errorf("output has %q:\n%s", unwanted, buf.String())
+				}
+			}
+		})
+	}
+}
+
+func TestRenderProfilesIncomplete(t *testing.T) {
+	partial := sampleResult()
+	partial.Profile = "partial"
+	partial.Incomplete = true
+	partial.Error = "getting access entries: access denied"
+	partial.RegionErrors = []scanner.RegionError{{Region: "ap-south-1", Error: "list denied"}}
+	complete := sampleResult()
+	complete.Profile = "dev"
+	complete.AccountAlias = "corp-main"
+	result := &scanner.ProfilesResult{
+		SchemaVersion: scanner.SchemaVersion,
+		Profiles:      []*scanner.ScanResult{complete, partial},
+		Skipped:       []scanner.SkippedProfile{{Profile: "broken", Error: "no credentials"}},
+		Incomplete:    true,
+	}
+
+	tests := []struct {
+		format string
+		want   []string
+	}{
+		{format: "text", want: []string{
+			"Profile dev (account 123456789012 corp-main):",
+			"Profile partial (account 123456789012):",
+			"Scan incomplete: results are partial (getting access entries: access denied)",
+			"Skipped profile broken: no credentials",
+		}},
+		{format: "json", want: []string{`"incomplete": true`, `"error": "getting access entries: access denied"`, `"region": "ap-south-1"`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := renderProfiles(&buf, tt.format, result, renderOptions{}); err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(buf.String(), want) {
+					t.Errorf("output lacks %q:\n%s", want, buf.String())
+				}
+			}
+			if got := strings.Count(buf.String(), "Scan incomplete"); tt.format == "text" && got != 1 {
+				t.Errorf("%d incomplete notices, want only the partial profile's", got)
+			}
+		})
+	}
+
+	t.Run("json round trip", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := renderProfiles(&buf, "json", result, renderOptions{}); err != nil {
+			t.Fatal(err)
+		}
+		var decoded scanner.ProfilesResult
+		if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+			t.Fatal(err)
+		}
+		if !decoded.Incomplete || len(decoded.Profiles) != 2 || !decoded.Profiles[1].Incomplete || len(decoded.Profiles[1].Clusters) != 2 {
+			t.Errorf("decoded = %+v, want the partial profile kept with its clusters", decoded)
+		}
+		if decoded.Err() == nil {
+			t.Error("decoded Err() = nil, want the partial profile")
+		}
+	})
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"shift-left-shuffle/scanner"
+)
+
+// splitByValues lists the values accepted by --split-by
+var splitByValues = []string{"account", "region"}
+
+// writeOutputDir writes each scan as JSON under dir, one file per account
+// (<account>.json) or, when splitBy is "region", one file per account and
+// region (<account>/<region>.json). The account alias replaces the ID in
+// names when it was resolved. With checksum, each file carries its own
+// checksum field and gets a detached .sha256 file. It returns the paths written.
+func writeOutputDir(dir, splitBy string, results []*scanner.ScanResult, checksum bool) ([]string, error) {
+	var paths []string
+	for _, result := range results {
+		if splitBy != "region" {
+			path := filepath.Join(dir, accountFileName(result)+".json")
+			if err := writeJSONFile(path, result, checksum); err != nil {
+				return paths, err
+			}
+			paths = append(paths, path)
+			continue
+		}
+
+		for _, region := range result.Regions {
+			part := *result
+			part.Regions = []string{region}
+			part.Clusters = []scanner.Cluster{}
+			for _, c := range result.Clusters {
+				if c.Region == region {
+					part.Clusters = append(part.Clusters, c)
+				}
+			}
+			part.ScannedRegions = nil
+			for _, count := range result.ScannedRegions {
+				if count.Region == region {
+					part.ScannedRegions = append(part.ScannedRegions, count)
+				}
+			}
+			part.RegionErrors = nil
+			for _, regionErr := range result.RegionErrors {
+				if regionErr.Region == region {
+					part.RegionErrors = append(part.RegionErrors, regionErr)
+				}
+			}
+
+			if checksum {
+				sum, err := scanner.ResultChecksum(&part)
+				if err != nil {
+					return paths, err
+				}
+				part.Checksum = sum
+			}
+
+			path := filepath.Join(dir, accountFileName(result), region+".json")
+			if err := writeJSONFile(path, &part, checksum); err != nil {
+				return paths, err
+			}
+			paths = append(paths, path)
+		}
+	}
+	return paths, nil
+}
+
+// accountFileName names the files of a scan after its account alias, falling
+// back to the account ID. Aliases are lowercase letters, digits and hyphens,
+// so they are safe in file names.
+func accountFileName(result *scanner.ScanResult) string {
+	if result.AccountAlias != "" {
+		return result.AccountAlias
+	}
+	return result.Account
+}
+
+// writeJSONFile writes v as indented JSON to path, creating parent
+// directories, followed by path.sha256 when checksum is set. The file is
+// written to a temporary name and renamed so readers never observe a partial file.
+func writeJSONFile(path string, v any, checksum bool) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeOutputFile(path, append(data, '\n'), checksum)
+}
+
+// writeOutputFile replaces path with data, the rendered output, and with
+// checksum writes its detached path.sha256 next to it
+func writeOutputFile(path string, data []byte, checksum bool) error {
+	err := writeFileAtomic(path, func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+	if err != nil || !checksum {
+		return err
+	}
+	return writeChecksumFile(path, data)
+}
+
+// writeFileAtomic creates path's directory and replaces path with the output of write
+func writeFileAtomic(path string, write func(io.Writer) error) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // no-op once renamed
+
+	if err := write(tmp); err != nil {
+		tmp.Close()
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
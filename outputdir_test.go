@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"shift-left-shuffle/scanner"
+)
+
+func TestWriteOutputDir(t *testing.T) {
+	aliased := sampleResult()
+	aliased.AccountAlias = "corp-main"
+	aliased.RegionErrors = []scanner.RegionError{{Region: "us-east-1", Error: "throttled"}}
+	tests := []struct {
+		name    string
+		splitBy string
+		results []*scanner.ScanResult
+		want    map[string][]string // file to cluster names
+	}{
+		{
+			name:    "per account",
+			splitBy: "account",
+			results: []*scanner.ScanResult{sampleResult()},
+			want:    map[string][]string{"123456789012.json": {"prod", "legacy"}},
+		},
+		{
+			name:    "per region under the alias",
+			splitBy: "region",
+			results: []*scanner.ScanResult{aliased},
+			want: map[string][]string{
+				filepath.Join("corp-main", "eu-west-1.json"): {"legacy"},
+				filepath.Join("corp-main", "us-east-1.json"): {"prod"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			paths, err := writeOutputDir(dir, tt.splitBy, tt.results, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(paths) != len(tt.want) {
+				t.Errorf("wrote %q, want %d files", paths, len(tt.want))
+			}
+			for file, names := range tt.want {
+				path := filepath.Join(dir, file)
+				if !slices.Contains(paths, path) {
+					t.Errorf("%s not among the paths returned", file)
+				}
+				var result scanner.ScanResult
+				data, err := os.ReadFile(path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := scanner.DecodeJSON(data, &result, true); err != nil {
+					t.Fatal(err)
+				}
+				var got []string
+				for _, c := range result.Clusters {
+					got = append(got, c.Name)
+				}
+				if !slices.Equal(got, names) {
+					t.Errorf("%s clusters = %q, want %q", file, got, names)
+				}
+				if tt.splitBy == "region" {
+					region := result.Regions[0]
+					if len(result.Regions) != 1 || len(result.ScannedRegions) != 1 || result.ScannedRegions[0].Region != region {
+						t.Errorf("%s regions = %v, scanned %v; want only %s", file, result.Regions, result.ScannedRegions, region)
+					}
+					if (len(result.RegionErrors) == 1) != (region == "us-east-1") {
+						t.Errorf("%s region errors = %v", file, result.RegionErrors)
+					}
+				}
+			}
+			leftovers, _ := filepath.Glob(filepath.Join(dir, "*", ".*.tmp-*"))
+			if top, _ := filepath.Glob(filepath.Join(dir, ".*.tmp-*")); len(leftovers)+len(top) > 0 {
+				t.Errorf("temporary files left behind: %q", append(leftovers, top...))
+			}
+		})
+	}
+}
+
+func TestWriteOutputDirChecksums(t *testing.T) {
+	dir := t.TempDir()
+	paths, err := writeOutputDir(dir, "region", []*scanner.ScanResult{sampleResult()}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var result scanner.ScanResult
+		if err := json.Unmarshal(data, &result); err != nil {
+			t.Fatal(err)
+		}
+		want, err := scanner.ResultChecksum(&result)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Checksum == "" || result.Checksum != want {
+			t.Errorf("%s checksum = %q, want %q over its own region", path, result.Checksum, want)
+		}
+		if _, err := os.Stat(path + ".sha256"); err != nil {
+			t.Errorf("detached checksum: %v", err)
+		}
+	}
+}
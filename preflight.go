@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"shift-left-shuffle/scanner"
+)
+
+// runPreflight implements "preflight". It returns whether every required permission is allowed.
+func runPreflight(args []string) (bool, error) {
+	fs := flag.NewFlagSet("preflight", flag.ExitOnError)
+	output := fs.String("output", "text", "Output format: text or json")
+	profile := fs.String("profile", "", "Named profile from the shared AWS config files")
+	region := fs.String("region", "us-east-1", "Region used to check the EKS permissions")
+	if err := parseSubcommand(fs, args); err != nil {
+		return false, err
+	}
+	if *output != "text" && *output != "json" {
+		return false, fmt.Errorf("unsupported preflight output format %q: must be text or json", *output)
+	}
+	if !validRegion(*region) {
+		return false, fmt.Errorf("invalid --region %q", *region)
+	}
+
+	checks := scanner.NewScanner(scanner.WithProfile(*profile)).Preflight(context.Background(), *region)
+	passed := true
+	for _, check := range checks {
+		passed = passed && check.Passed()
+	}
+
+	var err error
+	if *output == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(checks)
+	} else {
+		err = printChecks(os.Stdout, checks)
+	}
+	return passed, err
+}
+
+// printChecks writes one line per permission check
+func printChecks(w io.Writer, checks []scanner.PermissionCheck) error {
+	for _, check := range checks {
+		required := "required"
+		if !check.Required {
+			required = "optional"
+		}
+		line := fmt.Sprintf("[%s] %s (%s)", check.Status, check.Permission, required)
+		if check.Error != "" {
+			line += ": " + check.Error
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
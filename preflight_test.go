@@ -0,0 +1,56 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"shift-left-shuffle/scanner"
+)
+
+func TestPrintChecks(t *testing.T) {
+	tests := []struct {
+		name   string
+		checks []scanner.PermissionCheck
+		want   string
+	}{
+		{name: "none"},
+		{
+			name: "allowed and denied",
+			checks: []scanner.PermissionCheck{
+				{Permission: "sts:GetCallerIdentity", Required: true, Status: scanner.PermissionAllowed},
+				{Permission: "ec2:DescribeRegions", Status: scanner.PermissionDenied, Error: "AccessDenied"},
+				{Permission: "eks:ListClusters", Required: true, Status: scanner.PermissionError, Error: "connection reset"},
+			},
+			want: "[allowed] sts:GetCallerIdentity (required)\n" +
+				"[denied] ec2:DescribeRegions (optional): AccessDenied\n" +
+				"[error] eks:ListClusters (required): connection reset\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := printChecks(&buf, tt.checks); err != nil {
+				t.Fatal(err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("output =\n%s\nwant\n%s", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestRunPreflightArgs(t *testing.T) {
+	tests := []struct {
+		args    []string
+		wantErr string
+	}{
+		{args: []string{"--output", "yaml"}, wantErr: `unsupported preflight output format "yaml": must be text or json`},
+		{args: []string{"--region", "us-east"}, wantErr: `invalid --region "us-east"`},
+	}
+	for _, tt := range tests {
+		passed, err := runPreflight(tt.args)
+		if passed || err == nil || err.Error() != tt.wantErr {
+			t.Errorf("runPreflight(%q) = %t, %v; want %q", tt.args, passed, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"shift-left-shuffle/scanner"
+)
+
+// runRender implements "render <scan.json>": it re-renders a saved JSON scan,
+// single or multi-profile, to w without calling AWS
+func runRender(w io.Writer, args []string) error {
+	fs := flag.NewFlagSet("render", flag.ExitOnError)
+	output := fs.String("output", "text", "Output format: "+strings.Join(outputFormats, ", "))
+	compact := fs.Bool("compact", false, "Write JSON output on a single line instead of indented")
+	verbose := fs.Bool("verbose", false, "Also list every scanned region with its cluster count and listing time in text output")
+	groupBy := fs.String("group-by", "", "Nest text or json output by these keys, outermost first: "+strings.Join(groupKeys, ", "))
+	jsonpath := fs.String("jsonpath", "", "Print the values matching this JSONPath expression, one per line")
+	timezone := fs.String("timezone", "local", "Zone for timestamps in text and markdown output: local, utc or an IANA name (JSON is always UTC)")
+	var tags multiFlag
+	fs.Var(&tags, "tag", "Only keep clusters with this tag, as key=value or key (repeatable)")
+	var excludeTags multiFlag
+	fs.Var(&excludeTags, "exclude-tag", "Drop clusters with this tag, as key=value or key (repeatable; applied after --tag)")
+	onlyEOL := fs.Bool("only-eol", false, "Only keep clusters running a Kubernetes version past the end of standard support")
+	vpcIDs := fs.String("vpc-id", "", "Comma-separated VPC IDs; only keep clusters in one of these VPCs")
+	arnPrefixes := fs.String("arn-prefix", "", "Comma-separated ARN prefixes; only keep clusters whose ARN starts with one of them")
+	sortBy := fs.String("sort", "", "Order clusters by "+strings.Join(sortKeys, ", ")+" (default: the saved order)")
+	reverse := fs.Bool("reverse", false, "Reverse the --sort order")
+	strictJSON := fs.Bool("strict-json", false, "Fail on fields the scanner does not know instead of ignoring them")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: shift-left-shuffle render [flags] <scan.json>")
+		fmt.Fprintln(fs.Output(), "Reads a scan saved with --output json (use - for stdin).")
+		fs.PrintDefaults()
+	}
+	if err := parseSubcommand(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("render needs exactly one file, got %d", fs.NArg())
+	}
+	if !slices.Contains(outputFormats, *output) {
+		return fmt.Errorf("unsupported output format %q: must be one of %s", *output, strings.Join(outputFormats, ", "))
+	}
+	location, err := loadLocation(*timezone)
+	if err != nil {
+		return err
+	}
+	var query jsonPath
+	if *jsonpath != "" {
+		var err error
+		if query, err = compileJSONPath(*jsonpath); err != nil {
+			return err
+		}
+	}
+	var keys []string
+	if *groupBy != "" {
+		if *output != "text" && *output != "json" {
+			return fmt.Errorf("--group-by supports text or json output, got %q", *output)
+		}
+		var err error
+		if keys, err = parseGroupBy(*groupBy); err != nil {
+			return err
+		}
+	}
+	filters, err := parseTagFilters("tag", tags)
+	if err != nil {
+		return err
+	}
+	excludes, err := parseTagFilters("exclude-tag", excludeTags)
+	if err != nil {
+		return err
+	}
+
+	vpcs := splitList(*vpcIDs)
+	if err := validVpcIDs(vpcs); err != nil {
+		return err
+	}
+	prefixes := splitList(*arnPrefixes)
+	if err := validArnPrefixes(prefixes); err != nil {
+		return err
+	}
+	if err := validSortKey(*sortBy); err != nil {
+		return err
+	}
+	if *reverse && *sortBy == "" {
+		return fmt.Errorf("--reverse requires --sort")
+	}
+
+	result, profilesResult, err := readScanFile(fs.Arg(0), *strictJSON)
+	if err != nil {
+		return err
+	}
+	results := []*scanner.ScanResult{result}
+	if profilesResult != nil {
+		results = profilesResult.Profiles
+	}
+	if len(filters) > 0 {
+		for _, r := range results {
+			r.Clusters = slices.DeleteFunc(r.Clusters, func(c scanner.Cluster) bool { return !c.MatchesTags(filters) })
+		}
+	}
+	if len(excludes) > 0 {
+		for _, r := range results {
+			r.Clusters = slices.DeleteFunc(r.Clusters, func(c scanner.Cluster) bool { return c.MatchesAnyTag(excludes) })
+		}
+	}
+	if len(vpcs) > 0 {
+		for _, r := range results {
+			r.Clusters = slices.DeleteFunc(r.Clusters, func(c scanner.Cluster) bool { return !slices.Contains(vpcs, c.VpcID) })
+		}
+	}
+	if len(prefixes) > 0 {
+		for _, r := range results {
+			r.Clusters = slices.DeleteFunc(r.Clusters, func(c scanner.Cluster) bool { return !c.MatchesArnPrefix(prefixes) })
+		}
+	}
+	if *onlyEOL {
+		for _, r := range results {
+			r.Clusters = slices.DeleteFunc(r.Clusters, func(c scanner.Cluster) bool { return !c.EOL })
+		}
+	}
+	if *sortBy != "" {
+		sortClusters(results, *sortBy, *reverse)
+	}
+	// Warnings follow the clusters left by the filters, and are derived for
+	// scans saved before they were recorded
+	for _, r := range results {
+		r.Warnings = scanner.CollectWarnings(r.Clusters)
+	}
+	opts := savedRenderOptions(results)
+	opts.compact = *compact
+	opts.verbose = *verbose
+	opts.location = location
+
+	switch {
+	case query != nil && profilesResult != nil:
+		return printJSONPath(w, query, profilesResult)
+	case query != nil:
+		return printJSONPath(w, query, result)
+	case keys != nil:
+		return printGrouped(w, *output, results, keys, opts)
+	case profilesResult != nil:
+		return renderProfiles(w, *output, profilesResult, opts)
+	default:
+		return render(w, *output, result, opts)
+	}
+}
+
+// parseTagFilters groups key=value or key flag values by key; a bare key
+// matches any value
+func parseTagFilters(name string, tags []string) (map[string][]string, error) {
+	filters := make(map[string][]string)
+	for _, tag := range tags {
+		key, value, ok := strings.Cut(tag, "=")
+		if key == "" {
+			return nil, fmt.Errorf("invalid --%s %q: expected key=value or key", name, tag)
+		}
+		if ok {
+			filters[key] = append(filters[key], value)
+		} else if _, seen := filters[key]; !seen {
+			filters[key] = nil
+		}
+	}
+	return filters, nil
+}
+
+// readScanFile reads a saved scan from path, or stdin when path is "-". It
+// returns either a single-profile result or a multi-profile one. With strict,
+// fields the scanner does not know are an error.
+func readScanFile(path string, strict bool) (*scanner.ScanResult, *scanner.ProfilesResult, error) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	var probe map[string]json.RawMessage
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return nil, nil, fmt.Errorf("reading %s: expected a JSON scan: %w", path, err)
+	}
+	if _, ok := probe["profiles"]; ok {
+		var profiles scanner.ProfilesResult
+		if err := scanner.DecodeJSON(data, &profiles, strict); err != nil {
+			return nil, nil, fmt.Errorf("reading %s: %w", path, err)
+		}
+		return nil, &profiles, nil
+	}
+	if _, ok := probe["clusters"]; !ok {
+		return nil, nil, fmt.Errorf("reading %s: not a scan result (no clusters)", path)
+	}
+	var result scanner.ScanResult
+	if err := scanner.DecodeJSON(data, &result, strict); err != nil {
+		return nil, nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	return &result, nil, nil
+}
+
+// savedRenderOptions enables each optional text section that has data in results
+func savedRenderOptions(results []*scanner.ScanResult) renderOptions {
+	var opts renderOptions
+	for _, result := range results {
+		for _, c := range result.Clusters {
+			opts.accessEntries = opts.accessEntries || len(c.AccessEntries) > 0
+			opts.health = opts.health || c.HealthIssues != nil
+			opts.instanceCount = opts.instanceCount || c.InstanceCount != nil
+			opts.vpcCidr = opts.vpcCidr || len(c.VpcCidrs) > 0 || len(c.VpcIPv6Cidrs) > 0
+			opts.azs = opts.azs || c.AvailabilityZones != nil
+			opts.updates = opts.updates || len(c.Updates) > 0
+			opts.sgRules = opts.sgRules || len(c.SecurityGroupRules) > 0
+			opts.dns = opts.dns || c.EndpointResolves != nil
+			opts.versions = opts.versions || c.VersionsBehind != nil
+			opts.network = opts.network || c.IPFamily != ""
+			opts.nodegroups = opts.nodegroups || c.TotalNodes != nil
+			opts.cost = opts.cost || c.EstimatedMonthlyCost != nil
+		}
+	}
+	return opts
+}
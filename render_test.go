@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"shift-left-shuffle/scanner"
+)
+
+// sampleResult returns a scan of two clusters with the fields most renderers read
+func sampleResult() *scanner.ScanResult {
+	created := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	return &scanner.ScanResult{
+		SchemaVersion:  scanner.SchemaVersion,
+		GeneratedAt:    time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+		Account:        "123456789012",
+		Partition:      "aws",
+		Regions:        []string{"eu-west-1", "us-east-1"},
+		ScannedRegions: []scanner.RegionCount{{Region: "eu-west-1", Clusters: 1}, {Region: "us-east-1", Clusters: 1}},
+		Clusters: []scanner.Cluster{
+			{Name: "prod", Region: "us-east-1", Arn: "arn:aws:eks:us-east-1:123456789012:cluster/prod", Version: "1.31", Endpoint: "https://prod.example.com", CreatedAt: &created, Tags: map[string]string{"env": "prod"}, VpcID: "vpc-0a1b"},
+			{Name: "legacy", Region: "eu-west-1", Arn: "arn:aws:eks:eu-west-1:123456789012:cluster/legacy", Version: "1.24", EOL: true, Endpoint: "https://legacy.example.com", CreatedAt: &created, Tags: map[string]string{"env": "dev"}, VpcID: "vpc-0c2d"},
+		},
+	}
+}
+
+// writeScan saves v as a JSON scan in a temporary file and returns its path
+func writeScan(t *testing.T, v any) string {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "scan.json")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRenderRoundTripsEveryFormat(t *testing.T) {
+	path := writeScan(t, sampleResult())
+	for _, format := range outputFormats {
+		t.Run(format, func(t *testing.T) {
+			var out bytes.Buffer
+			if err := runRender(&out, []string{"--output", format, "--timezone", "utc", path}); err != nil {
+				t.Fatal(err)
+			}
+			for _, name := range []string{"prod", "legacy"} {
+				if !strings.Contains(out.String(), name) {
+					t.Errorf("%s output does not mention cluster %s:\n%s", format, name, out.String())
+				}
+			}
+		})
+	}
+}
+
+func TestRenderJSONRoundTrip(t *testing.T) {
+	want := sampleResult()
+	var out bytes.Buffer
+	if err := runRender(&out, []string{"--output", "json", writeScan(t, want)}); err != nil {
+		t.Fatal(err)
+	}
+	var got scanner.ScanResult
+	if err := scanner.DecodeJSON(out.Bytes(), &got, true); err != nil {
+		t.Fatalf("render output is not a strict scan result: %v", err)
+	}
+	if len(got.Clusters) != len(want.Clusters) || got.Account != want.Account {
+		t.Fatalf("round trip = %+v, want %+v", got, want)
+	}
+	for i := range want.Clusters {
+		if got.Clusters[i].Name != want.Clusters[i].Name || got.Clusters[i].Version != want.Clusters[i].Version || !got.Clusters[i].CreatedAt.Equal(*want.Clusters[i].CreatedAt) {
+			t.Errorf("cluster %d = %+v, want %+v", i, got.Clusters[i], want.Clusters[i])
+		}
+	}
+}
+
+func TestRenderFiltersAndSorts(t *testing.T) {
+	path := writeScan(t, sampleResult())
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{"sort by name", []string{"--sort", "name"}, []string{"legacy", "prod"}},
+		{"sort by version reversed", []string{"--sort", "version", "--reverse"}, []string{"prod", "legacy"}},
+		{"tag", []string{"--tag", "env=dev"}, []string{"legacy"}},
+		{"exclude tag", []string{"--exclude-tag", "env=dev"}, []string{"prod"}},
+		{"vpc", []string{"--vpc-id", "vpc-0a1b"}, []string{"prod"}},
+		{"only eol", []string{"--only-eol"}, []string{"legacy"}},
+		{"arn prefix", []string{"--arn-prefix", "arn:aws:eks:eu-west-1:"}, []string{"legacy"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			args := append(append([]string{"--output", "ndjson"}, tt.args...), path)
+			if err := runRender(&out, args); err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+				var record scanner.AccountCluster
+				if err := json.Unmarshal([]byte(line), &record); err != nil {
+					t.Fatal(err)
+				}
+				got = append(got, record.Name)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("render %v = %v, want %v", tt.args, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderRejectsReverseWithoutSort(t *testing.T) {
+	if err := runRender(&bytes.Buffer{}, []string{"--reverse", writeScan(t, sampleResult())}); err == nil {
+		t.Error("--reverse without --sort was accepted")
+	}
+}
+
+func TestRenderStrictJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scan.json")
+	if err := os.WriteFile(path, []byte(`{"schemaVersion":1,"account":"123456789012","clusters":[{"name":"prod","region":"us-east-1","versoin":"1.31"}]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if err := runRender(&out, []string{path}); err != nil {
+		t.Errorf("lenient render: %v", err)
+	}
+	if err := runRender(&out, []string{"--strict-json", path}); err == nil || !strings.Contains(err.Error(), `unknown field "versoin"`) {
+		t.Errorf("strict render err = %v, want the unknown field", err)
+	}
+}
@@ -0,0 +1,113 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eks"
+)
+
+// AccessEntry describes an IAM principal that can authenticate to a cluster
+type AccessEntry struct {
+	PrincipalArn     string   `json:"principalArn"`
+	Type             string   `json:"type,omitempty"`
+	Username         string   `json:"username,omitempty"`
+	KubernetesGroups []string `json:"kubernetesGroups,omitempty"`
+	AccessPolicies   []string `json:"accessPolicies,omitempty"`
+}
+
+// getAccessEntries collects the access entries of each cluster, following
+// pagination of both the entry list and each entry's associated policies.
+// Empty pages, principals and policies are skipped rather than dereferenced.
+func (s *Scanner) getAccessEntries(ctx context.Context, clusters []Cluster) error {
+	return s.forEachDescribe(len(clusters), func(i int) error {
+		c := &clusters[i]
+		if c.DescribeError != "" {
+			return nil
+		}
+		client, err := s.factory.EKS(ctx, c.Region)
+		if err != nil {
+			return fmt.Errorf("creating EKS client for region %s: %w", c.Region, err)
+		}
+
+		input := &eks.ListAccessEntriesInput{ClusterName: aws.String(c.Name)}
+		if s.accessPolicyArn != "" {
+			input.AssociatedPolicyArn = aws.String(s.accessPolicyArn)
+		}
+
+		var principals []string
+		for {
+			page, err := client.ListAccessEntries(ctx, input)
+			if err != nil {
+				return fmt.Errorf("listing access entries for cluster %s: %w", c.Name, err)
+			}
+			if page == nil {
+				break
+			}
+			for _, principal := range page.AccessEntries {
+				if principal != "" {
+					principals = append(principals, principal)
+				}
+			}
+			if page.NextToken == nil {
+				break
+			}
+			input.NextToken = page.NextToken
+		}
+
+		entries := make([]AccessEntry, 0, len(principals))
+		for _, principal := range principals {
+			entry, err := describeAccessEntry(ctx, client, c.Name, principal)
+			if err != nil {
+				return err
+			}
+			entries = append(entries, entry)
+		}
+		c.AccessEntries = entries
+		return nil
+	})
+}
+
+// describeAccessEntry fetches a single access entry and its associated access policies
+func describeAccessEntry(ctx context.Context, client EKSClient, cluster, principal string) (AccessEntry, error) {
+	entry := AccessEntry{PrincipalArn: principal}
+
+	out, err := client.DescribeAccessEntry(ctx, &eks.DescribeAccessEntryInput{
+		ClusterName:  aws.String(cluster),
+		PrincipalArn: aws.String(principal),
+	})
+	if err != nil {
+		return entry, fmt.Errorf("describing access entry %s for cluster %s: %w", principal, cluster, err)
+	}
+	if out != nil && out.AccessEntry != nil {
+		entry.Type = aws.ToString(out.AccessEntry.Type)
+		entry.Username = aws.ToString(out.AccessEntry.Username)
+		entry.KubernetesGroups = out.AccessEntry.KubernetesGroups
+	}
+
+	input := &eks.ListAssociatedAccessPoliciesInput{
+		ClusterName:  aws.String(cluster),
+		PrincipalArn: aws.String(principal),
+	}
+	for {
+		page, err := client.ListAssociatedAccessPolicies(ctx, input)
+		if err != nil {
+			return entry, fmt.Errorf("listing access policies of %s for cluster %s: %w", principal, cluster, err)
+		}
+		if page == nil {
+			break
+		}
+		for _, policy := range page.AssociatedAccessPolicies {
+			if policy.PolicyArn != nil {
+				entry.AccessPolicies = append(entry.AccessPolicies, *policy.PolicyArn)
+			}
+		}
+		if page.NextToken == nil {
+			break
+		}
+		input.NextToken = page.NextToken
+	}
+
+	return entry, nil
+}
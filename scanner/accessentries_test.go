@@ -0,0 +1,117 @@
+package scanner
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eks"
+	"github.com/aws/aws-sdk-go-v2/service/eks/types"
+)
+
+// pagedAccessEKS serves scripted access entry pages. entryPages are returned
+// in turn, a nil page as a nil output; policyPages likewise per principal,
+// and described answers DescribeAccessEntry (nil for a nil output).
+type pagedAccessEKS struct {
+	EKSClient
+	entryPages  []*eks.ListAccessEntriesOutput
+	policyPages map[string][]*eks.ListAssociatedAccessPoliciesOutput
+	described   map[string]*eks.DescribeAccessEntryOutput
+}
+
+func (c *pagedAccessEKS) ListAccessEntries(ctx context.Context, params *eks.ListAccessEntriesInput, optFns ...func(*eks.Options)) (*eks.ListAccessEntriesOutput, error) {
+	i := pageToken(params.NextToken)
+	page := c.entryPages[i]
+	if page != nil {
+		page.NextToken = nextToken(i, len(c.entryPages))
+	}
+	return page, nil
+}
+
+func (c *pagedAccessEKS) DescribeAccessEntry(ctx context.Context, params *eks.DescribeAccessEntryInput, optFns ...func(*eks.Options)) (*eks.DescribeAccessEntryOutput, error) {
+	return c.described[aws.ToString(params.PrincipalArn)], nil
+}
+
+func (c *pagedAccessEKS) ListAssociatedAccessPolicies(ctx context.Context, params *eks.ListAssociatedAccessPoliciesInput, optFns ...func(*eks.Options)) (*eks.ListAssociatedAccessPoliciesOutput, error) {
+	pages := c.policyPages[aws.ToString(params.PrincipalArn)]
+	if len(pages) == 0 {
+		return &eks.ListAssociatedAccessPoliciesOutput{}, nil
+	}
+	i := pageToken(params.NextToken)
+	page := pages[i]
+	if page != nil {
+		page.NextToken = nextToken(i, len(pages))
+	}
+	return page, nil
+}
+
+func TestGetAccessEntries(t *testing.T) {
+	const admin, dev = "arn:aws:iam::123456789012:role/admin", "arn:aws:iam::123456789012:role/dev"
+	policy := func(arn string) types.AssociatedAccessPolicy {
+		return types.AssociatedAccessPolicy{PolicyArn: aws.String(arn)}
+	}
+	tests := []struct {
+		name   string
+		client *pagedAccessEKS
+		want   []AccessEntry
+	}{
+		{
+			name:   "no entries",
+			client: &pagedAccessEKS{entryPages: []*eks.ListAccessEntriesOutput{{}}},
+			want:   []AccessEntry{},
+		},
+		{
+			name:   "nil page",
+			client: &pagedAccessEKS{entryPages: []*eks.ListAccessEntriesOutput{nil}},
+			want:   []AccessEntry{},
+		},
+		{
+			name: "paginated entries and policies",
+			client: &pagedAccessEKS{
+				entryPages: []*eks.ListAccessEntriesOutput{{AccessEntries: []string{admin}}, {AccessEntries: []string{dev}}},
+				policyPages: map[string][]*eks.ListAssociatedAccessPoliciesOutput{
+					admin: {
+						{AssociatedAccessPolicies: []types.AssociatedAccessPolicy{policy("arn:aws:eks::aws:cluster-access-policy/AmazonEKSClusterAdminPolicy")}},
+						{AssociatedAccessPolicies: []types.AssociatedAccessPolicy{policy("arn:aws:eks::aws:cluster-access-policy/AmazonEKSViewPolicy")}},
+					},
+				},
+				described: map[string]*eks.DescribeAccessEntryOutput{
+					admin: {AccessEntry: &types.AccessEntry{Type: aws.String("STANDARD"), Username: aws.String("admin"), KubernetesGroups: []string{"system:masters"}}},
+					dev:   {AccessEntry: &types.AccessEntry{Type: aws.String("STANDARD")}},
+				},
+			},
+			want: []AccessEntry{
+				{PrincipalArn: admin, Type: "STANDARD", Username: "admin", KubernetesGroups: []string{"system:masters"}, AccessPolicies: []string{
+					"arn:aws:eks::aws:cluster-access-policy/AmazonEKSClusterAdminPolicy",
+					"arn:aws:eks::aws:cluster-access-policy/AmazonEKSViewPolicy",
+				}},
+				{PrincipalArn: dev, Type: "STANDARD"},
+			},
+		},
+		{
+			name: "nil outputs and empty entries skipped",
+			client: &pagedAccessEKS{
+				entryPages: []*eks.ListAccessEntriesOutput{{AccessEntries: []string{"", admin}}},
+				policyPages: map[string][]*eks.ListAssociatedAccessPoliciesOutput{
+					admin: {{AssociatedAccessPolicies: []types.AssociatedAccessPolicy{{}, policy("arn:aws:eks::aws:cluster-access-policy/AmazonEKSViewPolicy")}}, nil},
+				},
+				described: map[string]*eks.DescribeAccessEntryOutput{admin: nil},
+			},
+			want: []AccessEntry{{PrincipalArn: admin, AccessPolicies: []string{"arn:aws:eks::aws:cluster-access-policy/AmazonEKSViewPolicy"}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &singleEKSFactory{fakeFactory: newFakeFactory(nil), client: tt.client}
+			s := NewScanner(WithClientFactory(f), WithAccessEntries(""))
+			clusters := []Cluster{{Name: "prod", Region: "us-east-1"}}
+			if err := s.getAccessEntries(context.Background(), clusters); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(clusters[0].AccessEntries, tt.want) {
+				t.Errorf("access entries = %+v, want %+v", clusters[0].AccessEntries, tt.want)
+			}
+		})
+	}
+}
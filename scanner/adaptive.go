@@ -0,0 +1,144 @@
+package scanner
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/smithy-go/middleware"
+)
+
+const (
+	// adaptiveRampAfter is the number of consecutive unthrottled operations
+	// after which the adaptive describe concurrency grows by one.
+	adaptiveRampAfter = 10
+	// adaptiveCooldown is the minimum time between two reductions, so the
+	// throttles of calls already in flight count as a single spike.
+	adaptiveCooldown = time.Second
+)
+
+// WithAdaptiveConcurrency lets the describe and enrichment phases start at the
+// describe concurrency, halve it when AWS throttles calls and ramp it back up
+// by one after every run of unthrottled calls. Throttling is observed through
+// the default client factory only; with a custom factory the concurrency
+// stays fixed.
+func WithAdaptiveConcurrency() Option {
+	return func(s *Scanner) {
+		s.adaptiveConcurrency = true
+	}
+}
+
+// withDescribeLimit makes the scanner use l as its adaptive describe limit,
+// so the scanners of several profiles back off together
+func withDescribeLimit(l *adaptiveLimit) Option {
+	return func(s *Scanner) {
+		s.describeLimit = l
+	}
+}
+
+// adaptiveLimit is a concurrency limit between 1 and max that callers acquire
+// a slot of before each unit of work. It is safe for concurrent use.
+type adaptiveLimit struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	max     int
+	limit   int
+	active  int
+	clean   int
+	reduced time.Time
+	logf    func(format string, args ...any)
+}
+
+// newAdaptiveLimit returns a limit starting at and capped by limit that reports changes through logf
+func newAdaptiveLimit(limit int, logf func(format string, args ...any)) *adaptiveLimit {
+	l := &adaptiveLimit{max: limit, limit: limit, logf: logf}
+	l.cond = sync.NewCond(&l.mu)
+	return l
+}
+
+// acquire blocks until fewer than limit slots are in use and takes one
+func (l *adaptiveLimit) acquire() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for l.active >= l.limit {
+		l.cond.Wait()
+	}
+	l.active++
+}
+
+// release returns a slot taken by acquire
+func (l *adaptiveLimit) release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.active--
+	l.cond.Signal()
+}
+
+// observe adjusts the limit after an operation: a throttled one halves it,
+// at most once per adaptiveCooldown, and adaptiveRampAfter unthrottled ones
+// in a row raise it by one until it is back at max
+func (l *adaptiveLimit) observe(throttled bool, now time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if throttled {
+		l.clean = 0
+		if l.limit > 1 && now.Sub(l.reduced) >= adaptiveCooldown {
+			l.limit = max(l.limit/2, 1)
+			l.reduced = now
+			l.logf("Throttled by AWS; reducing describe concurrency to %d\n", l.limit)
+		}
+		return
+	}
+	if l.limit >= l.max {
+		return
+	}
+	if l.clean++; l.clean >= adaptiveRampAfter {
+		l.clean = 0
+		l.limit++
+		l.logf("Raising describe concurrency to %d\n", l.limit)
+		l.cond.Broadcast()
+	}
+}
+
+// middleware reports each operation to the limit once it completes, as
+// throttled when the final error or any retried attempt was a throttle
+func (l *adaptiveLimit) middleware() func(*middleware.Stack) error {
+	return func(stack *middleware.Stack) error {
+		return stack.Initialize.Add(middleware.InitializeMiddlewareFunc("AdaptiveConcurrency",
+			func(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+				out, metadata, err := next.HandleInitialize(ctx, in)
+				l.observe(throttledOperation(metadata, err), time.Now())
+				return out, metadata, err
+			}), middleware.After)
+	}
+}
+
+// throttledOperation reports whether an operation or any of its attempts was throttled
+func throttledOperation(metadata middleware.Metadata, err error) bool {
+	throttles := retry.IsErrorThrottles(retry.DefaultThrottles)
+	if err != nil && throttles.IsErrorThrottle(err) == aws.TrueTernary {
+		return true
+	}
+	attempts, _ := retry.GetAttemptResults(metadata)
+	for _, attempt := range attempts.Results {
+		if attempt.Err != nil && throttles.IsErrorThrottle(attempt.Err) == aws.TrueTernary {
+			return true
+		}
+	}
+	return false
+}
+
+// forEachDescribe runs fn for every cluster index in [0, n) at the describe
+// concurrency, within the adaptive limit when WithAdaptiveConcurrency is set
+func (s *Scanner) forEachDescribe(n int, fn func(i int) error) error {
+	if s.describeLimit == nil {
+		return s.forEach(n, s.describeConcurrency, fn)
+	}
+	return s.forEach(n, s.describeConcurrency, func(i int) error {
+		s.describeLimit.acquire()
+		defer s.describeLimit.release()
+		return fn(i)
+	})
+}
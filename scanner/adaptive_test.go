@@ -0,0 +1,94 @@
+package scanner
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAdaptiveLimitObserve(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	type event struct {
+		throttled bool
+		after     time.Duration // since start
+		repeat    int
+	}
+	tests := []struct {
+		name   string
+		max    int
+		events []event
+		want   int
+	}{
+		{name: "throttle halves", max: 8, events: []event{{throttled: true}}, want: 4},
+		{name: "throttles within the cooldown count once", max: 8, events: []event{{throttled: true}, {throttled: true, after: adaptiveCooldown / 2}}, want: 4},
+		{name: "throttles after the cooldown", max: 8, events: []event{{throttled: true}, {throttled: true, after: adaptiveCooldown}}, want: 2},
+		{name: "never below one", max: 2, events: []event{{throttled: true}, {throttled: true, after: adaptiveCooldown}, {throttled: true, after: 2 * adaptiveCooldown}}, want: 1},
+		{name: "ramps by one", max: 8, events: []event{{throttled: true}, {repeat: adaptiveRampAfter}}, want: 5},
+		{name: "ramp needs a clean run", max: 8, events: []event{{throttled: true}, {repeat: adaptiveRampAfter - 1}, {throttled: true, after: time.Millisecond}, {repeat: adaptiveRampAfter - 1}}, want: 4},
+		{name: "capped at max", max: 2, events: []event{{throttled: true}, {repeat: 5 * adaptiveRampAfter}}, want: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := newAdaptiveLimit(tt.max, func(string, ...any) {})
+			for _, e := range tt.events {
+				for range max(e.repeat, 1) {
+					l.observe(e.throttled, start.Add(e.after))
+				}
+			}
+			if l.limit != tt.want {
+				t.Errorf("limit = %d, want %d", l.limit, tt.want)
+			}
+		})
+	}
+}
+
+func TestAdaptiveLimitBoundsConcurrency(t *testing.T) {
+	l := newAdaptiveLimit(3, func(string, ...any) {})
+	var mu sync.Mutex
+	active, peak := 0, 0
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			l.acquire()
+			defer l.release()
+			mu.Lock()
+			active++
+			peak = max(peak, active)
+			mu.Unlock()
+			time.Sleep(time.Millisecond)
+			mu.Lock()
+			active--
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	if peak > 3 {
+		t.Errorf("%d slots in use at once, want at most 3", peak)
+	}
+}
+
+func TestAdaptiveLimitMiddleware(t *testing.T) {
+	tests := []struct {
+		name      string
+		throttle  map[int]bool
+		wantCalls int
+		want      int
+	}{
+		{name: "unthrottled", wantCalls: 1, want: 8},
+		{name: "throttled attempt retried", throttle: map[int]bool{1: true}, wantCalls: 2, want: 4},
+		{name: "throttled to the end", throttle: map[int]bool{1: true, 2: true, 3: true}, wantCalls: 3, want: 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := newAdaptiveLimit(8, func(string, ...any) {})
+			client := &throttlingHTTPClient{throttle: tt.throttle}
+			sendRequest(context.Background(), t, client, 3, l.middleware())
+			if client.calls != tt.wantCalls || l.limit != tt.want {
+				t.Errorf("limit after %d attempts = %d, want %d after %d", client.calls, l.limit, tt.want, tt.wantCalls)
+			}
+		})
+	}
+}
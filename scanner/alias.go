@@ -0,0 +1,34 @@
+package scanner
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+)
+
+// getAccountAlias returns the IAM alias of the account, or "" when it has none.
+// An account has at most one alias.
+func getAccountAlias(ctx context.Context, client IAMClient) (string, error) {
+	out, err := client.ListAccountAliases(ctx, &iam.ListAccountAliasesInput{})
+	if err != nil {
+		return "", err
+	}
+	if len(out.AccountAliases) == 0 {
+		return "", nil
+	}
+	return out.AccountAliases[0], nil
+}
+
+// resolveAlias looks up the account alias for the result. Failures are
+// logged and leave the alias empty, since the alias is only cosmetic.
+func (s *Scanner) resolveAlias(ctx context.Context) string {
+	client, err := s.factory.IAM(ctx)
+	if err == nil {
+		var alias string
+		if alias, err = getAccountAlias(ctx, client); err == nil {
+			return alias
+		}
+	}
+	s.logf("Warning: cannot resolve the account alias: %v\n", err)
+	return ""
+}
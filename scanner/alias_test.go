@@ -0,0 +1,67 @@
+package scanner
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/eks/types"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+)
+
+// fakeIAM answers ListAccountAliases with aliases, or fails it with err
+type fakeIAM struct {
+	aliases []string
+	err     error
+}
+
+func (c *fakeIAM) ListAccountAliases(ctx context.Context, params *iam.ListAccountAliasesInput, optFns ...func(*iam.Options)) (*iam.ListAccountAliasesOutput, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	return &iam.ListAccountAliasesOutput{AccountAliases: c.aliases}, nil
+}
+
+// iamFactory serves client as the IAM client
+type iamFactory struct {
+	*fakeFactory
+	client IAMClient
+}
+
+func (f *iamFactory) IAM(ctx context.Context) (IAMClient, error) {
+	return f.client, nil
+}
+
+func TestRunAccountAlias(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []Option
+		client   *fakeIAM
+		want     string
+		wantWarn bool
+	}{
+		{name: "not requested", client: &fakeIAM{aliases: []string{"corp-main"}}},
+		{name: "alias present", opts: []Option{WithAccountAlias()}, client: &fakeIAM{aliases: []string{"corp-main"}}, want: "corp-main"},
+		{name: "alias absent", opts: []Option{WithAccountAlias()}, client: &fakeIAM{}},
+		{name: "lookup denied", opts: []Option{WithAccountAlias()}, client: &fakeIAM{err: errors.New("AccessDenied")}, wantWarn: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &iamFactory{fakeFactory: newFakeFactory(map[string][]types.Cluster{"us-east-1": {fakeCluster("prod", "1.31")}}), client: tt.client}
+			var buf bytes.Buffer
+			opts := append([]Option{WithClientFactory(f), WithRegions("us-east-1"), WithOutput(&buf)}, tt.opts...)
+			result, err := NewScanner(opts...).Run(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.AccountAlias != tt.want {
+				t.Errorf("alias = %q, want %q", result.AccountAlias, tt.want)
+			}
+			if warned := strings.Contains(buf.String(), "cannot resolve the account alias: AccessDenied"); warned != tt.wantWarn {
+				t.Errorf("warned = %t, want %t:\n%s", warned, tt.wantWarn, buf.String())
+			}
+		})
+	}
+}
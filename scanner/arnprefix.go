@@ -0,0 +1,48 @@
+package scanner
+
+import "strings"
+
+// WithArnPrefixes keeps only the clusters whose ARN starts with one of
+// prefixes, such as arn:aws:eks:eu-west-1:123456789012:cluster/prod-. The ARN
+// is known once a cluster is listed, so the other clusters are dropped before
+// they are described.
+func WithArnPrefixes(prefixes ...string) Option {
+	return func(s *Scanner) {
+		s.arnPrefixes = prefixes
+	}
+}
+
+// setARNs fills in the ARN of every cluster from its name and region
+func setARNs(clusters []Cluster, partition, account string) {
+	for i := range clusters {
+		c := &clusters[i]
+		c.Arn = ClusterARN{Partition: partition, Region: c.Region, Account: account, Name: c.Name}.String()
+	}
+}
+
+// matchesArnPrefix reports whether arn starts with any of prefixes
+func matchesArnPrefix(arn string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(arn, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchesArnPrefix reports whether the cluster ARN starts with any of prefixes
+func (c *Cluster) MatchesArnPrefix(prefixes []string) bool {
+	return matchesArnPrefix(c.Arn, prefixes)
+}
+
+// filterARNs drops the clusters whose ARN matches none of the WithArnPrefixes
+// prefixes and logs how many were kept
+func (s *Scanner) filterARNs(clusters []Cluster) []Cluster {
+	if len(s.arnPrefixes) == 0 {
+		return clusters
+	}
+	total := len(clusters)
+	clusters = filterClusters(clusters, func(c *Cluster) bool { return c.MatchesArnPrefix(s.arnPrefixes) })
+	s.logf("Kept %d of %d clusters matching the ARN prefixes\n", len(clusters), total)
+	return clusters
+}
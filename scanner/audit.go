@@ -0,0 +1,145 @@
+package scanner
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/aws/smithy-go/middleware"
+)
+
+// AuditLog records what a scan did for compliance review: the identities it
+// ran as and every AWS API call with its outcome. Create one with NewAuditLog
+// and pass it to WithAuditLog; it is safe for concurrent use, so the scanners
+// of several profiles can share it.
+type AuditLog struct {
+	mu     sync.Mutex
+	record AuditRecord
+}
+
+// AuditRecord is the JSON document written from an AuditLog
+type AuditRecord struct {
+	StartedAt   time.Time       `json:"startedAt"`
+	FinishedAt  time.Time       `json:"finishedAt"`
+	ToolVersion string          `json:"toolVersion"`
+	Identities  []AuditIdentity `json:"identities"`
+	Calls       []AuditCall     `json:"calls"`
+	Summary     AuditSummary    `json:"summary"`
+}
+
+// AuditIdentity is a caller identity returned by sts:GetCallerIdentity
+type AuditIdentity struct {
+	Account string `json:"account"`
+	Arn     string `json:"arn"`
+	UserID  string `json:"userId,omitempty"`
+}
+
+// AuditCall is one AWS API operation, including its retries
+type AuditCall struct {
+	Time       time.Time `json:"time"`
+	Service    string    `json:"service"`
+	Operation  string    `json:"operation"`
+	Region     string    `json:"region,omitempty"`
+	DurationMs int64     `json:"durationMs"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// AuditSummary sums up the results of the scan
+type AuditSummary struct {
+	Accounts       []string `json:"accounts"`
+	Regions        []string `json:"regions"`
+	Clusters       int      `json:"clusters"`
+	RegionErrors   int      `json:"regionErrors"`
+	DescribeErrors int      `json:"describeErrors"`
+	Incomplete     bool     `json:"incomplete,omitempty"`
+	// Error is the error that ended the scan, if any.
+	Error string `json:"error,omitempty"`
+}
+
+// NewAuditLog returns an empty audit log started now
+func NewAuditLog() *AuditLog {
+	return &AuditLog{record: AuditRecord{
+		StartedAt:   time.Now().UTC(),
+		ToolVersion: Version,
+		Identities:  []AuditIdentity{},
+		Calls:       []AuditCall{},
+	}}
+}
+
+// WithAuditLog records every API call of the scan and the caller identity in
+// audit. Calls are only recorded through the default client factory.
+func WithAuditLog(audit *AuditLog) Option {
+	return func(s *Scanner) {
+		s.audit = audit
+	}
+}
+
+// Finish stamps the finish time, sums up results and err (the error that ended
+// the scan, or nil) and returns the completed record
+func (a *AuditLog) Finish(results []*ScanResult, err error) AuditRecord {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	summary := AuditSummary{Accounts: []string{}, Regions: []string{}}
+	seenRegions := make(map[string]bool)
+	for _, result := range results {
+		summary.Accounts = append(summary.Accounts, result.Account)
+		for _, region := range result.Regions {
+			if !seenRegions[region] {
+				seenRegions[region] = true
+				summary.Regions = append(summary.Regions, region)
+			}
+		}
+		summary.Clusters += len(result.Clusters)
+		summary.RegionErrors += len(result.RegionErrors)
+		summary.DescribeErrors += countDescribeErrors(result.Clusters)
+		summary.Incomplete = summary.Incomplete || result.Incomplete
+	}
+	if err != nil {
+		summary.Error = err.Error()
+	}
+
+	a.record.FinishedAt = time.Now().UTC()
+	a.record.Summary = summary
+	record := a.record
+	record.Identities = append([]AuditIdentity{}, a.record.Identities...)
+	record.Calls = append([]AuditCall{}, a.record.Calls...)
+	return record
+}
+
+// middleware records each operation once it completes, retries included, and
+// the identity returned by GetCallerIdentity
+func (a *AuditLog) middleware() func(*middleware.Stack) error {
+	return func(stack *middleware.Stack) error {
+		return stack.Initialize.Add(middleware.InitializeMiddlewareFunc("AuditLog",
+			func(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+				start := time.Now()
+				out, metadata, err := next.HandleInitialize(ctx, in)
+
+				call := AuditCall{
+					Time:       start.UTC(),
+					Service:    awsmiddleware.GetServiceID(ctx),
+					Operation:  awsmiddleware.GetOperationName(ctx),
+					Region:     awsmiddleware.GetRegion(ctx),
+					DurationMs: time.Since(start).Milliseconds(),
+				}
+				if err != nil {
+					call.Error = err.Error()
+				}
+				a.mu.Lock()
+				a.record.Calls = append(a.record.Calls, call)
+				if identity, ok := out.Result.(*sts.GetCallerIdentityOutput); ok && err == nil {
+					a.record.Identities = append(a.record.Identities, AuditIdentity{
+						Account: aws.ToString(identity.Account),
+						Arn:     aws.ToString(identity.Arn),
+						UserID:  aws.ToString(identity.UserId),
+					})
+				}
+				a.mu.Unlock()
+				return out, metadata, err
+			}), middleware.After)
+	}
+}
@@ -0,0 +1,166 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/aws/smithy-go/middleware"
+)
+
+// serviceMetadata registers the service, operation and region of a call
+// ahead of the other middlewares, as the SDK clients do
+func serviceMetadata(service, operation, region string) func(*middleware.Stack) error {
+	return func(stack *middleware.Stack) error {
+		return stack.Initialize.Add(&awsmiddleware.RegisterServiceMetadata{ServiceID: service, OperationName: operation, Region: region}, middleware.Before)
+	}
+}
+
+func TestAuditLogRecordsCalls(t *testing.T) {
+	audit := NewAuditLog()
+	tests := []struct {
+		name      string
+		throttle  map[int]bool
+		attempts  int
+		operation string
+		wantErr   bool
+	}{
+		{name: "retried call recorded once", throttle: map[int]bool{1: true}, attempts: 3, operation: "ListClusters"},
+		{name: "failed call", throttle: map[int]bool{1: true, 2: true}, attempts: 2, operation: "DescribeCluster", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := sendRequest(context.Background(), t, &throttlingHTTPClient{throttle: tt.throttle}, tt.attempts,
+				audit.middleware(), serviceMetadata("EKS", tt.operation, "us-east-1"))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %t", err, tt.wantErr)
+			}
+		})
+	}
+
+	record := audit.Finish(nil, nil)
+	if len(record.Calls) != len(tests) {
+		t.Fatalf("%d calls recorded, want %d: %+v", len(record.Calls), len(tests), record.Calls)
+	}
+	for i, call := range record.Calls {
+		tt := tests[i]
+		if call.Service != "EKS" || call.Operation != tt.operation || call.Region != "us-east-1" {
+			t.Errorf("call %d = %s %s in %s, want EKS %s in us-east-1", i, call.Service, call.Operation, call.Region, tt.operation)
+		}
+		if (call.Error != "") != tt.wantErr {
+			t.Errorf("call %d error = %q, want error %t", i, call.Error, tt.wantErr)
+		}
+		if call.Time.IsZero() || call.Time.Location() != time.UTC || call.Time.Before(record.StartedAt) {
+			t.Errorf("call %d time = %v, want UTC after the start %v", i, call.Time, record.StartedAt)
+		}
+	}
+	if len(record.Identities) != 0 {
+		t.Errorf("identities = %+v, want none without GetCallerIdentity", record.Identities)
+	}
+}
+
+func TestAuditLogRecordsIdentity(t *testing.T) {
+	audit := NewAuditLog()
+	tests := []struct {
+		name string
+		err  error
+	}{
+		{name: "identity"},
+		{name: "failed lookup", err: errors.New("ExpiredToken")},
+	}
+	for _, tt := range tests {
+		stack := middleware.NewStack("GetCallerIdentity", func() any { return nil })
+		for _, fn := range []func(*middleware.Stack) error{audit.middleware(), serviceMetadata("STS", "GetCallerIdentity", "us-east-1")} {
+			if err := fn(stack); err != nil {
+				t.Fatal(err)
+			}
+		}
+		// Stands in for the deserializer, which turns the response into the result
+		stack.Deserialize.Add(middleware.DeserializeMiddlewareFunc("Deserialize", func(ctx context.Context, in middleware.DeserializeInput, next middleware.DeserializeHandler) (middleware.DeserializeOutput, middleware.Metadata, error) {
+			out, metadata, err := next.HandleDeserialize(ctx, in)
+			out.Result = out.RawResponse
+			return out, metadata, err
+		}), middleware.After)
+		handler := middleware.HandlerFunc(func(ctx context.Context, input any) (any, middleware.Metadata, error) {
+			if tt.err != nil {
+				return nil, middleware.Metadata{}, tt.err
+			}
+			return &sts.GetCallerIdentityOutput{
+				Account: aws.String("123456789012"),
+				Arn:     aws.String("arn:aws:iam::123456789012:user/test"),
+				UserId:  aws.String("AIDAEXAMPLE"),
+			}, middleware.Metadata{}, nil
+		})
+		middleware.DecorateHandler(handler, stack).Handle(context.Background(), struct{}{})
+	}
+
+	record := audit.Finish(nil, nil)
+	want := []AuditIdentity{{Account: "123456789012", Arn: "arn:aws:iam::123456789012:user/test", UserID: "AIDAEXAMPLE"}}
+	if !reflect.DeepEqual(record.Identities, want) {
+		t.Errorf("identities = %+v, want %+v", record.Identities, want)
+	}
+	if len(record.Calls) != 2 || record.Calls[1].Error != "ExpiredToken" {
+		t.Errorf("calls = %+v, want both lookups with the failure's error", record.Calls)
+	}
+}
+
+func TestAuditLogFinish(t *testing.T) {
+	tests := []struct {
+		name    string
+		results []*ScanResult
+		err     error
+		want    AuditSummary
+	}{
+		{name: "no results", want: AuditSummary{Accounts: []string{}, Regions: []string{}}},
+		{
+			name: "results summed",
+			results: []*ScanResult{
+				{Account: "123456789012", Regions: []string{"us-east-1", "eu-west-1"}, Clusters: []Cluster{{Name: "a"}, {Name: "b", DescribeError: "timeout"}}},
+				{Account: "210987654321", Regions: []string{"eu-west-1", "ap-south-1"}, Clusters: []Cluster{{Name: "c"}},
+					RegionErrors: []RegionError{{Region: "ap-south-1"}}, Incomplete: true},
+			},
+			err: errors.New("getting node groups: AccessDenied"),
+			want: AuditSummary{
+				Accounts:       []string{"123456789012", "210987654321"},
+				Regions:        []string{"us-east-1", "eu-west-1", "ap-south-1"},
+				Clusters:       3,
+				RegionErrors:   1,
+				DescribeErrors: 1,
+				Incomplete:     true,
+				Error:          "getting node groups: AccessDenied",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			audit := NewAuditLog()
+			record := audit.Finish(tt.results, tt.err)
+			if !reflect.DeepEqual(record.Summary, tt.want) {
+				t.Errorf("summary = %+v, want %+v", record.Summary, tt.want)
+			}
+			if record.ToolVersion != Version || record.FinishedAt.Before(record.StartedAt) {
+				t.Errorf("record = tool %q, %v to %v", record.ToolVersion, record.StartedAt, record.FinishedAt)
+			}
+			// The record is a copy: later calls do not change it
+			audit.record.Calls = append(audit.record.Calls, AuditCall{Operation: "ListClusters"})
+			if len(record.Calls) != 0 {
+				t.Errorf("record calls = %+v, want the copy unchanged", record.Calls)
+			}
+		})
+	}
+}
+
+func TestWithAuditLogInstallsMiddleware(t *testing.T) {
+	f, ok := NewScanner(WithAuditLog(NewAuditLog())).factory.(*DefaultClientFactory)
+	if !ok || len(f.APIOptions) != 1 {
+		t.Errorf("factory = %+v, want the default factory with the audit middleware", f)
+	}
+	if f, ok := NewScanner().factory.(*DefaultClientFactory); !ok || len(f.APIOptions) != 0 {
+		t.Errorf("factory = %+v, want no API options without an audit log", f)
+	}
+}
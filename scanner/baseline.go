@@ -0,0 +1,98 @@
+package scanner
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+)
+
+// ClusterRef identifies a cluster by account, region and name
+type ClusterRef struct {
+	Account string `json:"account"`
+	Region  string `json:"region"`
+	Name    string `json:"name"`
+}
+
+// Drift lists the differences between a live scan and an approved baseline
+type Drift struct {
+	// Unexpected clusters were found by the scan but are not in the baseline.
+	Unexpected []ClusterRef `json:"unexpected"`
+	// Missing clusters are in the baseline but were not found by the scan.
+	Missing []ClusterRef `json:"missing"`
+}
+
+// HasDrift reports whether any unexpected or missing cluster was found
+func (d *Drift) HasDrift() bool {
+	return len(d.Unexpected) > 0 || len(d.Missing) > 0
+}
+
+// LoadBaseline decodes a JSON list of approved clusters
+func LoadBaseline(r io.Reader) ([]ClusterRef, error) {
+	var baseline []ClusterRef
+	if err := json.NewDecoder(r).Decode(&baseline); err != nil {
+		return nil, fmt.Errorf("decoding baseline: %w", err)
+	}
+	for i, ref := range baseline {
+		if ref.Account == "" || ref.Region == "" || ref.Name == "" {
+			return nil, fmt.Errorf("baseline entry %d: account, region and name are required", i)
+		}
+	}
+	return baseline, nil
+}
+
+// clusterRefs returns the references of clusters in account
+func clusterRefs(account string, clusters []Cluster) []ClusterRef {
+	refs := make([]ClusterRef, 0, len(clusters))
+	for _, c := range clusters {
+		refs = append(refs, ClusterRef{Account: account, Region: c.Region, Name: c.Name})
+	}
+	return refs
+}
+
+// CompareBaseline compares a scan against the approved baseline. Baseline
+// entries for other accounts, or for regions that were not scanned or whose
+// listing failed, are ignored so a partial scan does not report them as
+// missing. Unexpected clusters are taken from Clusters, after the scan's
+// filters; missing ones are judged against every cluster the scan found
+// before filtering, so a narrowing filter such as WithTags does not turn the
+// baseline clusters it leaves out into drift.
+func CompareBaseline(result *ScanResult, baseline []ClusterRef) *Drift {
+	drift := &Drift{Unexpected: []ClusterRef{}, Missing: []ClusterRef{}}
+
+	expected := make(map[ClusterRef]bool, len(baseline))
+	for _, ref := range baseline {
+		if ref.Account == result.Account && slices.Contains(result.Regions, ref.Region) && !regionFailed(result.RegionErrors, ref.Region) {
+			expected[ref] = true
+		}
+	}
+
+	found := make(map[ClusterRef]bool, len(result.Clusters))
+	for _, c := range result.Clusters {
+		ref := ClusterRef{Account: result.Account, Region: c.Region, Name: c.Name}
+		found[ref] = true
+		if !expected[ref] {
+			drift.Unexpected = append(drift.Unexpected, ref)
+		}
+	}
+	// Clusters dropped by a filter were still found; a result decoded from
+	// JSON has no record of them and is compared on Clusters alone
+	for _, ref := range result.listed {
+		found[ref] = true
+	}
+
+	for _, ref := range baseline {
+		if expected[ref] && !found[ref] {
+			drift.Missing = append(drift.Missing, ref)
+			// Guard against duplicate baseline entries
+			found[ref] = true
+		}
+	}
+
+	return drift
+}
+
+// regionFailed reports whether region is among regionErrs
+func regionFailed(regionErrs []RegionError, region string) bool {
+	return slices.ContainsFunc(regionErrs, func(e RegionError) bool { return e.Region == region })
+}
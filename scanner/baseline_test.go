@@ -0,0 +1,141 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/eks/types"
+)
+
+func TestLoadBaseline(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []ClusterRef
+		wantErr string
+	}{
+		{name: "empty list", input: `[]`, want: []ClusterRef{}},
+		{
+			name:  "entries",
+			input: `[{"account":"123456789012","region":"us-east-1","name":"prod"}]`,
+			want:  []ClusterRef{{Account: "123456789012", Region: "us-east-1", Name: "prod"}},
+		},
+		{name: "missing name", input: `[{"account":"123456789012","region":"us-east-1"}]`, wantErr: "baseline entry 0"},
+		{name: "not a list", input: `{"account":"123456789012"}`, wantErr: "decoding baseline"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := LoadBaseline(strings.NewReader(tt.input))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("baseline = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompareBaseline(t *testing.T) {
+	const account = "123456789012"
+	ref := func(region, name string) ClusterRef {
+		return ClusterRef{Account: account, Region: region, Name: name}
+	}
+	baseline := []ClusterRef{
+		ref("us-east-1", "prod"),
+		ref("us-east-1", "retired"),
+		ref("eu-west-1", "eu"),
+		ref("ap-south-1", "unscanned"),
+		{Account: "210987654321", Region: "us-east-1", Name: "other-account"},
+	}
+	tests := []struct {
+		name           string
+		result         *ScanResult
+		wantUnexpected []ClusterRef
+		wantMissing    []ClusterRef
+	}{
+		{
+			name: "no drift outside the scan",
+			result: &ScanResult{Account: account, Regions: []string{"us-east-1", "eu-west-1"}, Clusters: []Cluster{
+				{Name: "prod", Region: "us-east-1"}, {Name: "retired", Region: "us-east-1"}, {Name: "eu", Region: "eu-west-1"},
+			}},
+		},
+		{
+			name: "unexpected and missing",
+			result: &ScanResult{Account: account, Regions: []string{"us-east-1", "eu-west-1"}, Clusters: []Cluster{
+				{Name: "prod", Region: "us-east-1"}, {Name: "shadow", Region: "us-east-1"}, {Name: "eu", Region: "eu-west-1"},
+			}},
+			wantUnexpected: []ClusterRef{ref("us-east-1", "shadow")},
+			wantMissing:    []ClusterRef{ref("us-east-1", "retired")},
+		},
+		{
+			name: "failed region not missing",
+			result: &ScanResult{Account: account, Regions: []string{"us-east-1", "eu-west-1"},
+				Clusters:     []Cluster{{Name: "prod", Region: "us-east-1"}, {Name: "retired", Region: "us-east-1"}},
+				RegionErrors: []RegionError{{Region: "eu-west-1", Error: "AccessDenied"}},
+			},
+		},
+		{
+			name: "filtered clusters not missing",
+			result: &ScanResult{Account: account, Regions: []string{"us-east-1", "eu-west-1"},
+				Clusters: []Cluster{{Name: "prod", Region: "us-east-1"}},
+				listed:   []ClusterRef{ref("us-east-1", "prod"), ref("us-east-1", "retired"), ref("eu-west-1", "eu")},
+			},
+		},
+		{
+			name: "decoded result compared on clusters",
+			result: &ScanResult{Account: account, Regions: []string{"us-east-1", "eu-west-1"},
+				Clusters: []Cluster{{Name: "prod", Region: "us-east-1"}, {Name: "retired", Region: "us-east-1"}},
+			},
+			wantMissing: []ClusterRef{ref("eu-west-1", "eu")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			drift := CompareBaseline(tt.result, baseline)
+			if tt.wantUnexpected == nil {
+				tt.wantUnexpected = []ClusterRef{}
+			}
+			if tt.wantMissing == nil {
+				tt.wantMissing = []ClusterRef{}
+			}
+			if !reflect.DeepEqual(drift.Unexpected, tt.wantUnexpected) || !reflect.DeepEqual(drift.Missing, tt.wantMissing) {
+				t.Errorf("drift = unexpected %v, missing %v; want %v, %v", drift.Unexpected, drift.Missing, tt.wantUnexpected, tt.wantMissing)
+			}
+			if drift.HasDrift() != (len(tt.wantUnexpected)+len(tt.wantMissing) > 0) {
+				t.Errorf("HasDrift() = %t", drift.HasDrift())
+			}
+		})
+	}
+}
+
+func TestCompareBaselineAfterScan(t *testing.T) {
+	prod := fakeCluster("prod", "1.31")
+	prod.Tags = map[string]string{"env": "prod"}
+	dev := fakeCluster("dev", "1.31")
+	dev.Tags = map[string]string{"env": "dev"}
+	f := newFakeFactory(map[string][]types.Cluster{"us-east-1": {prod, dev}, "eu-west-1": nil})
+	f.region("eu-west-1").listErr = errors.New("AccessDenied")
+
+	result, err := newFakeScanner(f, WithTagFilter("env", "prod")).Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	baseline := []ClusterRef{
+		{Account: f.account, Region: "us-east-1", Name: "prod"},
+		{Account: f.account, Region: "us-east-1", Name: "dev"},
+		{Account: f.account, Region: "eu-west-1", Name: "eu"},
+	}
+	if drift := CompareBaseline(result, baseline); drift.HasDrift() {
+		t.Errorf("drift = %+v, want none: dev is filtered out and eu-west-1 failed", drift)
+	}
+}
@@ -0,0 +1,54 @@
+package scanner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+
+	"github.com/aws/aws-sdk-go-v2/service/eks"
+	"github.com/aws/smithy-go/middleware"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+)
+
+// readBootstrapAddons is a per-call EKS option that stores the
+// cluster.bootstrapSelfManagedAddons member of the raw DescribeCluster
+// response in dst. The SDK's types.Cluster has no field for it, so the body
+// is read here and handed on unchanged to the SDK's deserializer; dst stays
+// nil when the response does not include it.
+func readBootstrapAddons(dst **bool) func(*eks.Options) {
+	return func(o *eks.Options) {
+		o.APIOptions = append(o.APIOptions, func(stack *middleware.Stack) error {
+			return stack.Deserialize.Add(middleware.DeserializeMiddlewareFunc("BootstrapSelfManagedAddons",
+				func(ctx context.Context, in middleware.DeserializeInput, next middleware.DeserializeHandler) (middleware.DeserializeOutput, middleware.Metadata, error) {
+					out, md, err := next.HandleDeserialize(ctx, in)
+					resp, ok := out.RawResponse.(*smithyhttp.Response)
+					if err != nil || !ok || resp.Body == nil || resp.StatusCode < 200 || resp.StatusCode > 299 {
+						return out, md, err
+					}
+					body, err := io.ReadAll(resp.Body)
+					resp.Body.Close()
+					resp.Body = io.NopCloser(bytes.NewReader(body))
+					if err != nil {
+						return out, md, err
+					}
+					*dst = parseBootstrapAddons(body)
+					return out, md, nil
+				}), middleware.After)
+		})
+	}
+}
+
+// parseBootstrapAddons returns cluster.bootstrapSelfManagedAddons of a
+// DescribeCluster response body, or nil when it is absent
+func parseBootstrapAddons(body []byte) *bool {
+	var doc struct {
+		Cluster struct {
+			BootstrapSelfManagedAddons *bool `json:"bootstrapSelfManagedAddons"`
+		} `json:"cluster"`
+	}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil
+	}
+	return doc.Cluster.BootstrapSelfManagedAddons
+}
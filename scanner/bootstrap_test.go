@@ -0,0 +1,59 @@
+package scanner
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eks"
+)
+
+func TestParseBootstrapAddons(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want *bool
+	}{
+		{"enabled", `{"cluster":{"name":"a","bootstrapSelfManagedAddons":true}}`, aws.Bool(true)},
+		{"disabled", `{"cluster":{"name":"a","bootstrapSelfManagedAddons":false}}`, aws.Bool(false)},
+		{"absent", `{"cluster":{"name":"a"}}`, nil},
+		{"not json", `<html>`, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseBootstrapAddons([]byte(tt.body))
+			if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+				t.Errorf("parseBootstrapAddons(%s) = %v, want %v", tt.body, aws.ToBool(got), aws.ToBool(tt.want))
+			}
+		})
+	}
+}
+
+func TestReadBootstrapAddons(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"cluster":{"name":"prod","version":"1.31","bootstrapSelfManagedAddons":false}}`))
+	}))
+	defer srv.Close()
+
+	client := eks.New(eks.Options{
+		Region:       "us-east-1",
+		BaseEndpoint: aws.String(srv.URL),
+		Credentials:  aws.AnonymousCredentials{},
+		HTTPClient:   srv.Client(),
+	})
+	var bootstrap *bool
+	out, err := client.DescribeCluster(context.Background(), &eks.DescribeClusterInput{Name: aws.String("prod")}, readBootstrapAddons(&bootstrap))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The SDK still decodes the body after it was read
+	if got := aws.ToString(out.Cluster.Version); got != "1.31" {
+		t.Errorf("Version = %q, want 1.31", got)
+	}
+	if bootstrap == nil || *bootstrap {
+		t.Errorf("bootstrap = %v, want false", bootstrap)
+	}
+}
@@ -0,0 +1,26 @@
+package scanner
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+)
+
+// checksumPrefix names the hash algorithm in ScanResult.Checksum
+const checksumPrefix = "sha256:"
+
+// ResultChecksum returns the SHA-256 of the canonical JSON encoding of r with
+// its Checksum field cleared, as "sha256:<hex>". The canonical encoding is
+// compact with object keys in struct order and map keys sorted, which is what
+// encoding/json produces, so consumers verify a result by decoding it into a
+// ScanResult and comparing ResultChecksum with its Checksum.
+func ResultChecksum(r *ScanResult) (string, error) {
+	unsigned := *r
+	unsigned.Checksum = ""
+	data, err := json.Marshal(&unsigned)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return checksumPrefix + hex.EncodeToString(sum[:]), nil
+}
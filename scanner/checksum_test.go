@@ -0,0 +1,62 @@
+package scanner
+
+import (
+	"encoding/json"
+	"regexp"
+	"testing"
+)
+
+func TestResultChecksum(t *testing.T) {
+	base := func() *ScanResult {
+		return &ScanResult{SchemaVersion: SchemaVersion, Account: "123456789012", Clusters: []Cluster{
+			{Name: "prod", Region: "us-east-1", Tags: map[string]string{"team": "a", "env": "prod"}},
+		}}
+	}
+	want, err := ResultChecksum(base())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !regexp.MustCompile(`^sha256:[0-9a-f]{64}$`).MatchString(want) {
+		t.Fatalf("checksum = %q, want sha256:<hex>", want)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(r *ScanResult)
+		same   bool
+	}{
+		{name: "own checksum ignored", modify: func(r *ScanResult) { r.Checksum = "sha256:0000" }, same: true},
+		{name: "map order ignored", modify: func(r *ScanResult) { r.Clusters[0].Tags = map[string]string{"env": "prod", "team": "a"} }, same: true},
+		{name: "cluster changed", modify: func(r *ScanResult) { r.Clusters[0].Version = "1.31" }},
+		{name: "account changed", modify: func(r *ScanResult) { r.Account = "210987654321" }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := base()
+			tt.modify(r)
+			got, err := ResultChecksum(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (got == want) != tt.same {
+				t.Errorf("checksum = %s, base %s; want same %t", got, want, tt.same)
+			}
+		})
+	}
+
+	t.Run("verified after decoding", func(t *testing.T) {
+		r := base()
+		r.Checksum = want
+		data, err := json.Marshal(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var decoded ScanResult
+		if err := DecodeJSON(data, &decoded, true); err != nil {
+			t.Fatal(err)
+		}
+		if got, err := ResultChecksum(&decoded); err != nil || got != decoded.Checksum {
+			t.Errorf("decoded checksum = %s, %v; want %s", got, err, decoded.Checksum)
+		}
+	})
+}
@@ -0,0 +1,36 @@
+package scanner
+
+import "net/netip"
+
+// WithAllowedCidrs checks the public access CIDRs of every public cluster
+// against allowed and records the entries not contained in any of them as
+// DisallowedCidrs. An entry is contained when it is an allowed prefix or a
+// subnet of one.
+func WithAllowedCidrs(allowed ...netip.Prefix) Option {
+	return func(s *Scanner) {
+		s.allowedCidrs = allowed
+	}
+}
+
+// disallowedCidrs returns the entries of cidrs not contained in any allowed
+// prefix. Entries that do not parse are returned too, since they cannot be shown to comply.
+func disallowedCidrs(cidrs []string, allowed []netip.Prefix) []string {
+	var disallowed []string
+	for _, cidr := range cidrs {
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil || !prefixAllowed(prefix.Masked(), allowed) {
+			disallowed = append(disallowed, cidr)
+		}
+	}
+	return disallowed
+}
+
+// prefixAllowed reports whether prefix lies entirely within one of allowed
+func prefixAllowed(prefix netip.Prefix, allowed []netip.Prefix) bool {
+	for _, a := range allowed {
+		if a.Bits() <= prefix.Bits() && a.Contains(prefix.Addr()) {
+			return true
+		}
+	}
+	return false
+}
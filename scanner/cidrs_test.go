@@ -0,0 +1,53 @@
+package scanner
+
+import (
+	"context"
+	"net/netip"
+	"slices"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/eks/types"
+)
+
+func TestDisallowedCidrs(t *testing.T) {
+	allowed := []netip.Prefix{netip.MustParsePrefix("203.0.113.0/24"), netip.MustParsePrefix("2001:db8::/32")}
+	tests := []struct {
+		name  string
+		cidrs []string
+		want  []string
+	}{
+		{name: "allowed prefix", cidrs: []string{"203.0.113.0/24"}},
+		{name: "subnet of an allowed prefix", cidrs: []string{"203.0.113.128/25", "2001:db8:1::/48"}},
+		{name: "host bits ignored", cidrs: []string{"203.0.113.7/26"}},
+		{name: "supernet", cidrs: []string{"203.0.0.0/16"}, want: []string{"203.0.0.0/16"}},
+		{name: "open", cidrs: []string{"0.0.0.0/0", "203.0.113.0/24"}, want: []string{"0.0.0.0/0"}},
+		{name: "unparsable", cidrs: []string{"not-a-cidr"}, want: []string{"not-a-cidr"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := disallowedCidrs(tt.cidrs, allowed); !slices.Equal(got, tt.want) {
+				t.Errorf("disallowedCidrs(%q) = %q, want %q", tt.cidrs, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunRecordsDisallowedCidrs(t *testing.T) {
+	public := fakeCluster("public", "1.31")
+	public.ResourcesVpcConfig = &types.VpcConfigResponse{EndpointPublicAccess: true, PublicAccessCidrs: []string{"0.0.0.0/0", "203.0.113.0/24"}}
+	private := fakeCluster("private", "1.31")
+	private.ResourcesVpcConfig = &types.VpcConfigResponse{EndpointPublicAccess: false, PublicAccessCidrs: []string{"0.0.0.0/0"}}
+	f := newFakeFactory(map[string][]types.Cluster{"us-east-1": {public, private}})
+
+	result, err := newFakeScanner(f, WithAllowedCidrs(netip.MustParsePrefix("203.0.113.0/24"))).Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string][]string{}
+	for _, c := range result.Clusters {
+		got[c.Name] = c.DisallowedCidrs
+	}
+	if !slices.Equal(got["public"], []string{"0.0.0.0/0"}) || got["private"] != nil {
+		t.Errorf("disallowed CIDRs = %q, want only 0.0.0.0/0 of the public cluster", got)
+	}
+}
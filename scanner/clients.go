@@ -0,0 +1,216 @@
+package scanner
+
+import (
+	"context"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/eks"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/aws/smithy-go/middleware"
+)
+
+// ConfigLoader defines an interface for loading AWS configuration.
+// This interface creation is necessary for mocking.
+type ConfigLoader interface {
+	LoadDefaultConfigMethod(ctx context.Context) (aws.Config, error)
+}
+
+// DefaultConfigLoader is the type upon which we call the LoadDefaultConfigMethod Method.
+// This type creation is necessary for mocking.
+type DefaultConfigLoader struct {
+	// Profile selects a named profile from the shared config files; empty uses the default chain.
+	Profile string
+	// MFATokenProvider, when set, supplies MFA codes for profiles that assume
+	// a role with an mfa_serial.
+	MFATokenProvider func() (string, error)
+}
+
+// LoadDefaultConfigMethod implements the ConfigLoader interface using the AWS SDK.
+// LoadDefaultConfigMethod is the func converted to method necessary for mocking.
+func (l *DefaultConfigLoader) LoadDefaultConfigMethod(ctx context.Context) (aws.Config, error) {
+	var optFns []func(*config.LoadOptions) error
+	if l.Profile != "" {
+		optFns = append(optFns, config.WithSharedConfigProfile(l.Profile))
+	}
+	if l.MFATokenProvider != nil {
+		optFns = append(optFns, config.WithAssumeRoleCredentialOptions(func(o *stscreds.AssumeRoleOptions) {
+			o.TokenProvider = l.MFATokenProvider
+		}))
+	}
+	return config.LoadDefaultConfig(ctx, optFns...)
+}
+
+// This is the STSClient interface for STS operations.
+type STSClient interface {
+	GetCallerIdentity(ctx context.Context, params *sts.GetCallerIdentityInput, optFns ...func(*sts.Options)) (*sts.GetCallerIdentityOutput, error)
+}
+
+// IAMClient interface for IAM operations
+type IAMClient interface {
+	ListAccountAliases(ctx context.Context, params *iam.ListAccountAliasesInput, optFns ...func(*iam.Options)) (*iam.ListAccountAliasesOutput, error)
+}
+
+// EC2Client interface for EC2 operations
+type EC2Client interface {
+	DescribeRegions(ctx context.Context, params *ec2.DescribeRegionsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeRegionsOutput, error)
+	DescribeInstances(ctx context.Context, params *ec2.DescribeInstancesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error)
+	DescribeVpcs(ctx context.Context, params *ec2.DescribeVpcsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeVpcsOutput, error)
+	DescribeSubnets(ctx context.Context, params *ec2.DescribeSubnetsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeSubnetsOutput, error)
+	DescribeSecurityGroupRules(ctx context.Context, params *ec2.DescribeSecurityGroupRulesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeSecurityGroupRulesOutput, error)
+}
+
+// EKSClient interface for EKS operations
+type EKSClient interface {
+	ListClusters(ctx context.Context, params *eks.ListClustersInput, optFns ...func(*eks.Options)) (*eks.ListClustersOutput, error)
+	DescribeCluster(ctx context.Context, params *eks.DescribeClusterInput, optFns ...func(*eks.Options)) (*eks.DescribeClusterOutput, error)
+	DescribeClusterVersions(ctx context.Context, params *eks.DescribeClusterVersionsInput, optFns ...func(*eks.Options)) (*eks.DescribeClusterVersionsOutput, error)
+	ListAccessEntries(ctx context.Context, params *eks.ListAccessEntriesInput, optFns ...func(*eks.Options)) (*eks.ListAccessEntriesOutput, error)
+	DescribeAccessEntry(ctx context.Context, params *eks.DescribeAccessEntryInput, optFns ...func(*eks.Options)) (*eks.DescribeAccessEntryOutput, error)
+	ListAssociatedAccessPolicies(ctx context.Context, params *eks.ListAssociatedAccessPoliciesInput, optFns ...func(*eks.Options)) (*eks.ListAssociatedAccessPoliciesOutput, error)
+	ListNodegroups(ctx context.Context, params *eks.ListNodegroupsInput, optFns ...func(*eks.Options)) (*eks.ListNodegroupsOutput, error)
+	DescribeNodegroup(ctx context.Context, params *eks.DescribeNodegroupInput, optFns ...func(*eks.Options)) (*eks.DescribeNodegroupOutput, error)
+	ListUpdates(ctx context.Context, params *eks.ListUpdatesInput, optFns ...func(*eks.Options)) (*eks.ListUpdatesOutput, error)
+	DescribeUpdate(ctx context.Context, params *eks.DescribeUpdateInput, optFns ...func(*eks.Options)) (*eks.DescribeUpdateOutput, error)
+}
+
+// TaggingClient interface for Resource Groups Tagging API operations
+type TaggingClient interface {
+	GetResources(ctx context.Context, params *resourcegroupstaggingapi.GetResourcesInput, optFns ...func(*resourcegroupstaggingapi.Options)) (*resourcegroupstaggingapi.GetResourcesOutput, error)
+}
+
+// ClientFactory builds the service clients used by a Scanner.
+// EC2 and EKS clients are regional, so one is requested per scanned region;
+// an empty region means the region of the loaded configuration.
+type ClientFactory interface {
+	STS(ctx context.Context) (STSClient, error)
+	IAM(ctx context.Context) (IAMClient, error)
+	EC2(ctx context.Context, region string) (EC2Client, error)
+	EKS(ctx context.Context, region string) (EKSClient, error)
+	Tagging(ctx context.Context, region string) (TaggingClient, error)
+}
+
+// DefaultClientFactory builds SDK clients from a single configuration
+// loaded once through its ConfigLoader.
+type DefaultClientFactory struct {
+	Loader ConfigLoader
+	// APIOptions are appended to the loaded configuration and apply to every client.
+	APIOptions []func(*middleware.Stack) error
+	// HTTPClient, when set, replaces the HTTP client of the loaded configuration.
+	HTTPClient aws.HTTPClient
+
+	mu     sync.Mutex
+	loaded bool
+	cfg    aws.Config
+	err    error
+	eks    map[string]*eks.Client
+}
+
+// Refresher is implemented by client factories that can reload their
+// credentials, for example after temporary credentials expire mid-scan.
+type Refresher interface {
+	Refresh(ctx context.Context) error
+}
+
+// NewDefaultClientFactory returns a factory that loads configuration with loader.
+func NewDefaultClientFactory(loader ConfigLoader) *DefaultClientFactory {
+	return &DefaultClientFactory{Loader: loader}
+}
+
+// config loads the shared configuration on first use
+func (f *DefaultClientFactory) config(ctx context.Context) (aws.Config, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.loaded {
+		f.cfg, f.err = f.Loader.LoadDefaultConfigMethod(ctx)
+		if f.err == nil {
+			f.cfg.APIOptions = append(f.cfg.APIOptions, f.APIOptions...)
+			if f.HTTPClient != nil {
+				f.cfg.HTTPClient = f.HTTPClient
+			}
+		}
+		f.loaded = true
+	}
+	return f.cfg, f.err
+}
+
+// Refresh reloads the configuration, and with it the credential chain
+// (shared files, SSO cache, assumed roles), and drops cached clients
+func (f *DefaultClientFactory) Refresh(ctx context.Context) error {
+	f.mu.Lock()
+	f.loaded = false
+	f.eks = nil
+	f.mu.Unlock()
+	_, err := f.config(ctx)
+	return err
+}
+
+// STS creates a new STS client in the configured region
+func (f *DefaultClientFactory) STS(ctx context.Context) (STSClient, error) {
+	cfg, err := f.config(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return sts.NewFromConfig(cfg), nil
+}
+
+// IAM creates a new IAM client; IAM is a global service
+func (f *DefaultClientFactory) IAM(ctx context.Context) (IAMClient, error) {
+	cfg, err := f.config(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return iam.NewFromConfig(cfg), nil
+}
+
+// EC2 creates a new EC2 client for the given region
+func (f *DefaultClientFactory) EC2(ctx context.Context, region string) (EC2Client, error) {
+	cfg, err := f.config(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if region == "" {
+		return ec2.NewFromConfig(cfg), nil
+	}
+	regionCfg := cfg.Copy()
+	regionCfg.Region = region
+	return ec2.NewFromConfig(regionCfg), nil
+}
+
+// EKS returns an EKS client for the given region, creating it on first use
+func (f *DefaultClientFactory) EKS(ctx context.Context, region string) (EKSClient, error) {
+	cfg, err := f.config(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if client, ok := f.eks[region]; ok {
+		return client, nil
+	}
+	if f.eks == nil {
+		f.eks = make(map[string]*eks.Client)
+	}
+	regionCfg := cfg.Copy()
+	regionCfg.Region = region
+	client := eks.NewFromConfig(regionCfg)
+	f.eks[region] = client
+	return client, nil
+}
+
+// Tagging creates a new Resource Groups Tagging API client for the given region
+func (f *DefaultClientFactory) Tagging(ctx context.Context, region string) (TaggingClient, error) {
+	cfg, err := f.config(ctx)
+	if err != nil {
+		return nil, err
+	}
+	regionCfg := cfg.Copy()
+	regionCfg.Region = region
+	return resourcegroupstaggingapi.NewFromConfig(regionCfg), nil
+}
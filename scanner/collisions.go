@@ -0,0 +1,33 @@
+package scanner
+
+import (
+	"slices"
+	"strings"
+)
+
+// NameCollision is a cluster name used in more than one region of an account
+type NameCollision struct {
+	Name    string   `json:"name"`
+	Regions []string `json:"regions"`
+}
+
+// FindNameCollisions returns the names shared by clusters in different
+// regions, sorted by name, each with its sorted regions
+func FindNameCollisions(clusters []Cluster) []NameCollision {
+	regions := make(map[string][]string)
+	for _, c := range clusters {
+		if !slices.Contains(regions[c.Name], c.Region) {
+			regions[c.Name] = append(regions[c.Name], c.Region)
+		}
+	}
+
+	var collisions []NameCollision
+	for name, rs := range regions {
+		if len(rs) > 1 {
+			slices.Sort(rs)
+			collisions = append(collisions, NameCollision{Name: name, Regions: rs})
+		}
+	}
+	slices.SortFunc(collisions, func(a, b NameCollision) int { return strings.Compare(a.Name, b.Name) })
+	return collisions
+}
@@ -0,0 +1,65 @@
+package scanner
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/eks/types"
+)
+
+func TestFindNameCollisions(t *testing.T) {
+	tests := []struct {
+		name     string
+		clusters []Cluster
+		want     []NameCollision
+	}{
+		{name: "no clusters"},
+		{name: "unique names", clusters: []Cluster{{Name: "prod", Region: "us-east-1"}, {Name: "dev", Region: "us-east-1"}}},
+		{
+			name: "shared names sorted",
+			clusters: []Cluster{
+				{Name: "web", Region: "us-west-2"}, {Name: "api", Region: "us-east-1"}, {Name: "web", Region: "eu-west-1"},
+				{Name: "api", Region: "ap-south-1"}, {Name: "web", Region: "ap-south-1"}, {Name: "solo", Region: "us-east-1"},
+			},
+			want: []NameCollision{
+				{Name: "api", Regions: []string{"ap-south-1", "us-east-1"}},
+				{Name: "web", Regions: []string{"ap-south-1", "eu-west-1", "us-west-2"}},
+			},
+		},
+		{name: "same region counted once", clusters: []Cluster{{Name: "prod", Region: "us-east-1"}, {Name: "prod", Region: "us-east-1"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FindNameCollisions(tt.clusters); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FindNameCollisions() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunNameCollisions(t *testing.T) {
+	f := newFakeFactory(map[string][]types.Cluster{
+		"us-east-1": {fakeCluster("prod", "1.31"), fakeCluster("dev", "1.31")},
+		"eu-west-1": {fakeCluster("prod", "1.31")},
+	})
+	tests := []struct {
+		name string
+		opts []Option
+		want []NameCollision
+	}{
+		{name: "not requested"},
+		{name: "requested", opts: []Option{WithNameCollisions()}, want: []NameCollision{{Name: "prod", Regions: []string{"eu-west-1", "us-east-1"}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := newFakeScanner(f, tt.opts...).Run(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(result.NameCollisions, tt.want) {
+				t.Errorf("collisions = %+v, want %+v", result.NameCollisions, tt.want)
+			}
+		})
+	}
+}
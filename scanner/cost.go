@@ -0,0 +1,59 @@
+package scanner
+
+import "math"
+
+// Default EKS control plane pricing in USD, as published on the Amazon EKS
+// pricing page: a flat hourly rate per cluster, plus a surcharge while the
+// cluster's Kubernetes version is in extended support.
+const (
+	DefaultHourlyRate               = 0.10
+	DefaultExtendedSupportSurcharge = 0.50
+	hoursPerMonth                   = 730
+)
+
+// CostRates are the hourly control plane rates used by WithCostEstimate
+type CostRates struct {
+	Hourly                   float64
+	ExtendedSupportSurcharge float64
+}
+
+// WithCostEstimate sets Cluster.EstimatedMonthlyCost, and the result total,
+// from rates: the hourly rate over a 730-hour month, plus the extended
+// support surcharge for clusters past the end of standard support. Connected
+// clusters have no EKS control plane and undescribed ones no known version,
+// so neither is estimated.
+func WithCostEstimate(rates CostRates) Option {
+	return func(s *Scanner) {
+		s.costRates = &rates
+	}
+}
+
+// monthlyCost returns the estimated monthly control plane cost of c in USD, rounded to cents
+func (r *CostRates) monthlyCost(c *Cluster) float64 {
+	hourly := r.Hourly
+	if c.EOL {
+		hourly += r.ExtendedSupportSurcharge
+	}
+	return roundCents(hourly * hoursPerMonth)
+}
+
+// estimateCosts sets EstimatedMonthlyCost on the clusters it applies to and
+// returns their total
+func (r *CostRates) estimateCosts(clusters []Cluster) float64 {
+	total := 0.0
+	for i := range clusters {
+		c := &clusters[i]
+		if c.DescribeError != "" || c.Connected() {
+			continue
+		}
+		cost := r.monthlyCost(c)
+		c.EstimatedMonthlyCost = &cost
+		total += cost
+	}
+	return roundCents(total)
+}
+
+// roundCents rounds an amount to whole cents
+func roundCents(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,91 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eks/types"
+)
+
+func TestEstimateCosts(t *testing.T) {
+	defaults := &CostRates{Hourly: DefaultHourlyRate, ExtendedSupportSurcharge: DefaultExtendedSupportSurcharge}
+	tests := []struct {
+		name      string
+		rates     *CostRates
+		clusters  []Cluster
+		want      []*float64
+		wantTotal float64
+	}{
+		{name: "no clusters", rates: defaults},
+		{
+			name:      "standard and extended support",
+			rates:     defaults,
+			clusters:  []Cluster{{Name: "current"}, {Name: "old", EOL: true}},
+			want:      []*float64{aws.Float64(73), aws.Float64(438)},
+			wantTotal: 511,
+		},
+		{
+			name:      "rounded to cents",
+			rates:     &CostRates{Hourly: 0.123456},
+			clusters:  []Cluster{{Name: "a"}, {Name: "b", EOL: true}},
+			want:      []*float64{aws.Float64(90.12), aws.Float64(90.12)},
+			wantTotal: 180.24,
+		},
+		{
+			name:      "connected and undescribed not estimated",
+			rates:     defaults,
+			clusters:  []Cluster{{Name: "connected", Connector: &Connector{Provider: "OTHER"}}, {Name: "broken", DescribeError: "AccessDenied"}, {Name: "current"}},
+			want:      []*float64{nil, nil, aws.Float64(73)},
+			wantTotal: 73,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			total := tt.rates.estimateCosts(tt.clusters)
+			if total != tt.wantTotal {
+				t.Errorf("total = %v, want %v", total, tt.wantTotal)
+			}
+			for i, c := range tt.clusters {
+				got, want := c.EstimatedMonthlyCost, tt.want[i]
+				if (got == nil) != (want == nil) || got != nil && *got != *want {
+					t.Errorf("%s cost = %v, want %v", c.Name, aws.ToFloat64(got), aws.ToFloat64(want))
+				}
+			}
+		})
+	}
+}
+
+func TestRunCostEstimate(t *testing.T) {
+	if !IsEOL("1.24", time.Now()) {
+		t.Fatal("1.24 is expected to be in extended support")
+	}
+	f := newFakeFactory(map[string][]types.Cluster{"us-east-1": {fakeCluster("old", "1.24"), fakeCluster("broken", "1.31")}})
+	f.region("us-east-1").describeErr = map[string]error{"broken": errors.New("AccessDenied")}
+	tests := []struct {
+		name      string
+		opts      []Option
+		wantTotal *float64
+	}{
+		{name: "not requested"},
+		{name: "requested", opts: []Option{WithCostEstimate(CostRates{Hourly: 1, ExtendedSupportSurcharge: 1})}, wantTotal: aws.Float64(1460)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := newFakeScanner(f, tt.opts...).Run(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := result.EstimatedMonthlyCost; (got == nil) != (tt.wantTotal == nil) || got != nil && *got != *tt.wantTotal {
+				t.Errorf("total = %v, want %v", aws.ToFloat64(got), aws.ToFloat64(tt.wantTotal))
+			}
+			for _, c := range result.Clusters {
+				if c.Name == "broken" && c.EstimatedMonthlyCost != nil {
+					t.Errorf("undescribed cluster estimated at %v", *c.EstimatedMonthlyCost)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package scanner
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+)
+
+// WithMaxDescribePerRegion describes at most n clusters in each region, the
+// first n by name, when clusters are discovered by listing. The others are
+// kept as listed only, with only Name and Region known, and the number left
+// out of each region is reported in ScanResult.ScannedRegions.
+func WithMaxDescribePerRegion(n int) Option {
+	return func(s *Scanner) {
+		s.maxDescribePerRegion = n
+	}
+}
+
+// capDescribes marks all but the first n clusters of each region, by name, as
+// listed only and returns how many were marked in each region
+func capDescribes(clusters []Cluster, n int) map[string]int {
+	regions, byRegion := groupByRegion(clusters)
+	skipped := make(map[string]int)
+	for _, region := range regions {
+		idx := byRegion[region]
+		if len(idx) <= n {
+			continue
+		}
+		slices.SortFunc(idx, func(a, b int) int { return cmp.Compare(clusters[a].Name, clusters[b].Name) })
+		for _, i := range idx[n:] {
+			clusters[i].ListedOnly = true
+			clusters[i].setDescribeError(fmt.Sprintf("listed only, over the limit of %d described clusters per region", n))
+		}
+		skipped[region] = len(idx) - n
+	}
+	return skipped
+}
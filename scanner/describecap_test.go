@@ -0,0 +1,81 @@
+package scanner
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/eks/types"
+)
+
+func TestMaxDescribePerRegion(t *testing.T) {
+	f := newFakeFactory(map[string][]types.Cluster{
+		"us-east-1": {fakeCluster("d", "1.31"), fakeCluster("b", "1.31"), fakeCluster("c", "1.31"), fakeCluster("a", "1.31")},
+		"eu-west-1": {fakeCluster("eu", "1.31")},
+	})
+	result, err := newFakeScanner(f, WithMaxDescribePerRegion(2)).Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The first two names of us-east-1 are described, the region under the cap in full
+	described := map[string]bool{"a": true, "b": true, "eu": true}
+	for _, c := range result.Clusters {
+		if got := !c.ListedOnly && c.Endpoint != ""; got != described[c.Name] {
+			t.Errorf("%s described = %t, want %t", c.Name, got, described[c.Name])
+		}
+		if c.ListedOnly && c.DescribeError == "" {
+			t.Errorf("%s is listed only without a marker", c.Name)
+		}
+	}
+	if n := f.region("us-east-1").describeCalls; n != 2 {
+		t.Errorf("us-east-1 describes = %d, want 2", n)
+	}
+	for _, count := range result.ScannedRegions {
+		want := map[string]int{"us-east-1": 2, "eu-west-1": 0}[count.Region]
+		if count.DescribeSkipped != want {
+			t.Errorf("%s DescribeSkipped = %d, want %d", count.Region, count.DescribeSkipped, want)
+		}
+	}
+	// Clusters left out by the cap are not reported as undescribed
+	if w := warningsWithCode(result.Warnings, WarningUndescribed); len(w) != 0 {
+		t.Errorf("undescribed warnings = %+v, want none", w)
+	}
+}
+
+func TestMaxDescribePerRegionLogsFilteredTotal(t *testing.T) {
+	tests := []struct {
+		name        string
+		opts        []Option
+		want        string
+		wantSkipped int
+	}{
+		{name: "every listed cluster", want: "Describing 2 of 4 clusters in region us-east-1\n", wantSkipped: 2},
+		{
+			name:        "after the ARN filter",
+			opts:        []Option{WithArnPrefixes("arn:aws:eks:us-east-1:123456789012:cluster/app-")},
+			want:        "Describing 2 of 3 clusters in region us-east-1\n",
+			wantSkipped: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFakeFactory(map[string][]types.Cluster{
+				"us-east-1": {fakeCluster("app-a", "1.31"), fakeCluster("app-b", "1.31"), fakeCluster("app-c", "1.31"), fakeCluster("db", "1.31")},
+			})
+			var buf bytes.Buffer
+			opts := append([]Option{WithMaxDescribePerRegion(2), WithOutput(&buf)}, tt.opts...)
+			result, err := newFakeScanner(f, opts...).Run(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(buf.String(), tt.want) {
+				t.Errorf("log lacks %q:\n%s", tt.want, buf.String())
+			}
+			if skipped := result.ScannedRegions[0].DescribeSkipped; skipped != tt.wantSkipped {
+				t.Errorf("DescribeSkipped = %d, want %d", skipped, tt.wantSkipped)
+			}
+		})
+	}
+}
@@ -0,0 +1,200 @@
+package scanner
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// AccountCluster is a cluster qualified by the account it was found in.
+// It is the record written by the NDJSON output, one per line.
+type AccountCluster struct {
+	Account string `json:"account"`
+	// Labels repeats the labels of the scan the cluster was found in.
+	Labels map[string]string `json:"labels,omitempty"`
+	Cluster
+}
+
+// Ref returns the identity of the cluster
+func (c AccountCluster) Ref() ClusterRef {
+	return ClusterRef{Account: c.Account, Region: c.Region, Name: c.Name}
+}
+
+// Flatten returns the clusters of result qualified by its account
+func (r *ScanResult) Flatten() []AccountCluster {
+	clusters := make([]AccountCluster, 0, len(r.Clusters))
+	for _, c := range r.Clusters {
+		clusters = append(clusters, AccountCluster{Account: r.Account, Labels: r.Labels, Cluster: c})
+	}
+	return clusters
+}
+
+// ReadClusters reads saved scan output and returns every cluster it contains.
+// The format is detected from each JSON value in the stream, so a single
+// ScanResult document, a multi-profile document, or NDJSON with one
+// ScanResult or one AccountCluster per line are all accepted.
+func ReadClusters(r io.Reader) ([]AccountCluster, error) {
+	return readClusters(r, false)
+}
+
+// ReadClustersStrict is ReadClusters rejecting fields the scanner does not
+// know, so input from another schema version or with misspelled keys fails
+// instead of being read partially. Missing fields are still accepted.
+func ReadClustersStrict(r io.Reader) ([]AccountCluster, error) {
+	return readClusters(r, true)
+}
+
+// DecodeJSON unmarshals the JSON value data into v. With strict, object keys
+// that match no field of v are an error.
+func DecodeJSON(data []byte, v any, strict bool) error {
+	if !strict {
+		return json.Unmarshal(data, v)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	return dec.Decode(v)
+}
+
+// readClusters implements ReadClusters and ReadClustersStrict
+func readClusters(r io.Reader, strict bool) ([]AccountCluster, error) {
+	var clusters []AccountCluster
+	dec := json.NewDecoder(r)
+	for n := 1; ; n++ {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); errors.Is(err, io.EOF) {
+			return clusters, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("value %d: %w", n, err)
+		}
+
+		var probe map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &probe); err != nil {
+			return nil, fmt.Errorf("value %d: expected a JSON object: %w", n, err)
+		}
+
+		switch {
+		case probe["profiles"] != nil:
+			var result ProfilesResult
+			if err := DecodeJSON(raw, &result, strict); err != nil {
+				return nil, fmt.Errorf("value %d: %w", n, err)
+			}
+			for _, scan := range result.Profiles {
+				clusters = append(clusters, scan.Flatten()...)
+			}
+		case probe["clusters"] != nil:
+			var result ScanResult
+			if err := DecodeJSON(raw, &result, strict); err != nil {
+				return nil, fmt.Errorf("value %d: %w", n, err)
+			}
+			clusters = append(clusters, result.Flatten()...)
+		default:
+			var c AccountCluster
+			if err := DecodeJSON(raw, &c, strict); err != nil {
+				return nil, fmt.Errorf("value %d: %w", n, err)
+			}
+			if c.Name == "" || c.Region == "" {
+				return nil, fmt.Errorf("value %d: cluster record needs name and region", n)
+			}
+			clusters = append(clusters, c)
+		}
+	}
+}
+
+// Diff lists the clusters that differ between two scans
+type Diff struct {
+	Added   []ClusterRef    `json:"added"`
+	Removed []ClusterRef    `json:"removed"`
+	Changed []ClusterChange `json:"changed"`
+}
+
+// ClusterChange names the fields that changed for a cluster present in both scans
+type ClusterChange struct {
+	ClusterRef
+	Fields []string `json:"fields"`
+}
+
+// Empty reports whether the scans are identical
+func (d *Diff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// DiffClusters compares an old and a new set of clusters. Results are sorted
+// by account, region and name so the output does not depend on input order.
+func DiffClusters(old, cur []AccountCluster) *Diff {
+	diff := &Diff{Added: []ClusterRef{}, Removed: []ClusterRef{}, Changed: []ClusterChange{}}
+
+	before := make(map[ClusterRef]AccountCluster, len(old))
+	for _, c := range old {
+		before[c.Ref()] = c
+	}
+	after := make(map[ClusterRef]AccountCluster, len(cur))
+	for _, c := range cur {
+		after[c.Ref()] = c
+	}
+
+	for ref, c := range after {
+		prev, ok := before[ref]
+		if !ok {
+			diff.Added = append(diff.Added, ref)
+			continue
+		}
+		if fields := changedFields(prev.Cluster, c.Cluster); len(fields) > 0 {
+			diff.Changed = append(diff.Changed, ClusterChange{ClusterRef: ref, Fields: fields})
+		}
+	}
+	for ref := range before {
+		if _, ok := after[ref]; !ok {
+			diff.Removed = append(diff.Removed, ref)
+		}
+	}
+
+	slices.SortFunc(diff.Added, compareRefs)
+	slices.SortFunc(diff.Removed, compareRefs)
+	slices.SortFunc(diff.Changed, func(a, b ClusterChange) int { return compareRefs(a.ClusterRef, b.ClusterRef) })
+	return diff
+}
+
+// compareRefs orders cluster references by account, region and name
+func compareRefs(a, b ClusterRef) int {
+	return strings.Compare(a.Account+"/"+a.Region+"/"+a.Name, b.Account+"/"+b.Region+"/"+b.Name)
+}
+
+// changedFields returns the JSON names of the fields that differ between a and b
+func changedFields(a, b Cluster) []string {
+	var fields []string
+	av, bv := reflect.ValueOf(a), reflect.ValueOf(b)
+	t := av.Type()
+	for i := 0; i < t.NumField(); i++ {
+		x, y := av.Field(i).Interface(), bv.Field(i).Interface()
+		if !jsonEqual(x, y) {
+			name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+			fields = append(fields, name)
+		}
+	}
+	return fields
+}
+
+// jsonEqual compares two values by their JSON encoding, so that values that
+// survive a save/load round trip (such as times and empty slices) compare equal
+func jsonEqual(x, y any) bool {
+	xb, errX := json.Marshal(x)
+	yb, errY := json.Marshal(y)
+	if errX != nil || errY != nil {
+		return reflect.DeepEqual(x, y)
+	}
+	return bytes.Equal(normalizeEmpty(xb), normalizeEmpty(yb))
+}
+
+// normalizeEmpty maps the encodings of empty values to null, matching omitempty
+func normalizeEmpty(b []byte) []byte {
+	switch string(b) {
+	case `""`, "[]", "{}", "false", "0":
+		return []byte("null")
+	}
+	return b
+}
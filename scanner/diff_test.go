@@ -0,0 +1,184 @@
+package scanner
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadClusters(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []string
+		wantErr string
+	}{
+		{name: "empty", input: ""},
+		{name: "scan document", input: `{"account":"111111111111","clusters":[{"name":"a","region":"us-east-1"},{"name":"b","region":"eu-west-1"}]}`, want: []string{"111111111111/us-east-1/a", "111111111111/eu-west-1/b"}},
+		{
+			name:  "profiles document",
+			input: `{"profiles":[{"account":"111111111111","clusters":[{"name":"a","region":"us-east-1"}]},{"account":"222222222222","clusters":[{"name":"b","region":"us-east-1"}]}]}`,
+			want:  []string{"111111111111/us-east-1/a", "222222222222/us-east-1/b"},
+		},
+		{
+			name:  "ndjson records",
+			input: "{\"account\":\"111111111111\",\"name\":\"a\",\"region\":\"us-east-1\"}\n{\"account\":\"222222222222\",\"name\":\"b\",\"region\":\"eu-west-1\"}\n",
+			want:  []string{"111111111111/us-east-1/a", "222222222222/eu-west-1/b"},
+		},
+		{
+			name:  "ndjson scans",
+			input: "{\"account\":\"111111111111\",\"clusters\":[{\"name\":\"a\",\"region\":\"us-east-1\"}]}\n{\"account\":\"222222222222\",\"clusters\":[]}\n",
+			want:  []string{"111111111111/us-east-1/a"},
+		},
+		{
+			name:  "mixed stream",
+			input: `{"account":"111111111111","clusters":[{"name":"a","region":"us-east-1"}]} {"account":"111111111111","name":"b","region":"us-east-1"}`,
+			want:  []string{"111111111111/us-east-1/a", "111111111111/us-east-1/b"},
+		},
+		{name: "record without region", input: `{"account":"111111111111","name":"a"}`, wantErr: "value 1: cluster record needs name and region"},
+		{name: "not an object", input: `[1, 2]`, wantErr: "value 1: expected a JSON object"},
+		{name: "truncated", input: "{\"account\":\"111111111111\",\"name\":\"a\",\"region\":\"us-east-1\"}\n{\"account\":", wantErr: "value 2"},
+		{name: "unknown fields ignored", input: `{"account":"111111111111","name":"a","region":"us-east-1","future":true}`, want: []string{"111111111111/us-east-1/a"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clusters, err := ReadClusters(strings.NewReader(tt.input))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, c := range clusters {
+				got = append(got, c.Account+"/"+c.Region+"/"+c.Name)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("clusters = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffClusters(t *testing.T) {
+	created := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	record := func(account, region, name string, mutate func(*Cluster)) AccountCluster {
+		c := AccountCluster{Account: account, Cluster: Cluster{Name: name, Region: region, Version: "1.30", CreatedAt: &created}}
+		if mutate != nil {
+			mutate(&c.Cluster)
+		}
+		return c
+	}
+	ref := func(account, region, name string) ClusterRef {
+		return ClusterRef{Account: account, Region: region, Name: name}
+	}
+	tests := []struct {
+		name     string
+		old, cur []AccountCluster
+		want     *Diff
+	}{
+		{
+			name: "identical",
+			old:  []AccountCluster{record("1", "us-east-1", "a", nil)},
+			cur:  []AccountCluster{record("1", "us-east-1", "a", nil)},
+			want: &Diff{Added: []ClusterRef{}, Removed: []ClusterRef{}, Changed: []ClusterChange{}},
+		},
+		{
+			name: "empty and missing values are equal",
+			old:  []AccountCluster{record("1", "us-east-1", "a", func(c *Cluster) { c.Tags = map[string]string{}; c.SubnetIDs = []string{} })},
+			cur:  []AccountCluster{record("1", "us-east-1", "a", nil)},
+			want: &Diff{Added: []ClusterRef{}, Removed: []ClusterRef{}, Changed: []ClusterChange{}},
+		},
+		{
+			name: "added removed and changed, sorted",
+			old: []AccountCluster{
+				record("1", "us-east-1", "gone", nil),
+				record("1", "us-east-1", "upgraded", nil),
+				record("2", "us-east-1", "same", nil),
+			},
+			cur: []AccountCluster{
+				record("2", "us-east-1", "same", nil),
+				record("1", "us-east-1", "upgraded", func(c *Cluster) { c.Version = "1.31"; c.Tags = map[string]string{"env": "prod"} }),
+				record("2", "eu-west-1", "new-b", nil),
+				record("1", "us-east-1", "new-a", nil),
+			},
+			want: &Diff{
+				Added:   []ClusterRef{ref("1", "us-east-1", "new-a"), ref("2", "eu-west-1", "new-b")},
+				Removed: []ClusterRef{ref("1", "us-east-1", "gone")},
+				Changed: []ClusterChange{{ClusterRef: ref("1", "us-east-1", "upgraded"), Fields: []string{"version", "tags"}}},
+			},
+		},
+		{
+			name: "same name in another account",
+			old:  []AccountCluster{record("1", "us-east-1", "a", nil)},
+			cur:  []AccountCluster{record("2", "us-east-1", "a", nil)},
+			want: &Diff{Added: []ClusterRef{ref("2", "us-east-1", "a")}, Removed: []ClusterRef{ref("1", "us-east-1", "a")}, Changed: []ClusterChange{}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := DiffClusters(tt.old, tt.cur)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("diff = %+v, want %+v", got, tt.want)
+			}
+			if got.Empty() != (len(tt.want.Added)+len(tt.want.Removed)+len(tt.want.Changed) == 0) {
+				t.Errorf("Empty() = %t", got.Empty())
+			}
+		})
+	}
+}
+
+func TestDiffSurvivesRoundTrip(t *testing.T) {
+	created := time.Date(2024, 3, 1, 12, 0, 0, 0, time.FixedZone("CET", 3600))
+	result := &ScanResult{Account: "1", Clusters: []Cluster{{Name: "a", Region: "us-east-1", Version: "1.31", CreatedAt: &created, Tags: map[string]string{}}}}
+	var ndjson strings.Builder
+	for _, c := range result.Flatten() {
+		data, err := json.Marshal(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ndjson.Write(append(data, '\n'))
+	}
+	saved, err := ReadClusters(strings.NewReader(ndjson.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := DiffClusters(result.Flatten(), saved); !diff.Empty() {
+		t.Errorf("diff after a save and load = %+v, want none", diff)
+	}
+}
+
+func TestReadClustersStrict(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{name: "known fields", input: `{"account":"1","clusters":[{"name":"a","region":"us-east-1","version":"1.31"}]}`},
+		{name: "missing fields", input: `{"account":"1","name":"a","region":"us-east-1"}`},
+		{name: "unknown scan field", input: `{"account":"1","clusters":[],"acount":"2"}`, wantErr: `unknown field "acount"`},
+		{name: "unknown cluster field", input: `{"account":"1","clusters":[{"name":"a","region":"us-east-1","verison":"1.31"}]}`, wantErr: `unknown field "verison"`},
+		{name: "unknown record field", input: `{"account":"1","name":"a","region":"us-east-1","future":true}`, wantErr: `unknown field "future"`},
+		{name: "unknown profiles field", input: `{"profiles":[],"skiped":[]}`, wantErr: `unknown field "skiped"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ReadClustersStrict(strings.NewReader(tt.input))
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatal(err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Fatalf("err = %v, want %q", err, tt.wantErr)
+			}
+			// The lenient reader accepts all of them
+			if _, err := ReadClusters(strings.NewReader(tt.input)); err != nil {
+				t.Errorf("ReadClusters: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi"
+	"github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi/types"
+)
+
+// Discovery selects how clusters are discovered in each region
+type Discovery string
+
+const (
+	// DiscoveryList calls eks:ListClusters in every region. It is the default.
+	DiscoveryList Discovery = "list"
+	// DiscoveryTagging calls tag:GetResources filtered to eks:cluster resources.
+	// It only sees clusters that have, or once had, at least one tag.
+	DiscoveryTagging Discovery = "tagging"
+)
+
+// ParseDiscovery validates a discovery backend name
+func ParseDiscovery(name string) (Discovery, error) {
+	switch d := Discovery(name); d {
+	case DiscoveryList, DiscoveryTagging:
+		return d, nil
+	default:
+		return "", fmt.Errorf("unknown discovery backend %q: must be %s or %s", name, DiscoveryList, DiscoveryTagging)
+	}
+}
+
+// getTaggedClusters discovers clusters through the Resource Groups Tagging API.
+// Like getAllClusters, a region that fails is logged, recorded and skipped.
+func (s *Scanner) getTaggedClusters(ctx context.Context, regions []string) ([]Cluster, []RegionError, []time.Duration, error) {
+	return s.collectRegions(ctx, regions, s.listTaggedClusters)
+}
+
+// listTaggedClusters lists the clusters of one region with tag:GetResources
+func (s *Scanner) listTaggedClusters(ctx context.Context, region string) ([]Cluster, error) {
+	client, err := s.factory.Tagging(ctx, region)
+	if err != nil {
+		return nil, &listError{fmt.Errorf("creating tagging client: %w", err)}
+	}
+
+	var clusters []Cluster
+	input := &resourcegroupstaggingapi.GetResourcesInput{
+		ResourceTypeFilters: []string{"eks:cluster"},
+		TagFilters:          tagFiltersInput(s.tagFilters),
+	}
+	for {
+		page, err := client.GetResources(ctx, input, taggingAttempts(s.listAttempts)...)
+		if err != nil {
+			return nil, &listError{err}
+		}
+
+		clusters = slices.Grow(clusters, len(page.ResourceTagMappingList))
+		for _, mapping := range page.ResourceTagMappingList {
+			arn, err := ParseClusterARN(aws.ToString(mapping.ResourceARN))
+			if err != nil {
+				s.logf("Skipping resource in region %s: %v\n", region, err)
+				continue
+			}
+			clusters = append(clusters, Cluster{Name: arn.Name, Region: arn.Region})
+			s.logf("Found cluster: %s in region: %s\n", arn.Name, arn.Region)
+		}
+
+		if aws.ToString(page.PaginationToken) == "" {
+			return clusters, nil
+		}
+		input.PaginationToken = page.PaginationToken
+	}
+}
+
+// tagFiltersInput converts tag filters to the Tagging API form, sorted by key
+func tagFiltersInput(filters map[string][]string) []types.TagFilter {
+	keys := make([]string, 0, len(filters))
+	for key := range filters {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	var input []types.TagFilter
+	for _, key := range keys {
+		input = append(input, types.TagFilter{Key: aws.String(key), Values: filters[key]})
+	}
+	return input
+}
+
+// matchesTags reports whether tags satisfy every filter
+func matchesTags(tags map[string]string, filters map[string][]string) bool {
+	for key, values := range filters {
+		value, ok := tags[key]
+		if !ok {
+			return false
+		}
+		if len(values) > 0 && !slices.Contains(values, value) {
+			return false
+		}
+	}
+	return true
+}
+
+// MatchesTags reports whether the cluster tags satisfy every filter: the
+// key must be present with one of the values, or any value when none is given
+func (c *Cluster) MatchesTags(filters map[string][]string) bool {
+	return matchesTags(c.Tags, filters)
+}
+
+// matchesAnyTag reports whether tags satisfy at least one filter
+func matchesAnyTag(tags map[string]string, filters map[string][]string) bool {
+	for key, values := range filters {
+		if value, ok := tags[key]; ok && (len(values) == 0 || slices.Contains(values, value)) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchesAnyTag reports whether the cluster tags satisfy at least one filter,
+// as used for exclusions
+func (c *Cluster) MatchesAnyTag(filters map[string][]string) bool {
+	return matchesAnyTag(c.Tags, filters)
+}
+
+// missingTags returns the keys absent from tags, in the order given
+func missingTags(tags map[string]string, keys []string) []string {
+	var missing []string
+	for _, key := range keys {
+		if _, ok := tags[key]; !ok {
+			missing = append(missing, key)
+		}
+	}
+	return missing
+}
+
+// filterClusters returns the clusters for which keep is true, preserving order
+func filterClusters(clusters []Cluster, keep func(*Cluster) bool) []Cluster {
+	kept := clusters[:0]
+	for i := range clusters {
+		if keep(&clusters[i]) {
+			kept = append(kept, clusters[i])
+		}
+	}
+	return kept
+}
+
+// ClusterARN is the parsed form of an EKS cluster ARN
+type ClusterARN struct {
+	Partition string
+	Region    string
+	Account   string
+	Name      string
+}
+
+// String formats the ARN as arn:<partition>:eks:<region>:<account>:cluster/<name>
+func (a ClusterARN) String() string {
+	return "arn:" + a.Partition + ":eks:" + a.Region + ":" + a.Account + ":cluster/" + a.Name
+}
+
+// ParseClusterARN parses arn:<partition>:eks:<region>:<account>:cluster/<name>
+func ParseClusterARN(arn string) (ClusterARN, error) {
+	parts := strings.SplitN(arn, ":", 6)
+	if len(parts) != 6 || parts[0] != "arn" || parts[2] != "eks" {
+		return ClusterARN{}, fmt.Errorf("invalid EKS cluster ARN %q", arn)
+	}
+	name, ok := strings.CutPrefix(parts[5], "cluster/")
+	if !ok || name == "" || strings.Contains(name, "/") || parts[1] == "" || parts[3] == "" || parts[4] == "" {
+		return ClusterARN{}, fmt.Errorf("invalid EKS cluster ARN %q", arn)
+	}
+	return ClusterARN{Partition: parts[1], Region: parts[3], Account: parts[4], Name: name}, nil
+}
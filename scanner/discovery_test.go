@@ -0,0 +1,184 @@
+package scanner
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eks/types"
+	"github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi"
+	taggingtypes "github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi/types"
+)
+
+// fakeTagging serves GetResources for one region of a taggingFactory,
+// returning one ARN per page
+type fakeTagging struct {
+	factory *taggingFactory
+	region  string
+}
+
+func (c *fakeTagging) GetResources(ctx context.Context, params *resourcegroupstaggingapi.GetResourcesInput, optFns ...func(*resourcegroupstaggingapi.Options)) (*resourcegroupstaggingapi.GetResourcesOutput, error) {
+	f := c.factory
+	f.mu.Lock()
+	f.filters = append(f.filters, params.TagFilters)
+	f.mu.Unlock()
+	if err := f.errs[c.region]; err != nil {
+		return nil, err
+	}
+	arns := f.arns[c.region]
+	if !slices.Equal(params.ResourceTypeFilters, []string{"eks:cluster"}) {
+		return nil, errors.New("GetResources without the eks:cluster resource type")
+	}
+	out := &resourcegroupstaggingapi.GetResourcesOutput{PaginationToken: aws.String("")}
+	if i := pageToken(params.PaginationToken); i < len(arns) {
+		out.ResourceTagMappingList = []taggingtypes.ResourceTagMapping{{ResourceARN: aws.String(arns[i])}}
+		if next := nextToken(i, len(arns)); next != nil {
+			out.PaginationToken = next
+		}
+	}
+	return out, nil
+}
+
+// taggingFactory serves the cluster ARNs of each region through the Tagging
+// API, failing the regions in errs, and records the tag filters of every call
+type taggingFactory struct {
+	*fakeFactory
+	arns map[string][]string
+	errs map[string]error
+
+	mu      sync.Mutex
+	filters [][]taggingtypes.TagFilter
+}
+
+func (f *taggingFactory) Tagging(ctx context.Context, region string) (TaggingClient, error) {
+	return &fakeTagging{factory: f, region: region}, nil
+}
+
+func TestParseDiscovery(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    Discovery
+		wantErr string
+	}{
+		{name: "list", want: DiscoveryList},
+		{name: "tagging", want: DiscoveryTagging},
+		{name: "", wantErr: `unknown discovery backend "": must be list or tagging`},
+		{name: "Tagging", wantErr: `unknown discovery backend "Tagging"`},
+	}
+	for _, tt := range tests {
+		got, err := ParseDiscovery(tt.name)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ParseDiscovery(%q) err = %v, want %q", tt.name, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ParseDiscovery(%q) = %q, %v; want %q", tt.name, got, err, tt.want)
+		}
+	}
+}
+
+func TestParseClusterARN(t *testing.T) {
+	tests := []struct {
+		arn  string
+		want ClusterARN
+		ok   bool
+	}{
+		{arn: "arn:aws:eks:us-east-1:123456789012:cluster/prod", want: ClusterARN{Partition: "aws", Region: "us-east-1", Account: "123456789012", Name: "prod"}, ok: true},
+		{arn: "arn:aws-us-gov:eks:us-gov-west-1:123456789012:cluster/gov", want: ClusterARN{Partition: "aws-us-gov", Region: "us-gov-west-1", Account: "123456789012", Name: "gov"}, ok: true},
+		{arn: "arn:aws-cn:eks:cn-north-1:123456789012:cluster/cn", want: ClusterARN{Partition: "aws-cn", Region: "cn-north-1", Account: "123456789012", Name: "cn"}, ok: true},
+		{arn: ""},
+		{arn: "prod"},
+		{arn: "arn:aws:ec2:us-east-1:123456789012:instance/i-1234"},
+		{arn: "arn:aws:eks:us-east-1:123456789012:nodegroup/prod/workers/1234"},
+		{arn: "arn:aws:eks:us-east-1:123456789012:cluster/"},
+		{arn: "arn:aws:eks::123456789012:cluster/prod"},
+		{arn: "arn:aws:eks:us-east-1::cluster/prod"},
+		{arn: "arn::eks:us-east-1:123456789012:cluster/prod"},
+		{arn: "aws:eks:us-east-1:123456789012:cluster/prod"},
+	}
+	for _, tt := range tests {
+		got, err := ParseClusterARN(tt.arn)
+		if !tt.ok {
+			if err == nil || err.Error() != fmt.Sprintf("invalid EKS cluster ARN %q", tt.arn) {
+				t.Errorf("ParseClusterARN(%q) = %+v, %v; want an invalid ARN error", tt.arn, got, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ParseClusterARN(%q) = %+v, %v; want %+v", tt.arn, got, err, tt.want)
+		}
+		if got.String() != tt.arn {
+			t.Errorf("String() = %q, want %q", got.String(), tt.arn)
+		}
+	}
+}
+
+func TestTagFiltersInput(t *testing.T) {
+	got := tagFiltersInput(map[string][]string{"team": {"web", "api"}, "env": nil})
+	want := []taggingtypes.TagFilter{{Key: aws.String("env")}, {Key: aws.String("team"), Values: []string{"web", "api"}}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("tagFiltersInput() = %+v, want %+v", got, want)
+	}
+	if got := tagFiltersInput(nil); got != nil {
+		t.Errorf("tagFiltersInput(nil) = %+v, want nil", got)
+	}
+}
+
+func TestRunTaggingDiscovery(t *testing.T) {
+	prod := fakeCluster("prod", "1.31")
+	prod.Tags = map[string]string{"env": "prod"}
+	web := fakeCluster("web", "1.31")
+	web.Tags = map[string]string{"env": "prod"}
+	f := &taggingFactory{
+		fakeFactory: newFakeFactory(map[string][]types.Cluster{"us-east-1": {prod, web}, "eu-west-1": nil, "ap-south-1": nil}),
+		arns: map[string][]string{"us-east-1": {
+			"arn:aws:eks:us-east-1:123456789012:cluster/prod",
+			"arn:aws:eks:us-east-1:123456789012:nodegroup/prod/workers/1234",
+			"arn:aws:eks:us-east-1:123456789012:cluster/web",
+		}},
+		errs: map[string]error{"ap-south-1": errors.New("AccessDenied")},
+	}
+	// ListClusters must not be used with the tagging backend
+	f.region("us-east-1").listErr = errors.New("ListClusters called")
+
+	var log bytes.Buffer
+	s := NewScanner(WithClientFactory(f), WithRegions("us-east-1", "eu-west-1", "ap-south-1"),
+		WithDiscovery(DiscoveryTagging), WithTagFilter("env", "prod"), WithOutput(&log))
+	result, err := s.Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, c := range result.Clusters {
+		names = append(names, c.Name)
+		if c.Endpoint == "" {
+			t.Errorf("%s was not described", c.Name)
+		}
+	}
+	slices.Sort(names)
+	if !slices.Equal(names, []string{"prod", "web"}) {
+		t.Errorf("clusters = %q, want prod and web", names)
+	}
+	if len(result.RegionErrors) != 1 || result.RegionErrors[0].Region != "ap-south-1" {
+		t.Errorf("region errors = %+v, want ap-south-1", result.RegionErrors)
+	}
+	if !strings.Contains(log.String(), "Skipping resource in region us-east-1: invalid EKS cluster ARN") {
+		t.Errorf("log lacks the skipped node group ARN:\n%s", log.String())
+	}
+	want := []taggingtypes.TagFilter{{Key: aws.String("env"), Values: []string{"prod"}}}
+	for _, filters := range f.filters {
+		if !reflect.DeepEqual(filters, want) {
+			t.Errorf("tag filters = %+v, want %+v", filters, want)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package scanner
+
+import (
+	"context"
+	"net"
+	"net/url"
+	"time"
+)
+
+// dnsTimeout bounds each endpoint lookup made for WithVerifyDNS
+const dnsTimeout = 2 * time.Second
+
+// Resolver looks up host names. *net.Resolver implements it.
+// This interface creation is necessary for mocking.
+type Resolver interface {
+	LookupHost(ctx context.Context, host string) ([]string, error)
+}
+
+// verifyEndpointDNS resolves the endpoint host of every described cluster and
+// records whether it resolves. Lookup failures are recorded, never returned.
+func (s *Scanner) verifyEndpointDNS(ctx context.Context, clusters []Cluster) error {
+	return s.forEachDescribe(len(clusters), func(i int) error {
+		c := &clusters[i]
+		if c.Endpoint == "" {
+			return nil
+		}
+		host := c.Endpoint
+		if u, err := url.Parse(c.Endpoint); err == nil && u.Hostname() != "" {
+			host = u.Hostname()
+		}
+
+		lookupCtx, cancel := context.WithTimeout(ctx, dnsTimeout)
+		defer cancel()
+		_, err := s.resolver.LookupHost(lookupCtx, host)
+		resolves := err == nil
+		c.EndpointResolves = &resolves
+		if err != nil {
+			c.EndpointDNSError = err.Error()
+			s.logf("Endpoint of cluster %s in region %s does not resolve: %v\n", c.Name, c.Region, err)
+		}
+		return nil
+	})
+}
+
+// defaultResolver is used when WithResolver is not given
+var defaultResolver Resolver = net.DefaultResolver
@@ -0,0 +1,75 @@
+package scanner
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eks/types"
+)
+
+// fakeResolver resolves every host but those in failing, recording the lookups
+type fakeResolver struct {
+	failing map[string]error
+
+	mu     sync.Mutex
+	looked []string
+}
+
+func (r *fakeResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	r.mu.Lock()
+	r.looked = append(r.looked, host)
+	r.mu.Unlock()
+	if err := r.failing[host]; err != nil {
+		return nil, err
+	}
+	return []string{"192.0.2.1"}, nil
+}
+
+func TestVerifyEndpointDNS(t *testing.T) {
+	bare := fakeCluster("bare", "1.31")
+	bare.Endpoint = aws.String("bare.eks.example.com")
+	f := newFakeFactory(map[string][]types.Cluster{"us-east-1": {
+		fakeCluster("prod", "1.31"), fakeCluster("stale", "1.31"), bare, fakeCluster("broken", "1.31"),
+	}})
+	f.region("us-east-1").describeErr = map[string]error{"broken": errors.New("AccessDenied")}
+	resolver := &fakeResolver{failing: map[string]error{"stale.eks.example.com": errors.New("no such host")}}
+
+	var log bytes.Buffer
+	result, err := newFakeScanner(f, WithVerifyDNS(), WithResolver(resolver), WithOutput(&log)).Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]struct {
+		resolves *bool
+		dnsError string
+	}{
+		"prod":   {resolves: aws.Bool(true)},
+		"stale":  {resolves: aws.Bool(false), dnsError: "no such host"},
+		"bare":   {resolves: aws.Bool(true)},
+		"broken": {},
+	}
+	if len(result.Clusters) != len(tests) {
+		t.Fatalf("%d clusters, want %d", len(result.Clusters), len(tests))
+	}
+	for _, c := range result.Clusters {
+		want := tests[c.Name]
+		if (c.EndpointResolves == nil) != (want.resolves == nil) || c.EndpointResolves != nil && *c.EndpointResolves != *want.resolves || c.EndpointDNSError != want.dnsError {
+			t.Errorf("%s resolves %v (%q), want %v (%q)", c.Name, aws.ToBool(c.EndpointResolves), c.EndpointDNSError, aws.ToBool(want.resolves), want.dnsError)
+		}
+	}
+
+	slices.Sort(resolver.looked)
+	if want := []string{"bare.eks.example.com", "prod.eks.example.com", "stale.eks.example.com"}; !slices.Equal(resolver.looked, want) {
+		t.Errorf("looked up %q, want %q", resolver.looked, want)
+	}
+	if !strings.Contains(log.String(), "Endpoint of cluster stale in region us-east-1 does not resolve: no such host") {
+		t.Errorf("log lacks the failed lookup:\n%s", log.String())
+	}
+}
@@ -0,0 +1,160 @@
+package scanner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+const (
+	// DynamoDBPartitionKey holds "<account>#<region>" in exported items.
+	DynamoDBPartitionKey = "pk"
+	// DynamoDBSortKey holds the cluster name in exported items.
+	DynamoDBSortKey = "sk"
+
+	// dynamoDBBatchSize is the most items BatchWriteItem accepts per request
+	dynamoDBBatchSize = 25
+	// dynamoDBAttempts bounds the requests made for one batch while DynamoDB
+	// keeps returning unprocessed items
+	dynamoDBAttempts = 8
+	// dynamoDBBackoff is the wait before the first retry of unprocessed items;
+	// it doubles with every further retry
+	dynamoDBBackoff = 100 * time.Millisecond
+)
+
+// DynamoDBClient interface for DynamoDB operations
+type DynamoDBClient interface {
+	BatchWriteItem(ctx context.Context, params *dynamodb.BatchWriteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error)
+}
+
+// DynamoDB creates a new DynamoDB client in the configured region
+func (f *DefaultClientFactory) DynamoDB(ctx context.Context) (DynamoDBClient, error) {
+	cfg, err := f.config(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return dynamodb.NewFromConfig(cfg), nil
+}
+
+// ExportDynamoDB upserts one item per cluster of results into table, keyed by
+// DynamoDBPartitionKey "<account>#<region>" and DynamoDBSortKey the cluster
+// name. The other attributes are the cluster's JSON fields plus account,
+// profile and scannedAt. Items are written with BatchWriteItem; items
+// DynamoDB leaves unprocessed, usually because of throttling, are retried
+// with exponential backoff. It returns the number of items written.
+func ExportDynamoDB(ctx context.Context, client DynamoDBClient, table string, results ...*ScanResult) (int, error) {
+	var items []map[string]types.AttributeValue
+	index := make(map[string]int)
+	for _, result := range results {
+		for _, c := range result.Clusters {
+			item, err := dynamoDBItem(result, c)
+			if err != nil {
+				return 0, fmt.Errorf("cluster %s in %s: %w", c.Name, c.Region, err)
+			}
+			// A batch must not repeat a key; with several profiles in one
+			// account the last scan wins
+			key := result.Account + "#" + c.Region + "#" + c.Name
+			if i, ok := index[key]; ok {
+				items[i] = item
+				continue
+			}
+			index[key] = len(items)
+			items = append(items, item)
+		}
+	}
+
+	for start := 0; start < len(items); start += dynamoDBBatchSize {
+		end := min(start+dynamoDBBatchSize, len(items))
+		requests := make([]types.WriteRequest, 0, end-start)
+		for _, item := range items[start:end] {
+			requests = append(requests, types.WriteRequest{PutRequest: &types.PutRequest{Item: item}})
+		}
+		if err := batchWrite(ctx, client, table, requests); err != nil {
+			return start, err
+		}
+	}
+	return len(items), nil
+}
+
+// batchWrite writes requests to table, resubmitting unprocessed ones
+func batchWrite(ctx context.Context, client DynamoDBClient, table string, requests []types.WriteRequest) error {
+	backoff := dynamoDBBackoff
+	for attempt := 1; ; attempt++ {
+		out, err := client.BatchWriteItem(ctx, &dynamodb.BatchWriteItemInput{
+			RequestItems: map[string][]types.WriteRequest{table: requests},
+		})
+		if err != nil {
+			return err
+		}
+		requests = out.UnprocessedItems[table]
+		if len(requests) == 0 {
+			return nil
+		}
+		if attempt == dynamoDBAttempts {
+			return fmt.Errorf("%d items still unprocessed after %d attempts", len(requests), attempt)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// dynamoDBItem converts a cluster of result to a DynamoDB item
+func dynamoDBItem(result *ScanResult, c Cluster) (map[string]types.AttributeValue, error) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var fields map[string]any
+	if err := dec.Decode(&fields); err != nil {
+		return nil, err
+	}
+	item := make(map[string]types.AttributeValue, len(fields)+5)
+	for name, value := range fields {
+		item[name] = attributeValue(value)
+	}
+	item[DynamoDBPartitionKey] = &types.AttributeValueMemberS{Value: result.Account + "#" + c.Region}
+	item[DynamoDBSortKey] = &types.AttributeValueMemberS{Value: c.Name}
+	item["account"] = &types.AttributeValueMemberS{Value: result.Account}
+	item["scannedAt"] = &types.AttributeValueMemberS{Value: result.GeneratedAt.UTC().Format(time.RFC3339)}
+	if result.Profile != "" {
+		item["profile"] = &types.AttributeValueMemberS{Value: result.Profile}
+	}
+	return item, nil
+}
+
+// attributeValue converts a value decoded from JSON with UseNumber
+func attributeValue(v any) types.AttributeValue {
+	switch v := v.(type) {
+	case string:
+		return &types.AttributeValueMemberS{Value: v}
+	case json.Number:
+		return &types.AttributeValueMemberN{Value: v.String()}
+	case bool:
+		return &types.AttributeValueMemberBOOL{Value: v}
+	case []any:
+		list := make([]types.AttributeValue, 0, len(v))
+		for _, elem := range v {
+			list = append(list, attributeValue(elem))
+		}
+		return &types.AttributeValueMemberL{Value: list}
+	case map[string]any:
+		m := make(map[string]types.AttributeValue, len(v))
+		for name, elem := range v {
+			m[name] = attributeValue(elem)
+		}
+		return &types.AttributeValueMemberM{Value: m}
+	default:
+		return &types.AttributeValueMemberNULL{Value: true}
+	}
+}
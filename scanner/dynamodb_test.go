@@ -0,0 +1,183 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// fakeDynamoDB records the items of every BatchWriteItem call. unprocessed
+// gives, per call, how many of its items are handed back unprocessed; errs
+// fails the calls with the given indexes.
+type fakeDynamoDB struct {
+	unprocessed []int
+	errs        map[int]error
+	batches     [][]types.WriteRequest
+	calls       int
+}
+
+func (c *fakeDynamoDB) BatchWriteItem(ctx context.Context, params *dynamodb.BatchWriteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error) {
+	c.calls++
+	if err := c.errs[c.calls-1]; err != nil {
+		return nil, err
+	}
+	requests := params.RequestItems["inventory"]
+	if len(params.RequestItems) != 1 || len(requests) > dynamoDBBatchSize {
+		return nil, fmt.Errorf("invalid batch of %d tables, %d items", len(params.RequestItems), len(requests))
+	}
+	c.batches = append(c.batches, requests)
+	out := &dynamodb.BatchWriteItemOutput{}
+	if i := len(c.batches) - 1; i < len(c.unprocessed) && c.unprocessed[i] > 0 {
+		out.UnprocessedItems = map[string][]types.WriteRequest{"inventory": requests[len(requests)-c.unprocessed[i]:]}
+	}
+	return out, nil
+}
+
+// sortKeys returns the sort key of each request
+func sortKeys(requests []types.WriteRequest) []string {
+	keys := make([]string, 0, len(requests))
+	for _, r := range requests {
+		keys = append(keys, r.PutRequest.Item[DynamoDBSortKey].(*types.AttributeValueMemberS).Value)
+	}
+	return keys
+}
+
+// namedClusters returns a scan of account 123456789012 with n clusters c0..
+func namedClusters(profile string, n int) *ScanResult {
+	result := &ScanResult{Account: "123456789012", Profile: profile}
+	for i := range n {
+		result.Clusters = append(result.Clusters, Cluster{Name: fmt.Sprintf("c%d", i), Region: "us-east-1"})
+	}
+	return result
+}
+
+func TestExportDynamoDB(t *testing.T) {
+	tests := []struct {
+		name        string
+		results     []*ScanResult
+		unprocessed []int
+		wantWritten int
+		wantBatches []int
+	}{
+		{name: "no clusters", results: []*ScanResult{namedClusters("", 0)}},
+		{name: "one batch", results: []*ScanResult{namedClusters("", 3)}, wantWritten: 3, wantBatches: []int{3}},
+		{name: "split into batches of 25", results: []*ScanResult{namedClusters("", 30)}, wantWritten: 30, wantBatches: []int{25, 5}},
+		{
+			name:        "duplicate keys written once",
+			results:     []*ScanResult{namedClusters("dev", 2), namedClusters("prod", 3)},
+			wantWritten: 3,
+			wantBatches: []int{3},
+		},
+		{
+			name:        "unprocessed items resubmitted",
+			results:     []*ScanResult{namedClusters("", 30)},
+			unprocessed: []int{5, 2},
+			wantWritten: 30,
+			wantBatches: []int{25, 5, 2, 5},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &fakeDynamoDB{unprocessed: tt.unprocessed}
+			written, err := ExportDynamoDB(context.Background(), client, "inventory", tt.results...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if written != tt.wantWritten {
+				t.Errorf("written = %d, want %d", written, tt.wantWritten)
+			}
+			var sizes []int
+			for _, batch := range client.batches {
+				sizes = append(sizes, len(batch))
+			}
+			if !reflect.DeepEqual(sizes, tt.wantBatches) {
+				t.Errorf("batch sizes = %v, want %v", sizes, tt.wantBatches)
+			}
+		})
+	}
+}
+
+func TestExportDynamoDBRetriesUnprocessed(t *testing.T) {
+	client := &fakeDynamoDB{unprocessed: []int{2}}
+	if _, err := ExportDynamoDB(context.Background(), client, "inventory", namedClusters("", 3)); err != nil {
+		t.Fatal(err)
+	}
+	if len(client.batches) != 2 || !reflect.DeepEqual(sortKeys(client.batches[1]), []string{"c1", "c2"}) {
+		t.Errorf("batches = %v, want the two unprocessed items resubmitted", client.batches)
+	}
+}
+
+func TestExportDynamoDBErrors(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	denied := errors.New("AccessDenied")
+	tests := []struct {
+		name        string
+		ctx         context.Context
+		client      *fakeDynamoDB
+		wantWritten int
+		wantErr     error
+	}{
+		{name: "request fails", ctx: context.Background(), client: &fakeDynamoDB{errs: map[int]error{0: denied}}, wantErr: denied},
+		{name: "later batch fails", ctx: context.Background(), client: &fakeDynamoDB{errs: map[int]error{1: denied}}, wantWritten: 25, wantErr: denied},
+		{name: "canceled while throttled", ctx: canceled, client: &fakeDynamoDB{unprocessed: []int{1}}, wantErr: context.Canceled},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			written, err := ExportDynamoDB(tt.ctx, tt.client, "inventory", namedClusters("", 30))
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("err = %v, want %v", err, tt.wantErr)
+			}
+			if written != tt.wantWritten {
+				t.Errorf("written = %d, want %d", written, tt.wantWritten)
+			}
+		})
+	}
+}
+
+func TestDynamoDBItem(t *testing.T) {
+	behind := 2
+	result := &ScanResult{Account: "123456789012", Profile: "prod", GeneratedAt: time.Date(2025, 1, 2, 3, 4, 5, 0, time.FixedZone("CET", 3600))}
+	c := Cluster{
+		Name: "prod", Region: "us-east-1", EOL: true, VersionsBehind: &behind,
+		PublicAccessCidrs: []string{"10.0.0.0/8"}, Tags: map[string]string{"env": "prod"},
+	}
+	item, err := dynamoDBItem(result, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]types.AttributeValue{
+		DynamoDBPartitionKey: &types.AttributeValueMemberS{Value: "123456789012#us-east-1"},
+		DynamoDBSortKey:      &types.AttributeValueMemberS{Value: "prod"},
+		"account":            &types.AttributeValueMemberS{Value: "123456789012"},
+		"profile":            &types.AttributeValueMemberS{Value: "prod"},
+		"scannedAt":          &types.AttributeValueMemberS{Value: "2025-01-02T02:04:05Z"},
+		"name":               &types.AttributeValueMemberS{Value: "prod"},
+		"region":             &types.AttributeValueMemberS{Value: "us-east-1"},
+		"eol":                &types.AttributeValueMemberBOOL{Value: true},
+		"versionsBehind":     &types.AttributeValueMemberN{Value: "2"},
+		"publicAccessCidrs":  &types.AttributeValueMemberL{Value: []types.AttributeValue{&types.AttributeValueMemberS{Value: "10.0.0.0/8"}}},
+		"tags":               &types.AttributeValueMemberM{Value: map[string]types.AttributeValue{"env": &types.AttributeValueMemberS{Value: "prod"}}},
+	}
+	for name, value := range want {
+		if !reflect.DeepEqual(item[name], value) {
+			t.Errorf("%s = %#v, want %#v", name, item[name], value)
+		}
+	}
+	for name := range item {
+		if _, ok := want[name]; !ok {
+			t.Errorf("unexpected attribute %s = %#v", name, item[name])
+		}
+	}
+
+	result.Profile = ""
+	if item, err = dynamoDBItem(result, c); err != nil || item["profile"] != nil {
+		t.Errorf("item without a profile = %v, %v; want no profile attribute", item["profile"], err)
+	}
+}
@@ -0,0 +1,100 @@
+package scanner
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"sync"
+	"time"
+)
+
+// DefaultRecheckEmpty is how long a region listed with no clusters is skipped
+// by WithSkipEmptyRegions before it is listed again.
+const DefaultRecheckEmpty = 7 * 24 * time.Hour
+
+// emptyRegionsMu serializes updates of empty-region cache files by the
+// scanners of one process, such as concurrently scanned profiles
+var emptyRegionsMu sync.Mutex
+
+// emptyRegions maps account IDs to the regions last listed with no clusters
+// and when that was. It is stored as JSON in the cache file.
+type emptyRegions map[string]map[string]time.Time
+
+// WithSkipEmptyRegions skips the regions that listed no clusters in an
+// earlier scan of the same account, as recorded in the JSON file at path.
+// An empty region is listed again once recheck has passed since it was last
+// verified, so a region that gains clusters is missed for at most recheck.
+func WithSkipEmptyRegions(path string, recheck time.Duration) Option {
+	return func(s *Scanner) {
+		s.emptyRegionsPath = path
+		s.recheckEmpty = recheck
+	}
+}
+
+// loadEmptyRegions reads the cache file at path; a missing file is an empty cache
+func loadEmptyRegions(path string) (emptyRegions, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return emptyRegions{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	cache := emptyRegions{}
+	if err := json.Unmarshal(data, &cache); err != nil {
+		return nil, err
+	}
+	return cache, nil
+}
+
+// skipEmpty splits regions into those to list and those verified empty for
+// account within recheck of now, preserving order
+func (c emptyRegions) skipEmpty(account string, regions []string, now time.Time, recheck time.Duration) (list, skipped []string) {
+	for _, region := range regions {
+		if verified, ok := c[account][region]; ok && now.Sub(verified) < recheck {
+			skipped = append(skipped, region)
+		} else {
+			list = append(list, region)
+		}
+	}
+	return list, skipped
+}
+
+// recordEmptyRegions updates the cache file at path with the outcome of listing
+// regions for account: regions that listed no clusters are marked empty as of
+// now and regions with clusters are unmarked. Failed regions are left as they were.
+func recordEmptyRegions(path, account string, regions []string, clusters []Cluster, regionErrs []RegionError, now time.Time) error {
+	emptyRegionsMu.Lock()
+	defer emptyRegionsMu.Unlock()
+
+	cache, err := loadEmptyRegions(path)
+	if err != nil {
+		return err
+	}
+	failed := make(map[string]bool, len(regionErrs))
+	for _, regionErr := range regionErrs {
+		failed[regionErr.Region] = true
+	}
+	occupied := make(map[string]bool)
+	for _, c := range clusters {
+		occupied[c.Region] = true
+	}
+
+	verified := cache[account]
+	if verified == nil {
+		verified = make(map[string]time.Time)
+		cache[account] = verified
+	}
+	for _, region := range regions {
+		switch {
+		case failed[region]:
+		case occupied[region]:
+			delete(verified, region)
+		default:
+			verified[region] = now.UTC()
+		}
+	}
+
+	return writeCacheFile(path, cache)
+}
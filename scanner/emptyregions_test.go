@@ -0,0 +1,121 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/eks/types"
+)
+
+func TestSkipEmpty(t *testing.T) {
+	now := time.Date(2025, 1, 10, 0, 0, 0, 0, time.UTC)
+	cache := emptyRegions{
+		"123456789012": {
+			"eu-west-1":  now.Add(-time.Hour),
+			"ap-south-1": now.Add(-8 * 24 * time.Hour),
+		},
+	}
+	regions := []string{"us-east-1", "eu-west-1", "ap-south-1"}
+	tests := []struct {
+		name        string
+		account     string
+		recheck     time.Duration
+		wantList    []string
+		wantSkipped []string
+	}{
+		{name: "recently verified skipped", account: "123456789012", recheck: DefaultRecheckEmpty, wantList: []string{"us-east-1", "ap-south-1"}, wantSkipped: []string{"eu-west-1"}},
+		{name: "longer recheck", account: "123456789012", recheck: 30 * 24 * time.Hour, wantList: []string{"us-east-1"}, wantSkipped: []string{"eu-west-1", "ap-south-1"}},
+		{name: "other account", account: "210987654321", recheck: DefaultRecheckEmpty, wantList: regions},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list, skipped := cache.skipEmpty(tt.account, regions, now, tt.recheck)
+			if !slices.Equal(list, tt.wantList) || !slices.Equal(skipped, tt.wantSkipped) {
+				t.Errorf("skipEmpty = %q, %q; want %q, %q", list, skipped, tt.wantList, tt.wantSkipped)
+			}
+		})
+	}
+}
+
+func TestSkipEmptyRegionsAcrossRuns(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "empty.json")
+	f := newFakeFactory(map[string][]types.Cluster{
+		"us-east-1":  {fakeCluster("prod", "1.31")},
+		"eu-west-1":  nil,
+		"ap-south-1": nil,
+	})
+	f.region("ap-south-1").listErr = errors.New("throttled")
+	scan := func() *ScanResult {
+		t.Helper()
+		result, err := newFakeScanner(f, WithSkipEmptyRegions(path, DefaultRecheckEmpty)).Run(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+
+	first := scan()
+	if len(first.SkippedEmptyRegions) != 0 || len(first.Regions) != 3 {
+		t.Fatalf("first scan skipped %q of %q, want nothing skipped", first.SkippedEmptyRegions, first.Regions)
+	}
+
+	// eu-west-1 listed empty; ap-south-1 failed, so it is not known empty
+	second := scan()
+	if want := []string{"eu-west-1"}; !slices.Equal(second.SkippedEmptyRegions, want) {
+		t.Errorf("second scan skipped %q, want %q", second.SkippedEmptyRegions, want)
+	}
+	if want := []string{"ap-south-1", "us-east-1"}; !slices.Equal(second.Regions, want) {
+		t.Errorf("second scan regions = %q, want %q", second.Regions, want)
+	}
+	if calls := f.region("eu-west-1").listCalls; calls != 1 {
+		t.Errorf("eu-west-1 listed %d times, want once", calls)
+	}
+
+	// Regions that list empty once they succeed or lose their clusters are marked
+	f.region("ap-south-1").listErr = nil
+	f.region("us-east-1").clusters = nil
+	scan()
+	cache, err := loadEmptyRegions(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for region, want := range map[string]bool{"eu-west-1": true, "ap-south-1": true, "us-east-1": true} {
+		if _, ok := cache[f.account][region]; ok != want {
+			t.Errorf("%s cached empty = %t, want %t", region, ok, want)
+		}
+	}
+
+	// and unmarked when they gain clusters
+	if err := recordEmptyRegions(path, f.account, []string{"us-east-1"}, []Cluster{{Name: "prod", Region: "us-east-1"}}, nil, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if cache, _ := loadEmptyRegions(path); !cacheHas(cache, f.account, "eu-west-1") || cacheHas(cache, f.account, "us-east-1") {
+		t.Errorf("cache after us-east-1 gained a cluster = %v", cache)
+	}
+}
+
+// cacheHas reports whether the empty-region cache marks region of account
+func cacheHas(cache emptyRegions, account, region string) bool {
+	_, ok := cache[account][region]
+	return ok
+}
+
+func TestSkipEmptyRegionsCorruptCache(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "empty.json")
+	if err := os.WriteFile(path, []byte("{not json"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	f := newFakeFactory(map[string][]types.Cluster{"us-east-1": {fakeCluster("prod", "1.31")}})
+	result, err := newFakeScanner(f, WithSkipEmptyRegions(path, DefaultRecheckEmpty)).Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Clusters) != 1 || len(result.SkippedEmptyRegions) != 0 {
+		t.Errorf("scan with a corrupt cache = %d clusters, skipped %q; want a full scan", len(result.Clusters), result.SkippedEmptyRegions)
+	}
+}
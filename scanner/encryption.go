@@ -0,0 +1,48 @@
+package scanner
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eks/types"
+)
+
+// Key managers reported in Cluster.EncryptionKeyManager
+const (
+	KeyManagerCustomer = "customer"
+	KeyManagerAWS      = "aws"
+)
+
+// secretsKeyArn returns the KMS key ARN of the encryption config covering secrets
+func secretsKeyArn(configs []types.EncryptionConfig) string {
+	for _, cfg := range configs {
+		if cfg.Provider != nil && slices.Contains(cfg.Resources, "secrets") {
+			return aws.ToString(cfg.Provider.KeyArn)
+		}
+	}
+	return ""
+}
+
+// keyManager guesses who manages a KMS key from its ARN. AWS-managed keys are
+// only addressable through their reserved "alias/aws/" aliases; key IDs and
+// any other alias are treated as customer managed. Confirming this for a key
+// ID would need kms:DescribeKey, which the scan does not call.
+func keyManager(keyArn string) string {
+	if keyArn == "" {
+		return ""
+	}
+	resource := keyArn
+	if parts := strings.SplitN(keyArn, ":", 6); len(parts) == 6 {
+		resource = parts[5]
+	}
+	if strings.HasPrefix(resource, "alias/aws/") {
+		return KeyManagerAWS
+	}
+	return KeyManagerCustomer
+}
+
+// UsesCMK reports whether the cluster encrypts secrets with a customer-managed key
+func (c *Cluster) UsesCMK() bool {
+	return c.EncryptionKeyManager == KeyManagerCustomer
+}
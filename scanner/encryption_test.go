@@ -0,0 +1,67 @@
+package scanner
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eks/types"
+)
+
+func TestKeyManager(t *testing.T) {
+	tests := []struct {
+		keyArn string
+		want   string
+	}{
+		{keyArn: "", want: ""},
+		{keyArn: "arn:aws:kms:us-east-1:123456789012:key/1234abcd-12ab-34cd-56ef-1234567890ab", want: KeyManagerCustomer},
+		{keyArn: "arn:aws:kms:us-east-1:123456789012:alias/eks-secrets", want: KeyManagerCustomer},
+		{keyArn: "arn:aws:kms:us-east-1:123456789012:alias/aws/eks", want: KeyManagerAWS},
+		{keyArn: "alias/aws/eks", want: KeyManagerAWS},
+	}
+	for _, tt := range tests {
+		t.Run(tt.keyArn, func(t *testing.T) {
+			if got := keyManager(tt.keyArn); got != tt.want {
+				t.Errorf("keyManager(%q) = %q, want %q", tt.keyArn, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSecretsKeyArn(t *testing.T) {
+	key := func(arn string, resources ...string) types.EncryptionConfig {
+		return types.EncryptionConfig{Provider: &types.Provider{KeyArn: aws.String(arn)}, Resources: resources}
+	}
+	tests := []struct {
+		name    string
+		configs []types.EncryptionConfig
+		want    string
+	}{
+		{name: "unencrypted"},
+		{name: "secrets", configs: []types.EncryptionConfig{key("arn:aws:kms:us-east-1:123456789012:key/a", "secrets")}, want: "arn:aws:kms:us-east-1:123456789012:key/a"},
+		{name: "other resources", configs: []types.EncryptionConfig{key("arn:aws:kms:us-east-1:123456789012:key/a", "configmaps")}},
+		{name: "no provider", configs: []types.EncryptionConfig{{Resources: []string{"secrets"}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := secretsKeyArn(tt.configs); got != tt.want {
+				t.Errorf("secretsKeyArn = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunRecordsEncryption(t *testing.T) {
+	cmk := fakeCluster("cmk", "1.31")
+	cmk.EncryptionConfig = []types.EncryptionConfig{{Provider: &types.Provider{KeyArn: aws.String("arn:aws:kms:us-east-1:123456789012:key/a")}, Resources: []string{"secrets"}}}
+	f := newFakeFactory(map[string][]types.Cluster{"us-east-1": {cmk, fakeCluster("plain", "1.31")}})
+	result, err := newFakeScanner(f).Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range result.Clusters {
+		if c.UsesCMK() != (c.Name == "cmk") {
+			t.Errorf("%s: key %q managed by %q, UsesCMK %t", c.Name, c.EncryptionKeyArn, c.EncryptionKeyManager, c.UsesCMK())
+		}
+	}
+}
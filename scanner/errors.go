@@ -0,0 +1,37 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/smithy-go"
+)
+
+// accessDeniedCodes are the API error codes AWS services use for missing permissions
+var accessDeniedCodes = map[string]bool{
+	"AccessDenied":          true,
+	"AccessDeniedException": true,
+	"UnauthorizedOperation": true,
+	"UnauthorizedException": true,
+}
+
+// isAccessDenied reports whether err is an AWS permission error
+func isAccessDenied(err error) bool {
+	var apiErr smithy.APIError
+	return errors.As(err, &apiErr) && accessDeniedCodes[apiErr.ErrorCode()]
+}
+
+// isTransient reports whether err is worth retrying: a throttle, a 5xx or a
+// connection error as classified by the SDK. Permission errors and
+// cancellation are permanent.
+func isTransient(err error) bool {
+	if err == nil || isAccessDenied(err) || errors.Is(err, context.Canceled) {
+		return false
+	}
+	if retry.IsErrorRetryables(retry.DefaultRetryables).IsErrorRetryable(err) == aws.TrueTernary {
+		return true
+	}
+	return retry.IsErrorThrottles(retry.DefaultThrottles).IsErrorThrottle(err) == aws.TrueTernary
+}
@@ -0,0 +1,31 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/smithy-go"
+)
+
+func TestIsTransient(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "nil"},
+		{name: "throttle", err: &smithy.GenericAPIError{Code: "ThrottlingException"}, want: true},
+		{name: "too many requests", err: &smithy.GenericAPIError{Code: "TooManyRequestsException"}, want: true},
+		{name: "access denied", err: &smithy.GenericAPIError{Code: "AccessDeniedException"}},
+		{name: "canceled", err: context.Canceled},
+		{name: "plain", err: errors.New("bad input")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isTransient(tt.err); got != tt.want {
+				t.Errorf("isTransient(%v) = %t, want %t", tt.err, got, tt.want)
+			}
+		})
+	}
+}
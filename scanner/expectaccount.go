@@ -0,0 +1,26 @@
+package scanner
+
+import (
+	"fmt"
+	"strings"
+)
+
+// UnexpectedAccountError reports that the credentials belong to an account
+// other than those passed to WithExpectedAccounts
+type UnexpectedAccountError struct {
+	Account  string
+	Expected []string
+}
+
+func (e *UnexpectedAccountError) Error() string {
+	return fmt.Sprintf("credentials are for account %s, expected %s", e.Account, strings.Join(e.Expected, " or "))
+}
+
+// WithExpectedAccounts makes the scan fail with an UnexpectedAccountError,
+// before any region is listed, when the caller identity is not in one of
+// accounts. With ScanProfiles, profiles of other accounts are skipped.
+func WithExpectedAccounts(accounts ...string) Option {
+	return func(s *Scanner) {
+		s.expectedAccounts = accounts
+	}
+}
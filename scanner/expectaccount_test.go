@@ -0,0 +1,59 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/eks/types"
+)
+
+func TestExpectedAccounts(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected []string
+		wantErr  bool
+	}{
+		{name: "not set"},
+		{name: "match", expected: []string{"123456789012"}},
+		{name: "one of several", expected: []string{"210987654321", "123456789012"}},
+		{name: "other account", expected: []string{"210987654321"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFakeFactory(map[string][]types.Cluster{"us-east-1": {fakeCluster("prod", "1.31")}})
+			var opts []Option
+			if tt.expected != nil {
+				opts = append(opts, WithExpectedAccounts(tt.expected...))
+			}
+			result, err := newFakeScanner(f, opts...).Run(context.Background())
+			if !tt.wantErr {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if result.Account != f.account {
+					t.Errorf("account = %s, want %s", result.Account, f.account)
+				}
+				return
+			}
+			var unexpected *UnexpectedAccountError
+			if !errors.As(err, &unexpected) || unexpected.Account != f.account {
+				t.Fatalf("err = %v, want an UnexpectedAccountError for %s", err, f.account)
+			}
+			if result != nil {
+				t.Errorf("result = %+v, want none", result)
+			}
+			// No region is listed for the wrong account
+			if n := f.region("us-east-1").listCalls; n != 0 {
+				t.Errorf("list calls = %d, want 0", n)
+			}
+		})
+	}
+}
+
+func TestUnexpectedAccountError(t *testing.T) {
+	err := &UnexpectedAccountError{Account: "123456789012", Expected: []string{"111111111111", "222222222222"}}
+	if got, want := err.Error(), "credentials are for account 123456789012, expected 111111111111 or 222222222222"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,371 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/ratelimit"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/eks"
+	"github.com/aws/aws-sdk-go-v2/service/eks/types"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/aws/smithy-go"
+	"github.com/aws/smithy-go/middleware"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+)
+
+// fakeFactory is a ClientFactory serving one account from in-memory fakes.
+// EKS clients are keyed by region; a region without one has no clusters.
+// The API calls named in expired fail with ExpiredTokenException.
+type fakeFactory struct {
+	account   string
+	partition string
+	stsErr    error
+	ec2       *fakeEC2
+
+	mu      sync.Mutex
+	eks     map[string]*fakeEKS
+	expired map[string]bool
+}
+
+// newFakeFactory returns a factory for account 123456789012 in the aws
+// partition, with the given clusters in each region
+func newFakeFactory(clusters map[string][]types.Cluster) *fakeFactory {
+	f := &fakeFactory{account: "123456789012", partition: PartitionAWS, eks: make(map[string]*fakeEKS)}
+	f.ec2 = &fakeEC2{factory: f}
+	for region, cs := range clusters {
+		f.eks[region] = &fakeEKS{factory: f, clusters: cs}
+		f.ec2.regions = append(f.ec2.regions, region)
+	}
+	slices.Sort(f.ec2.regions)
+	return f
+}
+
+func (f *fakeFactory) STS(ctx context.Context) (STSClient, error) {
+	return &fakeSTS{factory: f, account: f.account, partition: f.partition, err: f.stsErr}, nil
+}
+
+func (f *fakeFactory) IAM(ctx context.Context) (IAMClient, error) {
+	return nil, fmt.Errorf("no IAM fake")
+}
+
+func (f *fakeFactory) EC2(ctx context.Context, region string) (EC2Client, error) {
+	return f.ec2, nil
+}
+
+func (f *fakeFactory) EKS(ctx context.Context, region string) (EKSClient, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	client, ok := f.eks[region]
+	if !ok {
+		client = &fakeEKS{factory: f}
+		f.eks[region] = client
+	}
+	return client, nil
+}
+
+func (f *fakeFactory) Tagging(ctx context.Context, region string) (TaggingClient, error) {
+	return nil, fmt.Errorf("no tagging fake")
+}
+
+// region returns the EKS fake of region
+func (f *fakeFactory) region(region string) *fakeEKS {
+	client, _ := f.EKS(context.Background(), region)
+	return client.(*fakeEKS)
+}
+
+// expire makes the named API calls fail on expired credentials
+func (f *fakeFactory) expire(apis ...string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.expired == nil {
+		f.expired = make(map[string]bool)
+	}
+	for _, api := range apis {
+		f.expired[api] = true
+	}
+}
+
+// check returns ExpiredTokenException when api was expired
+func (f *fakeFactory) check(api string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.expired[api] {
+		return &smithy.GenericAPIError{Code: "ExpiredTokenException", Message: "The security token included in the request is expired"}
+	}
+	return nil
+}
+
+// refreshingFactory is a fakeFactory whose Refresh clears expired credentials
+type refreshingFactory struct {
+	*fakeFactory
+	refreshes int
+}
+
+func (f *refreshingFactory) Refresh(ctx context.Context) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.refreshes++
+	f.expired = nil
+	return nil
+}
+
+// fakeSTS answers GetCallerIdentity for one account
+type fakeSTS struct {
+	factory   *fakeFactory
+	account   string
+	partition string
+	err       error
+}
+
+func (c *fakeSTS) GetCallerIdentity(ctx context.Context, params *sts.GetCallerIdentityInput, optFns ...func(*sts.Options)) (*sts.GetCallerIdentityOutput, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	if err := c.factory.check("GetCallerIdentity"); err != nil {
+		return nil, err
+	}
+	return &sts.GetCallerIdentityOutput{
+		Account: aws.String(c.account),
+		Arn:     aws.String("arn:" + c.partition + ":iam::" + c.account + ":user/test"),
+	}, nil
+}
+
+// fakeEC2 serves DescribeRegions, each region opted in unless optIn gives
+// its status, or fails it with err; the other EC2 calls are not faked
+type fakeEC2 struct {
+	EC2Client
+	factory *fakeFactory
+	regions []string
+	optIn   map[string]string
+	err     error
+
+	mu    sync.Mutex
+	calls int
+}
+
+func (c *fakeEC2) DescribeRegions(ctx context.Context, params *ec2.DescribeRegionsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeRegionsOutput, error) {
+	c.mu.Lock()
+	c.calls++
+	c.mu.Unlock()
+	if c.err != nil {
+		return nil, c.err
+	}
+	if err := c.factory.check("DescribeRegions"); err != nil {
+		return nil, err
+	}
+	out := &ec2.DescribeRegionsOutput{}
+	for _, region := range c.regions {
+		status, ok := c.optIn[region]
+		if !ok {
+			status = "opt-in-not-required"
+		}
+		out.Regions = append(out.Regions, ec2types.Region{RegionName: aws.String(region), OptInStatus: aws.String(status)})
+	}
+	return out, nil
+}
+
+// fakeEKS serves ListClusters and DescribeCluster from clusters, pageSize
+// names per page (all at once when zero). listErr fails every listing and
+// describeErr the describes of the named clusters. accessEntries lists the
+// principals of each cluster, each with no groups or policies, unless
+// accessErr fails their listing. versions are the Kubernetes versions offered.
+type fakeEKS struct {
+	EKSClient
+	factory       *fakeFactory
+	clusters      []types.Cluster
+	accessEntries map[string][]string
+	accessErr     error
+	versions      []string
+	pageSize      int
+	listErr       error
+	describeErr   map[string]error
+
+	mu            sync.Mutex
+	listCalls     int
+	describeCalls int
+	index         map[string]int
+}
+
+func (c *fakeEKS) ListClusters(ctx context.Context, params *eks.ListClustersInput, optFns ...func(*eks.Options)) (*eks.ListClustersOutput, error) {
+	c.mu.Lock()
+	c.listCalls++
+	c.mu.Unlock()
+	if c.listErr != nil {
+		return nil, c.listErr
+	}
+	if err := c.factory.check("ListClusters"); err != nil {
+		return nil, err
+	}
+	start := 0
+	if params.NextToken != nil {
+		fmt.Sscan(*params.NextToken, &start)
+	}
+	end := len(c.clusters)
+	if c.pageSize > 0 {
+		end = min(start+c.pageSize, end)
+	}
+	out := &eks.ListClustersOutput{}
+	for _, cluster := range c.clusters[start:end] {
+		out.Clusters = append(out.Clusters, aws.ToString(cluster.Name))
+	}
+	if end < len(c.clusters) {
+		out.NextToken = aws.String(fmt.Sprint(end))
+	}
+	return out, nil
+}
+
+func (c *fakeEKS) DescribeCluster(ctx context.Context, params *eks.DescribeClusterInput, optFns ...func(*eks.Options)) (*eks.DescribeClusterOutput, error) {
+	c.mu.Lock()
+	c.describeCalls++
+	if c.index == nil {
+		c.index = make(map[string]int, len(c.clusters))
+		for i := range c.clusters {
+			c.index[aws.ToString(c.clusters[i].Name)] = i
+		}
+	}
+	i, found := c.index[aws.ToString(params.Name)]
+	c.mu.Unlock()
+	name := aws.ToString(params.Name)
+	if err := c.describeErr[name]; err != nil {
+		return nil, err
+	}
+	if err := c.factory.check("DescribeCluster"); err != nil {
+		return nil, err
+	}
+	if found {
+		cluster := c.clusters[i]
+		return &eks.DescribeClusterOutput{Cluster: &cluster}, nil
+	}
+	return nil, &types.ResourceNotFoundException{Message: aws.String("No cluster found for name: " + name)}
+}
+
+func (c *fakeEKS) ListAccessEntries(ctx context.Context, params *eks.ListAccessEntriesInput, optFns ...func(*eks.Options)) (*eks.ListAccessEntriesOutput, error) {
+	if c.accessErr != nil {
+		return nil, c.accessErr
+	}
+	if err := c.factory.check("ListAccessEntries"); err != nil {
+		return nil, err
+	}
+	return &eks.ListAccessEntriesOutput{AccessEntries: c.accessEntries[aws.ToString(params.ClusterName)]}, nil
+}
+
+func (c *fakeEKS) DescribeAccessEntry(ctx context.Context, params *eks.DescribeAccessEntryInput, optFns ...func(*eks.Options)) (*eks.DescribeAccessEntryOutput, error) {
+	return &eks.DescribeAccessEntryOutput{AccessEntry: &types.AccessEntry{PrincipalArn: params.PrincipalArn, Type: aws.String("STANDARD")}}, nil
+}
+
+func (c *fakeEKS) ListAssociatedAccessPolicies(ctx context.Context, params *eks.ListAssociatedAccessPoliciesInput, optFns ...func(*eks.Options)) (*eks.ListAssociatedAccessPoliciesOutput, error) {
+	return &eks.ListAssociatedAccessPoliciesOutput{}, nil
+}
+
+// DescribeClusterVersions serves versions one per page
+func (c *fakeEKS) DescribeClusterVersions(ctx context.Context, params *eks.DescribeClusterVersionsInput, optFns ...func(*eks.Options)) (*eks.DescribeClusterVersionsOutput, error) {
+	i := 0
+	if params.NextToken != nil {
+		fmt.Sscan(*params.NextToken, &i)
+	}
+	out := &eks.DescribeClusterVersionsOutput{}
+	if i < len(c.versions) {
+		out.ClusterVersions = []types.ClusterVersionInformation{{ClusterVersion: aws.String(c.versions[i])}}
+	}
+	if i+1 < len(c.versions) {
+		out.NextToken = aws.String(fmt.Sprint(i + 1))
+	}
+	return out, nil
+}
+
+// fakeCluster returns a described cluster with an endpoint and a version
+func fakeCluster(name, version string) types.Cluster {
+	return types.Cluster{
+		Name:     aws.String(name),
+		Version:  aws.String(version),
+		Endpoint: aws.String("https://" + name + ".eks.example.com"),
+	}
+}
+
+// newFakeScanner returns a scanner over f for its regions, plus opts
+func newFakeScanner(f *fakeFactory, opts ...Option) *Scanner {
+	return NewScanner(append([]Option{WithClientFactory(f), WithRegions(f.ec2.regions...)}, opts...)...)
+}
+
+// singleEKSFactory serves client for every region
+type singleEKSFactory struct {
+	*fakeFactory
+	client EKSClient
+}
+
+func (f *singleEKSFactory) EKS(ctx context.Context, region string) (EKSClient, error) {
+	return f.client, nil
+}
+
+// pageToken returns the page index a NextToken of nextToken points to
+func pageToken(token *string) int {
+	i := 0
+	if token != nil {
+		fmt.Sscan(*token, &i)
+	}
+	return i
+}
+
+// nextToken returns the token of the page after i of n, or nil after the last
+func nextToken(i, n int) *string {
+	if i+1 < n {
+		return aws.String(fmt.Sprint(i + 1))
+	}
+	return nil
+}
+
+// throttlingHTTPClient answers the calls numbered in throttle, counting from
+// 1, with a throttling error and the others with an empty response
+type throttlingHTTPClient struct {
+	throttle map[int]bool
+	calls    int
+}
+
+func (c *throttlingHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	c.calls++
+	if c.throttle[c.calls] {
+		return nil, &smithy.GenericAPIError{Code: "ThrottlingException", Message: "Rate exceeded"}
+	}
+	return &http.Response{StatusCode: 200, Header: http.Header{}, Body: http.NoBody}, nil
+}
+
+// sendRequest sends one request through an SDK stack with the standard
+// retryer, without backoff, making up to maxAttempts attempts with client,
+// and the given API options, and returns its error
+func sendRequest(ctx context.Context, t *testing.T, client smithyhttp.ClientDo, maxAttempts int, apiOptions ...func(*middleware.Stack) error) error {
+	t.Helper()
+	stack := middleware.NewStack("test", smithyhttp.NewStackRequest)
+	stack.Serialize.Add(middleware.SerializeMiddlewareFunc("URL", func(ctx context.Context, in middleware.SerializeInput, next middleware.SerializeHandler) (middleware.SerializeOutput, middleware.Metadata, error) {
+		in.Request.(*smithyhttp.Request).URL, _ = url.Parse("https://eks.us-east-1.amazonaws.com/clusters")
+		return next.HandleSerialize(ctx, in)
+	}), middleware.After)
+	// The retry middlewares are placed around request signing
+	stack.Finalize.Add(middleware.FinalizeMiddlewareFunc("Signing", func(ctx context.Context, in middleware.FinalizeInput, next middleware.FinalizeHandler) (middleware.FinalizeOutput, middleware.Metadata, error) {
+		return next.HandleFinalize(ctx, in)
+	}), middleware.After)
+	retryer := retry.NewStandard(func(o *retry.StandardOptions) {
+		o.MaxAttempts = maxAttempts
+		o.Backoff = retry.BackoffDelayerFunc(func(int, error) (time.Duration, error) { return 0, nil })
+		o.RateLimiter = ratelimit.None
+	})
+	if err := retry.AddRetryMiddlewares(stack, retry.AddRetryMiddlewaresOptions{Retryer: retryer}); err != nil {
+		t.Fatal(err)
+	}
+	for _, fn := range apiOptions {
+		if err := fn(stack); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, _, err := middleware.DecorateHandler(smithyhttp.NewClientHandler(client), stack).Handle(ctx, struct{}{})
+	return err
+}
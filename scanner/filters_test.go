@@ -0,0 +1,124 @@
+package scanner
+
+import (
+	"context"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eks/types"
+)
+
+func TestScanFilters(t *testing.T) {
+	cluster := func(name, version, vpc string, tags map[string]string) types.Cluster {
+		c := fakeCluster(name, version)
+		c.Tags = tags
+		c.ResourcesVpcConfig = &types.VpcConfigResponse{VpcId: aws.String(vpc)}
+		return c
+	}
+	f := newFakeFactory(map[string][]types.Cluster{
+		"us-east-1": {
+			cluster("prod", "1.31", "vpc-a", map[string]string{"env": "prod", "owner": "platform"}),
+			cluster("legacy", "1.24", "vpc-b", map[string]string{"env": "prod"}),
+		},
+		"eu-west-1": {
+			cluster("dev", "1.30", "vpc-a", map[string]string{"env": "dev", "owner": "apps"}),
+			cluster("scratch", "1.29", "vpc-c", nil),
+		},
+	})
+	tests := []struct {
+		name string
+		opts []Option
+		want []string
+	}{
+		{name: "no filter", want: []string{"dev", "scratch", "prod", "legacy"}},
+		{name: "tag key and value", opts: []Option{WithTagFilter("env", "prod")}, want: []string{"prod", "legacy"}},
+		{name: "tag with several values", opts: []Option{WithTagFilter("env", "prod", "dev")}, want: []string{"dev", "prod", "legacy"}},
+		{name: "tag key only", opts: []Option{WithTagFilter("owner")}, want: []string{"dev", "prod"}},
+		{name: "every tag filter must match", opts: []Option{WithTagFilter("env", "prod"), WithTagFilter("owner")}, want: []string{"prod"}},
+		{name: "exclude tag", opts: []Option{WithExcludeTag("env", "prod")}, want: []string{"dev", "scratch"}},
+		{name: "exclude after include", opts: []Option{WithTagFilter("env", "prod"), WithExcludeTag("owner", "platform")}, want: []string{"legacy"}},
+		{name: "vpc", opts: []Option{WithVpcIDs("vpc-a", "vpc-c")}, want: []string{"dev", "scratch", "prod"}},
+		{name: "only EOL", opts: []Option{WithOnlyEOL()}, want: eolNames(f, "eu-west-1", "us-east-1")},
+		{name: "missing required tags", opts: []Option{WithRequiredTags("owner")}, want: []string{"scratch", "legacy"}},
+		{name: "ARN prefix", opts: []Option{WithArnPrefixes("arn:aws:eks:us-east-1:123456789012:cluster/p", "arn:aws:eks:eu-west-1:123456789012:cluster/d")}, want: []string{"dev", "prod"}},
+		{name: "ARN prefix of another account", opts: []Option{WithArnPrefixes("arn:aws:eks:us-east-1:210987654321:cluster/")}, want: []string{}},
+		{name: "combined", opts: []Option{WithVpcIDs("vpc-a"), WithTagFilter("env", "dev")}, want: []string{"dev"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := newFakeScanner(f, tt.opts...).Run(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := []string{}
+			for _, c := range result.Clusters {
+				got = append(got, c.Name)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("clusters = %q, want %q", got, tt.want)
+			}
+			// Warnings follow the filtered clusters only
+			for _, w := range result.Warnings {
+				if !slices.Contains(got, w.Cluster) {
+					t.Errorf("warning for filtered-out cluster %s", w.Cluster)
+				}
+			}
+		})
+	}
+}
+
+// eolNames returns the clusters of f in regions that are EOL today
+func eolNames(f *fakeFactory, regions ...string) []string {
+	names := []string{}
+	for _, region := range regions {
+		for _, c := range f.region(region).clusters {
+			if IsEOL(aws.ToString(c.Version), time.Now()) {
+				names = append(names, aws.ToString(c.Name))
+			}
+		}
+	}
+	return names
+}
+
+func TestRequiredTagsRecordsMissing(t *testing.T) {
+	c := fakeCluster("prod", "1.31")
+	c.Tags = map[string]string{"env": "prod"}
+	f := newFakeFactory(map[string][]types.Cluster{"us-east-1": {c}})
+	result, err := newFakeScanner(f, WithRequiredTags("owner", "env", "cost-center")).Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := result.Clusters[0].MissingTags; !reflect.DeepEqual(got, []string{"owner", "cost-center"}) {
+		t.Errorf("missing tags = %q, want owner and cost-center in the given order", got)
+	}
+}
+
+func TestTagMatching(t *testing.T) {
+	tags := map[string]string{"env": "prod", "team": "platform"}
+	tests := []struct {
+		name    string
+		filters map[string][]string
+		all     bool
+		any     bool
+	}{
+		{name: "no filters", filters: map[string][]string{}, all: true},
+		{name: "key present", filters: map[string][]string{"env": nil}, all: true, any: true},
+		{name: "value matches", filters: map[string][]string{"env": {"dev", "prod"}}, all: true, any: true},
+		{name: "value differs", filters: map[string][]string{"env": {"dev"}}},
+		{name: "key absent", filters: map[string][]string{"owner": nil}},
+		{name: "one of two", filters: map[string][]string{"env": {"prod"}, "owner": nil}, any: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchesTags(tags, tt.filters); got != tt.all {
+				t.Errorf("matchesTags = %t, want %t", got, tt.all)
+			}
+			if got := matchesAnyTag(tags, tt.filters); got != tt.any {
+				t.Errorf("matchesAnyTag = %t, want %t", got, tt.any)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package scanner
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+)
+
+// HTTPTimeouts bounds the requests of an HTTP client built by NewHTTPClient;
+// zero values keep the SDK defaults
+type HTTPTimeouts struct {
+	// Request bounds each HTTP request attempt, from dialing to reading the
+	// response body.
+	Request time.Duration
+	// Dial bounds establishing the TCP connection.
+	Dial time.Duration
+}
+
+// NewHTTPClient returns an SDK HTTP client that trusts the PEM certificates in
+// caBundle in addition to the system roots, applies timeouts, and sends
+// requests through the proxy named by HTTPS_PROXY, HTTP_PROXY and NO_PROXY.
+// An empty caBundle keeps the system roots only.
+func NewHTTPClient(caBundle []byte, timeouts HTTPTimeouts) (aws.HTTPClient, error) {
+	var roots *x509.CertPool
+	if len(caBundle) > 0 {
+		var err error
+		roots, err = x509.SystemCertPool()
+		if err != nil {
+			roots = x509.NewCertPool()
+		}
+		if !roots.AppendCertsFromPEM(caBundle) {
+			return nil, errors.New("CA bundle contains no PEM certificates")
+		}
+	}
+	client := awshttp.NewBuildableClient()
+	if timeouts.Request > 0 {
+		client = client.WithTimeout(timeouts.Request)
+	}
+	if timeouts.Dial > 0 {
+		client = client.WithDialerOptions(func(d *net.Dialer) {
+			d.Timeout = timeouts.Dial
+		})
+	}
+	return client.WithTransportOptions(func(tr *http.Transport) {
+		tr.Proxy = http.ProxyFromEnvironment
+		if roots != nil {
+			if tr.TLSClientConfig == nil {
+				tr.TLSClientConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+			}
+			tr.TLSClientConfig.RootCAs = roots
+		}
+	}), nil
+}
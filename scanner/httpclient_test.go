@@ -0,0 +1,144 @@
+package scanner
+
+import (
+	"context"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+)
+
+// staticLoader returns cfg as the loaded configuration
+type staticLoader struct {
+	cfg aws.Config
+}
+
+func (l staticLoader) LoadDefaultConfigMethod(ctx context.Context) (aws.Config, error) {
+	return l.cfg, nil
+}
+
+func TestNewHTTPClientCABundle(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	serverCA := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+
+	tests := []struct {
+		name       string
+		bundle     []byte
+		wantErr    string
+		wantGetErr string
+	}{
+		{name: "bundle trusted", bundle: serverCA},
+		{name: "system roots only", wantGetErr: "certificate"},
+		{name: "no certificates", bundle: []byte("not a certificate"), wantErr: "CA bundle contains no PEM certificates"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := NewHTTPClient(tt.bundle, HTTPTimeouts{})
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if proxy := client.(*awshttp.BuildableClient).GetTransport().Proxy; proxy == nil {
+				t.Error("transport ignores the proxy environment")
+			}
+			req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+			resp, err := client.Do(req)
+			if err == nil {
+				resp.Body.Close()
+			}
+			if tt.wantGetErr == "" && err != nil || tt.wantGetErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantGetErr)) {
+				t.Errorf("GET err = %v, want %q", err, tt.wantGetErr)
+			}
+		})
+	}
+}
+
+func TestNewHTTPClientTimeouts(t *testing.T) {
+	defaults := awshttp.NewBuildableClient()
+	tests := []struct {
+		name        string
+		timeouts    HTTPTimeouts
+		wantRequest time.Duration
+		wantDial    time.Duration
+	}{
+		{name: "sdk defaults", wantRequest: defaults.GetTimeout(), wantDial: defaults.GetDialer().Timeout},
+		{name: "request", timeouts: HTTPTimeouts{Request: 5 * time.Second}, wantRequest: 5 * time.Second, wantDial: defaults.GetDialer().Timeout},
+		{name: "dial", timeouts: HTTPTimeouts{Dial: time.Second}, wantRequest: defaults.GetTimeout(), wantDial: time.Second},
+		{name: "both", timeouts: HTTPTimeouts{Request: 5 * time.Second, Dial: time.Second}, wantRequest: 5 * time.Second, wantDial: time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := NewHTTPClient(nil, tt.timeouts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			b := client.(*awshttp.BuildableClient)
+			if b.GetTimeout() != tt.wantRequest || b.GetDialer().Timeout != tt.wantDial {
+				t.Errorf("timeouts = request %v, dial %v; want %v, %v", b.GetTimeout(), b.GetDialer().Timeout, tt.wantRequest, tt.wantDial)
+			}
+		})
+	}
+}
+
+func TestNewHTTPClientRequestTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	client, err := NewHTTPClient(nil, HTTPTimeouts{Request: 50 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err == nil {
+		resp.Body.Close()
+		t.Fatal("request to a stalled server succeeded")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("request failed after %v, want the 50ms timeout", elapsed)
+	}
+}
+
+func TestDefaultClientFactoryHTTPClient(t *testing.T) {
+	client, err := NewHTTPClient(nil, HTTPTimeouts{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewScanner(WithHTTPClient(client))
+	f, ok := s.factory.(*DefaultClientFactory)
+	if !ok || f.HTTPClient != client {
+		t.Fatalf("factory = %+v, want the default factory with the HTTP client", s.factory)
+	}
+
+	f.Loader = staticLoader{cfg: aws.Config{Region: "us-east-1"}}
+	for _, step := range []string{"load", "refresh"} {
+		if step == "refresh" {
+			if err := f.Refresh(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+		}
+		cfg, err := f.config(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cfg.HTTPClient != client {
+			t.Errorf("%s: config HTTP client = %v, want the custom client", step, cfg.HTTPClient)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+// clusterTagPrefix is the tag key prefix identifying the cluster an instance belongs to
+const clusterTagPrefix = "kubernetes.io/cluster/"
+
+// maxFilterValues is the maximum number of values EC2 accepts in a single filter
+const maxFilterValues = 200
+
+// groupByRegion returns the indexes of clusters keyed by region, in first-seen region order
+func groupByRegion(clusters []Cluster) ([]string, map[string][]int) {
+	var regions []string
+	byRegion := make(map[string][]int)
+	for i, c := range clusters {
+		if _, ok := byRegion[c.Region]; !ok {
+			regions = append(regions, c.Region)
+		}
+		byRegion[c.Region] = append(byRegion[c.Region], i)
+	}
+	return regions, byRegion
+}
+
+// getInstanceCounts tallies, per region, the running instances tagged with
+// each cluster and records the counts on the clusters
+func (s *Scanner) getInstanceCounts(ctx context.Context, clusters []Cluster) error {
+	regions, byRegion := groupByRegion(clusters)
+	return s.forEach(len(regions), s.listConcurrency, func(i int) error {
+		region := regions[i]
+		client, err := s.factory.EC2(ctx, region)
+		if err != nil {
+			return fmt.Errorf("creating EC2 client for region %s: %w", region, err)
+		}
+
+		indexes := byRegion[region]
+		names := make([]string, 0, len(indexes))
+		for _, idx := range indexes {
+			names = append(names, clusters[idx].Name)
+		}
+
+		counts, err := countClusterInstances(ctx, client, names)
+		if err != nil {
+			return fmt.Errorf("describing instances in region %s: %w", region, err)
+		}
+		for _, idx := range indexes {
+			n := counts[clusters[idx].Name]
+			clusters[idx].InstanceCount = &n
+		}
+		return nil
+	})
+}
+
+// countClusterInstances returns the number of running instances tagged with
+// each of the named clusters, following DescribeInstances pagination
+func countClusterInstances(ctx context.Context, client EC2Client, names []string) (map[string]int, error) {
+	counts := make(map[string]int, len(names))
+	for start := 0; start < len(names); start += maxFilterValues {
+		end := min(start+maxFilterValues, len(names))
+		keys := make([]string, 0, end-start)
+		batch := make(map[string]bool, end-start)
+		for _, name := range names[start:end] {
+			keys = append(keys, clusterTagPrefix+name)
+			batch[name] = true
+		}
+
+		input := &ec2.DescribeInstancesInput{
+			Filters: []types.Filter{
+				{Name: aws.String("instance-state-name"), Values: []string{"running"}},
+				{Name: aws.String("tag-key"), Values: keys},
+			},
+		}
+		for {
+			page, err := client.DescribeInstances(ctx, input)
+			if err != nil {
+				return nil, err
+			}
+			for _, reservation := range page.Reservations {
+				for _, instance := range reservation.Instances {
+					for _, tag := range instance.Tags {
+						// An instance tagged for clusters of other batches is counted by those batches
+						if name, ok := strings.CutPrefix(aws.ToString(tag.Key), clusterTagPrefix); ok && batch[name] {
+							counts[name]++
+						}
+					}
+				}
+			}
+			if page.NextToken == nil {
+				break
+			}
+			input.NextToken = page.NextToken
+		}
+	}
+	return counts, nil
+}
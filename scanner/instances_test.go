@@ -0,0 +1,162 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+// instancesEC2 serves DescribeInstances from instances, applying the
+// instance-state-name and tag-key filters and returning pageSize instances per page
+type instancesEC2 struct {
+	EC2Client
+	instances []ec2types.Instance
+	pageSize  int
+	calls     int
+}
+
+func (c *instancesEC2) DescribeInstances(ctx context.Context, params *ec2.DescribeInstancesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error) {
+	c.calls++
+	var matched []ec2types.Instance
+	for _, instance := range c.instances {
+		if instanceMatches(instance, params.Filters) {
+			matched = append(matched, instance)
+		}
+	}
+	start := pageToken(params.NextToken)
+	end := min(start+c.pageSize, len(matched))
+	out := &ec2.DescribeInstancesOutput{Reservations: []ec2types.Reservation{{Instances: matched[start:end]}}}
+	if end < len(matched) {
+		out.NextToken = aws.String(fmt.Sprint(end))
+	}
+	return out, nil
+}
+
+// instanceMatches applies the filters countClusterInstances uses
+func instanceMatches(instance ec2types.Instance, filters []ec2types.Filter) bool {
+	for _, filter := range filters {
+		switch aws.ToString(filter.Name) {
+		case "instance-state-name":
+			if instance.State == nil || !slices.Contains(filter.Values, string(instance.State.Name)) {
+				return false
+			}
+		case "tag-key":
+			if !slices.ContainsFunc(instance.Tags, func(tag ec2types.Tag) bool { return slices.Contains(filter.Values, aws.ToString(tag.Key)) }) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// clusterInstance returns an instance in state tagged as a node of each of clusters
+func clusterInstance(state ec2types.InstanceStateName, clusters ...string) ec2types.Instance {
+	instance := ec2types.Instance{State: &ec2types.InstanceState{Name: state}}
+	for _, name := range clusters {
+		instance.Tags = append(instance.Tags, ec2types.Tag{Key: aws.String(clusterTagPrefix + name), Value: aws.String("owned")})
+	}
+	return instance
+}
+
+// ec2Factory serves client for every region
+type ec2Factory struct {
+	*fakeFactory
+	client EC2Client
+}
+
+func (f *ec2Factory) EC2(ctx context.Context, region string) (EC2Client, error) {
+	return f.client, nil
+}
+
+func TestCountClusterInstances(t *testing.T) {
+	many := make([]string, maxFilterValues+1)
+	for i := range many {
+		many[i] = fmt.Sprintf("c%03d", i)
+	}
+	last := many[maxFilterValues]
+	tests := []struct {
+		name      string
+		names     []string
+		instances []ec2types.Instance
+		want      map[string]int
+		wantCalls int
+	}{
+		{
+			name:  "running instances only",
+			names: []string{"prod", "dev"},
+			instances: []ec2types.Instance{
+				clusterInstance(ec2types.InstanceStateNameRunning, "prod"),
+				clusterInstance(ec2types.InstanceStateNameRunning, "prod"),
+				clusterInstance(ec2types.InstanceStateNameStopped, "dev"),
+				clusterInstance(ec2types.InstanceStateNameRunning, "other"),
+			},
+			want:      map[string]int{"prod": 2},
+			wantCalls: 1,
+		},
+		{
+			name:      "paginated",
+			names:     []string{"prod"},
+			instances: slices.Repeat([]ec2types.Instance{clusterInstance(ec2types.InstanceStateNameRunning, "prod")}, 5),
+			want:      map[string]int{"prod": 5},
+			wantCalls: 3,
+		},
+		{
+			name:  "batches of filter values",
+			names: many,
+			instances: []ec2types.Instance{
+				clusterInstance(ec2types.InstanceStateNameRunning, many[0]),
+				clusterInstance(ec2types.InstanceStateNameRunning, many[0], last),
+			},
+			want:      map[string]int{many[0]: 2, last: 1},
+			wantCalls: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &instancesEC2{instances: tt.instances, pageSize: 2}
+			got, err := countClusterInstances(context.Background(), client, tt.names)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for name, n := range got {
+				if n != tt.want[name] {
+					t.Errorf("%s: %d instances, want %d", name, n, tt.want[name])
+				}
+			}
+			for name, n := range tt.want {
+				if got[name] != n {
+					t.Errorf("%s: %d instances, want %d", name, got[name], n)
+				}
+			}
+			if client.calls != tt.wantCalls {
+				t.Errorf("%d DescribeInstances calls, want %d", client.calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestGetInstanceCounts(t *testing.T) {
+	client := &instancesEC2{pageSize: 10, instances: []ec2types.Instance{clusterInstance(ec2types.InstanceStateNameRunning, "prod")}}
+	f := &ec2Factory{fakeFactory: newFakeFactory(nil), client: client}
+	s := NewScanner(WithClientFactory(f), WithInstanceCount())
+	clusters := []Cluster{{Name: "prod", Region: "us-east-1"}, {Name: "idle", Region: "us-east-1"}}
+	if err := s.getInstanceCounts(context.Background(), clusters); err != nil {
+		t.Fatal(err)
+	}
+	var counts []string
+	for _, c := range clusters {
+		if c.InstanceCount == nil {
+			t.Fatalf("%s has no instance count", c.Name)
+		}
+		counts = append(counts, fmt.Sprintf("%s=%d", c.Name, *c.InstanceCount))
+	}
+	if got := strings.Join(counts, ","); got != "prod=1,idle=0" {
+		t.Errorf("instance counts = %s, want prod=1,idle=0", got)
+	}
+}
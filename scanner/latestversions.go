@@ -0,0 +1,77 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eks"
+)
+
+// getVersionsBehind lists the Kubernetes versions EKS offers in each region
+// and records on every cluster the latest one and how many newer versions
+// than its own are available
+func (s *Scanner) getVersionsBehind(ctx context.Context, clusters []Cluster) error {
+	regions, byRegion := groupByRegion(clusters)
+	return s.forEach(len(regions), s.listConcurrency, func(i int) error {
+		region := regions[i]
+		client, err := s.factory.EKS(ctx, region)
+		if err != nil {
+			return fmt.Errorf("creating EKS client for region %s: %w", region, err)
+		}
+		available, err := listClusterVersions(ctx, client)
+		if err != nil {
+			return fmt.Errorf("describing cluster versions in region %s: %w", region, err)
+		}
+		latest := ""
+		for _, v := range available {
+			if latest == "" || compareVersions(v, latest) > 0 {
+				latest = v
+			}
+		}
+
+		for _, idx := range byRegion[region] {
+			c := &clusters[idx]
+			if c.Version == "" || latest == "" {
+				continue
+			}
+			behind := versionsBehind(c.Version, available)
+			c.LatestVersion = latest
+			c.VersionsBehind = &behind
+		}
+		return nil
+	})
+}
+
+// listClusterVersions returns the standard and extended support versions
+// EKS offers, following pagination
+func listClusterVersions(ctx context.Context, client EKSClient) ([]string, error) {
+	var versions []string
+	input := &eks.DescribeClusterVersionsInput{}
+	for {
+		page, err := client.DescribeClusterVersions(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+		for _, info := range page.ClusterVersions {
+			if v := aws.ToString(info.ClusterVersion); v != "" {
+				versions = append(versions, v)
+			}
+		}
+		if page.NextToken == nil {
+			return versions, nil
+		}
+		input.NextToken = page.NextToken
+	}
+}
+
+// versionsBehind counts the available versions newer than version
+func versionsBehind(version string, available []string) int {
+	n := 0
+	for _, v := range available {
+		if compareVersions(v, version) > 0 {
+			n++
+		}
+	}
+	return n
+}
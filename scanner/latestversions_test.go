@@ -0,0 +1,56 @@
+package scanner
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/eks/types"
+)
+
+func TestVersionsBehind(t *testing.T) {
+	available := []string{"1.29", "1.30", "1.31", "1.32", "1.9"}
+	tests := []struct {
+		version string
+		want    int
+	}{
+		{"1.32", 0},
+		{"1.31", 1},
+		{"1.29", 3},
+		{"1.28", 4},
+		{"1.33", 0},
+	}
+	for _, tt := range tests {
+		if got := versionsBehind(tt.version, available); got != tt.want {
+			t.Errorf("versionsBehind(%q) = %d, want %d", tt.version, got, tt.want)
+		}
+	}
+}
+
+func TestGetVersionsBehind(t *testing.T) {
+	f := newFakeFactory(map[string][]types.Cluster{
+		"us-east-1": {fakeCluster("current", "1.32"), fakeCluster("lagging", "1.29")},
+		"eu-west-1": {fakeCluster("eu", "1.30")},
+	})
+	// Regions may offer different versions; the newest is found across pages
+	f.region("us-east-1").versions = []string{"1.30", "1.32", "1.29", "1.31"}
+	f.region("eu-west-1").versions = []string{"1.30", "1.31"}
+
+	result, err := newFakeScanner(f, WithVersionsBehind()).Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]struct {
+		latest string
+		behind int
+	}{
+		"current": {"1.32", 0},
+		"lagging": {"1.32", 3},
+		"eu":      {"1.31", 1},
+	}
+	for _, c := range result.Clusters {
+		w := want[c.Name]
+		if c.LatestVersion != w.latest || c.VersionsBehind == nil || *c.VersionsBehind != w.behind {
+			t.Errorf("%s = latest %q, behind %v; want %q, %d", c.Name, c.LatestVersion, c.VersionsBehind, w.latest, w.behind)
+		}
+	}
+}
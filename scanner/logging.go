@@ -0,0 +1,34 @@
+package scanner
+
+import (
+	"slices"
+
+	"github.com/aws/aws-sdk-go-v2/service/eks/types"
+)
+
+// LogTypeAudit is the control plane log type recording Kubernetes API audit events
+const LogTypeAudit = string(types.LogTypeAudit)
+
+// enabledLogTypes returns the control plane log types the logging config
+// enables, sorted
+func enabledLogTypes(logging *types.Logging) []string {
+	if logging == nil {
+		return nil
+	}
+	var enabled []string
+	for _, setup := range logging.ClusterLogging {
+		if setup.Enabled == nil || !*setup.Enabled {
+			continue
+		}
+		for _, t := range setup.Types {
+			enabled = append(enabled, string(t))
+		}
+	}
+	slices.Sort(enabled)
+	return slices.Compact(enabled)
+}
+
+// AuditLogging reports whether the cluster sends audit logs to CloudWatch
+func (c *Cluster) AuditLogging() bool {
+	return slices.Contains(c.EnabledLogTypes, LogTypeAudit)
+}
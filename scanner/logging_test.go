@@ -0,0 +1,62 @@
+package scanner
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eks/types"
+)
+
+func TestEnabledLogTypes(t *testing.T) {
+	setup := func(enabled *bool, logTypes ...types.LogType) types.LogSetup {
+		return types.LogSetup{Enabled: enabled, Types: logTypes}
+	}
+	tests := []struct {
+		name    string
+		logging *types.Logging
+		want    []string
+	}{
+		{name: "no logging config"},
+		{name: "nothing enabled", logging: &types.Logging{ClusterLogging: []types.LogSetup{setup(aws.Bool(false), types.LogTypeApi, types.LogTypeAudit)}}},
+		{name: "unset enabled", logging: &types.Logging{ClusterLogging: []types.LogSetup{setup(nil, types.LogTypeAudit)}}},
+		{
+			name: "enabled sorted and deduplicated",
+			logging: &types.Logging{ClusterLogging: []types.LogSetup{
+				setup(aws.Bool(true), types.LogTypeScheduler, types.LogTypeAudit),
+				setup(aws.Bool(false), types.LogTypeAuthenticator),
+				setup(aws.Bool(true), types.LogTypeApi, types.LogTypeAudit),
+			}},
+			want: []string{"api", "audit", "scheduler"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := enabledLogTypes(tt.logging)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("enabled = %q, want %q", got, tt.want)
+			}
+			c := Cluster{EnabledLogTypes: got}
+			if c.AuditLogging() != slices.Contains(tt.want, LogTypeAudit) {
+				t.Errorf("AuditLogging() = %t", c.AuditLogging())
+			}
+		})
+	}
+}
+
+func TestRunEnabledLogTypes(t *testing.T) {
+	audited := fakeCluster("audited", "1.31")
+	audited.Logging = &types.Logging{ClusterLogging: []types.LogSetup{{Enabled: aws.Bool(true), Types: []types.LogType{types.LogTypeAudit}}}}
+	f := newFakeFactory(map[string][]types.Cluster{"us-east-1": {audited, fakeCluster("quiet", "1.31")}})
+
+	result, err := newFakeScanner(f).Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range result.Clusters {
+		if want := c.Name == "audited"; c.AuditLogging() != want {
+			t.Errorf("%s audit logging = %t (log types %q), want %t", c.Name, c.AuditLogging(), c.EnabledLogTypes, want)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package scanner
+
+import (
+	"errors"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+)
+
+// ErrMFARequired is returned when the profile assumes a role with an
+// mfa_serial but no MFA token provider was given with WithMFATokenProvider
+var ErrMFARequired = errors.New("the profile assumes a role that requires MFA (mfa_serial) but no MFA token was provided")
+
+// WithMFATokenProvider supplies the MFA codes for profiles that assume a role
+// with an mfa_serial; provider is called whenever the role is assumed. It
+// applies to the default client factory only.
+func WithMFATokenProvider(provider func() (string, error)) Option {
+	return func(s *Scanner) {
+		s.mfaTokenProvider = provider
+	}
+}
+
+// isMFARequired reports whether loading the configuration failed for lack of an MFA token provider
+func isMFARequired(err error) bool {
+	var notSet config.AssumeRoleTokenProviderNotSetError
+	return errors.As(err, &notSet)
+}
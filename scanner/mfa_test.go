@@ -0,0 +1,80 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+)
+
+// mfaConfig writes a shared config whose mfa profile assumes a role with an
+// mfa_serial, and points AWS_CONFIG_FILE at it
+func mfaConfig(t *testing.T) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config")
+	config := `[profile base]
+aws_access_key_id = AKIAEXAMPLE
+aws_secret_access_key = secret
+region = us-east-1
+
+[profile mfa]
+role_arn = arn:aws:iam::123456789012:role/admin
+mfa_serial = arn:aws:iam::123456789012:mfa/test
+source_profile = base
+region = us-east-1
+`
+	if err := os.WriteFile(path, []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("AWS_CONFIG_FILE", path)
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(t.TempDir(), "credentials"))
+}
+
+func TestIsMFARequired(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{err: config.AssumeRoleTokenProviderNotSetError{}, want: true},
+		{err: fmt.Errorf("loading profile: %w", config.AssumeRoleTokenProviderNotSetError{}), want: true},
+		{err: errors.New("no credentials")},
+	}
+	for _, tt := range tests {
+		if got := isMFARequired(tt.err); got != tt.want {
+			t.Errorf("isMFARequired(%v) = %t, want %t", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestDefaultConfigLoaderMFA(t *testing.T) {
+	mfaConfig(t)
+	tests := []struct {
+		name     string
+		provider func() (string, error)
+		wantErr  bool
+	}{
+		{name: "no token provider", wantErr: true},
+		{name: "token provider", provider: func() (string, error) { return "123456", nil }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loader := &DefaultConfigLoader{Profile: "mfa", MFATokenProvider: tt.provider}
+			_, err := loader.LoadDefaultConfigMethod(context.Background())
+			if isMFARequired(err) != tt.wantErr {
+				t.Errorf("err = %v, want MFA required %t", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRunMFARequired(t *testing.T) {
+	mfaConfig(t)
+	_, err := NewScanner(WithProfile("mfa"), WithRegions("us-east-1")).Run(context.Background())
+	if !errors.Is(err, ErrMFARequired) {
+		t.Errorf("err = %v, want ErrMFARequired", err)
+	}
+}
@@ -0,0 +1,54 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eks"
+)
+
+// getNodegroupSizes sums the desired size of each cluster's managed node
+// groups and records the total on the cluster
+func (s *Scanner) getNodegroupSizes(ctx context.Context, clusters []Cluster) error {
+	return s.forEachDescribe(len(clusters), func(i int) error {
+		c := &clusters[i]
+		if c.DescribeError != "" || c.Connected() {
+			return nil
+		}
+		client, err := s.factory.EKS(ctx, c.Region)
+		if err != nil {
+			return fmt.Errorf("creating EKS client for region %s: %w", c.Region, err)
+		}
+
+		var names []string
+		input := &eks.ListNodegroupsInput{ClusterName: aws.String(c.Name)}
+		for {
+			page, err := client.ListNodegroups(ctx, input)
+			if err != nil {
+				return fmt.Errorf("listing node groups for cluster %s: %w", c.Name, err)
+			}
+			names = append(names, page.Nodegroups...)
+			if page.NextToken == nil {
+				break
+			}
+			input.NextToken = page.NextToken
+		}
+
+		total := 0
+		for _, name := range names {
+			out, err := client.DescribeNodegroup(ctx, &eks.DescribeNodegroupInput{ClusterName: aws.String(c.Name), NodegroupName: aws.String(name)})
+			if err != nil {
+				return fmt.Errorf("describing node group %s of cluster %s: %w", name, c.Name, err)
+			}
+			// Groups scaled to zero report a desired size of 0 or none at all
+			if ng := out.Nodegroup; ng != nil && ng.ScalingConfig != nil {
+				total += int(aws.ToInt32(ng.ScalingConfig.DesiredSize))
+			}
+		}
+		count := len(names)
+		c.NodegroupCount = &count
+		c.TotalNodes = &total
+		return nil
+	})
+}
@@ -0,0 +1,108 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eks"
+	"github.com/aws/aws-sdk-go-v2/service/eks/types"
+)
+
+// nodegroupsEKS adds the managed node groups of each cluster to a fakeEKS,
+// listing one group per page. describeErr fails every DescribeNodegroup.
+type nodegroupsEKS struct {
+	*fakeEKS
+	groups      map[string][]types.Nodegroup
+	describeErr error
+}
+
+func (c *nodegroupsEKS) ListNodegroups(ctx context.Context, params *eks.ListNodegroupsInput, optFns ...func(*eks.Options)) (*eks.ListNodegroupsOutput, error) {
+	groups := c.groups[aws.ToString(params.ClusterName)]
+	out := &eks.ListNodegroupsOutput{}
+	if i := pageToken(params.NextToken); i < len(groups) {
+		out.Nodegroups = []string{aws.ToString(groups[i].NodegroupName)}
+		out.NextToken = nextToken(i, len(groups))
+	}
+	return out, nil
+}
+
+func (c *nodegroupsEKS) DescribeNodegroup(ctx context.Context, params *eks.DescribeNodegroupInput, optFns ...func(*eks.Options)) (*eks.DescribeNodegroupOutput, error) {
+	if c.describeErr != nil {
+		return nil, c.describeErr
+	}
+	for _, ng := range c.groups[aws.ToString(params.ClusterName)] {
+		if aws.ToString(ng.NodegroupName) == aws.ToString(params.NodegroupName) {
+			return &eks.DescribeNodegroupOutput{Nodegroup: &ng}, nil
+		}
+	}
+	return nil, &types.ResourceNotFoundException{Message: aws.String("No node group found for name: " + aws.ToString(params.NodegroupName))}
+}
+
+// nodegroup returns a node group scaled to desired nodes, or without a
+// scaling configuration when desired is nil
+func nodegroup(name string, desired *int32) types.Nodegroup {
+	ng := types.Nodegroup{NodegroupName: aws.String(name)}
+	if desired != nil {
+		ng.ScalingConfig = &types.NodegroupScalingConfig{DesiredSize: desired}
+	}
+	return ng
+}
+
+func TestRunNodegroups(t *testing.T) {
+	connected := fakeCluster("connected", "")
+	connected.ConnectorConfig = &types.ConnectorConfigResponse{Provider: aws.String("OTHER")}
+	f := newFakeFactory(map[string][]types.Cluster{"us-east-1": {
+		fakeCluster("prod", "1.31"), fakeCluster("scaled-down", "1.31"), fakeCluster("empty", "1.31"), fakeCluster("broken", "1.31"), connected,
+	}})
+	f.region("us-east-1").describeErr = map[string]error{"broken": errors.New("AccessDenied")}
+	client := &nodegroupsEKS{fakeEKS: f.region("us-east-1"), groups: map[string][]types.Nodegroup{
+		"prod":        {nodegroup("system", aws.Int32(3)), nodegroup("workers", aws.Int32(2))},
+		"scaled-down": {nodegroup("zero", aws.Int32(0)), nodegroup("unset", nil)},
+	}}
+	type sizes struct{ groups, nodes int }
+	want := map[string]*sizes{
+		"prod":        {groups: 2, nodes: 5},
+		"scaled-down": {groups: 2, nodes: 0},
+		"empty":       {groups: 0, nodes: 0},
+		"broken":      nil,
+		"connected":   nil,
+	}
+
+	s := NewScanner(WithClientFactory(&singleEKSFactory{fakeFactory: f, client: client}), WithRegions("us-east-1"), WithNodegroups())
+	result, err := s.Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Clusters) != len(want) {
+		t.Fatalf("%d clusters, want %d", len(result.Clusters), len(want))
+	}
+	for _, c := range result.Clusters {
+		var got *sizes
+		if c.NodegroupCount != nil || c.TotalNodes != nil {
+			got = &sizes{groups: aws.ToInt(c.NodegroupCount), nodes: aws.ToInt(c.TotalNodes)}
+		}
+		if w := want[c.Name]; (got == nil) != (w == nil) || got != nil && *got != *w {
+			t.Errorf("%s node groups = %+v, want %+v", c.Name, got, w)
+		}
+	}
+}
+
+func TestRunNodegroupsError(t *testing.T) {
+	f := newFakeFactory(map[string][]types.Cluster{"us-east-1": {fakeCluster("prod", "1.31")}})
+	client := &nodegroupsEKS{
+		fakeEKS:     f.region("us-east-1"),
+		groups:      map[string][]types.Nodegroup{"prod": {nodegroup("system", aws.Int32(3))}},
+		describeErr: errors.New("AccessDenied"),
+	}
+	s := NewScanner(WithClientFactory(&singleEKSFactory{fakeFactory: f, client: client}), WithRegions("us-east-1"), WithNodegroups())
+	result, err := s.Run(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "describing node group system of cluster prod") {
+		t.Fatalf("err = %v, want the node group error", err)
+	}
+	if result == nil || !result.Incomplete || len(result.Clusters) != 1 {
+		t.Errorf("result = %+v, want an incomplete result keeping the cluster", result)
+	}
+}
@@ -0,0 +1,71 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Scan phases that can be bounded with WithPhaseTimeout
+const (
+	// PhaseRegions lists the regions of the account with DescribeRegions.
+	PhaseRegions = "regions"
+	// PhaseList lists the clusters of every scanned region.
+	PhaseList = "list"
+	// PhaseDescribe describes the clusters and runs the enrichments.
+	PhaseDescribe = "describe"
+)
+
+// Phases lists the scan phases in the order they run
+var Phases = []string{PhaseRegions, PhaseList, PhaseDescribe}
+
+// PhaseTimeoutError is returned when a scan phase exceeds its WithPhaseTimeout limit
+type PhaseTimeoutError struct {
+	Phase   string
+	Timeout time.Duration
+	// Err is the error the phase returned when its deadline passed, if any.
+	Err error
+}
+
+func (e *PhaseTimeoutError) Error() string {
+	return fmt.Sprintf("%s phase timed out after %s", e.Phase, e.Timeout)
+}
+
+func (e *PhaseTimeoutError) Unwrap() error {
+	return e.Err
+}
+
+// WithPhaseTimeout bounds one scan phase (PhaseRegions, PhaseList or
+// PhaseDescribe) independently of the others. A phase that runs out of time
+// fails the scan with a PhaseTimeoutError; a describe phase timeout still
+// returns the clusters collected so far in an Incomplete result.
+func WithPhaseTimeout(phase string, d time.Duration) Option {
+	return func(s *Scanner) {
+		if s.phaseTimeouts == nil {
+			s.phaseTimeouts = make(map[string]time.Duration)
+		}
+		s.phaseTimeouts[phase] = d
+	}
+}
+
+// runPhase runs fn within the timeout of phase, records how long it took in
+// the stats and reports a passed phase deadline as a PhaseTimeoutError
+func (s *Scanner) runPhase(ctx context.Context, phase string, fn func(ctx context.Context) error) error {
+	start := time.Now()
+	phaseCtx := ctx
+	timeout := s.phaseTimeouts[phase]
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		phaseCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	err := fn(phaseCtx)
+	if s.stats != nil {
+		s.stats.recordPhase(phase, time.Since(start))
+	}
+	if ctx.Err() == nil && errors.Is(phaseCtx.Err(), context.DeadlineExceeded) {
+		return &PhaseTimeoutError{Phase: phase, Timeout: timeout, Err: err}
+	}
+	return err
+}
@@ -0,0 +1,64 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/eks/types"
+)
+
+func TestPhaseTimeouts(t *testing.T) {
+	tests := []struct {
+		name       string
+		phase      string
+		client     func(*fakeEKS) *slowEKS
+		wantResult bool
+	}{
+		{
+			name:   "list",
+			phase:  PhaseList,
+			client: func(c *fakeEKS) *slowEKS { return &slowEKS{fakeEKS: c, slowList: true} },
+		},
+		{
+			name:       "describe keeps the listed clusters",
+			phase:      PhaseDescribe,
+			client:     func(c *fakeEKS) *slowEKS { return &slowEKS{fakeEKS: c, slow: map[string]bool{"prod": true}} },
+			wantResult: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFakeFactory(map[string][]types.Cluster{"us-east-1": {fakeCluster("prod", "1.31")}})
+			client := tt.client(f.region("us-east-1"))
+			s := NewScanner(WithClientFactory(&singleEKSFactory{fakeFactory: f, client: client}), WithRegions("us-east-1"), WithPhaseTimeout(tt.phase, 10*time.Millisecond))
+
+			result, err := s.Run(context.Background())
+			if (result != nil) != tt.wantResult {
+				t.Fatalf("result = %+v, want one: %t", result, tt.wantResult)
+			}
+			var timeout *PhaseTimeoutError
+			if !errors.As(err, &timeout) || timeout.Phase != tt.phase || timeout.Timeout != 10*time.Millisecond {
+				t.Fatalf("err = %v, want a %s PhaseTimeoutError", err, tt.phase)
+			}
+			if want := tt.phase + " phase timed out after 10ms"; timeout.Error() != want {
+				t.Errorf("error = %q, want %q", timeout.Error(), want)
+			}
+			if tt.wantResult && (!result.Incomplete || len(result.Clusters) != 1 || result.Clusters[0].Name != "prod") {
+				t.Errorf("result = incomplete %t, clusters %+v; want the listed cluster", result.Incomplete, result.Clusters)
+			}
+		})
+	}
+}
+
+func TestPhaseTimeoutIgnoresParentCancellation(t *testing.T) {
+	s := NewScanner(WithPhaseTimeout(PhaseList, time.Hour))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := s.runPhase(ctx, PhaseList, func(ctx context.Context) error { return ctx.Err() })
+	var timeout *PhaseTimeoutError
+	if errors.As(err, &timeout) || !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want the cancellation", err)
+	}
+}
@@ -0,0 +1,100 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/eks"
+	"github.com/aws/aws-sdk-go-v2/service/eks/types"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+)
+
+// Permission check outcomes
+const (
+	PermissionAllowed = "allowed"
+	PermissionDenied  = "denied"
+	PermissionError   = "error"
+)
+
+// preflightProbeCluster is described when the sample region has no clusters;
+// a not-found answer proves the caller may call DescribeCluster.
+const preflightProbeCluster = "shift-left-shuffle-preflight-probe"
+
+// PermissionCheck is the outcome of probing one IAM permission
+type PermissionCheck struct {
+	Permission string `json:"permission"`
+	Required   bool   `json:"required"`
+	Status     string `json:"status"`
+	Error      string `json:"error,omitempty"`
+}
+
+// Passed reports whether the check does not block a scan
+func (c PermissionCheck) Passed() bool {
+	return !c.Required || c.Status == PermissionAllowed
+}
+
+// Preflight calls each API the scan depends on once and reports whether the
+// caller is allowed to. ec2:DescribeRegions is optional because the scan falls
+// back to a built-in region list without it.
+func (s *Scanner) Preflight(ctx context.Context, sampleRegion string) []PermissionCheck {
+	var checks []PermissionCheck
+
+	stsCheck := PermissionCheck{Permission: "sts:GetCallerIdentity", Required: true}
+	if client, err := s.factory.STS(ctx); err != nil {
+		stsCheck.record(err)
+	} else {
+		_, err = client.GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+		stsCheck.record(err)
+	}
+	checks = append(checks, stsCheck)
+
+	ec2Check := PermissionCheck{Permission: "ec2:DescribeRegions"}
+	if client, err := s.factory.EC2(ctx, ""); err != nil {
+		ec2Check.record(err)
+	} else {
+		_, err = client.DescribeRegions(ctx, &ec2.DescribeRegionsInput{})
+		ec2Check.record(err)
+	}
+	checks = append(checks, ec2Check)
+
+	listCheck := PermissionCheck{Permission: "eks:ListClusters", Required: true}
+	describeCheck := PermissionCheck{Permission: "eks:DescribeCluster", Required: true}
+	client, err := s.factory.EKS(ctx, sampleRegion)
+	if err != nil {
+		listCheck.record(err)
+		describeCheck.record(err)
+		return append(checks, listCheck, describeCheck)
+	}
+
+	probe := preflightProbeCluster
+	listed, err := client.ListClusters(ctx, &eks.ListClustersInput{MaxResults: aws.Int32(1)})
+	listCheck.record(err)
+	if err == nil && len(listed.Clusters) > 0 {
+		probe = listed.Clusters[0]
+	}
+
+	_, err = client.DescribeCluster(ctx, &eks.DescribeClusterInput{Name: aws.String(probe)})
+	var notFound *types.ResourceNotFoundException
+	if errors.As(err, &notFound) {
+		err = nil
+	}
+	describeCheck.record(err)
+
+	return append(checks, listCheck, describeCheck)
+}
+
+// record classifies the error returned by a probe call
+func (c *PermissionCheck) record(err error) {
+	switch {
+	case err == nil:
+		c.Status = PermissionAllowed
+	case isAccessDenied(err):
+		c.Status = PermissionDenied
+		c.Error = err.Error()
+	default:
+		c.Status = PermissionError
+		c.Error = err.Error()
+	}
+}
@@ -0,0 +1,92 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/eks/types"
+	"github.com/aws/smithy-go"
+)
+
+func TestPreflight(t *testing.T) {
+	denied := &smithy.GenericAPIError{Code: "AccessDeniedException", Message: "not authorized"}
+	tests := []struct {
+		name       string
+		clusters   []types.Cluster
+		setup      func(f *fakeFactory)
+		wantStatus []string
+		wantPassed bool
+	}{
+		{
+			name:       "all allowed",
+			clusters:   []types.Cluster{fakeCluster("prod", "1.31")},
+			wantStatus: []string{PermissionAllowed, PermissionAllowed, PermissionAllowed, PermissionAllowed},
+			wantPassed: true,
+		},
+		{
+			name:       "no clusters to describe",
+			wantStatus: []string{PermissionAllowed, PermissionAllowed, PermissionAllowed, PermissionAllowed},
+			wantPassed: true,
+		},
+		{
+			name:       "caller identity denied",
+			clusters:   []types.Cluster{fakeCluster("prod", "1.31")},
+			setup:      func(f *fakeFactory) { f.stsErr = denied },
+			wantStatus: []string{PermissionDenied, PermissionAllowed, PermissionAllowed, PermissionAllowed},
+		},
+		{
+			name:       "describe regions optional",
+			clusters:   []types.Cluster{fakeCluster("prod", "1.31")},
+			setup:      func(f *fakeFactory) { f.ec2.err = denied },
+			wantStatus: []string{PermissionAllowed, PermissionDenied, PermissionAllowed, PermissionAllowed},
+			wantPassed: true,
+		},
+		{
+			name:       "list denied probes a placeholder",
+			clusters:   []types.Cluster{fakeCluster("prod", "1.31")},
+			setup:      func(f *fakeFactory) { f.region("us-east-1").listErr = denied },
+			wantStatus: []string{PermissionAllowed, PermissionAllowed, PermissionDenied, PermissionAllowed},
+		},
+		{
+			name:     "describe of the listed cluster denied",
+			clusters: []types.Cluster{fakeCluster("prod", "1.31")},
+			setup: func(f *fakeFactory) {
+				f.region("us-east-1").describeErr = map[string]error{"prod": denied}
+			},
+			wantStatus: []string{PermissionAllowed, PermissionAllowed, PermissionAllowed, PermissionDenied},
+		},
+		{
+			name:       "other failure",
+			clusters:   []types.Cluster{fakeCluster("prod", "1.31")},
+			setup:      func(f *fakeFactory) { f.region("us-east-1").listErr = errors.New("connection reset") },
+			wantStatus: []string{PermissionAllowed, PermissionAllowed, PermissionError, PermissionAllowed},
+		},
+	}
+	permissions := []string{"sts:GetCallerIdentity", "ec2:DescribeRegions", "eks:ListClusters", "eks:DescribeCluster"}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFakeFactory(map[string][]types.Cluster{"us-east-1": tt.clusters})
+			if tt.setup != nil {
+				tt.setup(f)
+			}
+			checks := NewScanner(WithClientFactory(f)).Preflight(context.Background(), "us-east-1")
+			if len(checks) != len(permissions) {
+				t.Fatalf("%d checks, want %d: %+v", len(checks), len(permissions), checks)
+			}
+			passed := true
+			for i, check := range checks {
+				if check.Permission != permissions[i] || check.Status != tt.wantStatus[i] {
+					t.Errorf("check %d = %s %s, want %s %s", i, check.Permission, check.Status, permissions[i], tt.wantStatus[i])
+				}
+				if (check.Status == PermissionAllowed) != (check.Error == "") {
+					t.Errorf("%s error = %q with status %s", check.Permission, check.Error, check.Status)
+				}
+				passed = passed && check.Passed()
+			}
+			if passed != tt.wantPassed {
+				t.Errorf("passed = %t, want %t", passed, tt.wantPassed)
+			}
+		})
+	}
+}
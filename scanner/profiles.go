@@ -0,0 +1,151 @@
+package scanner
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+)
+
+// ProfilesResult aggregates the scans of several shared-config profiles.
+// Profiles whose scan failed after listing clusters are kept in Profiles,
+// marked Incomplete with their error; Incomplete is set when there is one.
+type ProfilesResult struct {
+	SchemaVersion int              `json:"schemaVersion"`
+	GeneratedAt   time.Time        `json:"generatedAt"`
+	ToolVersion   string           `json:"toolVersion"`
+	Profiles      []*ScanResult    `json:"profiles"`
+	Skipped       []SkippedProfile `json:"skipped,omitempty"`
+	Incomplete    bool             `json:"incomplete,omitempty"`
+	// Stats is set by the caller to the API call counts and timings of all
+	// profiles, as for ScanResult.Stats.
+	Stats *StatsCounts `json:"stats,omitempty"`
+}
+
+// SkippedProfile records a profile whose scan failed before it collected
+// anything, usually because its credentials could not be resolved
+type SkippedProfile struct {
+	Profile string `json:"profile"`
+	Error   string `json:"error"`
+}
+
+// SharedConfigPath returns the shared config file location, honoring AWS_CONFIG_FILE
+func SharedConfigPath() string {
+	if path := os.Getenv("AWS_CONFIG_FILE"); path != "" {
+		return path
+	}
+	return config.DefaultSharedConfigFilename()
+}
+
+// ListProfiles returns the profile names defined in the shared config file at path
+func ListProfiles(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return parseProfiles(f)
+}
+
+// parseProfiles extracts profile names from shared config section headers.
+// Both "[default]" and "[profile name]" are recognized; other sections such
+// as "[sso-session name]" are ignored.
+func parseProfiles(r io.Reader) ([]string, error) {
+	var profiles []string
+	seen := make(map[string]bool)
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if !strings.HasPrefix(line, "[") || !strings.HasSuffix(line, "]") {
+			continue
+		}
+		section := strings.TrimSpace(line[1 : len(line)-1])
+
+		var name string
+		switch {
+		case section == "default":
+			name = section
+		case strings.HasPrefix(section, "profile "):
+			name = strings.TrimSpace(strings.TrimPrefix(section, "profile "))
+		default:
+			continue
+		}
+		if name != "" && !seen[name] {
+			seen[name] = true
+			profiles = append(profiles, name)
+		}
+	}
+	return profiles, sc.Err()
+}
+
+// ScanProfiles runs one scan per profile with opts plus WithProfile. Profiles
+// whose scan fails are skipped with a warning instead of aborting the others,
+// unless the scan failed late: their partial result is kept, as Run returns it.
+// Profiles listed by WithProfileRegions are scanned in their mapped regions only.
+// Up to WithAccountConcurrency profiles are scanned at once, each with its own
+// scanner and credentials; results keep the order of profiles. The scanners
+// share one rate limiter and one adaptive describe limit, so WithRateLimit and
+// WithAdaptiveConcurrency hold across all accounts rather than per account.
+func ScanProfiles(ctx context.Context, profiles []string, opts ...Option) *ProfilesResult {
+	result := &ProfilesResult{
+		SchemaVersion: SchemaVersion,
+		GeneratedAt:   time.Now().UTC(),
+		ToolVersion:   Version,
+		Profiles:      []*ScanResult{},
+	}
+
+	scans := make([]*ScanResult, len(profiles))
+	errs := make([]error, len(profiles))
+	base := NewScanner(opts...)
+	shared := append(append([]Option{}, opts...), WithLimiter(base.limiter), withDescribeLimit(base.describeLimit))
+	_ = base.forEach(len(profiles), base.accountConcurrency, func(i int) error {
+		profile := profiles[i]
+		s := NewScanner(append(shared[:len(shared):len(shared)], WithProfile(profile))...)
+		if regions, ok := s.profileRegions[profile]; ok {
+			s.regions = regions
+		}
+		s.logf("Scanning profile: %s\n", profile)
+
+		scans[i], errs[i] = s.Run(ctx)
+		switch {
+		case errs[i] != nil && scans[i] != nil:
+			s.logf("Warning: scan of profile %s is incomplete: %v\n", profile, errs[i])
+		case errs[i] != nil:
+			s.logf("Warning: skipping profile %s: %v\n", profile, errs[i])
+		}
+		return nil
+	})
+
+	for i, profile := range profiles {
+		if scans[i] == nil {
+			result.Skipped = append(result.Skipped, SkippedProfile{Profile: profile, Error: errs[i].Error()})
+			continue
+		}
+		scans[i].Profile = profile
+		if errs[i] != nil {
+			scans[i].Incomplete = true
+			scans[i].Error = errs[i].Error()
+			result.Incomplete = true
+		}
+		result.Profiles = append(result.Profiles, scans[i])
+	}
+	return result
+}
+
+// Err returns the errors of the Incomplete profiles, or nil when every
+// profile that was not skipped completed
+func (r *ProfilesResult) Err() error {
+	var errs []error
+	for _, scan := range r.Profiles {
+		if scan.Incomplete {
+			errs = append(errs, fmt.Errorf("profile %s: %s", scan.Profile, scan.Error))
+		}
+	}
+	return errors.Join(errs...)
+}
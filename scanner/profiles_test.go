@@ -0,0 +1,203 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/eks/types"
+)
+
+func TestParseProfiles(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		want   []string
+	}{
+		{name: "empty", config: ""},
+		{
+			name: "default and named",
+			config: `[default]
+region = us-east-1
+
+[profile dev]
+region = eu-west-1
+[ profile  prod ]
+`,
+			want: []string{"default", "dev", "prod"},
+		},
+		{
+			name: "other sections ignored",
+			config: `[sso-session corp]
+sso_start_url = https://example.awsapps.com/start
+[services local]
+[profile sso]
+sso_session = corp
+`,
+			want: []string{"sso"},
+		},
+		{name: "duplicates kept once", config: "[profile dev]\n[profile dev]\n[default]\n", want: []string{"dev", "default"}},
+		{name: "unnamed profile", config: "[profile ]\n[profile]\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseProfiles(strings.NewReader(tt.config))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("profiles = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScanProfiles(t *testing.T) {
+	f := newFakeFactory(map[string][]types.Cluster{
+		"us-east-1":  {fakeCluster("dev", "1.31")},
+		"eu-west-1":  {fakeCluster("partial", "1.30")},
+		"ap-south-1": {fakeCluster("unlisted", "1.30")},
+	})
+	f.region("eu-west-1").accessErr = errors.New("access denied")
+	f.region("ap-south-1").listErr = errors.New("list denied")
+
+	result := ScanProfiles(context.Background(), []string{"dev", "partial"},
+		WithClientFactory(f),
+		WithProfileRegions(map[string][]string{"dev": {"us-east-1"}, "partial": {"eu-west-1", "ap-south-1"}}),
+		WithAccessEntries(""),
+	)
+
+	if len(result.Skipped) != 0 {
+		t.Errorf("skipped = %v, want none", result.Skipped)
+	}
+	if len(result.Profiles) != 2 {
+		t.Fatalf("profiles = %d, want 2", len(result.Profiles))
+	}
+	if !result.Incomplete {
+		t.Error("result not marked incomplete")
+	}
+	if err := result.Err(); err == nil || !strings.Contains(err.Error(), "profile partial") || strings.Contains(err.Error(), "profile dev") {
+		t.Errorf("Err() = %v, want only the partial profile", err)
+	}
+
+	dev, partial := result.Profiles[0], result.Profiles[1]
+	if dev.Profile != "dev" || dev.Incomplete || dev.Error != "" || len(dev.Clusters) != 1 {
+		t.Errorf("dev = profile %q, incomplete %t, error %q, %d clusters; want complete with 1 cluster", dev.Profile, dev.Incomplete, dev.Error, len(dev.Clusters))
+	}
+	if partial.Profile != "partial" || !partial.Incomplete || !strings.Contains(partial.Error, "access denied") {
+		t.Errorf("partial = profile %q, incomplete %t, error %q; want incomplete with the access entries error", partial.Profile, partial.Incomplete, partial.Error)
+	}
+	if len(partial.Clusters) != 1 || partial.Clusters[0].Name != "partial" || partial.Clusters[0].Endpoint == "" {
+		t.Errorf("partial clusters = %+v, want the described partial cluster", partial.Clusters)
+	}
+	if len(partial.RegionErrors) != 1 || partial.RegionErrors[0].Region != "ap-south-1" {
+		t.Errorf("partial region errors = %+v, want ap-south-1", partial.RegionErrors)
+	}
+}
+
+func TestScanProfilesRegionMap(t *testing.T) {
+	f := newFakeFactory(map[string][]types.Cluster{
+		"us-east-1":  {fakeCluster("east", "1.31")},
+		"eu-west-1":  {fakeCluster("west", "1.31")},
+		"ap-south-1": {fakeCluster("south", "1.31")},
+	})
+	tests := []struct {
+		name         string
+		regionMap    map[string][]string
+		wantRegions  map[string][]string
+		wantClusters map[string][]string
+	}{
+		{
+			name:         "no map",
+			wantRegions:  map[string][]string{"dev": {"ap-south-1", "eu-west-1", "us-east-1"}, "prod": {"ap-south-1", "eu-west-1", "us-east-1"}},
+			wantClusters: map[string][]string{"dev": {"east", "south", "west"}, "prod": {"east", "south", "west"}},
+		},
+		{
+			name:         "each profile in its regions",
+			regionMap:    map[string][]string{"dev": {"eu-west-1"}, "prod": {"us-east-1", "ap-south-1"}},
+			wantRegions:  map[string][]string{"dev": {"eu-west-1"}, "prod": {"ap-south-1", "us-east-1"}},
+			wantClusters: map[string][]string{"dev": {"west"}, "prod": {"east", "south"}},
+		},
+		{
+			name:         "unmapped profile scanned as usual",
+			regionMap:    map[string][]string{"prod": {"us-east-1"}},
+			wantRegions:  map[string][]string{"dev": {"ap-south-1", "eu-west-1", "us-east-1"}, "prod": {"us-east-1"}},
+			wantClusters: map[string][]string{"dev": {"east", "south", "west"}, "prod": {"east"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := ScanProfiles(context.Background(), []string{"dev", "prod"},
+				WithClientFactory(f), WithRegions(f.ec2.regions...), WithProfileRegions(tt.regionMap))
+			if len(result.Profiles) != 2 || result.Err() != nil {
+				t.Fatalf("%d profiles, Err() %v; want 2 complete", len(result.Profiles), result.Err())
+			}
+			for _, scan := range result.Profiles {
+				regions := slices.Sorted(slices.Values(scan.Regions))
+				var names []string
+				for _, c := range scan.Clusters {
+					names = append(names, c.Name)
+				}
+				slices.Sort(names)
+				if !slices.Equal(regions, tt.wantRegions[scan.Profile]) || !slices.Equal(names, tt.wantClusters[scan.Profile]) {
+					t.Errorf("%s scanned regions %q with clusters %q, want %q with %q", scan.Profile, regions, names, tt.wantRegions[scan.Profile], tt.wantClusters[scan.Profile])
+				}
+			}
+		})
+	}
+}
+
+func TestScanProfilesSkipsFailedCredentials(t *testing.T) {
+	f := newFakeFactory(map[string][]types.Cluster{"us-east-1": {fakeCluster("dev", "1.31")}})
+	f.stsErr = errors.New("no credentials")
+
+	result := ScanProfiles(context.Background(), []string{"dev", "prod"}, WithClientFactory(f), WithRegions("us-east-1"))
+
+	if len(result.Profiles) != 0 || result.Incomplete || result.Err() != nil {
+		t.Errorf("profiles = %d, incomplete %t, Err() %v; want none", len(result.Profiles), result.Incomplete, result.Err())
+	}
+	var skipped []string
+	for _, s := range result.Skipped {
+		skipped = append(skipped, s.Profile)
+		if !strings.Contains(s.Error, "no credentials") {
+			t.Errorf("skipped %s error = %q, want the credentials error", s.Profile, s.Error)
+		}
+	}
+	if !slices.Equal(skipped, []string{"dev", "prod"}) {
+		t.Errorf("skipped = %q, want dev and prod", skipped)
+	}
+}
+
+func TestScanProfilesConcurrency(t *testing.T) {
+	profiles := []string{"a", "b", "c", "d", "e", "f"}
+	tests := []struct {
+		name         string
+		opts         []Option
+		wantList     int
+		wantDescribe int
+	}{
+		{name: "one profile at a time", wantList: 1, wantDescribe: 1},
+		{name: "profiles in parallel", opts: []Option{WithAccountConcurrency(3)}, wantList: 3, wantDescribe: 3},
+		{name: "adaptive limit shared across profiles", opts: []Option{WithAccountConcurrency(3), WithAdaptiveConcurrency()}, wantList: 3, wantDescribe: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &gaugedFactory{fakeFactory: newFakeFactory(map[string][]types.Cluster{"us-east-1": {fakeCluster("prod", "1.31")}})}
+			opts := append([]Option{WithClientFactory(f), WithRegions("us-east-1"), WithConcurrency(1)}, tt.opts...)
+			result := ScanProfiles(context.Background(), profiles, opts...)
+			if len(result.Profiles) != len(profiles) {
+				t.Fatalf("%d profiles scanned, want %d", len(result.Profiles), len(profiles))
+			}
+			for i, scan := range result.Profiles {
+				if scan.Profile != profiles[i] {
+					t.Errorf("profile %d = %s, want %s", i, scan.Profile, profiles[i])
+				}
+			}
+			if f.list.peak > tt.wantList || f.describe.peak > tt.wantDescribe {
+				t.Errorf("peak concurrency = %d listing, %d describing; want at most %d and %d", f.list.peak, f.describe.peak, tt.wantList, tt.wantDescribe)
+			}
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package scanner
+
+import (
+	"context"
+
+	"github.com/aws/smithy-go/middleware"
+	"golang.org/x/time/rate"
+)
+
+// WithLimiter paces outgoing AWS calls, including retries, with limiter
+// instead of one built by WithRateLimit. Scanners given the same limiter
+// share its budget, so several scans running at once stay within one rate.
+// Like WithRateLimit it applies to the default client factory only.
+func WithLimiter(limiter *rate.Limiter) Option {
+	return func(s *Scanner) {
+		s.limiter = limiter
+	}
+}
+
+// rateLimitMiddleware waits for limiter before every request attempt. It is
+// added at the end of the finalize step, after the retry middleware, so
+// retried attempts are paced as well.
+func rateLimitMiddleware(limiter *rate.Limiter) func(*middleware.Stack) error {
+	return func(stack *middleware.Stack) error {
+		return stack.Finalize.Add(middleware.FinalizeMiddlewareFunc("RateLimit",
+			func(ctx context.Context, in middleware.FinalizeInput, next middleware.FinalizeHandler) (middleware.FinalizeOutput, middleware.Metadata, error) {
+				if err := limiter.Wait(ctx); err != nil {
+					return middleware.FinalizeOutput{}, middleware.Metadata{}, err
+				}
+				return next.HandleFinalize(ctx, in)
+			}), middleware.After)
+	}
+}
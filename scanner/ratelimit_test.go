@@ -0,0 +1,60 @@
+package scanner
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+func TestNewScannerLimiter(t *testing.T) {
+	given := rate.NewLimiter(2, 1)
+	tests := []struct {
+		name      string
+		opts      []Option
+		wantNil   bool
+		wantLimit rate.Limit
+		wantGiven bool
+	}{
+		{name: "unlimited", wantNil: true},
+		{name: "rate limit", opts: []Option{WithRateLimit(5)}, wantLimit: 5},
+		{name: "given limiter wins", opts: []Option{WithRateLimit(5), WithLimiter(given)}, wantLimit: 2, wantGiven: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewScanner(append([]Option{WithClientFactory(newFakeFactory(nil))}, tt.opts...)...)
+			if tt.wantNil {
+				if s.limiter != nil {
+					t.Errorf("limiter = %v, want none", s.limiter)
+				}
+				return
+			}
+			if s.limiter == nil || s.limiter.Limit() != tt.wantLimit || s.limiter.Burst() != 1 || (s.limiter == given) != tt.wantGiven {
+				t.Errorf("limiter = %+v, want %v calls per second with a burst of 1", s.limiter, tt.wantLimit)
+			}
+		})
+	}
+}
+
+func TestRateLimitMiddlewarePacesRetries(t *testing.T) {
+	// One token, refilled long after the test: the retry of the throttled
+	// first attempt has to wait and gives up with the context
+	limiter := rate.NewLimiter(rate.Every(time.Hour), 1)
+	client := &throttlingHTTPClient{throttle: map[int]bool{1: true}}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err := sendRequest(ctx, t, client, 3, rateLimitMiddleware(limiter))
+	if err == nil {
+		t.Fatal("request succeeded without a token for its retry")
+	}
+	if client.calls != 1 {
+		t.Errorf("%d attempts sent, want only the first", client.calls)
+	}
+
+	client = &throttlingHTTPClient{}
+	if err := sendRequest(context.Background(), t, client, 3, rateLimitMiddleware(rate.NewLimiter(rate.Inf, 1))); err != nil || client.calls != 1 {
+		t.Errorf("unlimited request = %v after %d attempts", err, client.calls)
+	}
+}
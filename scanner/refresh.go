@@ -0,0 +1,61 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/smithy-go"
+)
+
+// maxCredentialRefreshes caps how many times a scan reloads expired credentials
+const maxCredentialRefreshes = 3
+
+// expiredTokenCodes are the API error codes for expired temporary credentials
+var expiredTokenCodes = map[string]bool{
+	"ExpiredToken":          true,
+	"ExpiredTokenException": true,
+}
+
+// isExpiredToken reports whether err was caused by expired credentials
+func isExpiredToken(err error) bool {
+	var apiErr smithy.APIError
+	return errors.As(err, &apiErr) && expiredTokenCodes[apiErr.ErrorCode()]
+}
+
+// withRefresh runs call and, when it fails on expired credentials, refreshes
+// the client factory and runs it again. Concurrent callers that fail on the
+// same credentials share a single refresh. Factories that do not implement
+// Refresher, and scans that used up maxCredentialRefreshes, return the error.
+// Every API call of a scan goes through it: account lookup, DescribeRegions,
+// listing, describes, including those of Describe and DescribeARNs, and the
+// enrichments. call must create its clients itself so that a retry after a
+// refresh uses the reloaded credentials.
+func (s *Scanner) withRefresh(ctx context.Context, call func() error) error {
+	refresher, ok := s.factory.(Refresher)
+	for {
+		s.refreshMu.Lock()
+		generation := s.refreshes
+		s.refreshMu.Unlock()
+
+		err := call()
+		if !ok || !isExpiredToken(err) {
+			return err
+		}
+
+		s.refreshMu.Lock()
+		if s.refreshes == generation {
+			if s.refreshes >= maxCredentialRefreshes {
+				s.refreshMu.Unlock()
+				return fmt.Errorf("credentials expired again after %d refreshes: %w", maxCredentialRefreshes, err)
+			}
+			s.logf("Credentials expired; reloading configuration (refresh %d of %d)\n", s.refreshes+1, maxCredentialRefreshes)
+			if refreshErr := refresher.Refresh(ctx); refreshErr != nil {
+				s.refreshMu.Unlock()
+				return fmt.Errorf("refreshing expired credentials: %w", refreshErr)
+			}
+			s.refreshes++
+		}
+		s.refreshMu.Unlock()
+	}
+}
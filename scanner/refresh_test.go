@@ -0,0 +1,113 @@
+package scanner
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/eks/types"
+)
+
+func TestScanRefreshesExpiredCredentials(t *testing.T) {
+	tests := []struct {
+		name string
+		api  string
+		scan func(s *Scanner, ctx context.Context) (*ScanResult, error)
+		opts []Option
+	}{
+		{name: "account lookup", api: "GetCallerIdentity"},
+		{name: "regions", api: "DescribeRegions"},
+		{name: "listing", api: "ListClusters"},
+		{name: "describe", api: "DescribeCluster"},
+		{
+			name: "describe by ARN",
+			api:  "DescribeCluster",
+			scan: func(s *Scanner, ctx context.Context) (*ScanResult, error) {
+				arn, err := ParseClusterARN("arn:aws:eks:us-east-1:123456789012:cluster/prod")
+				if err != nil {
+					return nil, err
+				}
+				return s.DescribeARNs(ctx, []ClusterARN{arn})
+			},
+		},
+		{
+			name: "describe by name",
+			api:  "DescribeCluster",
+			scan: func(s *Scanner, ctx context.Context) (*ScanResult, error) {
+				return s.Describe(ctx, "us-east-1", []string{"prod"})
+			},
+		},
+		{name: "access entries", api: "ListAccessEntries", opts: []Option{WithAccessEntries("")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFakeFactory(map[string][]types.Cluster{"us-east-1": {fakeCluster("prod", "1.31")}})
+			f.region("us-east-1").accessEntries = map[string][]string{"prod": {"arn:aws:iam::123456789012:role/admin"}}
+			f.expire(tt.api)
+			rf := &refreshingFactory{fakeFactory: f}
+			opts := append([]Option{WithClientFactory(rf), WithOutput(io.Discard)}, tt.opts...)
+			s := NewScanner(opts...)
+
+			scan := tt.scan
+			if scan == nil {
+				scan = (*Scanner).Run
+			}
+			result, err := scan(s, context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if rf.refreshes != 1 {
+				t.Errorf("refreshes = %d, want 1", rf.refreshes)
+			}
+			if len(result.Clusters) != 1 {
+				t.Fatalf("clusters = %d, want 1", len(result.Clusters))
+			}
+			c := result.Clusters[0]
+			if c.DescribeError != "" || c.Endpoint == "" {
+				t.Errorf("cluster not described after refresh: endpoint %q, error %q", c.Endpoint, c.DescribeError)
+			}
+			if tt.api == "ListAccessEntries" && len(c.AccessEntries) != 1 {
+				t.Errorf("access entries = %v, want one", c.AccessEntries)
+			}
+		})
+	}
+}
+
+func TestScanRefreshLimits(t *testing.T) {
+	t.Run("factory without Refresher", func(t *testing.T) {
+		f := newFakeFactory(map[string][]types.Cluster{"us-east-1": {fakeCluster("prod", "1.31")}})
+		f.expire("DescribeCluster")
+		result, err := newFakeScanner(f, WithOutput(io.Discard)).Run(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := result.Clusters[0].DescribeError; !strings.Contains(got, "ExpiredTokenException") {
+			t.Errorf("describe error = %q, want the expired token", got)
+		}
+	})
+
+	t.Run("credentials that stay expired", func(t *testing.T) {
+		f := newFakeFactory(map[string][]types.Cluster{"us-east-1": {fakeCluster("prod", "1.31")}})
+		rf := &stuckFactory{fakeFactory: f}
+		f.expire("GetCallerIdentity")
+		_, err := NewScanner(WithClientFactory(rf), WithOutput(io.Discard)).Run(context.Background())
+		if err == nil || !strings.Contains(err.Error(), "after 3 refreshes") {
+			t.Fatalf("err = %v, want the refresh cap", err)
+		}
+		if rf.refreshes != maxCredentialRefreshes {
+			t.Errorf("refreshes = %d, want %d", rf.refreshes, maxCredentialRefreshes)
+		}
+	})
+}
+
+// stuckFactory refreshes without ever clearing the expired credentials
+type stuckFactory struct {
+	*fakeFactory
+	refreshes int
+}
+
+func (f *stuckFactory) Refresh(ctx context.Context) error {
+	f.refreshes++
+	return nil
+}
@@ -0,0 +1,69 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/eks/types"
+)
+
+func TestCountRegions(t *testing.T) {
+	regions := []string{"us-east-1", "eu-west-1", "ap-south-1"}
+	clusters := []Cluster{{Name: "a", Region: "us-east-1"}, {Name: "b", Region: "us-east-1"}, {Name: "c", Region: "eu-west-1"}}
+	regionErrs := []RegionError{{Region: "ap-south-1", Error: "throttled"}}
+	tests := []struct {
+		name    string
+		elapsed []time.Duration
+		want    []RegionCount
+	}{
+		{
+			name: "not listed",
+			want: []RegionCount{{Region: "us-east-1", Clusters: 2}, {Region: "eu-west-1", Clusters: 1}, {Region: "ap-south-1", Failed: true}},
+		},
+		{
+			name:    "listing times",
+			elapsed: []time.Duration{time.Second, 2 * time.Second, 3 * time.Second},
+			want: []RegionCount{
+				{Region: "us-east-1", Clusters: 2, Elapsed: time.Second},
+				{Region: "eu-west-1", Clusters: 1, Elapsed: 2 * time.Second},
+				{Region: "ap-south-1", Failed: true, Elapsed: 3 * time.Second},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := countRegions(regions, clusters, regionErrs, tt.elapsed); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("countRegions = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunRecordsScannedRegions(t *testing.T) {
+	f := newFakeFactory(map[string][]types.Cluster{
+		"us-east-1":  {fakeCluster("prod", "1.31"), fakeCluster("dev", "1.31")},
+		"eu-west-1":  nil,
+		"ap-south-1": nil,
+	})
+	f.region("ap-south-1").listErr = errors.New("throttled")
+	result, err := newFakeScanner(f).Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []RegionCount{{Region: "ap-south-1", Failed: true}, {Region: "eu-west-1"}, {Region: "us-east-1", Clusters: 2}}
+	if len(result.ScannedRegions) != len(want) {
+		t.Fatalf("scanned regions = %+v, want %+v", result.ScannedRegions, want)
+	}
+	for i, count := range result.ScannedRegions {
+		if count.Elapsed <= 0 {
+			t.Errorf("%s has no listing time", count.Region)
+		}
+		count.Elapsed = 0
+		if count != want[i] {
+			t.Errorf("scanned region %d = %+v, want %+v", i, count, want[i])
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package scanner
+
+import (
+	"math/rand/v2"
+	"slices"
+	"strings"
+)
+
+// Region is an AWS region as returned by DescribeRegions
+type Region struct {
+	Name string `json:"name"`
+	// OptInStatus is "opt-in-not-required", "opted-in" or "not-opted-in".
+	OptInStatus string `json:"optInStatus"`
+}
+
+// Enabled reports whether the account can use the region. Regions enabled by
+// default report "opt-in-not-required"; opt-in regions report "opted-in" once
+// the account has enabled them.
+func (r Region) Enabled() bool {
+	return r.OptInStatus == "opt-in-not-required" || r.OptInStatus == "opted-in"
+}
+
+// prioritize returns regions with the priority regions first, in priority
+// order, followed by the remaining regions in their original order
+func prioritize(regions, priority []string) []string {
+	if len(priority) == 0 {
+		return regions
+	}
+	ordered := make([]string, 0, len(regions))
+	taken := make(map[string]bool, len(priority))
+	for _, p := range priority {
+		if !taken[p] && slices.Contains(regions, p) {
+			taken[p] = true
+			ordered = append(ordered, p)
+		}
+	}
+	for _, region := range regions {
+		if !taken[region] {
+			ordered = append(ordered, region)
+		}
+	}
+	return ordered
+}
+
+// fallbackRegions is used when ec2:DescribeRegions is denied. It lists the
+// commercial (aws partition) regions; keep it in sync with
+// https://docs.aws.amazon.com/global-infrastructure/latest/regions/aws-regions.html
+var fallbackRegions = []string{
+	"af-south-1",
+	"ap-east-1",
+	"ap-east-2",
+	"ap-northeast-1",
+	"ap-northeast-2",
+	"ap-northeast-3",
+	"ap-south-1",
+	"ap-south-2",
+	"ap-southeast-1",
+	"ap-southeast-2",
+	"ap-southeast-3",
+	"ap-southeast-4",
+	"ap-southeast-5",
+	"ap-southeast-6",
+	"ap-southeast-7",
+	"ca-central-1",
+	"ca-west-1",
+	"eu-central-1",
+	"eu-central-2",
+	"eu-north-1",
+	"eu-south-1",
+	"eu-south-2",
+	"eu-west-1",
+	"eu-west-2",
+	"eu-west-3",
+	"il-central-1",
+	"me-central-1",
+	"me-south-1",
+	"mx-central-1",
+	"sa-east-1",
+	"us-east-1",
+	"us-east-2",
+	"us-west-1",
+	"us-west-2",
+}
+
+// eksRegions lists the regions where Amazon EKS is available: every commercial
+// region in fallbackRegions plus AWS GovCloud (US) and China. Keep it in sync
+// with https://docs.aws.amazon.com/general/latest/gr/eks.html
+var eksRegions = append([]string{
+	"cn-north-1",
+	"cn-northwest-1",
+	"us-gov-east-1",
+	"us-gov-west-1",
+}, fallbackRegions...)
+
+// EKSAvailable reports whether Amazon EKS is known to be available in region
+func EKSAvailable(region string) bool {
+	return slices.Contains(eksRegions, region)
+}
+
+// splitEKSRegions separates the regions where EKS is available from the others,
+// preserving order
+func splitEKSRegions(regions []string) (supported, unsupported []string) {
+	for _, region := range regions {
+		if EKSAvailable(region) {
+			supported = append(supported, region)
+		} else {
+			unsupported = append(unsupported, region)
+		}
+	}
+	return supported, unsupported
+}
+
+// Partitions the scanner knows the regions of
+const (
+	PartitionAWS      = "aws"
+	PartitionAWSUSGov = "aws-us-gov"
+	PartitionAWSCN    = "aws-cn"
+)
+
+// RegionPartition returns the partition region belongs to, judged by its name
+func RegionPartition(region string) string {
+	switch {
+	case strings.HasPrefix(region, "us-gov-"):
+		return PartitionAWSUSGov
+	case strings.HasPrefix(region, "cn-"):
+		return PartitionAWSCN
+	default:
+		return PartitionAWS
+	}
+}
+
+// partitionFallbackRegions returns the built-in region list used in partition
+// when ec2:DescribeRegions is denied
+func partitionFallbackRegions(partition string) []string {
+	switch partition {
+	case PartitionAWSUSGov:
+		return []string{"us-gov-east-1", "us-gov-west-1"}
+	case PartitionAWSCN:
+		return []string{"cn-north-1", "cn-northwest-1"}
+	default:
+		return append([]string{}, fallbackRegions...)
+	}
+}
+
+// splitPartitionRegions separates the regions of partition from the others,
+// preserving order
+func splitPartitionRegions(regions []string, partition string) (in, out []string) {
+	for _, region := range regions {
+		if RegionPartition(region) == partition {
+			in = append(in, region)
+		} else {
+			out = append(out, region)
+		}
+	}
+	return in, out
+}
+
+// sampleRegions returns n regions chosen at random from regions with a
+// generator seeded by seed, in their original order. All regions are returned
+// when there are no more than n.
+func sampleRegions(regions []string, n int, seed uint64) []string {
+	if len(regions) <= n {
+		return regions
+	}
+	picked := rand.New(rand.NewPCG(seed, 0)).Perm(len(regions))[:n]
+	slices.Sort(picked)
+	sample := make([]string, 0, n)
+	for _, i := range picked {
+		sample = append(sample, regions[i])
+	}
+	return sample
+}
@@ -0,0 +1,296 @@
+package scanner
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/eks/types"
+	"github.com/aws/smithy-go"
+)
+
+func TestRunFallsBackWhenDescribeRegionsDenied(t *testing.T) {
+	tests := []struct {
+		name        string
+		err         error
+		wantRegions []string
+		wantErr     string
+	}{
+		{
+			name:        "access denied",
+			err:         &smithy.GenericAPIError{Code: "UnauthorizedOperation", Message: "You are not authorized to perform this operation."},
+			wantRegions: fallbackRegions,
+		},
+		{name: "other errors fail the scan", err: errors.New("connection reset"), wantErr: "describing regions: connection reset"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFakeFactory(map[string][]types.Cluster{"us-east-1": {fakeCluster("prod", "1.31")}})
+			f.ec2.err = tt.err
+			var log bytes.Buffer
+			result, err := NewScanner(WithClientFactory(f), WithOutput(&log)).Run(context.Background())
+			if tt.wantErr != "" {
+				if result != nil || err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("Run = %v, %v; want %q", result, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(result.Regions, tt.wantRegions) {
+				t.Errorf("regions = %q, want the built-in list", result.Regions)
+			}
+			if len(result.Clusters) != 1 || result.Clusters[0].Name != "prod" {
+				t.Errorf("clusters = %+v, want prod", result.Clusters)
+			}
+			if !strings.Contains(log.String(), "DescribeRegions denied") {
+				t.Errorf("log lacks the fallback warning:\n%s", log.String())
+			}
+		})
+	}
+}
+
+func TestRegionEnabled(t *testing.T) {
+	for status, want := range map[string]bool{"opt-in-not-required": true, "opted-in": true, "not-opted-in": false, "": false} {
+		if got := (Region{Name: "me-south-1", OptInStatus: status}).Enabled(); got != want {
+			t.Errorf("Enabled with status %q = %t, want %t", status, got, want)
+		}
+	}
+}
+
+func TestRunSkipsDisabledRegions(t *testing.T) {
+	tests := []struct {
+		name        string
+		opts        []Option
+		wantRegions []string
+	}{
+		{name: "opted in only", wantRegions: []string{"af-south-1", "us-east-1"}},
+		{name: "with disabled regions", opts: []Option{WithDisabledRegions()}, wantRegions: []string{"af-south-1", "me-south-1", "us-east-1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFakeFactory(map[string][]types.Cluster{"us-east-1": {fakeCluster("prod", "1.31")}, "af-south-1": nil, "me-south-1": nil})
+			f.ec2.optIn = map[string]string{"af-south-1": "opted-in", "me-south-1": "not-opted-in"}
+			result, err := NewScanner(append([]Option{WithClientFactory(f)}, tt.opts...)...).Run(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(result.Regions, tt.wantRegions) {
+				t.Errorf("regions = %q, want %q", result.Regions, tt.wantRegions)
+			}
+			if listed := f.region("me-south-1").listCalls > 0; listed != slices.Contains(tt.wantRegions, "me-south-1") {
+				t.Errorf("me-south-1 listed = %t", listed)
+			}
+		})
+	}
+}
+
+func TestPrioritize(t *testing.T) {
+	regions := []string{"ap-south-1", "eu-west-1", "us-east-1", "us-west-2"}
+	tests := []struct {
+		name     string
+		priority []string
+		want     []string
+	}{
+		{name: "none", want: regions},
+		{name: "moved to the front in priority order", priority: []string{"us-west-2", "eu-west-1"}, want: []string{"us-west-2", "eu-west-1", "ap-south-1", "us-east-1"}},
+		{name: "unscanned priorities ignored", priority: []string{"sa-east-1", "us-east-1"}, want: []string{"us-east-1", "ap-south-1", "eu-west-1", "us-west-2"}},
+		{name: "duplicates kept once", priority: []string{"us-east-1", "us-east-1"}, want: []string{"us-east-1", "ap-south-1", "eu-west-1", "us-west-2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := prioritize(regions, tt.priority); !slices.Equal(got, tt.want) {
+				t.Errorf("prioritize(%q) = %q, want %q", tt.priority, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunScansPriorityRegionsFirst(t *testing.T) {
+	f := newFakeFactory(map[string][]types.Cluster{
+		"ap-south-1": {fakeCluster("mumbai", "1.31")},
+		"eu-west-1":  {fakeCluster("dublin", "1.31")},
+		"us-east-1":  {fakeCluster("virginia", "1.31")},
+	})
+	result, err := newFakeScanner(f, WithPriorityRegions("us-east-1")).Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"us-east-1", "ap-south-1", "eu-west-1"}; !slices.Equal(result.Regions, want) {
+		t.Errorf("regions = %q, want %q", result.Regions, want)
+	}
+	if len(result.Clusters) != 3 || result.Clusters[0].Name != "virginia" {
+		t.Errorf("clusters = %+v, want virginia first", result.Clusters)
+	}
+}
+
+func TestSplitEKSRegions(t *testing.T) {
+	supported, unsupported := splitEKSRegions([]string{"us-east-1", "xx-north-9", "us-gov-west-1", "cn-north-1", "yy-east-1"})
+	if want := []string{"us-east-1", "us-gov-west-1", "cn-north-1"}; !slices.Equal(supported, want) {
+		t.Errorf("supported = %q, want %q", supported, want)
+	}
+	if want := []string{"xx-north-9", "yy-east-1"}; !slices.Equal(unsupported, want) {
+		t.Errorf("unsupported = %q, want %q", unsupported, want)
+	}
+}
+
+func TestRunSkipsRegionsWithoutEKS(t *testing.T) {
+	tests := []struct {
+		name        string
+		opts        []Option
+		wantRegions []string
+	}{
+		{name: "skipped", wantRegions: []string{"us-east-1"}},
+		{name: "all regions", opts: []Option{WithAllRegions()}, wantRegions: []string{"us-east-1", "xx-north-9"}},
+		{name: "explicit regions always scanned", opts: []Option{WithRegions("xx-north-9")}, wantRegions: []string{"xx-north-9"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFakeFactory(map[string][]types.Cluster{"us-east-1": {fakeCluster("prod", "1.31")}, "xx-north-9": nil})
+			var log bytes.Buffer
+			opts := append([]Option{WithClientFactory(f), WithOutput(&log)}, tt.opts...)
+			result, err := NewScanner(opts...).Run(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(result.Regions, tt.wantRegions) {
+				t.Errorf("regions = %q, want %q", result.Regions, tt.wantRegions)
+			}
+			if warned := strings.Contains(log.String(), "skipping regions without EKS: xx-north-9"); warned == slices.Contains(tt.wantRegions, "xx-north-9") {
+				t.Errorf("skip warning logged = %t:\n%s", warned, log.String())
+			}
+		})
+	}
+}
+
+func TestRegionPartition(t *testing.T) {
+	for region, want := range map[string]string{
+		"us-east-1":      PartitionAWS,
+		"us-gov-west-1":  PartitionAWSUSGov,
+		"cn-northwest-1": PartitionAWSCN,
+		"eu-west-1":      PartitionAWS,
+	} {
+		if got := RegionPartition(region); got != want {
+			t.Errorf("RegionPartition(%s) = %s, want %s", region, got, want)
+		}
+	}
+}
+
+func TestRunStaysInPartition(t *testing.T) {
+	denied := &smithy.GenericAPIError{Code: "AccessDenied"}
+	tests := []struct {
+		name          string
+		partition     string
+		regions       []string
+		describeErr   error
+		wantRegions   []string
+		wantWarning   string
+		wantPartition string
+	}{
+		{
+			name:          "foreign regions skipped",
+			partition:     PartitionAWSUSGov,
+			regions:       []string{"us-east-1", "us-gov-west-1", "cn-north-1"},
+			wantRegions:   []string{"us-gov-west-1"},
+			wantWarning:   "skipping regions outside partition aws-us-gov: us-east-1, cn-north-1",
+			wantPartition: PartitionAWSUSGov,
+		},
+		{
+			name:          "gov fallback",
+			partition:     PartitionAWSUSGov,
+			describeErr:   denied,
+			wantRegions:   []string{"us-gov-east-1", "us-gov-west-1"},
+			wantWarning:   "built-in list of 2 aws-us-gov regions",
+			wantPartition: PartitionAWSUSGov,
+		},
+		{
+			name:          "china fallback",
+			partition:     PartitionAWSCN,
+			describeErr:   denied,
+			wantRegions:   []string{"cn-north-1", "cn-northwest-1"},
+			wantWarning:   "built-in list of 2 aws-cn regions",
+			wantPartition: PartitionAWSCN,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFakeFactory(map[string][]types.Cluster{"us-gov-west-1": {fakeCluster("gov", "1.31")}})
+			f.partition = tt.partition
+			f.ec2.err = tt.describeErr
+			var log bytes.Buffer
+			result, err := NewScanner(WithClientFactory(f), WithRegions(tt.regions...), WithOutput(&log)).Run(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(result.Regions, tt.wantRegions) {
+				t.Errorf("regions = %q, want %q", result.Regions, tt.wantRegions)
+			}
+			if result.Partition != tt.wantPartition {
+				t.Errorf("partition = %q, want %q", result.Partition, tt.wantPartition)
+			}
+			if !strings.Contains(log.String(), tt.wantWarning) {
+				t.Errorf("log lacks %q:\n%s", tt.wantWarning, log.String())
+			}
+		})
+	}
+}
+
+func TestSampleRegions(t *testing.T) {
+	regions := []string{"ap-south-1", "eu-central-1", "eu-west-1", "sa-east-1", "us-east-1", "us-east-2", "us-west-2"}
+	tests := []struct {
+		name string
+		n    int
+		want int
+	}{
+		{name: "fewer regions than n", n: 10, want: 7},
+		{name: "as many regions as n", n: 7, want: 7},
+		{name: "sampled", n: 3, want: 3},
+		{name: "one", n: 1, want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := sampleRegions(regions, tt.n, 42)
+			if len(got) != tt.want {
+				t.Fatalf("sample = %q, want %d regions", got, tt.want)
+			}
+			if !slices.IsSortedFunc(got, func(a, b string) int {
+				return slices.Index(regions, a) - slices.Index(regions, b)
+			}) {
+				t.Errorf("sample %q is not in the original order", got)
+			}
+			if again := sampleRegions(regions, tt.n, 42); !slices.Equal(again, got) {
+				t.Errorf("seed 42 sampled %q then %q", got, again)
+			}
+		})
+	}
+}
+
+func TestRunSamplesRegions(t *testing.T) {
+	f := newFakeFactory(map[string][]types.Cluster{"ap-south-1": nil, "eu-west-1": nil, "sa-east-1": nil, "us-east-1": nil, "us-west-2": nil})
+	first, err := newFakeScanner(f, WithSampleRegions(2, 0)).Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(first.Regions) != 2 || first.SampledFrom != 5 || first.SampleSeed == 0 {
+		t.Fatalf("regions %q sampled from %d with seed %d; want 2 of 5 with a chosen seed", first.Regions, first.SampledFrom, first.SampleSeed)
+	}
+	again, err := newFakeScanner(f, WithSampleRegions(2, first.SampleSeed)).Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(again.Regions, first.Regions) || again.SampleSeed != first.SampleSeed {
+		t.Errorf("seed %d sampled %q, then %q", first.SampleSeed, first.Regions, again.Regions)
+	}
+
+	all, err := newFakeScanner(f, WithSampleRegions(5, 7)).Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all.Regions) != 5 || all.SampledFrom != 0 || all.SampleSeed != 0 {
+		t.Errorf("sample of every region = %q from %d with seed %d, want an unsampled scan", all.Regions, all.SampledFrom, all.SampleSeed)
+	}
+}
@@ -0,0 +1,102 @@
+package scanner
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// DefaultRegionsCacheTTL is how long a region list cached by WithRegionsCache
+// is reused before DescribeRegions is called again.
+const DefaultRegionsCacheTTL = 24 * time.Hour
+
+// regionsCacheMu serializes updates of region cache files by the scanners of
+// one process, such as concurrently scanned profiles
+var regionsCacheMu sync.Mutex
+
+// regionsCacheEntry is the region list DescribeRegions returned for an account
+type regionsCacheEntry struct {
+	Partition string    `json:"partition"`
+	FetchedAt time.Time `json:"fetchedAt"`
+	Regions   []Region  `json:"regions"`
+}
+
+// regionsCache maps account IDs to their cached region lists. Opt-in status
+// differs between accounts, so entries are kept per account; each records its
+// partition so an entry is never used for another partition. It is stored as
+// JSON in the cache file.
+type regionsCache map[string]regionsCacheEntry
+
+// WithRegionsCache reuses the region list of an earlier scan of the same
+// account, as recorded in the JSON file at path, for ttl instead of calling
+// DescribeRegions on every run. With refresh the cached list is ignored but
+// the file is still updated.
+func WithRegionsCache(path string, ttl time.Duration, refresh bool) Option {
+	return func(s *Scanner) {
+		s.regionsCachePath = path
+		s.regionsCacheTTL = ttl
+		s.refreshRegions = refresh
+	}
+}
+
+// loadRegionsCache reads the cache file at path; a missing file is an empty cache
+func loadRegionsCache(path string) (regionsCache, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return regionsCache{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	cache := regionsCache{}
+	if err := json.Unmarshal(data, &cache); err != nil {
+		return nil, err
+	}
+	return cache, nil
+}
+
+// lookup returns the regions cached for account in partition when they were
+// fetched within ttl of now
+func (c regionsCache) lookup(account, partition string, now time.Time, ttl time.Duration) (regionsCacheEntry, bool) {
+	entry, ok := c[account]
+	if !ok || entry.Partition != partition || now.Sub(entry.FetchedAt) >= ttl {
+		return regionsCacheEntry{}, false
+	}
+	return entry, true
+}
+
+// recordRegions stores the regions fetched for account in partition at now in
+// the cache file at path
+func recordRegions(path, account, partition string, regions []Region, now time.Time) error {
+	regionsCacheMu.Lock()
+	defer regionsCacheMu.Unlock()
+
+	cache, err := loadRegionsCache(path)
+	if err != nil {
+		// A corrupt cache is replaced rather than blocking updates forever
+		cache = regionsCache{}
+	}
+	cache[account] = regionsCacheEntry{Partition: partition, FetchedAt: now.UTC(), Regions: regions}
+	return writeCacheFile(path, cache)
+}
+
+// writeCacheFile writes v as indented JSON to path, creating its directory,
+// through a temporary file so readers never see a partial write
+func writeCacheFile(path string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,90 @@
+package scanner
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/eks/types"
+)
+
+func TestRegionsCacheLookup(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	cache := regionsCache{
+		"123456789012": {Partition: PartitionAWS, FetchedAt: now.Add(-time.Hour), Regions: []Region{{Name: "us-east-1"}}},
+	}
+	tests := []struct {
+		name      string
+		account   string
+		partition string
+		ttl       time.Duration
+		want      bool
+	}{
+		{name: "fresh", account: "123456789012", partition: PartitionAWS, ttl: 2 * time.Hour, want: true},
+		{name: "expired", account: "123456789012", partition: PartitionAWS, ttl: time.Hour},
+		{name: "other account", account: "210987654321", partition: PartitionAWS, ttl: 2 * time.Hour},
+		{name: "other partition", account: "123456789012", partition: "aws-us-gov", ttl: 2 * time.Hour},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, ok := cache.lookup(tt.account, tt.partition, now, tt.ttl); ok != tt.want {
+				t.Errorf("lookup = %t, want %t", ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegionsCacheAcrossRuns(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache", "regions.json")
+	f := newFakeFactory(map[string][]types.Cluster{"us-east-1": {fakeCluster("prod", "1.31")}, "eu-west-1": nil})
+	scan := func(t *testing.T, ttl time.Duration, refresh bool) *ScanResult {
+		t.Helper()
+		result, err := NewScanner(WithClientFactory(f), WithRegionsCache(path, ttl, refresh)).Run(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+	steps := []struct {
+		name      string
+		ttl       time.Duration
+		refresh   bool
+		wantCalls int
+	}{
+		{name: "first run fetches", ttl: time.Hour, wantCalls: 1},
+		{name: "second run uses the cache", ttl: time.Hour, wantCalls: 1},
+		{name: "refresh fetches", ttl: time.Hour, refresh: true, wantCalls: 2},
+		{name: "expired entry fetches", ttl: time.Nanosecond, wantCalls: 3},
+	}
+	for _, step := range steps {
+		t.Run(step.name, func(t *testing.T) {
+			result := scan(t, step.ttl, step.refresh)
+			if f.ec2.calls != step.wantCalls {
+				t.Errorf("DescribeRegions calls = %d, want %d", f.ec2.calls, step.wantCalls)
+			}
+			if len(result.Regions) != 2 || len(result.Clusters) != 1 {
+				t.Errorf("regions %q, %d clusters; want both regions and prod", result.Regions, len(result.Clusters))
+			}
+		})
+	}
+}
+
+func TestRegionsCacheCorruptFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "regions.json")
+	if err := os.WriteFile(path, []byte("{not json"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	f := newFakeFactory(map[string][]types.Cluster{"us-east-1": {fakeCluster("prod", "1.31")}})
+	if _, err := NewScanner(WithClientFactory(f), WithRegionsCache(path, time.Hour, false)).Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	cache, err := loadRegionsCache(path)
+	if err != nil {
+		t.Fatalf("corrupt cache not replaced: %v", err)
+	}
+	if _, ok := cache.lookup(f.account, PartitionAWS, time.Now(), time.Hour); !ok {
+		t.Errorf("cache = %+v, want an entry for %s", cache, f.account)
+	}
+}
@@ -0,0 +1,43 @@
+package scanner
+
+import (
+	"github.com/aws/aws-sdk-go-v2/service/eks"
+	"github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi"
+)
+
+// WithDescribeRetries sets how many times each DescribeCluster call is
+// retried by the SDK after a retryable error, instead of the SDK default.
+// Zero disables retries. The setting is passed with every call, so it also
+// reaches clients from a custom factory.
+func WithDescribeRetries(n int) Option {
+	return func(s *Scanner) {
+		s.describeAttempts = n + 1
+	}
+}
+
+// WithListRetries sets how many times each call listing the clusters of a
+// region (eks:ListClusters, or tag:GetResources with DiscoveryTagging) is
+// retried by the SDK, like WithDescribeRetries. WithRegionRetries relists
+// a region from scratch on top of these retries.
+func WithListRetries(n int) Option {
+	return func(s *Scanner) {
+		s.listAttempts = n + 1
+	}
+}
+
+// eksAttempts returns the call option limiting an EKS call to attempts
+// attempts, or none when attempts is zero (the SDK default)
+func eksAttempts(attempts int) []func(*eks.Options) {
+	if attempts == 0 {
+		return nil
+	}
+	return []func(*eks.Options){func(o *eks.Options) { o.RetryMaxAttempts = attempts }}
+}
+
+// taggingAttempts is eksAttempts for Resource Groups Tagging API calls
+func taggingAttempts(attempts int) []func(*resourcegroupstaggingapi.Options) {
+	if attempts == 0 {
+		return nil
+	}
+	return []func(*resourcegroupstaggingapi.Options){func(o *resourcegroupstaggingapi.Options) { o.RetryMaxAttempts = attempts }}
+}
@@ -0,0 +1,95 @@
+package scanner
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/eks"
+	"github.com/aws/aws-sdk-go-v2/service/eks/types"
+	"github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi"
+)
+
+// attemptsEKS records the RetryMaxAttempts each call's options set
+type attemptsEKS struct {
+	*fakeEKS
+
+	mu       sync.Mutex
+	list     []int
+	describe []int
+}
+
+// maxAttempts returns the RetryMaxAttempts optFns set, zero when unset
+func maxAttempts(optFns []func(*eks.Options)) int {
+	var o eks.Options
+	for _, fn := range optFns {
+		fn(&o)
+	}
+	return o.RetryMaxAttempts
+}
+
+func (c *attemptsEKS) ListClusters(ctx context.Context, params *eks.ListClustersInput, optFns ...func(*eks.Options)) (*eks.ListClustersOutput, error) {
+	c.mu.Lock()
+	c.list = append(c.list, maxAttempts(optFns))
+	c.mu.Unlock()
+	return c.fakeEKS.ListClusters(ctx, params, optFns...)
+}
+
+func (c *attemptsEKS) DescribeCluster(ctx context.Context, params *eks.DescribeClusterInput, optFns ...func(*eks.Options)) (*eks.DescribeClusterOutput, error) {
+	c.mu.Lock()
+	c.describe = append(c.describe, maxAttempts(optFns))
+	c.mu.Unlock()
+	return c.fakeEKS.DescribeCluster(ctx, params, optFns...)
+}
+
+func TestOperationRetries(t *testing.T) {
+	tests := []struct {
+		name         string
+		opts         []Option
+		wantList     int
+		wantDescribe int
+	}{
+		{name: "sdk defaults"},
+		{name: "describe retries disabled", opts: []Option{WithDescribeRetries(0)}, wantDescribe: 1},
+		{name: "both", opts: []Option{WithListRetries(5), WithDescribeRetries(2)}, wantList: 6, wantDescribe: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFakeFactory(map[string][]types.Cluster{"us-east-1": {fakeCluster("a", "1.31"), fakeCluster("b", "1.31")}})
+			client := &attemptsEKS{fakeEKS: f.region("us-east-1")}
+			client.pageSize = 1
+			opts := append([]Option{WithClientFactory(&singleEKSFactory{fakeFactory: f, client: client}), WithRegions("us-east-1")}, tt.opts...)
+			if _, err := NewScanner(opts...).Run(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			for _, got := range client.list {
+				if got != tt.wantList {
+					t.Errorf("ListClusters attempts = %v, want %d on every page", client.list, tt.wantList)
+					break
+				}
+			}
+			for _, got := range client.describe {
+				if got != tt.wantDescribe {
+					t.Errorf("DescribeCluster attempts = %v, want %d on every call", client.describe, tt.wantDescribe)
+					break
+				}
+			}
+			if len(client.list) != 2 || len(client.describe) != 2 {
+				t.Errorf("%d list and %d describe calls, want 2 of each", len(client.list), len(client.describe))
+			}
+		})
+	}
+}
+
+func TestTaggingAttempts(t *testing.T) {
+	if taggingAttempts(0) != nil {
+		t.Error("taggingAttempts(0) set an option, want the SDK default")
+	}
+	var o resourcegroupstaggingapi.Options
+	for _, fn := range taggingAttempts(4) {
+		fn(&o)
+	}
+	if o.RetryMaxAttempts != 4 {
+		t.Errorf("RetryMaxAttempts = %d, want 4", o.RetryMaxAttempts)
+	}
+}
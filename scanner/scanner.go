@@ -0,0 +1,1523 @@
+// Package scanner discovers EKS clusters across the regions of an AWS account.
+package scanner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/netip"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/eks"
+	"github.com/aws/aws-sdk-go-v2/service/eks/types"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"golang.org/x/time/rate"
+)
+
+// DefaultConcurrency is the number of regions and clusters processed in parallel
+// when WithConcurrency is not given.
+const DefaultConcurrency = 8
+
+// regionRetryDelay is the pause before the first WithRegionRetries attempt;
+// later attempts wait proportionally longer.
+const regionRetryDelay = time.Second
+
+// SchemaVersion identifies the shape of the JSON encoding of ScanResult.
+// Bump it whenever the shape of ScanResult or Cluster changes.
+const SchemaVersion = 1
+
+// Version is the tool version embedded in results. Release builds set it with
+// -ldflags "-X shift-left-shuffle/scanner.Version=v1.2.3".
+var Version = "dev"
+
+// ScanResult holds everything collected by a single scan
+type ScanResult struct {
+	SchemaVersion int       `json:"schemaVersion"`
+	GeneratedAt   time.Time `json:"generatedAt"`
+	ToolVersion   string    `json:"toolVersion"`
+	// Labels is the run metadata given with WithLabel.
+	Labels map[string]string `json:"labels,omitempty"`
+	// Profile is the shared-config profile used for the scan, when scanning several.
+	Profile string `json:"profile,omitempty"`
+	Account string `json:"account"`
+	// Partition is the partition of the caller identity, such as aws or aws-us-gov.
+	Partition string `json:"partition,omitempty"`
+	// AccountAlias is the IAM account alias, when resolved with WithAccountAlias.
+	AccountAlias string   `json:"accountAlias,omitempty"`
+	Regions      []string `json:"regions"`
+	// ScannedRegions counts the clusters listed in each of Regions, zeros
+	// included, before any filter applies.
+	ScannedRegions []RegionCount `json:"scannedRegions,omitempty"`
+	// SampledFrom is the number of regions Regions was sampled from with
+	// WithSampleRegions, and SampleSeed the seed that reproduces the sample.
+	SampledFrom int    `json:"sampledFrom,omitempty"`
+	SampleSeed  uint64 `json:"sampleSeed,omitempty"`
+	// SkippedEmptyRegions lists the regions not listed because an earlier scan
+	// found them empty; see WithSkipEmptyRegions.
+	SkippedEmptyRegions []string  `json:"skippedEmptyRegions,omitempty"`
+	Clusters            []Cluster `json:"clusters"`
+	// NameCollisions lists cluster names used in several regions; it is only
+	// computed with WithNameCollisions.
+	NameCollisions []NameCollision `json:"nameCollisions,omitempty"`
+	// RegionErrors lists the regions whose clusters could not be listed.
+	RegionErrors []RegionError `json:"regionErrors,omitempty"`
+	// Drift is set by the caller when the scan is compared to a baseline.
+	Drift *Drift `json:"drift,omitempty"`
+	// Checksum is set by the caller to ResultChecksum for tamper evidence.
+	Checksum string `json:"checksum,omitempty"`
+	// EstimatedMonthlyCost totals Cluster.EstimatedMonthlyCost with WithCostEstimate.
+	EstimatedMonthlyCost *float64 `json:"estimatedMonthlyCost,omitempty"`
+	// Warnings collects the soft findings about Clusters; see CollectWarnings.
+	Warnings []Warning `json:"warnings,omitempty"`
+	// Stats is set by the caller to the API call counts and timings of a
+	// scan run with WithStats.
+	Stats *StatsCounts `json:"stats,omitempty"`
+	// Incomplete marks a result returned alongside an error: the scan failed
+	// after listing clusters and Clusters holds what was collected so far.
+	Incomplete bool `json:"incomplete,omitempty"`
+	// Error is set by ScanProfiles on an Incomplete profile to the error its
+	// scan returned.
+	Error string `json:"error,omitempty"`
+
+	// listed holds every cluster found before any filter applied, for
+	// CompareBaseline. It is not serialized.
+	listed []ClusterRef
+}
+
+// Cluster holds information about a single EKS cluster
+type Cluster struct {
+	Name      string     `json:"name"`
+	Region    string     `json:"region"`
+	Arn       string     `json:"arn,omitempty"`
+	Endpoint  string     `json:"endpoint,omitempty"`
+	CreatedAt *time.Time `json:"createdAt,omitempty"`
+	Version   string     `json:"version,omitempty"`
+	// PlatformVersion is the EKS platform version, such as eks.12.
+	PlatformVersion string `json:"platformVersion,omitempty"`
+	// OIDCIssuer is the URL of the cluster's OpenID Connect issuer.
+	OIDCIssuer string `json:"oidcIssuer,omitempty"`
+	// EOL is set when Version is past the end of standard support.
+	EOL                  bool     `json:"eol,omitempty"`
+	EndpointPublicAccess bool     `json:"endpointPublicAccess,omitempty"`
+	PublicAccessCidrs    []string `json:"publicAccessCidrs,omitempty"`
+	// DisallowedCidrs lists the PublicAccessCidrs outside the WithAllowedCidrs allowlist.
+	DisallowedCidrs []string          `json:"disallowedCidrs,omitempty"`
+	Tags            map[string]string `json:"tags,omitempty"`
+	VpcID           string            `json:"vpcId,omitempty"`
+	// VpcCidrs and VpcIPv6Cidrs are the associated CIDR blocks of the cluster VPC.
+	VpcCidrs     []string `json:"vpcCidrs,omitempty"`
+	VpcIPv6Cidrs []string `json:"vpcIpv6Cidrs,omitempty"`
+	// SubnetIDs are the cluster subnets and AvailabilityZones the distinct zones
+	// they cover; BelowMinAZs is set when they cover fewer zones than the
+	// WithAvailabilityZones minimum. All are only set with WithAvailabilityZones.
+	SubnetIDs         []string `json:"subnetIds,omitempty"`
+	AvailabilityZones []string `json:"availabilityZones,omitempty"`
+	BelowMinAZs       bool     `json:"belowMinAzs,omitempty"`
+	// IPFamily, ServiceIPv4Cidr and ServiceIPv6Cidr describe the Kubernetes
+	// service network; they are only set with WithNetwork.
+	IPFamily        string `json:"ipFamily,omitempty"`
+	ServiceIPv4Cidr string `json:"serviceIpv4Cidr,omitempty"`
+	ServiceIPv6Cidr string `json:"serviceIpv6Cidr,omitempty"`
+	// BootstrapSelfManagedAddons reports whether EKS installed the default
+	// self-managed add-ons (VPC CNI, kube-proxy, CoreDNS) at creation. It is
+	// only set with WithNetwork, and only when DescribeCluster reports it.
+	BootstrapSelfManagedAddons *bool `json:"bootstrapSelfManagedAddons,omitempty"`
+	// ClusterSecurityGroupID is the security group EKS created for the control plane.
+	ClusterSecurityGroupID string `json:"clusterSecurityGroupId,omitempty"`
+	// SecurityGroupRules are the rules of ClusterSecurityGroupID.
+	SecurityGroupRules []SecurityGroupRule `json:"securityGroupRules,omitempty"`
+	// MissingTags lists the WithRequiredTags keys the cluster lacks.
+	MissingTags []string `json:"missingTags,omitempty"`
+	// DeletionProtected reports whether the cluster carries the WithProtectionTag
+	// tag; it is only set when a protection tag is configured.
+	DeletionProtected *bool `json:"deletionProtected,omitempty"`
+	// LatestVersion is the newest Kubernetes version EKS offers in the region and
+	// VersionsBehind the number of offered versions newer than Version; both
+	// are only set with WithVersionsBehind.
+	LatestVersion  string `json:"latestVersion,omitempty"`
+	VersionsBehind *int   `json:"versionsBehind,omitempty"`
+	// BelowMinVersion is set when Version is older than the WithMinVersion minimum.
+	BelowMinVersion bool `json:"belowMinVersion,omitempty"`
+	// Stale is set when the cluster is older than the WithMaxAgeWarn threshold.
+	Stale bool `json:"stale,omitempty"`
+	// Undescribed marks a listed cluster that DescribeCluster failed for;
+	// DescribeError records why. Only Name and Region are known for it.
+	Undescribed   bool   `json:"undescribed,omitempty"`
+	DescribeError string `json:"describeError,omitempty"`
+	// ListedOnly marks an undescribed cluster left out by
+	// WithMaxDescribePerRegion rather than one whose describe failed.
+	ListedOnly    bool          `json:"listedOnly,omitempty"`
+	AccessEntries []AccessEntry `json:"accessEntries,omitempty"`
+	HealthIssues  []HealthIssue `json:"healthIssues,omitempty"`
+	// EnabledLogTypes lists the control plane log types sent to CloudWatch Logs.
+	EnabledLogTypes []string `json:"enabledLogTypes,omitempty"`
+	// EncryptionKeyArn is the KMS key encrypting Kubernetes secrets, if any.
+	EncryptionKeyArn string `json:"encryptionKeyArn,omitempty"`
+	// EncryptionKeyManager is KeyManagerCustomer or KeyManagerAWS when EncryptionKeyArn is set.
+	EncryptionKeyManager string `json:"encryptionKeyManager,omitempty"`
+	// Connector is set for clusters registered through EKS Connector rather than
+	// running on EKS; it is nil for native clusters.
+	Connector *Connector `json:"connector,omitempty"`
+	// InstanceCount is the number of running EC2 instances tagged with the cluster.
+	InstanceCount *int `json:"instanceCount,omitempty"`
+	// EndpointResolves reports whether the endpoint host resolved in DNS from
+	// where the scan ran; it is only set with WithVerifyDNS.
+	EndpointResolves *bool  `json:"endpointResolves,omitempty"`
+	EndpointDNSError string `json:"endpointDnsError,omitempty"`
+	// NodegroupCount is the number of managed node groups and TotalNodes the sum
+	// of their desired sizes; both are only set with WithNodegroups.
+	NodegroupCount *int `json:"nodegroupCount,omitempty"`
+	TotalNodes     *int `json:"totalNodes,omitempty"`
+	// Updates lists in-progress and recently failed cluster updates.
+	Updates []Update `json:"updates,omitempty"`
+	// EstimatedMonthlyCost is the control plane cost in USD estimated with
+	// WithCostEstimate, including any extended support surcharge.
+	EstimatedMonthlyCost *float64 `json:"estimatedMonthlyCost,omitempty"`
+	// Exec is set by the caller with the outcome of a post-processing command.
+	Exec *ExecResult `json:"exec,omitempty"`
+}
+
+// ExecResult is the outcome of a command run for a cluster. Error is set when
+// the command could not be run to completion, for example on timeout; a
+// command that ran and exited non-zero only sets ExitCode.
+type ExecResult struct {
+	ExitCode int    `json:"exitCode"`
+	Output   string `json:"output,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Connector describes how a registered (EKS Connector) cluster is attached
+type Connector struct {
+	Provider string `json:"provider,omitempty"`
+	RoleArn  string `json:"roleArn,omitempty"`
+}
+
+// setDescribeError marks the cluster undescribed because of msg
+func (c *Cluster) setDescribeError(msg string) {
+	c.Undescribed = true
+	c.DescribeError = msg
+}
+
+// Connected reports whether the cluster is registered through EKS Connector
+func (c *Cluster) Connected() bool {
+	return c.Connector != nil
+}
+
+// OpenEndpoint reports whether the cluster API endpoint is reachable from any address
+func (c *Cluster) OpenEndpoint() bool {
+	return c.EndpointPublicAccess && slices.Contains(c.PublicAccessCidrs, "0.0.0.0/0")
+}
+
+// HealthIssue is a cluster-level problem reported by EKS
+type HealthIssue struct {
+	Code        string   `json:"code"`
+	Message     string   `json:"message,omitempty"`
+	ResourceIDs []string `json:"resourceIds,omitempty"`
+}
+
+// Scanner discovers EKS clusters. Create one with NewScanner.
+type Scanner struct {
+	regions                []string
+	listConcurrency        int
+	describeConcurrency    int
+	profile                string
+	out                    io.Writer
+	factory                ClientFactory
+	withAccessEntries      bool
+	accessPolicyArn        string
+	withHealth             bool
+	withInstanceCount      bool
+	withVpcCidr            bool
+	withUpdates            bool
+	withSGRules            bool
+	verifyDNS              bool
+	resolver               Resolver
+	discovery              Discovery
+	tagFilters             map[string][]string
+	excludeTags            map[string][]string
+	describeTimeout        time.Duration
+	requiredTags           []string
+	includeDisabledRegions bool
+	forceAllRegions        bool
+	rateLimit              float64
+	limiter                *rate.Limiter
+	httpClient             aws.HTTPClient
+	includeConnected       bool
+	priorityRegions        []string
+	regionRetries          int
+	maxAgeWarn             time.Duration
+	minVersion             string
+	labels                 map[string]string
+	findCollisions         bool
+	profileRegions         map[string][]string
+	protectionTag          map[string][]string
+	allowedCidrs           []netip.Prefix
+	minAZs                 int
+	audit                  *AuditLog
+	stats                  *Stats
+	emptyRegionsPath       string
+	recheckEmpty           time.Duration
+	ssoSession             string
+	adaptiveConcurrency    bool
+	vpcIDs                 []string
+	phaseTimeouts          map[string]time.Duration
+	onlyEOL                bool
+	mfaTokenProvider       func() (string, error)
+	describeAttempts       int
+	listAttempts           int
+	costRates              *CostRates
+	describeLimit          *adaptiveLimit
+	sampleRegions          int
+	sampleSeed             uint64
+	expectedAccounts       []string
+	maxDescribePerRegion   int
+	arnPrefixes            []string
+	regionsCachePath       string
+	regionsCacheTTL        time.Duration
+	refreshRegions         bool
+	withVersionsBehind     bool
+	withNetwork            bool
+	accountConcurrency     int
+	withNodegroups         bool
+	withAccountAlias       bool
+
+	mu sync.Mutex
+
+	// refreshMu guards refreshes, the number of credential refreshes so far.
+	refreshMu sync.Mutex
+	refreshes int
+}
+
+// Option configures a Scanner
+type Option func(*Scanner)
+
+// WithRegions restricts the scan to the given regions instead of every region
+// returned by DescribeRegions.
+func WithRegions(regions ...string) Option {
+	return func(s *Scanner) {
+		s.regions = regions
+	}
+}
+
+// WithConcurrency sets how many regions and clusters are processed in parallel.
+// It sets both the list and describe limits. Values below 1 are ignored.
+func WithConcurrency(n int) Option {
+	return func(s *Scanner) {
+		if n > 0 {
+			s.listConcurrency = n
+			s.describeConcurrency = n
+		}
+	}
+}
+
+// WithListConcurrency sets how many regions are listed in parallel,
+// overriding WithConcurrency for the list phase. Values below 1 are ignored.
+func WithListConcurrency(n int) Option {
+	return func(s *Scanner) {
+		if n > 0 {
+			s.listConcurrency = n
+		}
+	}
+}
+
+// WithDescribeConcurrency sets how many clusters are described in parallel,
+// overriding WithConcurrency for the describe and enrichment phases.
+// Values below 1 are ignored.
+func WithDescribeConcurrency(n int) Option {
+	return func(s *Scanner) {
+		if n > 0 {
+			s.describeConcurrency = n
+		}
+	}
+}
+
+// WithAccountConcurrency sets how many profiles ScanProfiles scans in
+// parallel; each still fans out over its regions. The default is 1.
+func WithAccountConcurrency(n int) Option {
+	return func(s *Scanner) {
+		if n > 0 {
+			s.accountConcurrency = n
+		}
+	}
+}
+
+// WithProfile selects a named profile from the shared AWS config files.
+// It has no effect when combined with WithClientFactory.
+func WithProfile(profile string) Option {
+	return func(s *Scanner) {
+		s.profile = profile
+	}
+}
+
+// WithOutput sets where progress messages are written. By default they are discarded.
+func WithOutput(w io.Writer) Option {
+	return func(s *Scanner) {
+		s.out = w
+	}
+}
+
+// WithClientFactory replaces the factory used to build AWS service clients.
+func WithClientFactory(f ClientFactory) Option {
+	return func(s *Scanner) {
+		s.factory = f
+	}
+}
+
+// WithAccessEntries collects the access entries of every cluster. A non-empty
+// policyArn restricts the entries to those associated with that access policy.
+func WithAccessEntries(policyArn string) Option {
+	return func(s *Scanner) {
+		s.withAccessEntries = true
+		s.accessPolicyArn = policyArn
+	}
+}
+
+// WithHealth records the health issues EKS reports for every cluster.
+func WithHealth() Option {
+	return func(s *Scanner) {
+		s.withHealth = true
+	}
+}
+
+// WithInstanceCount counts the running EC2 instances tagged
+// kubernetes.io/cluster/<name> for every cluster.
+func WithInstanceCount() Option {
+	return func(s *Scanner) {
+		s.withInstanceCount = true
+	}
+}
+
+// WithDiscovery selects how clusters are discovered in each region.
+func WithDiscovery(d Discovery) Option {
+	return func(s *Scanner) {
+		s.discovery = d
+	}
+}
+
+// WithTagFilter keeps only clusters whose tag key has one of values, or any
+// value when values is empty. Filters on different keys must all match.
+// With DiscoveryTagging the filter is applied by the Tagging API as well.
+func WithTagFilter(key string, values ...string) Option {
+	return func(s *Scanner) {
+		if s.tagFilters == nil {
+			s.tagFilters = make(map[string][]string)
+		}
+		s.tagFilters[key] = append(s.tagFilters[key], values...)
+	}
+}
+
+// WithExcludeTag drops clusters whose tag key has one of values, or any value
+// when values is empty. A cluster matching any exclusion is dropped; exclusions
+// apply after the WithTagFilter filters.
+func WithExcludeTag(key string, values ...string) Option {
+	return func(s *Scanner) {
+		if s.excludeTags == nil {
+			s.excludeTags = make(map[string][]string)
+		}
+		s.excludeTags[key] = append(s.excludeTags[key], values...)
+	}
+}
+
+// WithRequiredTags keeps only the clusters missing at least one of keys and
+// records the missing keys on each. Clusters that could not be described are dropped.
+func WithRequiredTags(keys ...string) Option {
+	return func(s *Scanner) {
+		s.requiredTags = append(s.requiredTags, keys...)
+	}
+}
+
+// WithDisabledRegions also scans regions the account has not opted in to.
+// By default only regions with opt-in status "opt-in-not-required" or
+// "opted-in" are scanned. It has no effect when combined with WithRegions.
+func WithDisabledRegions() Option {
+	return func(s *Scanner) {
+		s.includeDisabledRegions = true
+	}
+}
+
+// WithAllRegions also scans regions where EKS is not known to be available.
+// By default such regions returned by DescribeRegions are skipped with a
+// warning; regions given through WithRegions are always scanned.
+func WithAllRegions() Option {
+	return func(s *Scanner) {
+		s.forceAllRegions = true
+	}
+}
+
+// WithConnected also lists clusters registered through EKS Connector
+// (on-premises or other clouds) alongside native EKS clusters.
+func WithConnected() Option {
+	return func(s *Scanner) {
+		s.includeConnected = true
+	}
+}
+
+// WithPriorityRegions scans the given regions, in order, before all others.
+// Priority regions that are not part of the scan are ignored.
+func WithPriorityRegions(regions ...string) Option {
+	return func(s *Scanner) {
+		s.priorityRegions = regions
+	}
+}
+
+// WithSampleRegions scans only n regions picked at random from those that
+// would otherwise be scanned. The same seed picks the same regions from the
+// same candidates; a zero seed picks a random seed, which is reported in the
+// result so the sample can be repeated.
+func WithSampleRegions(n int, seed uint64) Option {
+	return func(s *Scanner) {
+		s.sampleRegions = n
+		s.sampleSeed = seed
+	}
+}
+
+// WithRateLimit caps outgoing AWS calls, including retries, at callsPerSecond
+// across all goroutines of the scan. It applies to the default client factory
+// only; custom factories are expected to pace their own clients.
+func WithRateLimit(callsPerSecond float64) Option {
+	return func(s *Scanner) {
+		s.rateLimit = callsPerSecond
+	}
+}
+
+// WithHTTPClient makes every AWS client send its requests through client,
+// for example one built by NewHTTPClient. Like WithRateLimit it applies to
+// the default client factory only.
+func WithHTTPClient(client aws.HTTPClient) Option {
+	return func(s *Scanner) {
+		s.httpClient = client
+	}
+}
+
+// WithRegionRetries relists a region from its first page up to n times when
+// listing fails with a transient error, so a region is never reported from a
+// partial listing. Permission errors are not retried.
+func WithRegionRetries(n int) Option {
+	return func(s *Scanner) {
+		s.regionRetries = n
+	}
+}
+
+// WithDescribeTimeout bounds each DescribeCluster call. A cluster whose
+// describe times out is kept with DescribeError set and the scan continues.
+func WithDescribeTimeout(d time.Duration) Option {
+	return func(s *Scanner) {
+		s.describeTimeout = d
+	}
+}
+
+// WithVpcCidr looks up the CIDR blocks of every cluster's VPC.
+func WithVpcCidr() Option {
+	return func(s *Scanner) {
+		s.withVpcCidr = true
+	}
+}
+
+// WithUpdates records the in-progress cluster updates of every cluster and
+// those that failed or were cancelled in the last seven days.
+func WithUpdates() Option {
+	return func(s *Scanner) {
+		s.withUpdates = true
+	}
+}
+
+// WithSecurityGroupRules looks up the ingress and egress rules of every
+// cluster's control-plane security group.
+func WithSecurityGroupRules() Option {
+	return func(s *Scanner) {
+		s.withSGRules = true
+	}
+}
+
+// WithVerifyDNS checks that the endpoint host of every cluster resolves in DNS.
+// Private endpoints typically only resolve from inside the cluster VPC.
+func WithVerifyDNS() Option {
+	return func(s *Scanner) {
+		s.verifyDNS = true
+	}
+}
+
+// WithResolver replaces the DNS resolver used by WithVerifyDNS.
+func WithResolver(r Resolver) Option {
+	return func(s *Scanner) {
+		s.resolver = r
+	}
+}
+
+// WithMaxAgeWarn marks clusters older than maxAge as stale and logs a warning
+// for each of them. Stale clusters are kept in the result.
+func WithMaxAgeWarn(maxAge time.Duration) Option {
+	return func(s *Scanner) {
+		s.maxAgeWarn = maxAge
+	}
+}
+
+// WithOnlyEOL keeps only the clusters running a Kubernetes version past the
+// end of standard support, as marked by Cluster.EOL.
+func WithOnlyEOL() Option {
+	return func(s *Scanner) {
+		s.onlyEOL = true
+	}
+}
+
+// WithMinVersion marks clusters running a Kubernetes version older than
+// minVersion (e.g. "1.28"). Versions compare numerically, so 1.9 < 1.10.
+func WithMinVersion(minVersion string) Option {
+	return func(s *Scanner) {
+		s.minVersion = minVersion
+	}
+}
+
+// WithLabel attaches run metadata, such as a CI run ID, to the result.
+func WithLabel(key, value string) Option {
+	return func(s *Scanner) {
+		if s.labels == nil {
+			s.labels = make(map[string]string)
+		}
+		s.labels[key] = value
+	}
+}
+
+// WithNameCollisions reports cluster names that appear in more than one
+// region of the account. Names are only unique per region, so a repeated
+// name usually means copy-pasted infrastructure.
+func WithNameCollisions() Option {
+	return func(s *Scanner) {
+		s.findCollisions = true
+	}
+}
+
+// WithProfileRegions restricts the scan of each profile named in regions to
+// its listed regions when scanning with ScanProfiles, overriding WithRegions.
+// Profiles not in the map are scanned as usual.
+func WithProfileRegions(regions map[string][]string) Option {
+	return func(s *Scanner) {
+		s.profileRegions = regions
+	}
+}
+
+// WithProtectionTag marks clusters as deletion protected when they carry tag
+// key with one of values, or with any value when values is empty. EKS has no
+// deletion protection setting of its own, so protection is a tagging convention.
+func WithProtectionTag(key string, values ...string) Option {
+	return func(s *Scanner) {
+		s.protectionTag = map[string][]string{key: values}
+	}
+}
+
+// WithVersionsBehind looks up the Kubernetes versions EKS offers in each
+// region and records how many newer versions each cluster could upgrade to.
+func WithVersionsBehind() Option {
+	return func(s *Scanner) {
+		s.withVersionsBehind = true
+	}
+}
+
+// WithNetwork records the IP family and service CIDR blocks of every
+// cluster, and whether its default self-managed add-ons were bootstrapped.
+func WithNetwork() Option {
+	return func(s *Scanner) {
+		s.withNetwork = true
+	}
+}
+
+// WithNodegroups sums the desired size of every cluster's managed node groups.
+// Self-managed nodes and Fargate are not included.
+func WithNodegroups() Option {
+	return func(s *Scanner) {
+		s.withNodegroups = true
+	}
+}
+
+// WithAccountAlias resolves the IAM alias of the account. An account without
+// an alias, or a denied lookup, leaves ScanResult.AccountAlias empty.
+func WithAccountAlias() Option {
+	return func(s *Scanner) {
+		s.withAccountAlias = true
+	}
+}
+
+// NewScanner returns a Scanner configured by opts
+func NewScanner(opts ...Option) *Scanner {
+	s := &Scanner{
+		listConcurrency:     DefaultConcurrency,
+		describeConcurrency: DefaultConcurrency,
+		accountConcurrency:  1,
+		out:                 io.Discard,
+		resolver:            defaultResolver,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.limiter == nil && s.rateLimit > 0 {
+		s.limiter = rate.NewLimiter(rate.Limit(s.rateLimit), 1)
+	}
+	if s.adaptiveConcurrency && s.describeLimit == nil {
+		s.describeLimit = newAdaptiveLimit(s.describeConcurrency, s.logf)
+	}
+	if s.factory == nil {
+		f := NewDefaultClientFactory(&DefaultConfigLoader{Profile: s.profile, MFATokenProvider: s.mfaTokenProvider})
+		if s.limiter != nil {
+			f.APIOptions = append(f.APIOptions, rateLimitMiddleware(s.limiter))
+		}
+		if s.audit != nil {
+			f.APIOptions = append(f.APIOptions, s.audit.middleware())
+		}
+		if s.stats != nil {
+			f.APIOptions = append(f.APIOptions, s.stats.middleware())
+		}
+		if s.describeLimit != nil {
+			f.APIOptions = append(f.APIOptions, s.describeLimit.middleware())
+		}
+		f.HTTPClient = s.httpClient
+		s.factory = f
+	}
+	return s
+}
+
+// Run performs the scan: it resolves the account, lists regions (unless fixed
+// with WithRegions), lists the clusters in every region and describes them.
+// When a later phase fails, Run returns the clusters collected so far in a
+// result marked Incomplete along with the error. Regions outside the
+// partition of the caller are skipped, since its credentials are not valid there.
+func (s *Scanner) Run(ctx context.Context) (*ScanResult, error) {
+	// Get account info
+	account, partition, err := s.resolveAccount(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get regions
+	regions := s.regions
+	if len(regions) > 0 {
+		var foreign []string
+		regions, foreign = splitPartitionRegions(regions, partition)
+		if len(foreign) > 0 {
+			s.logf("Warning: skipping regions outside partition %s: %s\n", partition, strings.Join(foreign, ", "))
+		}
+	} else {
+		var described []Region
+		var clientErr error
+		err := s.runPhase(ctx, PhaseRegions, func(ctx context.Context) error {
+			return s.withRefresh(ctx, func() error {
+				ec2Client, err := s.factory.EC2(ctx, "")
+				if err != nil {
+					clientErr = err
+					return nil
+				}
+				described, err = s.describeRegions(ctx, ec2Client, account, partition)
+				return err
+			})
+		})
+		if clientErr != nil {
+			return nil, fmt.Errorf("creating EC2 client: %w", clientErr)
+		}
+		switch {
+		case isAccessDenied(err):
+			regions = partitionFallbackRegions(partition)
+			s.logf("Warning: DescribeRegions denied (%v); falling back to the built-in list of %d %s regions\n", err, len(regions), partition)
+		case err != nil:
+			return nil, fmt.Errorf("describing regions: %w", err)
+		default:
+			regions = usableRegions(described, s.includeDisabledRegions)
+		}
+		if !s.forceAllRegions {
+			var unsupported []string
+			regions, unsupported = splitEKSRegions(regions)
+			if len(unsupported) > 0 {
+				s.logf("Warning: skipping regions without EKS: %s\n", strings.Join(unsupported, ", "))
+			}
+		}
+	}
+	regions = prioritize(regions, s.priorityRegions)
+
+	// Skip regions an earlier scan found empty
+	var skippedEmpty []string
+	if s.emptyRegionsPath != "" {
+		cache, err := loadEmptyRegions(s.emptyRegionsPath)
+		if err != nil {
+			s.logf("Warning: ignoring empty-region cache %s: %v\n", s.emptyRegionsPath, err)
+		} else if regions, skippedEmpty = cache.skipEmpty(account, regions, time.Now(), s.recheckEmpty); len(skippedEmpty) > 0 {
+			s.logf("Skipping regions with no clusters in an earlier scan: %s\n", strings.Join(skippedEmpty, ", "))
+		}
+	}
+
+	// Sample the remaining regions
+	sampledFrom, seed := 0, s.sampleSeed
+	if s.sampleRegions > 0 && len(regions) > s.sampleRegions {
+		if seed == 0 {
+			seed = rand.Uint64()
+		}
+		sampledFrom = len(regions)
+		regions = sampleRegions(regions, s.sampleRegions, seed)
+		s.logf("Sampled %d of %d regions (seed %d): %s\n", len(regions), sampledFrom, seed, strings.Join(regions, ", "))
+	}
+	s.printRegions(regions)
+
+	// Get EKS clusters across all regions
+	var clusters []Cluster
+	var regionErrs []RegionError
+	var elapsed []time.Duration
+	err = s.runPhase(ctx, PhaseList, func(ctx context.Context) error {
+		var err error
+		if s.discovery == DiscoveryTagging {
+			clusters, regionErrs, elapsed, err = s.getTaggedClusters(ctx, regions)
+		} else {
+			clusters, regionErrs, elapsed, err = s.getAllClusters(ctx, regions)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("getting clusters: %w", err)
+	}
+	s.logf("Total clusters found: %d\n", len(clusters))
+	if s.emptyRegionsPath != "" {
+		if err := recordEmptyRegions(s.emptyRegionsPath, account, regions, clusters, regionErrs, time.Now()); err != nil {
+			s.logf("Warning: updating empty-region cache %s: %v\n", s.emptyRegionsPath, err)
+		}
+	}
+
+	result := newScanResult(account, regions, clusters)
+	result.listed = clusterRefs(account, clusters)
+	result.ScannedRegions = countRegions(regions, clusters, regionErrs, elapsed)
+	result.SkippedEmptyRegions = skippedEmpty
+	if sampledFrom > 0 {
+		result.SampledFrom, result.SampleSeed = sampledFrom, seed
+	}
+	result.Partition = partition
+	result.RegionErrors = regionErrs
+	result.Labels = s.labels
+
+	// Drop the clusters outside the ARN prefixes before describing them
+	// filterARNs reuses the backing array, so the result must follow it
+	setARNs(clusters, partition, account)
+	clusters = s.filterARNs(clusters)
+	result.Clusters = clusters
+
+	// Bound the clusters described in each region
+	if s.maxDescribePerRegion > 0 {
+		skipped := capDescribes(clusters, s.maxDescribePerRegion)
+		// Count the clusters left after the ARN filter, not those listed
+		_, byRegion := groupByRegion(clusters)
+		for i := range result.ScannedRegions {
+			count := &result.ScannedRegions[i]
+			if n := skipped[count.Region]; n > 0 {
+				count.DescribeSkipped = n
+				total := len(byRegion[count.Region])
+				s.logf("Describing %d of %d clusters in region %s\n", total-n, total, count.Region)
+			}
+		}
+	}
+
+	// Get cluster endpoints and run the enrichments
+	err = s.runPhase(ctx, PhaseDescribe, func(ctx context.Context) error {
+		if err := s.getClusterEndpoints(ctx, clusters); err != nil {
+			return fmt.Errorf("getting cluster endpoints: %w", err)
+		}
+		var err error
+		result.Clusters, err = s.enrich(ctx, clusters)
+		return err
+	})
+	if err != nil {
+		result.Incomplete = true
+		return result, err
+	}
+	clusters = result.Clusters
+	if s.costRates != nil {
+		total := s.costRates.estimateCosts(clusters)
+		result.EstimatedMonthlyCost = &total
+	}
+	result.Warnings = CollectWarnings(clusters)
+
+	if s.withAccountAlias {
+		result.AccountAlias = s.resolveAlias(ctx)
+	}
+	if s.findCollisions {
+		result.NameCollisions = FindNameCollisions(clusters)
+	}
+	return result, nil
+}
+
+// Describe skips discovery and describes the named clusters in a single region.
+// A cluster that cannot be described (for example because it does not exist)
+// is kept in the result with DescribeError set instead of failing the call.
+// Like Run, it returns an Incomplete result when an enrichment fails.
+func (s *Scanner) Describe(ctx context.Context, region string, names []string) (*ScanResult, error) {
+	account, partition, err := s.resolveAccount(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	clusters := make([]Cluster, 0, len(names))
+	for _, name := range names {
+		clusters = append(clusters, Cluster{Name: name, Region: region})
+	}
+	setARNs(clusters, partition, account)
+	return s.describeClusters(ctx, account, partition, []string{region}, clusters)
+}
+
+// DescribeARNs skips discovery and describes each cluster in the region of
+// its ARN, like Describe. ARNs of another account or partition are described
+// with the scanner's credentials anyway, which usually fails, and logged as a warning.
+func (s *Scanner) DescribeARNs(ctx context.Context, arns []ClusterARN) (*ScanResult, error) {
+	account, partition, err := s.resolveAccount(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var regions []string
+	clusters := make([]Cluster, 0, len(arns))
+	for _, arn := range arns {
+		if arn.Account != account || arn.Partition != partition {
+			s.logf("Warning: cluster %s is in account %s (partition %s), not %s (partition %s)\n", arn.Name, arn.Account, arn.Partition, account, partition)
+		}
+		if !slices.Contains(regions, arn.Region) {
+			regions = append(regions, arn.Region)
+		}
+		clusters = append(clusters, Cluster{Name: arn.Name, Region: arn.Region, Arn: arn.String()})
+	}
+	return s.describeClusters(ctx, account, partition, regions, clusters)
+}
+
+// describeClusters describes and enriches clusters for Describe and DescribeARNs
+func (s *Scanner) describeClusters(ctx context.Context, account, partition string, regions []string, clusters []Cluster) (*ScanResult, error) {
+	result := newScanResult(account, regions, clusters)
+	result.listed = clusterRefs(account, clusters)
+	result.ScannedRegions = countRegions(regions, clusters, nil, nil)
+	result.Partition = partition
+	result.Labels = s.labels
+	clusters = s.filterARNs(clusters)
+	result.Clusters = clusters
+	err := s.runPhase(ctx, PhaseDescribe, func(ctx context.Context) error {
+		err := s.forEachDescribe(len(clusters), func(i int) error {
+			c := &clusters[i]
+			if err := s.withRefresh(ctx, func() error { return s.describeCluster(ctx, c) }); err != nil {
+				c.setDescribeError(err.Error())
+				s.logf("Error describing cluster %s in region %s: %v\n", c.Name, c.Region, err)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if n := countDescribeErrors(clusters); n > 0 {
+			s.logf("Failed to describe %d of %d clusters\n", n, len(clusters))
+		}
+		result.Clusters, err = s.enrich(ctx, clusters)
+		return err
+	})
+	if err != nil {
+		result.Incomplete = true
+		return result, err
+	}
+	if s.costRates != nil {
+		total := s.costRates.estimateCosts(result.Clusters)
+		result.EstimatedMonthlyCost = &total
+	}
+	result.Warnings = CollectWarnings(result.Clusters)
+	if s.withAccountAlias {
+		result.AccountAlias = s.resolveAlias(ctx)
+	}
+	return result, nil
+}
+
+// enrich applies the filters that need describe output, then runs the
+// optional enrichments on the remaining clusters. On error it still returns
+// the remaining clusters with the enrichments that completed.
+//
+// Every enrichment is off unless its option is set, and one that is off
+// makes no API calls. Each reads describe fields and fills others:
+//
+//	WithAccessEntries      Name -> AccessEntries (eks:ListAccessEntries, DescribeAccessEntry, ListAssociatedAccessPolicies)
+//	WithInstanceCount      Name -> InstanceCount (ec2:DescribeInstances)
+//	WithVpcCidr            VpcID -> VpcCidrs, VpcIPv6Cidrs (ec2:DescribeVpcs)
+//	WithAvailabilityZones  SubnetIDs -> AvailabilityZones, BelowMinAZs (ec2:DescribeSubnets)
+//	WithUpdates            Name -> Updates (eks:ListUpdates, DescribeUpdate)
+//	WithSecurityGroupRules ClusterSecurityGroupID -> SecurityGroupRules (ec2:DescribeSecurityGroupRules)
+//	WithNodegroups         Name -> NodegroupCount, TotalNodes (eks:ListNodegroups, DescribeNodegroup)
+//	WithVersionsBehind     Version -> LatestVersion, VersionsBehind (eks:DescribeClusterVersions)
+//	WithVerifyDNS          Endpoint -> EndpointResolves, EndpointDNSError (DNS lookups only)
+//
+// WithHealth and WithNetwork only parse the DescribeCluster response, in
+// describeCluster, and SubnetIDs is only kept for WithAvailabilityZones.
+// An enrichment that fails on expired credentials is run again after a
+// refresh; each one only sets fields, so a rerun overwrites its own work.
+func (s *Scanner) enrich(ctx context.Context, clusters []Cluster) ([]Cluster, error) {
+	// Apply tag filters now that tags are known
+	if len(s.tagFilters) > 0 {
+		clusters = filterClusters(clusters, func(c *Cluster) bool { return matchesTags(c.Tags, s.tagFilters) })
+	}
+	if len(s.excludeTags) > 0 {
+		clusters = filterClusters(clusters, func(c *Cluster) bool { return !matchesAnyTag(c.Tags, s.excludeTags) })
+	}
+	if len(s.vpcIDs) > 0 {
+		clusters = filterClusters(clusters, func(c *Cluster) bool { return slices.Contains(s.vpcIDs, c.VpcID) })
+	}
+	if s.onlyEOL {
+		clusters = filterClusters(clusters, func(c *Cluster) bool { return c.EOL })
+	}
+
+	// Keep only clusters missing a required tag
+	if len(s.requiredTags) > 0 {
+		clusters = filterClusters(clusters, func(c *Cluster) bool {
+			if c.DescribeError != "" {
+				return false
+			}
+			c.MissingTags = missingTags(c.Tags, s.requiredTags)
+			return len(c.MissingTags) > 0
+		})
+	}
+
+	// Get access entries
+	if s.withAccessEntries {
+		err := s.withRefresh(ctx, func() error { return s.getAccessEntries(ctx, clusters) })
+		if err != nil {
+			return clusters, fmt.Errorf("getting access entries: %w", err)
+		}
+	}
+
+	// Count backing instances
+	if s.withInstanceCount {
+		err := s.withRefresh(ctx, func() error { return s.getInstanceCounts(ctx, clusters) })
+		if err != nil {
+			return clusters, fmt.Errorf("counting instances: %w", err)
+		}
+	}
+
+	// Look up VPC CIDR blocks
+	if s.withVpcCidr {
+		err := s.withRefresh(ctx, func() error { return s.getVpcCidrs(ctx, clusters) })
+		if err != nil {
+			return clusters, fmt.Errorf("describing VPCs: %w", err)
+		}
+	}
+
+	// Map subnets to availability zones
+	if s.minAZs > 0 {
+		err := s.withRefresh(ctx, func() error { return s.getAvailabilityZones(ctx, clusters) })
+		if err != nil {
+			return clusters, fmt.Errorf("describing subnets: %w", err)
+		}
+	}
+
+	// Look up pending and failed updates
+	if s.withUpdates {
+		err := s.withRefresh(ctx, func() error { return s.getUpdates(ctx, clusters) })
+		if err != nil {
+			return clusters, fmt.Errorf("getting updates: %w", err)
+		}
+	}
+
+	// Look up security group rules
+	if s.withSGRules {
+		err := s.withRefresh(ctx, func() error { return s.getSecurityGroupRules(ctx, clusters) })
+		if err != nil {
+			return clusters, fmt.Errorf("describing security group rules: %w", err)
+		}
+	}
+
+	// Sum managed node group sizes
+	if s.withNodegroups {
+		err := s.withRefresh(ctx, func() error { return s.getNodegroupSizes(ctx, clusters) })
+		if err != nil {
+			return clusters, fmt.Errorf("getting node groups: %w", err)
+		}
+	}
+
+	// Compare versions with the latest offered
+	if s.withVersionsBehind {
+		err := s.withRefresh(ctx, func() error { return s.getVersionsBehind(ctx, clusters) })
+		if err != nil {
+			return clusters, fmt.Errorf("getting available versions: %w", err)
+		}
+	}
+
+	// Check that endpoints resolve
+	if s.verifyDNS {
+		err := s.verifyEndpointDNS(ctx, clusters)
+		if err != nil {
+			return clusters, fmt.Errorf("verifying endpoint DNS: %w", err)
+		}
+	}
+
+	return clusters, nil
+}
+
+// newScanResult stamps a result with the schema version, tool version and generation time
+func newScanResult(account string, regions []string, clusters []Cluster) *ScanResult {
+	return &ScanResult{
+		SchemaVersion: SchemaVersion,
+		GeneratedAt:   time.Now().UTC(),
+		ToolVersion:   Version,
+		Account:       account,
+		Regions:       regions,
+		Clusters:      clusters,
+	}
+}
+
+// resolveAccount looks up the account ID and partition of the caller and logs them
+func (s *Scanner) resolveAccount(ctx context.Context) (account, partition string, err error) {
+	// Clients are created inside the call so a refresh reaches them
+	var clientErr error
+	err = s.withRefresh(ctx, func() error {
+		stsClient, err := s.factory.STS(ctx)
+		if err != nil {
+			clientErr = err
+			return nil
+		}
+		account, partition, err = getAccountInfo(ctx, stsClient)
+		return err
+	})
+	if clientErr != nil && isMFARequired(clientErr) {
+		return "", "", ErrMFARequired
+	}
+	if clientErr != nil {
+		return "", "", fmt.Errorf("creating STS client: %w", clientErr)
+	}
+	if err != nil && isSSOExpired(err) {
+		return "", "", &SSOExpiredError{LoginCommand: s.ssoLoginCommand(ctx), Err: err}
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("getting account info: %w", err)
+	}
+	if len(s.expectedAccounts) > 0 && !slices.Contains(s.expectedAccounts, account) {
+		return "", "", &UnexpectedAccountError{Account: account, Expected: s.expectedAccounts}
+	}
+	if partition != PartitionAWS {
+		s.logf("Analyzing EKS clusters for AWS Account: %s (partition %s)\n\n", account, partition)
+	} else {
+		s.logf("Analyzing EKS clusters for AWS Account: %s\n\n", account)
+	}
+	return account, partition, nil
+}
+
+// logf writes a progress message; it is safe for concurrent use
+func (s *Scanner) logf(format string, args ...any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fmt.Fprintf(s.out, format, args...)
+}
+
+// forEach calls fn for every index in [0, n) using at most limit goroutines.
+// It returns the first error reported by fn, if any.
+func (s *Scanner) forEach(n, limit int, fn func(i int) error) error {
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+	sem := make(chan struct{}, limit)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if err := fn(i); err != nil {
+				errOnce.Do(func() { firstErr = err })
+			}
+		}(i)
+	}
+	wg.Wait()
+	return firstErr
+}
+
+// errEmptyAccount is returned when STS answers without an account ID
+var errEmptyAccount = errors.New("STS returned empty account identity; check credentials")
+
+// getAccountInfo retrieves the AWS account ID and, from the caller ARN, its
+// partition. A missing or unparsable ARN is taken to be in the aws partition.
+func getAccountInfo(ctx context.Context, client STSClient) (account, partition string, err error) {
+	clientDetails, err := client.GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+	if err != nil {
+		return "", "", err
+	}
+	if clientDetails == nil || aws.ToString(clientDetails.Account) == "" {
+		return "", "", errEmptyAccount
+	}
+	partition = PartitionAWS
+	if callerArn, err := arn.Parse(aws.ToString(clientDetails.Arn)); err == nil && callerArn.Partition != "" {
+		partition = callerArn.Partition
+	}
+	return *clientDetails.Account, partition, nil
+}
+
+// printRegions prints the list of AWS regions
+func (s *Scanner) printRegions(regions []string) {
+	s.logf("Available AWS Regions:\n")
+	for _, region := range regions {
+		s.logf("* %s \n", region)
+	}
+}
+
+// RegionError records a region whose clusters could not be listed
+type RegionError struct {
+	Region string `json:"region"`
+	Error  string `json:"error"`
+}
+
+// RegionCount is the number of clusters listed in a scanned region. Failed
+// marks a region whose listing failed, so its count is not known. Elapsed is
+// the time spent listing the region, retries included; it is zero when the
+// clusters were named rather than listed. DescribeSkipped counts the clusters
+// left listed only by WithMaxDescribePerRegion.
+type RegionCount struct {
+	Region          string        `json:"region"`
+	Clusters        int           `json:"clusters"`
+	Failed          bool          `json:"failed,omitempty"`
+	Elapsed         time.Duration `json:"elapsed,omitempty"`
+	DescribeSkipped int           `json:"describeSkipped,omitempty"`
+}
+
+// countRegions returns a RegionCount for every region, in order, including
+// regions without clusters. elapsed holds the listing time of each region, or
+// is nil when the regions were not listed.
+func countRegions(regions []string, clusters []Cluster, regionErrs []RegionError, elapsed []time.Duration) []RegionCount {
+	counts := make([]RegionCount, 0, len(regions))
+	for i, region := range regions {
+		count := RegionCount{Region: region}
+		if elapsed != nil {
+			count.Elapsed = elapsed[i]
+		}
+		for _, c := range clusters {
+			if c.Region == region {
+				count.Clusters++
+			}
+		}
+		count.Failed = slices.ContainsFunc(regionErrs, func(e RegionError) bool { return e.Region == region })
+		counts = append(counts, count)
+	}
+	return counts
+}
+
+// regionLister lists the clusters of a single region
+type regionLister func(ctx context.Context, region string) ([]Cluster, error)
+
+// collectRegions runs list for every region. A region whose listing fails is
+// logged, recorded and skipped rather than failing the scan. Clusters are
+// returned grouped by region, in the order the regions were given, along with
+// the time spent on each region.
+func (s *Scanner) collectRegions(ctx context.Context, regions []string, list regionLister) ([]Cluster, []RegionError, []time.Duration, error) {
+	perRegion := make([][]Cluster, len(regions))
+	listErrs := make([]error, len(regions))
+	elapsed := make([]time.Duration, len(regions))
+
+	err := s.forEach(len(regions), s.listConcurrency, func(i int) error {
+		region := regions[i]
+		s.logf("Checking region: %s\n", region)
+		start := time.Now()
+		defer func() {
+			elapsed[i] = time.Since(start)
+			if s.stats != nil {
+				s.stats.recordRegion(region, elapsed[i])
+			}
+		}()
+
+		var found []Cluster
+		listOnce := func() error {
+			var err error
+			found, err = list(ctx, region)
+			return err
+		}
+		err := s.withRefresh(ctx, listOnce)
+		for attempt := 1; attempt <= s.regionRetries && isTransient(err); attempt++ {
+			s.logf("Transient error listing clusters in region %s, restarting (attempt %d of %d): %v\n", region, attempt, s.regionRetries, err)
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(time.Duration(attempt) * regionRetryDelay):
+			}
+			err = s.withRefresh(ctx, listOnce)
+		}
+		var listErr *listError
+		if errors.As(err, &listErr) {
+			s.logf("Error listing clusters in region %s: %v\n", region, listErr.err)
+			listErrs[i] = listErr.err
+			return nil // Skip to next region instead of fatal error
+		}
+		if err != nil {
+			return err
+		}
+		perRegion[i] = found
+		return nil
+	})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	total := 0
+	for _, c := range perRegion {
+		total += len(c)
+	}
+	clusters := make([]Cluster, 0, total)
+	var regionErrs []RegionError
+	for i, c := range perRegion {
+		clusters = append(clusters, c...)
+		if listErrs[i] != nil {
+			regionErrs = append(regionErrs, RegionError{Region: regions[i], Error: listErrs[i].Error()})
+		}
+	}
+	return clusters, regionErrs, elapsed, nil
+}
+
+// listError marks an error that only loses one region, such as a failed list
+// call or a regional client that cannot be built, so the region is recorded
+// and skipped instead of aborting the scan
+type listError struct {
+	err error
+}
+
+func (e *listError) Error() string { return e.err.Error() }
+func (e *listError) Unwrap() error { return e.err }
+
+// getAllClusters gets all EKS clusters across specified regions
+func (s *Scanner) getAllClusters(ctx context.Context, regions []string) ([]Cluster, []RegionError, []time.Duration, error) {
+	return s.collectRegions(ctx, regions, s.listRegionClusters)
+}
+
+// listRegionClusters lists the clusters of one region with eks:ListClusters
+func (s *Scanner) listRegionClusters(ctx context.Context, region string) ([]Cluster, error) {
+	eksClient, err := s.factory.EKS(ctx, region)
+	if err != nil {
+		// A client that cannot be built only loses this region
+		return nil, &listError{fmt.Errorf("creating EKS client: %w", err)}
+	}
+
+	var clusters []Cluster
+	input := &eks.ListClustersInput{}
+	if s.includeConnected {
+		input.Include = []string{"all"}
+	}
+	for {
+		clustersListOutput, err := eksClient.ListClusters(ctx, input, eksAttempts(s.listAttempts)...)
+		if err != nil {
+			return nil, &listError{err}
+		}
+
+		clusters = slices.Grow(clusters, len(clustersListOutput.Clusters))
+		for _, v := range clustersListOutput.Clusters {
+			clusters = append(clusters, Cluster{Name: v, Region: region})
+			s.logf("Found cluster: %s in region: %s\n", v, region)
+		}
+
+		if clustersListOutput.NextToken == nil {
+			return clusters, nil
+		}
+		input.NextToken = clustersListOutput.NextToken
+	}
+}
+
+// getClusterEndpoints describes each cluster in its own region and records its
+// endpoint along with any details requested through options. A cluster that
+// cannot be described, including one whose describe exceeds the per-cluster
+// timeout, is kept as undescribed with the error instead of failing the scan.
+// Only cancellation of ctx itself aborts.
+func (s *Scanner) getClusterEndpoints(ctx context.Context, clusters []Cluster) error {
+	err := s.forEachDescribe(len(clusters), func(i int) error {
+		c := &clusters[i]
+		if c.ListedOnly {
+			return nil
+		}
+		err := s.withRefresh(ctx, func() error { return s.describeCluster(ctx, c) })
+		switch {
+		case err == nil:
+			return nil
+		case ctx.Err() != nil:
+			return err
+		case errors.Is(err, context.DeadlineExceeded):
+			c.setDescribeError(fmt.Sprintf("describe timed out after %s", s.describeTimeout))
+			s.logf("Timed out describing cluster %s in region %s\n", c.Name, c.Region)
+		default:
+			c.setDescribeError(err.Error())
+			s.logf("Error describing cluster %s in region %s: %v\n", c.Name, c.Region, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if n := countDescribeErrors(clusters); n > 0 {
+		s.logf("Failed to describe %d of %d clusters\n", n, len(clusters))
+	}
+	return nil
+}
+
+// countDescribeErrors returns the number of clusters whose describe failed
+func countDescribeErrors(clusters []Cluster) int {
+	n := 0
+	for _, c := range clusters {
+		if c.DescribeError != "" && !c.ListedOnly {
+			n++
+		}
+	}
+	return n
+}
+
+// describeCluster runs DescribeCluster for c in its region and fills in its details
+func (s *Scanner) describeCluster(ctx context.Context, c *Cluster) error {
+	client, err := s.factory.EKS(ctx, c.Region)
+	if err != nil {
+		return fmt.Errorf("creating EKS client for region %s: %w", c.Region, err)
+	}
+	describeCtx := ctx
+	if s.describeTimeout > 0 {
+		var cancel context.CancelFunc
+		describeCtx, cancel = context.WithTimeout(ctx, s.describeTimeout)
+		defer cancel()
+	}
+	optFns := eksAttempts(s.describeAttempts)
+	var bootstrapAddons *bool
+	if s.withNetwork {
+		optFns = append(optFns, readBootstrapAddons(&bootstrapAddons))
+	}
+	clusterInfo, err := client.DescribeCluster(describeCtx, &eks.DescribeClusterInput{Name: aws.String(c.Name)}, optFns...)
+	if err != nil {
+		return err
+	}
+	if clusterInfo == nil || clusterInfo.Cluster == nil {
+		return fmt.Errorf("DescribeCluster returned no details for cluster %s in region %s", c.Name, c.Region)
+	}
+	if arn := aws.ToString(clusterInfo.Cluster.Arn); arn != "" {
+		c.Arn = arn
+	}
+	c.Endpoint = aws.ToString(clusterInfo.Cluster.Endpoint)
+	c.CreatedAt = clusterInfo.Cluster.CreatedAt
+	c.Version = aws.ToString(clusterInfo.Cluster.Version)
+	c.PlatformVersion = aws.ToString(clusterInfo.Cluster.PlatformVersion)
+	if identity := clusterInfo.Cluster.Identity; identity != nil && identity.Oidc != nil {
+		c.OIDCIssuer = aws.ToString(identity.Oidc.Issuer)
+	}
+	c.Tags = clusterInfo.Cluster.Tags
+	if s.protectionTag != nil {
+		protected := matchesTags(c.Tags, s.protectionTag)
+		c.DeletionProtected = &protected
+	}
+	c.EnabledLogTypes = enabledLogTypes(clusterInfo.Cluster.Logging)
+	c.EncryptionKeyArn = secretsKeyArn(clusterInfo.Cluster.EncryptionConfig)
+	c.EncryptionKeyManager = keyManager(c.EncryptionKeyArn)
+	if cc := clusterInfo.Cluster.ConnectorConfig; cc != nil {
+		c.Connector = &Connector{Provider: aws.ToString(cc.Provider), RoleArn: aws.ToString(cc.RoleArn)}
+	}
+	c.EOL = c.Version != "" && IsEOL(c.Version, time.Now())
+	c.BelowMinVersion = s.minVersion != "" && c.Version != "" && compareVersions(c.Version, s.minVersion) < 0
+	if vpc := clusterInfo.Cluster.ResourcesVpcConfig; vpc != nil {
+		c.VpcID = aws.ToString(vpc.VpcId)
+		c.ClusterSecurityGroupID = aws.ToString(vpc.ClusterSecurityGroupId)
+		if s.minAZs > 0 {
+			c.SubnetIDs = vpc.SubnetIds
+		}
+		c.EndpointPublicAccess = vpc.EndpointPublicAccess
+		if vpc.EndpointPublicAccess {
+			c.PublicAccessCidrs = vpc.PublicAccessCidrs
+			if s.allowedCidrs != nil {
+				c.DisallowedCidrs = disallowedCidrs(c.PublicAccessCidrs, s.allowedCidrs)
+			}
+		}
+	}
+	if s.maxAgeWarn > 0 && c.CreatedAt != nil {
+		if age := time.Since(*c.CreatedAt); age > s.maxAgeWarn {
+			c.Stale = true
+			s.logf("Warning: cluster %s in region %s is %s old, exceeding %s\n", c.Name, c.Region, age.Round(time.Hour), s.maxAgeWarn)
+		}
+	}
+	if s.withHealth {
+		c.HealthIssues = healthIssues(clusterInfo.Cluster.Health)
+	}
+	if network := clusterInfo.Cluster.KubernetesNetworkConfig; s.withNetwork && network != nil {
+		c.IPFamily = string(network.IpFamily)
+		c.ServiceIPv4Cidr = aws.ToString(network.ServiceIpv4Cidr)
+		c.ServiceIPv6Cidr = aws.ToString(network.ServiceIpv6Cidr)
+	}
+	if s.withNetwork {
+		c.BootstrapSelfManagedAddons = bootstrapAddons
+	}
+	return nil
+}
+
+// healthIssues converts the health reported by DescribeCluster
+func healthIssues(health *types.ClusterHealth) []HealthIssue {
+	if health == nil {
+		return nil
+	}
+	issues := make([]HealthIssue, 0, len(health.Issues))
+	for _, issue := range health.Issues {
+		issues = append(issues, HealthIssue{
+			Code:        string(issue.Code),
+			Message:     aws.ToString(issue.Message),
+			ResourceIDs: issue.ResourceIds,
+		})
+	}
+	return issues
+}
+
+// describeRegions returns the regions of the partition, from the regions
+// cache when WithRegionsCache is set and it holds a fresh list for account
+func (s *Scanner) describeRegions(ctx context.Context, ec2Client EC2Client, account, partition string) ([]Region, error) {
+	if s.regionsCachePath == "" {
+		return listAwsRegions(ctx, ec2Client)
+	}
+	if !s.refreshRegions {
+		cache, err := loadRegionsCache(s.regionsCachePath)
+		if err != nil {
+			s.logf("Warning: reading region cache %s: %v\n", s.regionsCachePath, err)
+		} else if entry, ok := cache.lookup(account, partition, time.Now(), s.regionsCacheTTL); ok {
+			s.logf("Using the region list cached at %s\n", entry.FetchedAt.Format(time.RFC3339))
+			return entry.Regions, nil
+		}
+	}
+	regions, err := listAwsRegions(ctx, ec2Client)
+	if err != nil {
+		return nil, err
+	}
+	if err := recordRegions(s.regionsCachePath, account, partition, regions, time.Now()); err != nil {
+		s.logf("Warning: updating region cache %s: %v\n", s.regionsCachePath, err)
+	}
+	return regions, nil
+}
+
+// listAwsRegions gets every region of the partition together with its opt-in
+// status. AllRegions is always requested so the caller can tell regions the
+// account has not opted in to apart from regions that do not exist.
+func listAwsRegions(ctx context.Context, ec2Client EC2Client) ([]Region, error) {
+	var regionsSlice []Region
+	regionsOutput, err := ec2Client.DescribeRegions(ctx, &ec2.DescribeRegionsInput{
+		AllRegions: aws.Bool(true),
+	})
+
+	if err != nil {
+		return []Region{}, err
+	}
+
+	for _, region := range regionsOutput.Regions {
+		if region.RegionName == nil {
+			continue
+		}
+		regionsSlice = append(regionsSlice, Region{
+			Name:        *region.RegionName,
+			OptInStatus: aws.ToString(region.OptInStatus),
+		})
+	}
+
+	return regionsSlice, err
+}
+
+// usableRegions returns the names of the regions to scan. Regions the account
+// has not opted in to are only included when includeDisabled is set; calls to
+// them fail until the account opts in.
+func usableRegions(regions []Region, includeDisabled bool) []string {
+	names := make([]string, 0, len(regions))
+	for _, region := range regions {
+		if includeDisabled || region.Enabled() {
+			names = append(names, region.Name)
+		}
+	}
+	return names
+}
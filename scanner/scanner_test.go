@@ -0,0 +1,962 @@
+package scanner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/eks"
+	"github.com/aws/aws-sdk-go-v2/service/eks/types"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/aws/smithy-go"
+)
+
+func TestNewScanner(t *testing.T) {
+	var out bytes.Buffer
+	f := newFakeFactory(nil)
+	tests := []struct {
+		name   string
+		opts   []Option
+		check  func(*Scanner) bool
+		expect string
+	}{
+		{name: "defaults", check: func(s *Scanner) bool {
+			return s.listConcurrency == DefaultConcurrency && s.describeConcurrency == DefaultConcurrency && s.accountConcurrency == 1 && s.out == io.Discard && s.regions == nil
+		}, expect: "default concurrency, discarded output and every region"},
+		{name: "regions", opts: []Option{WithRegions("us-east-1", "eu-west-1")}, check: func(s *Scanner) bool {
+			return slices.Equal(s.regions, []string{"us-east-1", "eu-west-1"})
+		}, expect: "the given regions"},
+		{name: "concurrency", opts: []Option{WithConcurrency(3)}, check: func(s *Scanner) bool {
+			return s.listConcurrency == 3 && s.describeConcurrency == 3
+		}, expect: "both limits set"},
+		{name: "separate limits", opts: []Option{WithConcurrency(3), WithListConcurrency(2), WithDescribeConcurrency(7)}, check: func(s *Scanner) bool {
+			return s.listConcurrency == 2 && s.describeConcurrency == 7
+		}, expect: "the later options winning"},
+		{name: "invalid concurrency ignored", opts: []Option{WithConcurrency(0), WithListConcurrency(-1), WithDescribeConcurrency(0)}, check: func(s *Scanner) bool {
+			return s.listConcurrency == DefaultConcurrency && s.describeConcurrency == DefaultConcurrency
+		}, expect: "the defaults kept"},
+		{name: "profile", opts: []Option{WithProfile("dev")}, check: func(s *Scanner) bool {
+			factory, ok := s.factory.(*DefaultClientFactory)
+			if !ok {
+				return false
+			}
+			loader, ok := factory.Loader.(*DefaultConfigLoader)
+			return ok && loader.Profile == "dev"
+		}, expect: "the default factory loading the profile"},
+		{name: "output", opts: []Option{WithOutput(&out)}, check: func(s *Scanner) bool { return s.out == &out }, expect: "the given writer"},
+		{name: "client factory", opts: []Option{WithClientFactory(f), WithProfile("dev")}, check: func(s *Scanner) bool { return s.factory == f }, expect: "the given factory kept"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if s := NewScanner(tt.opts...); !tt.check(s) {
+				t.Errorf("NewScanner() = %+v, want %s", s, tt.expect)
+			}
+		})
+	}
+}
+
+func TestRunLogsProgress(t *testing.T) {
+	f := newFakeFactory(map[string][]types.Cluster{"us-east-1": {fakeCluster("prod", "1.31")}})
+	var out bytes.Buffer
+	result, err := newFakeScanner(f, WithOutput(&out)).Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Account != "123456789012" || len(result.Clusters) != 1 {
+		t.Errorf("result = account %s, %d clusters; want 123456789012 with prod", result.Account, len(result.Clusters))
+	}
+	if !strings.Contains(out.String(), "Found cluster: prod in region: us-east-1") {
+		t.Errorf("progress lacks the found cluster:\n%s", out.String())
+	}
+}
+
+func TestResultVersioning(t *testing.T) {
+	f := newFakeFactory(map[string][]types.Cluster{"us-east-1": {fakeCluster("prod", "1.31")}})
+	tests := []struct {
+		name string
+		scan func(*Scanner) (*ScanResult, error)
+	}{
+		{name: "run", scan: func(s *Scanner) (*ScanResult, error) { return s.Run(context.Background()) }},
+		{name: "describe", scan: func(s *Scanner) (*ScanResult, error) {
+			return s.Describe(context.Background(), "us-east-1", []string{"prod"})
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := time.Now().UTC()
+			result, err := tt.scan(newFakeScanner(f))
+			if err != nil {
+				t.Fatal(err)
+			}
+			after := time.Now().UTC()
+			if result.SchemaVersion != SchemaVersion || result.ToolVersion != Version {
+				t.Errorf("schema %d, tool %q; want %d, %q", result.SchemaVersion, result.ToolVersion, SchemaVersion, Version)
+			}
+			if result.GeneratedAt.Location() != time.UTC || result.GeneratedAt.Before(before) || result.GeneratedAt.After(after) {
+				t.Errorf("generated at %v, want UTC between %v and %v", result.GeneratedAt, before, after)
+			}
+
+			data, err := json.Marshal(result)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var decoded struct {
+				SchemaVersion *int   `json:"schemaVersion"`
+				GeneratedAt   string `json:"generatedAt"`
+				ToolVersion   string `json:"toolVersion"`
+			}
+			if err := json.Unmarshal(data, &decoded); err != nil {
+				t.Fatal(err)
+			}
+			if decoded.SchemaVersion == nil || *decoded.SchemaVersion != SchemaVersion || decoded.ToolVersion == "" {
+				t.Errorf("encoded schemaVersion %v, toolVersion %q", decoded.SchemaVersion, decoded.ToolVersion)
+			}
+			if generated, err := time.Parse(time.RFC3339Nano, decoded.GeneratedAt); err != nil || !generated.Equal(result.GeneratedAt) {
+				t.Errorf("encoded generatedAt %q: %v", decoded.GeneratedAt, err)
+			}
+		})
+	}
+}
+
+func TestDescribeClusterNetwork(t *testing.T) {
+	tests := []struct {
+		name        string
+		withNetwork bool
+		config      *types.KubernetesNetworkConfigResponse
+		wantFamily  string
+		wantIPv4    string
+		wantIPv6    string
+	}{
+		{
+			name:        "ipv4",
+			withNetwork: true,
+			config:      &types.KubernetesNetworkConfigResponse{IpFamily: types.IpFamilyIpv4, ServiceIpv4Cidr: aws.String("10.100.0.0/16")},
+			wantFamily:  "ipv4",
+			wantIPv4:    "10.100.0.0/16",
+		},
+		{
+			name:        "ipv6",
+			withNetwork: true,
+			config:      &types.KubernetesNetworkConfigResponse{IpFamily: types.IpFamilyIpv6, ServiceIpv4Cidr: aws.String("172.20.0.0/16"), ServiceIpv6Cidr: aws.String("fd12:3456:789a::/108")},
+			wantFamily:  "ipv6",
+			wantIPv4:    "172.20.0.0/16",
+			wantIPv6:    "fd12:3456:789a::/108",
+		},
+		{
+			name:        "no network config",
+			withNetwork: true,
+		},
+		{
+			name:   "without WithNetwork",
+			config: &types.KubernetesNetworkConfigResponse{IpFamily: types.IpFamilyIpv4, ServiceIpv4Cidr: aws.String("10.100.0.0/16")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cluster := fakeCluster("prod", "1.31")
+			cluster.KubernetesNetworkConfig = tt.config
+			f := newFakeFactory(map[string][]types.Cluster{"us-east-1": {cluster}})
+			var opts []Option
+			if tt.withNetwork {
+				opts = append(opts, WithNetwork())
+			}
+			result, err := newFakeScanner(f, opts...).Run(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			c := result.Clusters[0]
+			if c.IPFamily != tt.wantFamily || c.ServiceIPv4Cidr != tt.wantIPv4 || c.ServiceIPv6Cidr != tt.wantIPv6 {
+				t.Errorf("network = %q %q %q, want %q %q %q", c.IPFamily, c.ServiceIPv4Cidr, c.ServiceIPv6Cidr, tt.wantFamily, tt.wantIPv4, tt.wantIPv6)
+			}
+		})
+	}
+}
+
+func TestDescribeClusterHealth(t *testing.T) {
+	tests := []struct {
+		name       string
+		withHealth bool
+		health     *types.ClusterHealth
+		want       []HealthIssue
+	}{
+		{
+			name:       "issues",
+			withHealth: true,
+			health: &types.ClusterHealth{Issues: []types.ClusterIssue{
+				{Code: types.ClusterIssueCodeInsufficientFreeAddresses, Message: aws.String("subnet-1 is full"), ResourceIds: []string{"subnet-1"}},
+				{Code: types.ClusterIssueCodeIamRoleNotFound},
+			}},
+			want: []HealthIssue{
+				{Code: "InsufficientFreeAddresses", Message: "subnet-1 is full", ResourceIDs: []string{"subnet-1"}},
+				{Code: "IamRoleNotFound"},
+			},
+		},
+		{name: "healthy", withHealth: true, health: &types.ClusterHealth{}, want: []HealthIssue{}},
+		{name: "no health reported", withHealth: true},
+		{
+			name:   "without WithHealth",
+			health: &types.ClusterHealth{Issues: []types.ClusterIssue{{Code: types.ClusterIssueCodeVpcNotFound}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cluster := fakeCluster("prod", "1.31")
+			cluster.Health = tt.health
+			f := newFakeFactory(map[string][]types.Cluster{"us-east-1": {cluster}})
+			var opts []Option
+			if tt.withHealth {
+				opts = append(opts, WithHealth())
+			}
+			result, err := newFakeScanner(f, opts...).Run(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := result.Clusters[0].HealthIssues; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("health issues = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDescribeClusterMaxAgeWarn(t *testing.T) {
+	created := func(name string, age time.Duration) types.Cluster {
+		c := fakeCluster(name, "1.31")
+		c.CreatedAt = aws.Time(time.Now().Add(-age))
+		return c
+	}
+	const day = 24 * time.Hour
+	f := newFakeFactory(map[string][]types.Cluster{"us-east-1": {
+		created("old", 100*day), created("young", 10*day), fakeCluster("undated", "1.31"),
+	}})
+	tests := []struct {
+		name      string
+		opts      []Option
+		wantStale map[string]bool
+	}{
+		{name: "threshold", opts: []Option{WithMaxAgeWarn(30 * day)}, wantStale: map[string]bool{"old": true}},
+		{name: "no threshold"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var log bytes.Buffer
+			result, err := newFakeScanner(f, append(tt.opts, WithOutput(&log))...).Run(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(result.Clusters) != 3 {
+				t.Fatalf("%d clusters, want all 3 kept", len(result.Clusters))
+			}
+			for _, c := range result.Clusters {
+				if c.Stale != tt.wantStale[c.Name] {
+					t.Errorf("%s stale = %t, want %t", c.Name, c.Stale, tt.wantStale[c.Name])
+				}
+			}
+			if got := strings.Count(log.String(), "Warning: cluster "); got != len(tt.wantStale) {
+				t.Errorf("%d age warnings logged, want %d:\n%s", got, len(tt.wantStale), log.String())
+			}
+			if tt.wantStale["old"] && !strings.Contains(log.String(), "Warning: cluster old in region us-east-1 is 2400h0m0s old, exceeding 720h0m0s") {
+				t.Errorf("log lacks the age warning:\n%s", log.String())
+			}
+		})
+	}
+}
+
+func TestRunLabels(t *testing.T) {
+	f := newFakeFactory(map[string][]types.Cluster{"us-east-1": {fakeCluster("prod", "1.31")}})
+	tests := []struct {
+		name string
+		opts []Option
+		want map[string]string
+	}{
+		{name: "no labels"},
+		{
+			name: "labels",
+			opts: []Option{WithLabel("run-id", "123"), WithLabel("env", "dev"), WithLabel("env", "prod"), WithLabel("note", "")},
+			want: map[string]string{"run-id": "123", "env": "prod", "note": ""},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := newFakeScanner(f, tt.opts...).Run(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(result.Labels, tt.want) {
+				t.Errorf("labels = %v, want %v", result.Labels, tt.want)
+			}
+			for _, c := range result.Flatten() {
+				if !reflect.DeepEqual(c.Labels, tt.want) {
+					t.Errorf("%s labels = %v, want the scan's %v", c.Name, c.Labels, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestDescribeClusterDeletionProtection(t *testing.T) {
+	tagged := func(name string, tags map[string]string) types.Cluster {
+		c := fakeCluster(name, "1.31")
+		c.Tags = tags
+		return c
+	}
+	f := newFakeFactory(map[string][]types.Cluster{"us-east-1": {
+		tagged("locked", map[string]string{"protected": "true"}),
+		tagged("unlocked", map[string]string{"protected": "false"}),
+		tagged("untagged", nil),
+	}})
+	tests := []struct {
+		name string
+		opts []Option
+		want map[string]*bool
+	}{
+		{name: "no protection tag", want: map[string]*bool{}},
+		{
+			name: "tag key",
+			opts: []Option{WithProtectionTag("protected")},
+			want: map[string]*bool{"locked": aws.Bool(true), "unlocked": aws.Bool(true), "untagged": aws.Bool(false)},
+		},
+		{
+			name: "tag value",
+			opts: []Option{WithProtectionTag("protected", "true")},
+			want: map[string]*bool{"locked": aws.Bool(true), "unlocked": aws.Bool(false), "untagged": aws.Bool(false)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := newFakeScanner(f, tt.opts...).Run(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, c := range result.Clusters {
+				got, want := c.DeletionProtected, tt.want[c.Name]
+				if (got == nil) != (want == nil) || got != nil && *got != *want {
+					t.Errorf("%s protected = %v, want %v", c.Name, got, want)
+				}
+			}
+		})
+	}
+}
+
+// connectorEKS lists the connected clusters of a fakeEKS only when
+// ListClusters asks for them with Include, as EKS does
+type connectorEKS struct {
+	*fakeEKS
+}
+
+func (c *connectorEKS) ListClusters(ctx context.Context, params *eks.ListClustersInput, optFns ...func(*eks.Options)) (*eks.ListClustersOutput, error) {
+	out, err := c.fakeEKS.ListClusters(ctx, params, optFns...)
+	if err != nil || slices.Contains(params.Include, "all") {
+		return out, err
+	}
+	out.Clusters = slices.DeleteFunc(out.Clusters, func(name string) bool {
+		i := slices.IndexFunc(c.clusters, func(cluster types.Cluster) bool { return aws.ToString(cluster.Name) == name })
+		return c.clusters[i].ConnectorConfig != nil
+	})
+	return out, nil
+}
+
+func TestRunConnectedClusters(t *testing.T) {
+	onprem := types.Cluster{
+		Name:            aws.String("onprem"),
+		Status:          types.ClusterStatusActive,
+		ConnectorConfig: &types.ConnectorConfigResponse{Provider: aws.String("EKS_ANYWHERE"), RoleArn: aws.String("arn:aws:iam::123456789012:role/connector")},
+	}
+	f := newFakeFactory(map[string][]types.Cluster{"us-east-1": {fakeCluster("prod", "1.31"), onprem}})
+	factory := &singleEKSFactory{fakeFactory: f, client: &connectorEKS{f.region("us-east-1")}}
+	tests := []struct {
+		name string
+		opts []Option
+		want map[string]*Connector
+	}{
+		{name: "eks clusters only", want: map[string]*Connector{"prod": nil}},
+		{
+			name: "with connected clusters",
+			opts: []Option{WithConnected()},
+			want: map[string]*Connector{"prod": nil, "onprem": {Provider: "EKS_ANYWHERE", RoleArn: "arn:aws:iam::123456789012:role/connector"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := append([]Option{WithClientFactory(factory), WithRegions("us-east-1")}, tt.opts...)
+			result, err := NewScanner(opts...).Run(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := make(map[string]*Connector)
+			for _, c := range result.Clusters {
+				got[c.Name] = c.Connector
+				if c.Connected() && (c.EOL || c.Endpoint != "") {
+					t.Errorf("%s = EOL %t, endpoint %q; want neither for a connected cluster", c.Name, c.EOL, c.Endpoint)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("connectors = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDescribeNames(t *testing.T) {
+	f := newFakeFactory(map[string][]types.Cluster{"us-east-1": {fakeCluster("prod", "1.31"), fakeCluster("dev", "1.30")}})
+	var log bytes.Buffer
+	s := NewScanner(WithClientFactory(f), WithOutput(&log))
+	result, err := s.Describe(context.Background(), "us-east-1", []string{"prod", "missing", "dev"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Clusters) != 3 {
+		t.Fatalf("%d clusters, want every name kept", len(result.Clusters))
+	}
+	for _, c := range result.Clusters {
+		if c.Region != "us-east-1" || c.Arn != "arn:aws:eks:us-east-1:123456789012:cluster/"+c.Name {
+			t.Errorf("%s = region %s, ARN %s", c.Name, c.Region, c.Arn)
+		}
+		switch c.Name {
+		case "missing":
+			if !c.Undescribed || !strings.Contains(c.DescribeError, "No cluster found for name: missing") {
+				t.Errorf("missing = undescribed %t, error %q; want the not-found error", c.Undescribed, c.DescribeError)
+			}
+		default:
+			if c.Undescribed || c.Endpoint == "" || c.Version == "" {
+				t.Errorf("%s = undescribed %t, endpoint %q, version %q; want it described", c.Name, c.Undescribed, c.Endpoint, c.Version)
+			}
+		}
+	}
+	if calls := f.region("us-east-1").listCalls; calls != 0 {
+		t.Errorf("%d ListClusters calls, want none", calls)
+	}
+	if !slices.Equal(result.Regions, []string{"us-east-1"}) {
+		t.Errorf("regions = %q, want us-east-1", result.Regions)
+	}
+	if !strings.Contains(log.String(), "Error describing cluster missing in region us-east-1") {
+		t.Errorf("log lacks the describe error:\n%s", log.String())
+	}
+}
+
+func TestDescribeARNs(t *testing.T) {
+	f := newFakeFactory(map[string][]types.Cluster{
+		"us-east-1": {fakeCluster("prod", "1.31")},
+		"eu-west-1": {fakeCluster("eu", "1.30")},
+	})
+	arns := []ClusterARN{
+		{Partition: "aws", Region: "us-east-1", Account: "123456789012", Name: "prod"},
+		{Partition: "aws", Region: "eu-west-1", Account: "123456789012", Name: "eu"},
+		{Partition: "aws", Region: "us-east-1", Account: "210987654321", Name: "other"},
+	}
+	var log bytes.Buffer
+	result, err := NewScanner(WithClientFactory(f), WithOutput(&log)).DescribeARNs(context.Background(), arns)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Clusters) != len(arns) {
+		t.Fatalf("%d clusters, want one per ARN", len(result.Clusters))
+	}
+	for i, c := range result.Clusters {
+		if c.Name != arns[i].Name || c.Region != arns[i].Region || c.Arn != arns[i].String() {
+			t.Errorf("cluster %d = %s in %s (%s), want %s", i, c.Name, c.Region, c.Arn, arns[i])
+		}
+		if undescribed := c.Name == "other"; c.Undescribed != undescribed {
+			t.Errorf("%s undescribed = %t, want %t", c.Name, c.Undescribed, undescribed)
+		}
+	}
+	if !slices.Equal(result.Regions, []string{"us-east-1", "eu-west-1"}) {
+		t.Errorf("regions = %q, want us-east-1 and eu-west-1", result.Regions)
+	}
+	if f.region("us-east-1").listCalls+f.region("eu-west-1").listCalls != 0 {
+		t.Error("DescribeARNs listed clusters")
+	}
+	if !strings.Contains(log.String(), "Warning: cluster other is in account 210987654321 (partition aws), not 123456789012 (partition aws)") {
+		t.Errorf("log lacks the other account warning:\n%s", log.String())
+	}
+}
+
+// flakyEKS fails the ListClusters calls numbered in fail, counting from 1
+type flakyEKS struct {
+	*fakeEKS
+	fail map[int]error
+
+	mu    sync.Mutex
+	calls int
+}
+
+func (c *flakyEKS) ListClusters(ctx context.Context, params *eks.ListClustersInput, optFns ...func(*eks.Options)) (*eks.ListClustersOutput, error) {
+	c.mu.Lock()
+	c.calls++
+	err := c.fail[c.calls]
+	c.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	return c.fakeEKS.ListClusters(ctx, params, optFns...)
+}
+
+func TestRunUndescribedClusters(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+	}{
+		{name: "access denied", err: &smithy.GenericAPIError{Code: "AccessDeniedException", Message: "not authorized"}},
+		{name: "throttled", err: &smithy.GenericAPIError{Code: "ThrottlingException", Message: "Rate exceeded"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFakeFactory(map[string][]types.Cluster{"us-east-1": {fakeCluster("healthy", "1.31"), fakeCluster("broken", "1.30")}})
+			f.region("us-east-1").describeErr = map[string]error{"broken": tt.err}
+			var buf bytes.Buffer
+
+			result, err := newFakeScanner(f, WithOutput(&buf)).Run(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(result.Clusters) != 2 {
+				t.Fatalf("clusters = %+v, want both kept", result.Clusters)
+			}
+			for _, c := range result.Clusters {
+				switch c.Name {
+				case "healthy":
+					if c.Undescribed || c.DescribeError != "" || c.Version != "1.31" || c.Endpoint != "https://healthy.eks.example.com" {
+						t.Errorf("healthy = %+v, want fully described", c)
+					}
+				case "broken":
+					if !c.Undescribed || !strings.Contains(c.DescribeError, tt.err.Error()) || c.Region != "us-east-1" || c.Version != "" || c.Endpoint != "" {
+						t.Errorf("broken = %+v, want undescribed with the describe error", c)
+					}
+				}
+			}
+			if n := Summarize(result).DescribeErrors; n != 1 {
+				t.Errorf("summary describe errors = %d, want 1", n)
+			}
+			if w := warningsWithCode(result.Warnings, WarningUndescribed); len(w) != 1 || w[0].Cluster != "broken" {
+				t.Errorf("undescribed warnings = %+v, want broken", w)
+			}
+			if want := "Failed to describe 1 of 2 clusters\n"; !strings.Contains(buf.String(), want) {
+				t.Errorf("log lacks %q:\n%s", want, buf.String())
+			}
+		})
+	}
+}
+
+// brokenEKSFactory fails to build the EKS clients of the regions in errs
+type brokenEKSFactory struct {
+	*fakeFactory
+	errs map[string]error
+}
+
+func (f *brokenEKSFactory) EKS(ctx context.Context, region string) (EKSClient, error) {
+	if err := f.errs[region]; err != nil {
+		return nil, err
+	}
+	return f.fakeFactory.EKS(ctx, region)
+}
+
+func TestRunIsolatesClientErrors(t *testing.T) {
+	tests := []struct {
+		name         string
+		errs         map[string]error
+		wantClusters []string
+		wantFailed   []string
+	}{
+		{name: "all clients built", wantClusters: []string{"dev", "eu", "prod"}},
+		{
+			name:         "one region fails",
+			errs:         map[string]error{"eu-west-1": errors.New("no endpoint")},
+			wantClusters: []string{"dev", "prod"},
+			wantFailed:   []string{"eu-west-1"},
+		},
+		{
+			name:       "every region fails",
+			errs:       map[string]error{"eu-west-1": errors.New("no endpoint"), "us-east-1": errors.New("no endpoint")},
+			wantFailed: []string{"eu-west-1", "us-east-1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &brokenEKSFactory{fakeFactory: newFakeFactory(map[string][]types.Cluster{
+				"us-east-1": {fakeCluster("prod", "1.31"), fakeCluster("dev", "1.31")},
+				"eu-west-1": {fakeCluster("eu", "1.31")},
+			}), errs: tt.errs}
+			result, err := NewScanner(WithClientFactory(f), WithRegions("us-east-1", "eu-west-1")).Run(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			var names []string
+			for _, c := range result.Clusters {
+				names = append(names, c.Name)
+			}
+			slices.Sort(names)
+			if !slices.Equal(names, tt.wantClusters) {
+				t.Errorf("clusters = %q, want %q", names, tt.wantClusters)
+			}
+			var failed []string
+			for _, regionErr := range result.RegionErrors {
+				failed = append(failed, regionErr.Region)
+				if !strings.Contains(regionErr.Error, "creating EKS client: no endpoint") {
+					t.Errorf("%s error = %q, want the client error", regionErr.Region, regionErr.Error)
+				}
+			}
+			slices.Sort(failed)
+			if !slices.Equal(failed, tt.wantFailed) {
+				t.Errorf("failed regions = %q, want %q", failed, tt.wantFailed)
+			}
+		})
+	}
+}
+
+func TestRegionRetries(t *testing.T) {
+	throttled := &smithy.GenericAPIError{Code: "ThrottlingException", Message: "Rate exceeded"}
+	denied := &smithy.GenericAPIError{Code: "AccessDeniedException"}
+	tests := []struct {
+		name         string
+		retries      int
+		fail         map[int]error
+		wantClusters int
+		wantCalls    int
+		wantErr      bool
+	}{
+		{name: "relisted from the first page", retries: 1, fail: map[int]error{2: throttled}, wantClusters: 2, wantCalls: 4},
+		{name: "no retries", fail: map[int]error{2: throttled}, wantCalls: 2, wantErr: true},
+		{name: "retries exhausted", retries: 1, fail: map[int]error{1: throttled, 2: throttled}, wantCalls: 2, wantErr: true},
+		{name: "permission errors not retried", retries: 2, fail: map[int]error{1: denied}, wantCalls: 1, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			f := newFakeFactory(map[string][]types.Cluster{"us-east-1": {fakeCluster("a", "1.31"), fakeCluster("b", "1.31")}})
+			client := &flakyEKS{fakeEKS: f.region("us-east-1"), fail: tt.fail}
+			client.pageSize = 1
+			s := NewScanner(WithClientFactory(&singleEKSFactory{fakeFactory: f, client: client}), WithRegions("us-east-1"), WithRegionRetries(tt.retries))
+			result, err := s.Run(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(result.Clusters) != tt.wantClusters {
+				t.Errorf("clusters = %+v, want %d", result.Clusters, tt.wantClusters)
+			}
+			if failed := len(result.RegionErrors) == 1; failed != tt.wantErr {
+				t.Errorf("region errors = %+v, want failed %t", result.RegionErrors, tt.wantErr)
+			}
+			if client.calls != tt.wantCalls {
+				t.Errorf("%d ListClusters calls, want %d", client.calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+// slowEKS blocks the describes of the clusters in slow, and every listing
+// when slowList is set, until their context ends
+type slowEKS struct {
+	*fakeEKS
+	slow     map[string]bool
+	slowList bool
+}
+
+func (c *slowEKS) ListClusters(ctx context.Context, params *eks.ListClustersInput, optFns ...func(*eks.Options)) (*eks.ListClustersOutput, error) {
+	if c.slowList {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	return c.fakeEKS.ListClusters(ctx, params, optFns...)
+}
+
+func (c *slowEKS) DescribeCluster(ctx context.Context, params *eks.DescribeClusterInput, optFns ...func(*eks.Options)) (*eks.DescribeClusterOutput, error) {
+	if c.slow[aws.ToString(params.Name)] {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	return c.fakeEKS.DescribeCluster(ctx, params, optFns...)
+}
+
+func TestDescribeTimeout(t *testing.T) {
+	f := newFakeFactory(map[string][]types.Cluster{"us-east-1": {fakeCluster("fast", "1.31"), fakeCluster("stuck", "1.31")}})
+	client := &slowEKS{fakeEKS: f.region("us-east-1"), slow: map[string]bool{"stuck": true}}
+	s := NewScanner(WithClientFactory(&singleEKSFactory{fakeFactory: f, client: client}), WithRegions("us-east-1"), WithDescribeTimeout(10*time.Millisecond))
+
+	result, err := s.Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Clusters) != 2 {
+		t.Fatalf("clusters = %+v, want fast and stuck", result.Clusters)
+	}
+	fast, stuck := result.Clusters[0], result.Clusters[1]
+	if fast.DescribeError != "" || fast.Endpoint == "" {
+		t.Errorf("fast = %+v, want described", fast)
+	}
+	if stuck.DescribeError != "describe timed out after 10ms" || stuck.Endpoint != "" {
+		t.Errorf("stuck describe error = %q, want the timeout", stuck.DescribeError)
+	}
+}
+
+func TestDescribeTimeoutCanceledScan(t *testing.T) {
+	f := newFakeFactory(map[string][]types.Cluster{"us-east-1": {fakeCluster("stuck", "1.31")}})
+	client := &slowEKS{fakeEKS: f.region("us-east-1"), slow: map[string]bool{"stuck": true}}
+	s := NewScanner(WithClientFactory(&singleEKSFactory{fakeFactory: f, client: client}), WithRegions("us-east-1"), WithDescribeTimeout(time.Hour))
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	clusters := []Cluster{{Name: "stuck", Region: "us-east-1"}}
+	if err := s.getClusterEndpoints(ctx, clusters); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want the scan deadline", err)
+	}
+	if clusters[0].DescribeError != "" {
+		t.Errorf("describe error = %q, want none when the scan itself ends", clusters[0].DescribeError)
+	}
+}
+
+// gauge tracks the peak number of calls in flight
+type gauge struct {
+	mu     sync.Mutex
+	active int
+	peak   int
+}
+
+// enter records a call in flight until the returned func is called
+func (g *gauge) enter() func() {
+	g.mu.Lock()
+	g.active++
+	g.peak = max(g.peak, g.active)
+	g.mu.Unlock()
+	time.Sleep(2 * time.Millisecond)
+	return func() {
+		g.mu.Lock()
+		g.active--
+		g.mu.Unlock()
+	}
+}
+
+// gaugedFactory is a fakeFactory measuring the listing and describe concurrency across regions
+type gaugedFactory struct {
+	*fakeFactory
+	list, describe gauge
+}
+
+func (f *gaugedFactory) EKS(ctx context.Context, region string) (EKSClient, error) {
+	client, err := f.fakeFactory.EKS(ctx, region)
+	if err != nil {
+		return nil, err
+	}
+	return &gaugedEKS{fakeEKS: client.(*fakeEKS), factory: f}, nil
+}
+
+type gaugedEKS struct {
+	*fakeEKS
+	factory *gaugedFactory
+}
+
+func (c *gaugedEKS) ListClusters(ctx context.Context, params *eks.ListClustersInput, optFns ...func(*eks.Options)) (*eks.ListClustersOutput, error) {
+	defer c.factory.list.enter()()
+	return c.fakeEKS.ListClusters(ctx, params, optFns...)
+}
+
+func (c *gaugedEKS) DescribeCluster(ctx context.Context, params *eks.DescribeClusterInput, optFns ...func(*eks.Options)) (*eks.DescribeClusterOutput, error) {
+	defer c.factory.describe.enter()()
+	return c.fakeEKS.DescribeCluster(ctx, params, optFns...)
+}
+
+func TestConcurrencyLimits(t *testing.T) {
+	tests := []struct {
+		name         string
+		opts         []Option
+		wantList     int
+		wantDescribe int
+	}{
+		{name: "sequential", opts: []Option{WithConcurrency(1)}, wantList: 1, wantDescribe: 1},
+		{name: "shared limit", opts: []Option{WithConcurrency(3)}, wantList: 3, wantDescribe: 3},
+		{name: "separate limits", opts: []Option{WithListConcurrency(2), WithDescribeConcurrency(5)}, wantList: 2, wantDescribe: 5},
+		{name: "overrides", opts: []Option{WithConcurrency(4), WithListConcurrency(1), WithDescribeConcurrency(0)}, wantList: 1, wantDescribe: 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clusters := make(map[string][]types.Cluster)
+			for _, region := range []string{"ap-south-1", "eu-west-1", "eu-west-2", "us-east-1", "us-east-2", "us-west-2"} {
+				clusters[region] = []types.Cluster{fakeCluster(region+"-a", "1.31"), fakeCluster(region+"-b", "1.31")}
+			}
+			f := &gaugedFactory{fakeFactory: newFakeFactory(clusters)}
+			opts := append([]Option{WithClientFactory(f), WithRegions(f.ec2.regions...)}, tt.opts...)
+			result, err := NewScanner(opts...).Run(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(result.Clusters) != 12 {
+				t.Errorf("%d clusters, want 12", len(result.Clusters))
+			}
+			if f.list.peak > tt.wantList || f.describe.peak > tt.wantDescribe {
+				t.Errorf("peak concurrency = %d listing, %d describing; want at most %d and %d", f.list.peak, f.describe.peak, tt.wantList, tt.wantDescribe)
+			}
+		})
+	}
+}
+
+// callCounter counts API calls by name
+type callCounter struct {
+	mu    sync.Mutex
+	calls map[string]int
+}
+
+func (c *callCounter) count(api string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calls[api]++
+}
+
+// countingFactory is a fakeFactory counting every STS, EC2 and EKS call. The
+// calls the fakes do not serve succeed with empty output.
+type countingFactory struct {
+	*fakeFactory
+	counter *callCounter
+}
+
+func (f *countingFactory) STS(ctx context.Context) (STSClient, error) {
+	client, err := f.fakeFactory.STS(ctx)
+	return &countingSTS{STSClient: client, callCounter: f.counter}, err
+}
+
+func (f *countingFactory) EC2(ctx context.Context, region string) (EC2Client, error) {
+	client, err := f.fakeFactory.EC2(ctx, region)
+	return &countingEC2{EC2Client: client, callCounter: f.counter}, err
+}
+
+func (f *countingFactory) EKS(ctx context.Context, region string) (EKSClient, error) {
+	client, err := f.fakeFactory.EKS(ctx, region)
+	return &countingEKS{EKSClient: client, callCounter: f.counter}, err
+}
+
+type countingSTS struct {
+	STSClient
+	*callCounter
+}
+
+func (c *countingSTS) GetCallerIdentity(ctx context.Context, params *sts.GetCallerIdentityInput, optFns ...func(*sts.Options)) (*sts.GetCallerIdentityOutput, error) {
+	c.count("GetCallerIdentity")
+	return c.STSClient.GetCallerIdentity(ctx, params, optFns...)
+}
+
+type countingEC2 struct {
+	EC2Client
+	*callCounter
+}
+
+func (c *countingEC2) DescribeRegions(ctx context.Context, params *ec2.DescribeRegionsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeRegionsOutput, error) {
+	c.count("DescribeRegions")
+	return c.EC2Client.DescribeRegions(ctx, params, optFns...)
+}
+
+func (c *countingEC2) DescribeInstances(ctx context.Context, params *ec2.DescribeInstancesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error) {
+	c.count("DescribeInstances")
+	return &ec2.DescribeInstancesOutput{}, nil
+}
+
+func (c *countingEC2) DescribeVpcs(ctx context.Context, params *ec2.DescribeVpcsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeVpcsOutput, error) {
+	c.count("DescribeVpcs")
+	return &ec2.DescribeVpcsOutput{}, nil
+}
+
+func (c *countingEC2) DescribeSubnets(ctx context.Context, params *ec2.DescribeSubnetsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeSubnetsOutput, error) {
+	c.count("DescribeSubnets")
+	return &ec2.DescribeSubnetsOutput{}, nil
+}
+
+func (c *countingEC2) DescribeSecurityGroupRules(ctx context.Context, params *ec2.DescribeSecurityGroupRulesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeSecurityGroupRulesOutput, error) {
+	c.count("DescribeSecurityGroupRules")
+	return &ec2.DescribeSecurityGroupRulesOutput{}, nil
+}
+
+type countingEKS struct {
+	EKSClient
+	*callCounter
+}
+
+func (c *countingEKS) ListClusters(ctx context.Context, params *eks.ListClustersInput, optFns ...func(*eks.Options)) (*eks.ListClustersOutput, error) {
+	c.count("ListClusters")
+	return c.EKSClient.ListClusters(ctx, params, optFns...)
+}
+
+func (c *countingEKS) DescribeCluster(ctx context.Context, params *eks.DescribeClusterInput, optFns ...func(*eks.Options)) (*eks.DescribeClusterOutput, error) {
+	c.count("DescribeCluster")
+	return c.EKSClient.DescribeCluster(ctx, params, optFns...)
+}
+
+func (c *countingEKS) DescribeClusterVersions(ctx context.Context, params *eks.DescribeClusterVersionsInput, optFns ...func(*eks.Options)) (*eks.DescribeClusterVersionsOutput, error) {
+	c.count("DescribeClusterVersions")
+	return c.EKSClient.DescribeClusterVersions(ctx, params, optFns...)
+}
+
+func (c *countingEKS) ListAccessEntries(ctx context.Context, params *eks.ListAccessEntriesInput, optFns ...func(*eks.Options)) (*eks.ListAccessEntriesOutput, error) {
+	c.count("ListAccessEntries")
+	return c.EKSClient.ListAccessEntries(ctx, params, optFns...)
+}
+
+func (c *countingEKS) ListNodegroups(ctx context.Context, params *eks.ListNodegroupsInput, optFns ...func(*eks.Options)) (*eks.ListNodegroupsOutput, error) {
+	c.count("ListNodegroups")
+	return &eks.ListNodegroupsOutput{}, nil
+}
+
+func (c *countingEKS) ListUpdates(ctx context.Context, params *eks.ListUpdatesInput, optFns ...func(*eks.Options)) (*eks.ListUpdatesOutput, error) {
+	c.count("ListUpdates")
+	return &eks.ListUpdatesOutput{}, nil
+}
+
+func TestRunEnrichmentCalls(t *testing.T) {
+	core := []string{"GetCallerIdentity", "DescribeRegions", "ListClusters", "DescribeCluster"}
+	enrichments := []string{
+		"ListAccessEntries", "DescribeInstances", "DescribeVpcs", "DescribeSubnets",
+		"ListUpdates", "DescribeSecurityGroupRules", "ListNodegroups", "DescribeClusterVersions",
+	}
+	tests := []struct {
+		name string
+		opt  Option
+		want string
+	}{
+		{name: "all enrichments off"},
+		{name: "access entries", opt: WithAccessEntries(""), want: "ListAccessEntries"},
+		{name: "instance count", opt: WithInstanceCount(), want: "DescribeInstances"},
+		{name: "vpc cidr", opt: WithVpcCidr(), want: "DescribeVpcs"},
+		{name: "availability zones", opt: WithAvailabilityZones(2), want: "DescribeSubnets"},
+		{name: "updates", opt: WithUpdates(), want: "ListUpdates"},
+		{name: "security group rules", opt: WithSecurityGroupRules(), want: "DescribeSecurityGroupRules"},
+		{name: "nodegroups", opt: WithNodegroups(), want: "ListNodegroups"},
+		{name: "versions behind", opt: WithVersionsBehind(), want: "DescribeClusterVersions"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cluster := fakeCluster("prod", "1.31")
+			cluster.ResourcesVpcConfig = &types.VpcConfigResponse{
+				VpcId:                  aws.String("vpc-1"),
+				SubnetIds:              []string{"subnet-1", "subnet-2"},
+				ClusterSecurityGroupId: aws.String("sg-1"),
+			}
+			counter := &callCounter{calls: make(map[string]int)}
+			f := &countingFactory{fakeFactory: newFakeFactory(map[string][]types.Cluster{"us-east-1": {cluster}}), counter: counter}
+			f.region("us-east-1").versions = []string{"1.32", "1.31"}
+			opts := []Option{WithClientFactory(f)}
+			if tt.opt != nil {
+				opts = append(opts, tt.opt)
+			}
+			if _, err := NewScanner(opts...).Run(context.Background()); err != nil && tt.opt == nil {
+				t.Fatal(err)
+			}
+
+			for _, api := range core {
+				if counter.calls[api] == 0 {
+					t.Errorf("%s not called", api)
+				}
+			}
+			for _, api := range enrichments {
+				if n := counter.calls[api]; (n > 0) != (api == tt.want) {
+					t.Errorf("%s called %d times", api, n)
+				}
+			}
+		})
+	}
+}
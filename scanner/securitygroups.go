@@ -0,0 +1,133 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+// SecurityGroupRule summarizes one ingress or egress rule of a security group
+type SecurityGroupRule struct {
+	Direction string `json:"direction"`
+	Protocol  string `json:"protocol"`
+	// Ports is a single port, a from-to range, or "all".
+	Ports string `json:"ports"`
+	// Source is the CIDR block, prefix list or security group the rule applies to:
+	// the peer for ingress rules and the destination for egress rules.
+	Source      string `json:"source"`
+	Description string `json:"description,omitempty"`
+}
+
+// getSecurityGroupRules looks up the rules of the clusters' security groups
+// with one batched DescribeSecurityGroupRules per region and records them on
+// the clusters. Clusters whose group no longer exists are logged and left without rules.
+func (s *Scanner) getSecurityGroupRules(ctx context.Context, clusters []Cluster) error {
+	regions, byRegion := groupByRegion(clusters)
+	return s.forEach(len(regions), s.listConcurrency, func(i int) error {
+		region := regions[i]
+
+		var groupIDs []string
+		seen := make(map[string]bool)
+		for _, idx := range byRegion[region] {
+			if id := clusters[idx].ClusterSecurityGroupID; id != "" && !seen[id] {
+				seen[id] = true
+				groupIDs = append(groupIDs, id)
+			}
+		}
+		if len(groupIDs) == 0 {
+			return nil
+		}
+
+		client, err := s.factory.EC2(ctx, region)
+		if err != nil {
+			return fmt.Errorf("creating EC2 client for region %s: %w", region, err)
+		}
+		rules, err := describeSecurityGroupRules(ctx, client, groupIDs)
+		if err != nil {
+			return fmt.Errorf("region %s: %w", region, err)
+		}
+		for _, idx := range byRegion[region] {
+			c := &clusters[idx]
+			if c.ClusterSecurityGroupID == "" {
+				continue
+			}
+			groupRules, ok := rules[c.ClusterSecurityGroupID]
+			if !ok {
+				s.logf("Security group %s of cluster %s in region %s was not found\n", c.ClusterSecurityGroupID, c.Name, c.Region)
+				continue
+			}
+			c.SecurityGroupRules = groupRules
+		}
+		return nil
+	})
+}
+
+// describeSecurityGroupRules returns the rules of each security group, following pagination.
+// Groups that do not exist, or have no rules, are absent from the result.
+func describeSecurityGroupRules(ctx context.Context, client EC2Client, groupIDs []string) (map[string][]SecurityGroupRule, error) {
+	result := make(map[string][]SecurityGroupRule, len(groupIDs))
+	for start := 0; start < len(groupIDs); start += maxFilterValues {
+		end := min(start+maxFilterValues, len(groupIDs))
+		input := &ec2.DescribeSecurityGroupRulesInput{
+			Filters: []types.Filter{{Name: aws.String("group-id"), Values: groupIDs[start:end]}},
+		}
+		for {
+			page, err := client.DescribeSecurityGroupRules(ctx, input)
+			if err != nil {
+				return nil, err
+			}
+			for _, rule := range page.SecurityGroupRules {
+				id := aws.ToString(rule.GroupId)
+				result[id] = append(result[id], securityGroupRuleOf(rule))
+			}
+			if page.NextToken == nil {
+				break
+			}
+			input.NextToken = page.NextToken
+		}
+	}
+	return result, nil
+}
+
+// securityGroupRuleOf summarizes a rule returned by DescribeSecurityGroupRules
+func securityGroupRuleOf(rule types.SecurityGroupRule) SecurityGroupRule {
+	summary := SecurityGroupRule{
+		Direction:   "ingress",
+		Protocol:    aws.ToString(rule.IpProtocol),
+		Ports:       portRange(aws.ToInt32(rule.FromPort), aws.ToInt32(rule.ToPort)),
+		Description: aws.ToString(rule.Description),
+	}
+	if aws.ToBool(rule.IsEgress) {
+		summary.Direction = "egress"
+	}
+	if summary.Protocol == "-1" {
+		summary.Protocol = "all"
+		summary.Ports = "all"
+	}
+	switch {
+	case rule.CidrIpv4 != nil:
+		summary.Source = *rule.CidrIpv4
+	case rule.CidrIpv6 != nil:
+		summary.Source = *rule.CidrIpv6
+	case rule.PrefixListId != nil:
+		summary.Source = *rule.PrefixListId
+	case rule.ReferencedGroupInfo != nil:
+		summary.Source = aws.ToString(rule.ReferencedGroupInfo.GroupId)
+	}
+	return summary
+}
+
+// portRange formats a rule's port range; -1 on both ends means every port
+func portRange(from, to int32) string {
+	switch {
+	case from == -1 && to == -1:
+		return "all"
+	case from == to:
+		return fmt.Sprint(from)
+	default:
+		return fmt.Sprintf("%d-%d", from, to)
+	}
+}
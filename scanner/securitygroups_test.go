@@ -0,0 +1,118 @@
+package scanner
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/eks/types"
+)
+
+// rulesEC2 serves DescribeSecurityGroupRules from rules, applying the
+// group-id filter and returning one rule per page
+type rulesEC2 struct {
+	EC2Client
+	rules []ec2types.SecurityGroupRule
+	calls int
+}
+
+func (c *rulesEC2) DescribeSecurityGroupRules(ctx context.Context, params *ec2.DescribeSecurityGroupRulesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeSecurityGroupRulesOutput, error) {
+	c.calls++
+	var matched []ec2types.SecurityGroupRule
+	for _, rule := range c.rules {
+		if slices.Contains(params.Filters[0].Values, aws.ToString(rule.GroupId)) {
+			matched = append(matched, rule)
+		}
+	}
+	out := &ec2.DescribeSecurityGroupRulesOutput{}
+	if i := pageToken(params.NextToken); i < len(matched) {
+		out.SecurityGroupRules = matched[i : i+1]
+		out.NextToken = nextToken(i, len(matched))
+	}
+	return out, nil
+}
+
+func TestSecurityGroupRuleOf(t *testing.T) {
+	tests := []struct {
+		name string
+		rule ec2types.SecurityGroupRule
+		want SecurityGroupRule
+	}{
+		{
+			name: "https from a cidr",
+			rule: ec2types.SecurityGroupRule{IpProtocol: aws.String("tcp"), FromPort: aws.Int32(443), ToPort: aws.Int32(443), CidrIpv4: aws.String("10.0.0.0/8"), Description: aws.String("office")},
+			want: SecurityGroupRule{Direction: "ingress", Protocol: "tcp", Ports: "443", Source: "10.0.0.0/8", Description: "office"},
+		},
+		{
+			name: "port range from ipv6",
+			rule: ec2types.SecurityGroupRule{IpProtocol: aws.String("udp"), FromPort: aws.Int32(1024), ToPort: aws.Int32(65535), CidrIpv6: aws.String("::/0")},
+			want: SecurityGroupRule{Direction: "ingress", Protocol: "udp", Ports: "1024-65535", Source: "::/0"},
+		},
+		{
+			name: "all traffic egress",
+			rule: ec2types.SecurityGroupRule{IsEgress: aws.Bool(true), IpProtocol: aws.String("-1"), FromPort: aws.Int32(-1), ToPort: aws.Int32(-1), CidrIpv4: aws.String("0.0.0.0/0")},
+			want: SecurityGroupRule{Direction: "egress", Protocol: "all", Ports: "all", Source: "0.0.0.0/0"},
+		},
+		{
+			name: "prefix list",
+			rule: ec2types.SecurityGroupRule{IpProtocol: aws.String("tcp"), FromPort: aws.Int32(22), ToPort: aws.Int32(22), PrefixListId: aws.String("pl-1234")},
+			want: SecurityGroupRule{Direction: "ingress", Protocol: "tcp", Ports: "22", Source: "pl-1234"},
+		},
+		{
+			name: "referenced group",
+			rule: ec2types.SecurityGroupRule{IpProtocol: aws.String("-1"), ReferencedGroupInfo: &ec2types.ReferencedSecurityGroup{GroupId: aws.String("sg-nodes")}},
+			want: SecurityGroupRule{Direction: "ingress", Protocol: "all", Ports: "all", Source: "sg-nodes"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := securityGroupRuleOf(tt.rule); got != tt.want {
+				t.Errorf("securityGroupRuleOf() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunSecurityGroupRules(t *testing.T) {
+	cluster := func(name, group string) types.Cluster {
+		c := fakeCluster(name, "1.31")
+		c.ResourcesVpcConfig = &types.VpcConfigResponse{VpcId: aws.String("vpc-1"), ClusterSecurityGroupId: aws.String(group)}
+		return c
+	}
+	f := newFakeFactory(map[string][]types.Cluster{"us-east-1": {
+		cluster("prod", "sg-prod"), cluster("canary", "sg-prod"), cluster("orphan", "sg-deleted"), fakeCluster("bare", "1.31"),
+	}})
+	https := ec2types.SecurityGroupRule{GroupId: aws.String("sg-prod"), IpProtocol: aws.String("tcp"), FromPort: aws.Int32(443), ToPort: aws.Int32(443), CidrIpv4: aws.String("10.0.0.0/8")}
+	egress := ec2types.SecurityGroupRule{GroupId: aws.String("sg-prod"), IsEgress: aws.Bool(true), IpProtocol: aws.String("-1"), CidrIpv4: aws.String("0.0.0.0/0")}
+	client := &rulesEC2{EC2Client: f.ec2, rules: []ec2types.SecurityGroupRule{https, egress}}
+
+	var log bytes.Buffer
+	s := NewScanner(WithClientFactory(&ec2Factory{fakeFactory: f, client: client}), WithRegions("us-east-1"), WithSecurityGroupRules(), WithOutput(&log))
+	result, err := s.Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	prodRules := []SecurityGroupRule{securityGroupRuleOf(https), securityGroupRuleOf(egress)}
+	want := map[string][]SecurityGroupRule{"prod": prodRules, "canary": prodRules, "orphan": nil, "bare": nil}
+	if len(result.Clusters) != len(want) {
+		t.Fatalf("%d clusters, want %d", len(result.Clusters), len(want))
+	}
+	for _, c := range result.Clusters {
+		if !reflect.DeepEqual(c.SecurityGroupRules, want[c.Name]) {
+			t.Errorf("%s rules = %+v, want %+v", c.Name, c.SecurityGroupRules, want[c.Name])
+		}
+	}
+	if client.calls != 2 {
+		t.Errorf("%d DescribeSecurityGroupRules calls, want 2: one batch of two pages", client.calls)
+	}
+	if !strings.Contains(log.String(), "Security group sg-deleted of cluster orphan in region us-east-1 was not found") {
+		t.Errorf("log lacks the missing group:\n%s", log.String())
+	}
+}
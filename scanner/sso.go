@@ -0,0 +1,86 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials/ssocreds"
+	ssotypes "github.com/aws/aws-sdk-go-v2/service/sso/types"
+)
+
+// SSOExpiredError reports that the IAM Identity Center (SSO) token behind the
+// credentials has expired or is invalid. LoginCommand is the aws CLI command
+// that renews it.
+type SSOExpiredError struct {
+	LoginCommand string
+	Err          error
+}
+
+func (e *SSOExpiredError) Error() string {
+	return fmt.Sprintf("the AWS SSO session has expired or is invalid; run %q and try again (%v)", e.LoginCommand, e.Err)
+}
+
+func (e *SSOExpiredError) Unwrap() error { return e.Err }
+
+// WithSSOSession names the sso-session whose login is suggested when the SSO
+// token has expired. By default it is read from the profile's sso_session setting.
+func WithSSOSession(name string) Option {
+	return func(s *Scanner) {
+		s.ssoSession = name
+	}
+}
+
+// ssoTokenExpiredMessages are the untyped errors of the SSO token provider
+// used by sso-session profiles when the cached token cannot be used or refreshed
+var ssoTokenExpiredMessages = []string{
+	"cached SSO token is expired",
+	"refresh cached SSO token failed",
+}
+
+// isSSOExpired reports whether err was caused by an expired or invalid SSO token
+func isSSOExpired(err error) bool {
+	var tokenErr *ssocreds.InvalidTokenError
+	var unauthorized *ssotypes.UnauthorizedException
+	if errors.As(err, &tokenErr) || errors.As(err, &unauthorized) {
+		return true
+	}
+	for _, msg := range ssoTokenExpiredMessages {
+		if strings.Contains(err.Error(), msg) {
+			return true
+		}
+	}
+	return false
+}
+
+// ssoLoginCommand returns the command renewing the SSO session used by the
+// scanner: by sso-session when it is known, by profile otherwise
+func (s *Scanner) ssoLoginCommand(ctx context.Context) string {
+	profile := s.profile
+	if profile == "" {
+		profile = os.Getenv("AWS_PROFILE")
+	}
+	session := s.ssoSession
+	if session == "" {
+		name := profile
+		if name == "" {
+			name = config.DefaultSharedConfigProfile
+		}
+		// Read the shared config file the SDK loads, honoring AWS_CONFIG_FILE
+		withConfigFile := func(o *config.LoadSharedConfigOptions) { o.ConfigFiles = []string{SharedConfigPath()} }
+		if shared, err := config.LoadSharedConfigProfile(ctx, name, withConfigFile); err == nil {
+			session = shared.SSOSessionName
+		}
+	}
+	switch {
+	case session != "":
+		return "aws sso login --sso-session " + session
+	case profile != "":
+		return "aws sso login --profile " + profile
+	default:
+		return "aws sso login"
+	}
+}
@@ -0,0 +1,99 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/credentials/ssocreds"
+	"github.com/aws/aws-sdk-go-v2/service/eks/types"
+	ssotypes "github.com/aws/aws-sdk-go-v2/service/sso/types"
+)
+
+func TestIsSSOExpired(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "invalid token", err: fmt.Errorf("get identity: %w", &ssocreds.InvalidTokenError{Err: errors.New("token expired")}), want: true},
+		{name: "unauthorized", err: fmt.Errorf("get role credentials: %w", &ssotypes.UnauthorizedException{}), want: true},
+		{name: "expired cached token", err: errors.New("get identity: cached SSO token is expired, or not present"), want: true},
+		{name: "failed refresh", err: errors.New("get identity: refresh cached SSO token failed: InvalidGrantException"), want: true},
+		{name: "other credentials error", err: errors.New("no EC2 IMDS role found")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isSSOExpired(tt.err); got != tt.want {
+				t.Errorf("isSSOExpired(%v) = %t, want %t", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSSOLoginCommand(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config")
+	config := `[default]
+sso_session = corp-default
+
+[profile dev]
+sso_session = corp
+
+[profile legacy]
+sso_start_url = https://example.awsapps.com/start
+
+[sso-session corp]
+sso_start_url = https://corp.awsapps.com/start
+sso_region = us-east-1
+
+[sso-session corp-default]
+sso_start_url = https://corp.awsapps.com/start
+sso_region = us-east-1
+`
+	if err := os.WriteFile(path, []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("AWS_CONFIG_FILE", path)
+	tests := []struct {
+		name       string
+		opts       []Option
+		envProfile string
+		want       string
+	}{
+		{name: "session of the profile", opts: []Option{WithProfile("dev")}, want: "aws sso login --sso-session corp"},
+		{name: "explicit session", opts: []Option{WithProfile("dev"), WithSSOSession("other")}, want: "aws sso login --sso-session other"},
+		{name: "profile without session", opts: []Option{WithProfile("legacy")}, want: "aws sso login --profile legacy"},
+		{name: "unknown profile", opts: []Option{WithProfile("missing")}, want: "aws sso login --profile missing"},
+		{name: "profile from the environment", envProfile: "dev", want: "aws sso login --sso-session corp"},
+		{name: "default profile", want: "aws sso login --sso-session corp-default"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("AWS_PROFILE", tt.envProfile)
+			if got := NewScanner(tt.opts...).ssoLoginCommand(context.Background()); got != tt.want {
+				t.Errorf("ssoLoginCommand() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunSSOExpired(t *testing.T) {
+	f := newFakeFactory(map[string][]types.Cluster{"us-east-1": {fakeCluster("prod", "1.31")}})
+	f.stsErr = &ssocreds.InvalidTokenError{Err: errors.New("the SSO session has expired")}
+	_, err := newFakeScanner(f, WithSSOSession("corp")).Run(context.Background())
+	var expired *SSOExpiredError
+	if !errors.As(err, &expired) || expired.LoginCommand != "aws sso login --sso-session corp" {
+		t.Fatalf("err = %v, want an SSOExpiredError suggesting the corp session login", err)
+	}
+	if want := `the AWS SSO session has expired or is invalid; run "aws sso login --sso-session corp" and try again (`; !strings.HasPrefix(err.Error(), want) {
+		t.Errorf("err = %q, want it to start with %q", err, want)
+	}
+	var tokenErr *ssocreds.InvalidTokenError
+	if !errors.As(err, &tokenErr) {
+		t.Error("SSOExpiredError does not unwrap to the token error")
+	}
+}
@@ -0,0 +1,140 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/smithy-go/middleware"
+)
+
+// Retry causes counted by Stats
+const (
+	RetryThrottling = "throttling"
+	RetryNetwork    = "network"
+	RetryServer     = "server"
+	RetryOther      = "other"
+)
+
+// RetryCauses lists the retry causes in display order
+var RetryCauses = []string{RetryThrottling, RetryNetwork, RetryServer, RetryOther}
+
+// Stats counts the AWS API calls of a scan and the retries the SDK made, by
+// cause. Create one with NewStats and pass it to WithStats; it is safe for
+// concurrent use.
+type Stats struct {
+	mu     sync.Mutex
+	counts StatsCounts
+}
+
+// StatsCounts is a snapshot of Stats
+type StatsCounts struct {
+	// Calls counts operations, each including its retries; Failed counts the
+	// operations that still failed after retrying.
+	Calls  int `json:"calls"`
+	Failed int `json:"failed"`
+	// Retries counts retried attempts by cause (RetryThrottling and so on).
+	Retries map[string]int `json:"retries"`
+	// Phases sums the time spent in each scan phase (PhaseRegions and so on),
+	// across profiles when several are scanned.
+	Phases map[string]time.Duration `json:"phases"`
+	// Regions sums the time spent listing the clusters of each region.
+	Regions map[string]time.Duration `json:"regions"`
+	// Elapsed is the wall time of the whole run, set by the caller. Like the
+	// other durations it is encoded in nanoseconds.
+	Elapsed time.Duration `json:"elapsed,omitempty"`
+}
+
+// NewStats returns zeroed stats
+func NewStats() *Stats {
+	return &Stats{counts: StatsCounts{Retries: make(map[string]int), Phases: make(map[string]time.Duration), Regions: make(map[string]time.Duration)}}
+}
+
+// WithStats counts the API calls and retries of the scan in stats. Calls are
+// only counted through the default client factory.
+func WithStats(stats *Stats) Option {
+	return func(s *Scanner) {
+		s.stats = stats
+	}
+}
+
+// Counts returns a snapshot of the counters
+func (st *Stats) Counts() StatsCounts {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	counts := st.counts
+	counts.Retries = make(map[string]int, len(st.counts.Retries))
+	for cause, n := range st.counts.Retries {
+		counts.Retries[cause] = n
+	}
+	counts.Phases = make(map[string]time.Duration, len(st.counts.Phases))
+	for phase, d := range st.counts.Phases {
+		counts.Phases[phase] = d
+	}
+	counts.Regions = make(map[string]time.Duration, len(st.counts.Regions))
+	for region, d := range st.counts.Regions {
+		counts.Regions[region] = d
+	}
+	return counts
+}
+
+// recordPhase adds the duration of one run of a scan phase
+func (st *Stats) recordPhase(phase string, d time.Duration) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.counts.Phases[phase] += d
+}
+
+// recordRegion adds the time spent listing the clusters of region
+func (st *Stats) recordRegion(region string, d time.Duration) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.counts.Regions[region] += d
+}
+
+// record counts one completed operation and the retried attempts in its metadata
+func (st *Stats) record(metadata middleware.Metadata, err error) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.counts.Calls++
+	if err != nil {
+		st.counts.Failed++
+	}
+	attempts, _ := retry.GetAttemptResults(metadata)
+	for _, attempt := range attempts.Results {
+		if attempt.Retried {
+			st.counts.Retries[retryCause(attempt.Err)]++
+		}
+	}
+}
+
+// middleware records each operation once it completes, after the SDK retries
+func (st *Stats) middleware() func(*middleware.Stack) error {
+	return func(stack *middleware.Stack) error {
+		return stack.Initialize.Add(middleware.InitializeMiddlewareFunc("Stats",
+			func(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+				out, metadata, err := next.HandleInitialize(ctx, in)
+				st.record(metadata, err)
+				return out, metadata, err
+			}), middleware.After)
+	}
+}
+
+// retryCause classifies the error of a retried attempt
+func retryCause(err error) string {
+	if retry.IsErrorThrottles(retry.DefaultThrottles).IsErrorThrottle(err) == aws.TrueTernary {
+		return RetryThrottling
+	}
+	if (retry.RetryableConnectionError{}).IsErrorRetryable(err) == aws.TrueTernary {
+		return RetryNetwork
+	}
+	var respErr *awshttp.ResponseError
+	if errors.As(err, &respErr) && respErr.HTTPStatusCode() >= 500 {
+		return RetryServer
+	}
+	return RetryOther
+}
@@ -0,0 +1,164 @@
+package scanner
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/ratelimit"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/eks/types"
+	"github.com/aws/smithy-go"
+)
+
+func TestStatsRecordsPhasesAndRegions(t *testing.T) {
+	f := newFakeFactory(map[string][]types.Cluster{
+		"us-east-1": {fakeCluster("a", "1.31")},
+		"eu-west-1": {},
+	})
+	stats := NewStats()
+	if _, err := newFakeScanner(f, WithStats(stats)).Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	counts := stats.Counts()
+	for _, phase := range []string{PhaseList, PhaseDescribe} {
+		if _, ok := counts.Phases[phase]; !ok {
+			t.Errorf("phase %s not recorded: %v", phase, counts.Phases)
+		}
+	}
+	for _, region := range []string{"us-east-1", "eu-west-1"} {
+		if _, ok := counts.Regions[region]; !ok {
+			t.Errorf("region %s not recorded: %v", region, counts.Regions)
+		}
+	}
+}
+
+func TestStatsInJSONResult(t *testing.T) {
+	result := &ScanResult{Account: "123456789012", Stats: &StatsCounts{
+		Calls:   3,
+		Failed:  1,
+		Retries: map[string]int{RetryThrottling: 2},
+		Phases:  map[string]time.Duration{PhaseList: 2 * time.Second},
+		Elapsed: 3 * time.Second,
+	}}
+	data, err := json.Marshal(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		Stats map[string]json.RawMessage `json:"stats"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"calls":   "3",
+		"failed":  "1",
+		"retries": `{"throttling":2}`,
+		"phases":  `{"list":2000000000}`,
+		"elapsed": "3000000000",
+	}
+	for key, value := range want {
+		if got := string(doc.Stats[key]); got != value {
+			t.Errorf("stats.%s = %s, want %s", key, got, value)
+		}
+	}
+}
+
+func TestRetryCause(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"throttling", &smithy.GenericAPIError{Code: "ThrottlingException"}, RetryThrottling},
+		{"network", &net.OpError{Op: "dial", Err: errors.New("connection refused")}, RetryNetwork},
+		{"other", errors.New("boom"), RetryOther},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := retryCause(tt.err); got != tt.want {
+				t.Errorf("retryCause(%v) = %s, want %s", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+// awsHTTPClient answers GetCallerIdentity and an empty ListClusters, failing
+// the EKS attempts numbered in fail, counting from 1, with the given status:
+// 429 as a throttle, any other as a server error
+type awsHTTPClient struct {
+	fail     map[int]int
+	attempts int
+}
+
+func (c *awsHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	if strings.HasPrefix(req.URL.Host, "sts.") {
+		const body = `<GetCallerIdentityResponse><GetCallerIdentityResult><Account>123456789012</Account><Arn>arn:aws:iam::123456789012:user/scanner</Arn></GetCallerIdentityResult></GetCallerIdentityResponse>`
+		return &http.Response{StatusCode: 200, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(body))}, nil
+	}
+	c.attempts++
+	switch status := c.fail[c.attempts]; status {
+	case 0:
+		return &http.Response{StatusCode: 200, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(`{"clusters":[]}`))}, nil
+	case http.StatusTooManyRequests:
+		header := http.Header{"X-Amzn-Errortype": {"ThrottlingException"}}
+		return &http.Response{StatusCode: status, Header: header, Body: io.NopCloser(strings.NewReader(`{"message":"Rate exceeded"}`))}, nil
+	default:
+		header := http.Header{"X-Amzn-Errortype": {"ServerException"}}
+		return &http.Response{StatusCode: status, Header: header, Body: io.NopCloser(strings.NewReader(`{"message":"Internal error"}`))}, nil
+	}
+}
+
+func TestStatsCountsSDKRetries(t *testing.T) {
+	tests := []struct {
+		name        string
+		fail        map[int]int
+		wantFailed  int
+		wantRetries map[string]int
+	}{
+		{name: "no retries", wantRetries: map[string]int{}},
+		{name: "throttled once", fail: map[int]int{1: 429}, wantRetries: map[string]int{RetryThrottling: 1}},
+		{name: "server error then throttled", fail: map[int]int{1: 500, 2: 429}, wantRetries: map[string]int{RetryServer: 1, RetryThrottling: 1}},
+		{name: "throttled to the end", fail: map[int]int{1: 429, 2: 429, 3: 429}, wantFailed: 1, wantRetries: map[string]int{RetryThrottling: 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &awsHTTPClient{fail: tt.fail}
+			stats := NewStats()
+			s := NewScanner(WithStats(stats), WithRegions("us-east-1"), WithHTTPClient(client))
+			s.factory.(*DefaultClientFactory).Loader = staticLoader{cfg: aws.Config{
+				Region:      "us-east-1",
+				Credentials: credentials.NewStaticCredentialsProvider("AKID", "SECRET", ""),
+				Retryer: func() aws.Retryer {
+					return retry.NewStandard(func(o *retry.StandardOptions) {
+						o.Backoff = retry.BackoffDelayerFunc(func(int, error) (time.Duration, error) { return 0, nil })
+						o.RateLimiter = ratelimit.None
+					})
+				},
+			}}
+			// A failed listing is reported on the result, not by Run
+			if _, err := s.Run(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+
+			counts := stats.Counts()
+			// GetCallerIdentity and ListClusters, however many attempts each took
+			if counts.Calls != 2 || counts.Failed != tt.wantFailed {
+				t.Errorf("calls = %d, failed = %d; want 2, %d", counts.Calls, counts.Failed, tt.wantFailed)
+			}
+			if !reflect.DeepEqual(counts.Retries, tt.wantRetries) {
+				t.Errorf("retries = %v, want %v", counts.Retries, tt.wantRetries)
+			}
+		})
+	}
+}
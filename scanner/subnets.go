@@ -0,0 +1,99 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+// WithAvailabilityZones maps the subnets of every cluster to their
+// availability zones with EC2 DescribeSubnets and marks clusters whose subnets
+// span fewer than minAZs zones.
+func WithAvailabilityZones(minAZs int) Option {
+	return func(s *Scanner) {
+		s.minAZs = minAZs
+	}
+}
+
+// getAvailabilityZones looks up the subnets of the clusters with one batched
+// DescribeSubnets per region and records the zones they cover on the clusters
+func (s *Scanner) getAvailabilityZones(ctx context.Context, clusters []Cluster) error {
+	regions, byRegion := groupByRegion(clusters)
+	return s.forEach(len(regions), s.listConcurrency, func(i int) error {
+		region := regions[i]
+
+		var subnetIDs []string
+		seen := make(map[string]bool)
+		for _, idx := range byRegion[region] {
+			for _, id := range clusters[idx].SubnetIDs {
+				if !seen[id] {
+					seen[id] = true
+					subnetIDs = append(subnetIDs, id)
+				}
+			}
+		}
+		if len(subnetIDs) == 0 {
+			return nil
+		}
+
+		client, err := s.factory.EC2(ctx, region)
+		if err != nil {
+			return fmt.Errorf("creating EC2 client for region %s: %w", region, err)
+		}
+		zones, err := describeSubnetZones(ctx, client, subnetIDs)
+		if err != nil {
+			return fmt.Errorf("region %s: %w", region, err)
+		}
+		for _, idx := range byRegion[region] {
+			c := &clusters[idx]
+			if len(c.SubnetIDs) == 0 {
+				continue
+			}
+			c.AvailabilityZones = subnetZones(c.SubnetIDs, zones)
+			c.BelowMinAZs = len(c.AvailabilityZones) < s.minAZs
+		}
+		return nil
+	})
+}
+
+// describeSubnetZones returns the availability zone of each subnet, following pagination
+func describeSubnetZones(ctx context.Context, client EC2Client, subnetIDs []string) (map[string]string, error) {
+	zones := make(map[string]string, len(subnetIDs))
+	for start := 0; start < len(subnetIDs); start += maxFilterValues {
+		end := min(start+maxFilterValues, len(subnetIDs))
+		input := &ec2.DescribeSubnetsInput{
+			Filters: []types.Filter{{Name: aws.String("subnet-id"), Values: subnetIDs[start:end]}},
+		}
+		for {
+			page, err := client.DescribeSubnets(ctx, input)
+			if err != nil {
+				return nil, err
+			}
+			for _, subnet := range page.Subnets {
+				zones[aws.ToString(subnet.SubnetId)] = aws.ToString(subnet.AvailabilityZone)
+			}
+			if page.NextToken == nil {
+				break
+			}
+			input.NextToken = page.NextToken
+		}
+	}
+	return zones, nil
+}
+
+// subnetZones returns the sorted, distinct zones of subnetIDs. Subnets that
+// were not found (for example because they were deleted) cover no zone.
+func subnetZones(subnetIDs []string, zones map[string]string) []string {
+	covered := []string{}
+	for _, id := range subnetIDs {
+		if zone := zones[id]; zone != "" && !slices.Contains(covered, zone) {
+			covered = append(covered, zone)
+		}
+	}
+	slices.Sort(covered)
+	return covered
+}
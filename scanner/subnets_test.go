@@ -0,0 +1,102 @@
+package scanner
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/eks/types"
+)
+
+// subnetsEC2 serves DescribeSubnets from the zone of each subnet, applying
+// the subnet-id filter and returning one subnet per page
+type subnetsEC2 struct {
+	EC2Client
+	zones map[string]string
+	calls int
+}
+
+func (c *subnetsEC2) DescribeSubnets(ctx context.Context, params *ec2.DescribeSubnetsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeSubnetsOutput, error) {
+	c.calls++
+	var matched []string
+	for _, id := range params.Filters[0].Values {
+		if _, ok := c.zones[id]; ok {
+			matched = append(matched, id)
+		}
+	}
+	out := &ec2.DescribeSubnetsOutput{}
+	if i := pageToken(params.NextToken); i < len(matched) {
+		out.Subnets = []ec2types.Subnet{{SubnetId: aws.String(matched[i]), AvailabilityZone: aws.String(c.zones[matched[i]])}}
+		out.NextToken = nextToken(i, len(matched))
+	}
+	return out, nil
+}
+
+func TestSubnetZones(t *testing.T) {
+	zones := map[string]string{"subnet-a1": "us-east-1a", "subnet-a2": "us-east-1a", "subnet-b": "us-east-1b", "subnet-c": "us-east-1c"}
+	tests := []struct {
+		name    string
+		subnets []string
+		want    []string
+	}{
+		{name: "distinct and sorted", subnets: []string{"subnet-c", "subnet-a1", "subnet-a2", "subnet-b"}, want: []string{"us-east-1a", "us-east-1b", "us-east-1c"}},
+		{name: "deleted subnet covers no zone", subnets: []string{"subnet-b", "subnet-gone"}, want: []string{"us-east-1b"}},
+		{name: "no subnets found", subnets: []string{"subnet-gone"}, want: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := subnetZones(tt.subnets, zones); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("subnetZones() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunAvailabilityZones(t *testing.T) {
+	cluster := func(name string, subnets ...string) types.Cluster {
+		c := fakeCluster(name, "1.31")
+		c.ResourcesVpcConfig = &types.VpcConfigResponse{VpcId: aws.String("vpc-1"), SubnetIds: subnets}
+		return c
+	}
+	f := newFakeFactory(map[string][]types.Cluster{"us-east-1": {
+		cluster("spread", "subnet-a", "subnet-b", "subnet-c"),
+		cluster("narrow", "subnet-a", "subnet-a2"),
+		cluster("gone", "subnet-deleted"),
+		fakeCluster("none", "1.31"),
+	}})
+	client := &subnetsEC2{EC2Client: f.ec2, zones: map[string]string{
+		"subnet-a": "us-east-1a", "subnet-a2": "us-east-1a", "subnet-b": "us-east-1b", "subnet-c": "us-east-1c",
+	}}
+	s := NewScanner(WithClientFactory(&ec2Factory{fakeFactory: f, client: client}), WithRegions("us-east-1"), WithAvailabilityZones(2))
+	result, err := s.Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type zones struct {
+		zones []string
+		below bool
+	}
+	want := map[string]zones{
+		"spread": {zones: []string{"us-east-1a", "us-east-1b", "us-east-1c"}},
+		"narrow": {zones: []string{"us-east-1a"}, below: true},
+		"gone":   {zones: []string{}, below: true},
+		"none":   {},
+	}
+	if len(result.Clusters) != len(want) {
+		t.Fatalf("%d clusters, want %d", len(result.Clusters), len(want))
+	}
+	for _, c := range result.Clusters {
+		got := zones{zones: c.AvailabilityZones, below: c.BelowMinAZs}
+		if !reflect.DeepEqual(got, want[c.Name]) {
+			t.Errorf("%s = %+v, want %+v", c.Name, got, want[c.Name])
+		}
+	}
+	// The four distinct subnets are looked up together, one found subnet per page
+	if client.calls != 4 {
+		t.Errorf("%d DescribeSubnets calls, want 4", client.calls)
+	}
+}
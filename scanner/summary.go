@@ -0,0 +1,71 @@
+package scanner
+
+import (
+	"slices"
+)
+
+// Summary aggregates one or more scans without per-cluster detail
+type Summary struct {
+	Accounts         int            `json:"accounts"`
+	RegionsScanned   int            `json:"regionsScanned"`
+	TotalClusters    int            `json:"totalClusters"`
+	ClustersByRegion map[string]int `json:"clustersByRegion"`
+	Versions         map[string]int `json:"versions"`
+	EOL              int            `json:"eol"`
+	DescribeErrors   int            `json:"describeErrors"`
+	RegionErrors     int            `json:"regionErrors"`
+	// TotalNodes sums the managed node group sizes of the clusters that have them.
+	TotalNodes *int `json:"totalNodes,omitempty"`
+}
+
+// Summarize aggregates the given scans. Clusters without a known version are
+// counted under "unknown".
+func Summarize(results ...*ScanResult) *Summary {
+	sum := &Summary{
+		ClustersByRegion: make(map[string]int),
+		Versions:         make(map[string]int),
+	}
+	accounts := make(map[string]bool)
+	regions := make(map[string]bool)
+	for _, result := range results {
+		accounts[result.Account] = true
+		for _, region := range result.Regions {
+			regions[region] = true
+		}
+		sum.RegionErrors += len(result.RegionErrors)
+		for _, c := range result.Clusters {
+			sum.TotalClusters++
+			sum.ClustersByRegion[c.Region]++
+			version := c.Version
+			if version == "" {
+				version = "unknown"
+			}
+			sum.Versions[version]++
+			if c.EOL {
+				sum.EOL++
+			}
+			if c.DescribeError != "" {
+				sum.DescribeErrors++
+			}
+			if c.TotalNodes != nil {
+				if sum.TotalNodes == nil {
+					sum.TotalNodes = new(int)
+				}
+				*sum.TotalNodes += *c.TotalNodes
+			}
+		}
+	}
+	sum.Accounts = len(accounts)
+	sum.RegionsScanned = len(regions)
+	return sum
+}
+
+// SortedVersions returns the versions present in the summary, oldest first
+func (s *Summary) SortedVersions() []string {
+	versions := make([]string, 0, len(s.Versions))
+	for v := range s.Versions {
+		versions = append(versions, v)
+	}
+	slices.SortFunc(versions, compareVersions)
+	return versions
+}
@@ -0,0 +1,78 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/eks/types"
+)
+
+func TestSummarize(t *testing.T) {
+	nodes := func(n int) *int { return &n }
+	tests := []struct {
+		name    string
+		results []*ScanResult
+		want    *Summary
+	}{
+		{
+			name:    "empty scan",
+			results: []*ScanResult{{Account: "123456789012"}},
+			want:    &Summary{Accounts: 1, ClustersByRegion: map[string]int{}, Versions: map[string]int{}},
+		},
+		{
+			name: "accounts and regions deduplicated",
+			results: []*ScanResult{
+				{Account: "123456789012", Regions: []string{"us-east-1", "eu-west-1"}, RegionErrors: []RegionError{{Region: "eu-west-1"}}, Clusters: []Cluster{
+					{Name: "prod", Region: "us-east-1", Version: "1.31", TotalNodes: nodes(3)},
+					{Name: "legacy", Region: "us-east-1", Version: "1.24", EOL: true},
+				}},
+				{Account: "123456789012", Regions: []string{"us-east-1"}, Clusters: []Cluster{
+					{Name: "hidden", Region: "us-east-1", DescribeError: "AccessDenied"},
+				}},
+				{Account: "210987654321", Regions: []string{"ap-south-1"}, Clusters: []Cluster{
+					{Name: "edge", Region: "ap-south-1", Version: "1.31", TotalNodes: nodes(2)},
+				}},
+			},
+			want: &Summary{
+				Accounts: 2, RegionsScanned: 3, TotalClusters: 4,
+				ClustersByRegion: map[string]int{"us-east-1": 3, "ap-south-1": 1},
+				Versions:         map[string]int{"1.31": 2, "1.24": 1, "unknown": 1},
+				EOL:              1, DescribeErrors: 1, RegionErrors: 1, TotalNodes: nodes(5),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Summarize(tt.results...); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Summarize = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSummarySortedVersions(t *testing.T) {
+	sum := &Summary{Versions: map[string]int{"1.10": 1, "unknown": 1, "1.9": 2, "1.31": 1}}
+	if got, want := sum.SortedVersions(), []string{"unknown", "1.9", "1.10", "1.31"}; !slices.Equal(got, want) {
+		t.Errorf("SortedVersions = %q, want %q", got, want)
+	}
+}
+
+func TestSummarizeCountsListingErrors(t *testing.T) {
+	f := newFakeFactory(map[string][]types.Cluster{
+		"us-east-1":  {fakeCluster("prod", "1.31")},
+		"eu-west-1":  nil,
+		"ap-south-1": nil,
+	})
+	f.region("eu-west-1").listErr = errors.New("AccessDenied")
+	result, err := newFakeScanner(f).Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := Summarize(result)
+	if sum.RegionErrors != 1 || sum.RegionsScanned != 3 || sum.TotalClusters != 1 {
+		t.Errorf("summary = %+v, want 1 region error across 3 regions", sum)
+	}
+}
@@ -0,0 +1,93 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eks"
+	"github.com/aws/aws-sdk-go-v2/service/eks/types"
+)
+
+// recentUpdateWindow is how far back failed and cancelled updates are reported
+const recentUpdateWindow = 7 * 24 * time.Hour
+
+// Update is an in-progress or recently failed cluster update
+type Update struct {
+	ID        string     `json:"id"`
+	Type      string     `json:"type,omitempty"`
+	Status    string     `json:"status"`
+	CreatedAt *time.Time `json:"createdAt,omitempty"`
+	Errors    []string   `json:"errors,omitempty"`
+}
+
+// getUpdates records the in-progress updates of each cluster, along with the
+// updates that failed or were cancelled within recentUpdateWindow
+func (s *Scanner) getUpdates(ctx context.Context, clusters []Cluster) error {
+	since := time.Now().Add(-recentUpdateWindow)
+	return s.forEachDescribe(len(clusters), func(i int) error {
+		c := &clusters[i]
+		if c.DescribeError != "" || c.Connected() {
+			return nil
+		}
+		client, err := s.factory.EKS(ctx, c.Region)
+		if err != nil {
+			return fmt.Errorf("creating EKS client for region %s: %w", c.Region, err)
+		}
+
+		var ids []string
+		input := &eks.ListUpdatesInput{Name: aws.String(c.Name)}
+		for {
+			page, err := client.ListUpdates(ctx, input)
+			if err != nil {
+				return fmt.Errorf("listing updates for cluster %s: %w", c.Name, err)
+			}
+			ids = append(ids, page.UpdateIds...)
+			if page.NextToken == nil {
+				break
+			}
+			input.NextToken = page.NextToken
+		}
+
+		var updates []Update
+		for _, id := range ids {
+			out, err := client.DescribeUpdate(ctx, &eks.DescribeUpdateInput{Name: aws.String(c.Name), UpdateId: aws.String(id)})
+			if err != nil {
+				return fmt.Errorf("describing update %s of cluster %s: %w", id, c.Name, err)
+			}
+			if out.Update == nil || !reportUpdate(out.Update, since) {
+				continue
+			}
+			updates = append(updates, newUpdate(out.Update))
+		}
+		c.Updates = updates
+		return nil
+	})
+}
+
+// reportUpdate reports whether u is in progress, or failed or was cancelled after since
+func reportUpdate(u *types.Update, since time.Time) bool {
+	switch u.Status {
+	case types.UpdateStatusInProgress:
+		return true
+	case types.UpdateStatusFailed, types.UpdateStatusCancelled:
+		return u.CreatedAt == nil || u.CreatedAt.After(since)
+	default:
+		return false
+	}
+}
+
+// newUpdate converts an update returned by DescribeUpdate
+func newUpdate(u *types.Update) Update {
+	update := Update{
+		ID:        aws.ToString(u.Id),
+		Type:      string(u.Type),
+		Status:    string(u.Status),
+		CreatedAt: u.CreatedAt,
+	}
+	for _, e := range u.Errors {
+		update.Errors = append(update.Errors, fmt.Sprintf("%s: %s", e.ErrorCode, aws.ToString(e.ErrorMessage)))
+	}
+	return update
+}
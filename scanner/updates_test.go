@@ -0,0 +1,99 @@
+package scanner
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eks"
+	"github.com/aws/aws-sdk-go-v2/service/eks/types"
+)
+
+// updatesEKS adds the updates of each cluster to a fakeEKS, listing one
+// update ID per page
+type updatesEKS struct {
+	*fakeEKS
+	updates map[string][]types.Update
+}
+
+func (c *updatesEKS) ListUpdates(ctx context.Context, params *eks.ListUpdatesInput, optFns ...func(*eks.Options)) (*eks.ListUpdatesOutput, error) {
+	updates := c.updates[aws.ToString(params.Name)]
+	out := &eks.ListUpdatesOutput{}
+	if i := pageToken(params.NextToken); i < len(updates) {
+		out.UpdateIds = []string{aws.ToString(updates[i].Id)}
+		out.NextToken = nextToken(i, len(updates))
+	}
+	return out, nil
+}
+
+func (c *updatesEKS) DescribeUpdate(ctx context.Context, params *eks.DescribeUpdateInput, optFns ...func(*eks.Options)) (*eks.DescribeUpdateOutput, error) {
+	for _, u := range c.updates[aws.ToString(params.Name)] {
+		if aws.ToString(u.Id) == aws.ToString(params.UpdateId) {
+			return &eks.DescribeUpdateOutput{Update: &u}, nil
+		}
+	}
+	return nil, &types.ResourceNotFoundException{Message: aws.String("No update found for id: " + aws.ToString(params.UpdateId))}
+}
+
+func TestReportUpdate(t *testing.T) {
+	since := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	before, after := since.Add(-time.Hour), since.Add(time.Hour)
+	tests := []struct {
+		status  types.UpdateStatus
+		created *time.Time
+		want    bool
+	}{
+		{status: types.UpdateStatusInProgress, created: &before, want: true},
+		{status: types.UpdateStatusFailed, created: &after, want: true},
+		{status: types.UpdateStatusFailed, created: &before},
+		{status: types.UpdateStatusCancelled, created: &after, want: true},
+		{status: types.UpdateStatusCancelled, want: true},
+		{status: types.UpdateStatusSuccessful, created: &after},
+	}
+	for _, tt := range tests {
+		u := &types.Update{Status: tt.status, CreatedAt: tt.created}
+		if got := reportUpdate(u, since); got != tt.want {
+			t.Errorf("reportUpdate(%s, created %v) = %t, want %t", tt.status, tt.created, got, tt.want)
+		}
+	}
+}
+
+func TestRunUpdates(t *testing.T) {
+	recent, old := time.Now().Add(-time.Hour), time.Now().Add(-2*recentUpdateWindow)
+	update := func(id string, status types.UpdateStatus, created time.Time, errs ...types.ErrorDetail) types.Update {
+		return types.Update{Id: aws.String(id), Type: types.UpdateTypeVersionUpdate, Status: status, CreatedAt: &created, Errors: errs}
+	}
+	f := newFakeFactory(map[string][]types.Cluster{"us-east-1": {fakeCluster("prod", "1.31"), fakeCluster("quiet", "1.31")}})
+	client := &updatesEKS{fakeEKS: f.region("us-east-1"), updates: map[string][]types.Update{
+		"prod": {
+			update("upgrading", types.UpdateStatusInProgress, recent),
+			update("failed", types.UpdateStatusFailed, recent, types.ErrorDetail{ErrorCode: types.ErrorCodeAccessDenied, ErrorMessage: aws.String("not authorized")}),
+			update("old-failure", types.UpdateStatusFailed, old),
+			update("done", types.UpdateStatusSuccessful, recent),
+		},
+		"quiet": {update("done", types.UpdateStatusSuccessful, recent)},
+	}}
+	want := map[string][]Update{
+		"prod": {
+			{ID: "upgrading", Type: "VersionUpdate", Status: "InProgress", CreatedAt: &recent},
+			{ID: "failed", Type: "VersionUpdate", Status: "Failed", CreatedAt: &recent, Errors: []string{"AccessDenied: not authorized"}},
+		},
+		"quiet": nil,
+	}
+
+	s := NewScanner(WithClientFactory(&singleEKSFactory{fakeFactory: f, client: client}), WithRegions("us-east-1"), WithUpdates())
+	result, err := s.Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Clusters) != len(want) {
+		t.Fatalf("%d clusters, want %d", len(result.Clusters), len(want))
+	}
+	for _, c := range result.Clusters {
+		if !reflect.DeepEqual(c.Updates, want[c.Name]) {
+			t.Errorf("%s updates = %+v, want %+v", c.Name, c.Updates, want[c.Name])
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package scanner
+
+import (
+	"fmt"
+	"slices"
+)
+
+// UnknownVersion is the UpgradeWave version of clusters whose version is not known
+const UnknownVersion = "unknown"
+
+// UpgradeWave is the clusters running one Kubernetes minor version
+type UpgradeWave struct {
+	Version string `json:"version"`
+	// EOL is set when the version is past the end of standard support.
+	EOL      bool         `json:"eol,omitempty"`
+	Clusters []ClusterRef `json:"clusters"`
+}
+
+// PlanUpgrades buckets the clusters of results by major.minor version,
+// oldest version first so the most outdated clusters come up first.
+// Clusters without a parsable version, such as undescribed ones, form a
+// final UnknownVersion wave.
+func PlanUpgrades(results ...*ScanResult) []UpgradeWave {
+	byVersion := make(map[string]*UpgradeWave)
+	for _, result := range results {
+		for _, c := range result.Clusters {
+			version := UnknownVersion
+			if major, minor, ok := parseVersion(c.Version); ok {
+				version = fmt.Sprintf("%d.%d", major, minor)
+			}
+			wave, ok := byVersion[version]
+			if !ok {
+				wave = &UpgradeWave{Version: version}
+				byVersion[version] = wave
+			}
+			wave.EOL = wave.EOL || c.EOL
+			wave.Clusters = append(wave.Clusters, ClusterRef{Account: result.Account, Region: c.Region, Name: c.Name})
+		}
+	}
+
+	waves := make([]UpgradeWave, 0, len(byVersion))
+	for _, wave := range byVersion {
+		waves = append(waves, *wave)
+	}
+	slices.SortFunc(waves, func(a, b UpgradeWave) int {
+		switch {
+		case a.Version == UnknownVersion:
+			return 1
+		case b.Version == UnknownVersion:
+			return -1
+		}
+		return compareVersions(a.Version, b.Version)
+	})
+	return waves
+}
@@ -0,0 +1,50 @@
+package scanner
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPlanUpgrades(t *testing.T) {
+	ref := func(account, name string) ClusterRef {
+		return ClusterRef{Account: account, Region: "us-east-1", Name: name}
+	}
+	tests := []struct {
+		name    string
+		results []*ScanResult
+		want    []UpgradeWave
+	}{
+		{name: "no clusters", results: []*ScanResult{{Account: "123456789012"}}, want: []UpgradeWave{}},
+		{
+			name: "numeric order with unknown last",
+			results: []*ScanResult{{Account: "123456789012", Clusters: []Cluster{
+				{Name: "new", Region: "us-east-1", Version: "1.10"},
+				{Name: "hidden", Region: "us-east-1"},
+				{Name: "old", Region: "us-east-1", Version: "1.9", EOL: true},
+				{Name: "patched", Region: "us-east-1", Version: "v1.10.3"},
+			}}},
+			want: []UpgradeWave{
+				{Version: "1.9", EOL: true, Clusters: []ClusterRef{ref("123456789012", "old")}},
+				{Version: "1.10", Clusters: []ClusterRef{ref("123456789012", "new"), ref("123456789012", "patched")}},
+				{Version: UnknownVersion, Clusters: []ClusterRef{ref("123456789012", "hidden")}},
+			},
+		},
+		{
+			name: "accounts merged into one wave",
+			results: []*ScanResult{
+				{Account: "123456789012", Clusters: []Cluster{{Name: "dev", Region: "us-east-1", Version: "1.29"}}},
+				{Account: "210987654321", Clusters: []Cluster{{Name: "prod", Region: "us-east-1", Version: "1.29", EOL: true}}},
+			},
+			want: []UpgradeWave{
+				{Version: "1.29", EOL: true, Clusters: []ClusterRef{ref("123456789012", "dev"), ref("210987654321", "prod")}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PlanUpgrades(tt.results...); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("PlanUpgrades = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package scanner
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// versionSupport holds the end of standard and extended support for an EKS Kubernetes version
+type versionSupport struct {
+	standardEnd time.Time
+	extendedEnd time.Time
+}
+
+// supportDate parses a date in the support table; the table is static so a bad entry panics at init
+func supportDate(s string) time.Time {
+	t, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// supportTable lists EKS Kubernetes versions with their support windows, as
+// published in the Amazon EKS Kubernetes version lifecycle documentation.
+// Versions older than the oldest entry are treated as end of life.
+var supportTable = map[string]versionSupport{
+	"1.23": {supportDate("2023-10-11"), supportDate("2024-10-11")},
+	"1.24": {supportDate("2024-01-31"), supportDate("2025-01-31")},
+	"1.25": {supportDate("2024-05-01"), supportDate("2025-05-01")},
+	"1.26": {supportDate("2024-06-11"), supportDate("2025-06-11")},
+	"1.27": {supportDate("2024-07-24"), supportDate("2025-07-24")},
+	"1.28": {supportDate("2024-11-26"), supportDate("2025-11-26")},
+	"1.29": {supportDate("2025-03-23"), supportDate("2026-03-23")},
+	"1.30": {supportDate("2025-07-23"), supportDate("2026-07-23")},
+	"1.31": {supportDate("2025-11-26"), supportDate("2026-11-26")},
+	"1.32": {supportDate("2026-03-23"), supportDate("2027-03-23")},
+	"1.33": {supportDate("2026-07-29"), supportDate("2027-07-29")},
+	"1.34": {supportDate("2026-12-02"), supportDate("2027-12-02")},
+}
+
+// oldestSupported is the oldest version listed in supportTable
+const oldestSupported = "1.23"
+
+// parseVersion splits an EKS version such as "1.29" into its numeric major and minor parts
+func parseVersion(v string) (major, minor int, ok bool) {
+	majorStr, minorStr, found := strings.Cut(strings.TrimPrefix(v, "v"), ".")
+	if !found {
+		return 0, 0, false
+	}
+	// Tolerate a patch component such as "1.29.3"
+	minorStr, _, _ = strings.Cut(minorStr, ".")
+	major, err := strconv.Atoi(majorStr)
+	if err != nil {
+		return 0, 0, false
+	}
+	minor, err = strconv.Atoi(minorStr)
+	if err != nil {
+		return 0, 0, false
+	}
+	return major, minor, true
+}
+
+// compareVersions returns -1, 0 or 1 as a is older than, equal to or newer than b,
+// comparing numerically so that 1.9 < 1.10. Unparseable versions sort first.
+func compareVersions(a, b string) int {
+	aMajor, aMinor, aOK := parseVersion(a)
+	bMajor, bMinor, bOK := parseVersion(b)
+	switch {
+	case !aOK && !bOK:
+		return strings.Compare(a, b)
+	case !aOK:
+		return -1
+	case !bOK:
+		return 1
+	case aMajor != bMajor:
+		return cmpInt(aMajor, bMajor)
+	default:
+		return cmpInt(aMinor, bMinor)
+	}
+}
+
+// CompareVersions is compareVersions for callers outside the package, such
+// as output sorted by version
+func CompareVersions(a, b string) int {
+	return compareVersions(a, b)
+}
+
+func cmpInt(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// IsEOL reports whether version is past the end of standard support at now.
+// Versions newer than the support table are assumed to be supported.
+func IsEOL(version string, now time.Time) bool {
+	if support, ok := supportTable[version]; ok {
+		return !now.Before(support.standardEnd)
+	}
+	_, _, ok := parseVersion(version)
+	return ok && compareVersions(version, oldestSupported) < 0
+}
+
+// ValidVersion reports whether v is a Kubernetes minor version such as "1.28"
+func ValidVersion(v string) bool {
+	_, _, ok := parseVersion(v)
+	return ok
+}
@@ -0,0 +1,89 @@
+package scanner
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/eks/types"
+)
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.29", "1.29", 0},
+		{"1.9", "1.10", -1},
+		{"1.30", "1.29", 1},
+		{"2.0", "1.99", 1},
+		{"v1.29", "1.29", 0},
+		{"1.29.3", "1.29", 0},
+		{"latest", "1.29", -1},
+		{"1.29", "latest", 1},
+		{"a", "b", -1},
+	}
+	for _, tt := range tests {
+		if got := CompareVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("CompareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestIsEOL(t *testing.T) {
+	day := func(s string) time.Time { return supportDate(s) }
+	tests := []struct {
+		version string
+		now     time.Time
+		want    bool
+	}{
+		{"1.29", day("2025-03-22"), false},
+		{"1.29", day("2025-03-23"), true},
+		{"1.31", day("2025-01-01"), false},
+		{"1.22", day("2020-01-01"), true},
+		{"1.99", day("2030-01-01"), false},
+		{"", day("2030-01-01"), false},
+		{"unknown", day("2030-01-01"), false},
+	}
+	for _, tt := range tests {
+		if got := IsEOL(tt.version, tt.now); got != tt.want {
+			t.Errorf("IsEOL(%q, %s) = %t, want %t", tt.version, tt.now.Format(time.DateOnly), got, tt.want)
+		}
+	}
+}
+
+func TestValidVersion(t *testing.T) {
+	for v, want := range map[string]bool{"1.28": true, "v1.28": true, "1.28.4": true, "1": false, "1.x": false, "": false} {
+		if got := ValidVersion(v); got != want {
+			t.Errorf("ValidVersion(%q) = %t, want %t", v, got, want)
+		}
+	}
+}
+
+func TestMinVersion(t *testing.T) {
+	f := newFakeFactory(map[string][]types.Cluster{"us-east-1": {fakeCluster("old", "1.9"), fakeCluster("exact", "1.10"), fakeCluster("new", "1.31")}})
+	result, err := newFakeScanner(f, WithMinVersion("1.10")).Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]bool{"old": true, "exact": false, "new": false}
+	for _, c := range result.Clusters {
+		if c.BelowMinVersion != want[c.Name] {
+			t.Errorf("%s BelowMinVersion = %t, want %t", c.Name, c.BelowMinVersion, want[c.Name])
+		}
+	}
+	if n := len(warningsWithCode(result.Warnings, WarningBelowMinVersion)); n != 1 {
+		t.Errorf("%d below-min-version warnings, want 1", n)
+	}
+}
+
+// warningsWithCode returns the warnings of one code
+func warningsWithCode(warnings []Warning, code string) []Warning {
+	var matched []Warning
+	for _, w := range warnings {
+		if w.Code == code {
+			matched = append(matched, w)
+		}
+	}
+	return matched
+}
@@ -0,0 +1,107 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+// WithVpcIDs keeps only the clusters whose VPC is one of ids. The VPC is
+// known from DescribeCluster, so undescribed clusters are dropped.
+func WithVpcIDs(ids ...string) Option {
+	return func(s *Scanner) {
+		s.vpcIDs = ids
+	}
+}
+
+// vpcCidrs holds the associated CIDR blocks of a VPC
+type vpcCidrs struct {
+	ipv4 []string
+	ipv6 []string
+}
+
+// getVpcCidrs looks up the VPCs of the clusters with one batched DescribeVpcs
+// per region and records their associated CIDR blocks on the clusters
+func (s *Scanner) getVpcCidrs(ctx context.Context, clusters []Cluster) error {
+	regions, byRegion := groupByRegion(clusters)
+	return s.forEach(len(regions), s.listConcurrency, func(i int) error {
+		region := regions[i]
+
+		var vpcIDs []string
+		seen := make(map[string]bool)
+		for _, idx := range byRegion[region] {
+			if id := clusters[idx].VpcID; id != "" && !seen[id] {
+				seen[id] = true
+				vpcIDs = append(vpcIDs, id)
+			}
+		}
+		if len(vpcIDs) == 0 {
+			return nil
+		}
+
+		client, err := s.factory.EC2(ctx, region)
+		if err != nil {
+			return fmt.Errorf("creating EC2 client for region %s: %w", region, err)
+		}
+		cidrs, err := describeVpcCidrs(ctx, client, vpcIDs)
+		if err != nil {
+			return fmt.Errorf("region %s: %w", region, err)
+		}
+		for _, idx := range byRegion[region] {
+			c := &clusters[idx]
+			c.VpcCidrs = cidrs[c.VpcID].ipv4
+			c.VpcIPv6Cidrs = cidrs[c.VpcID].ipv6
+		}
+		return nil
+	})
+}
+
+// describeVpcCidrs returns the associated CIDR blocks of each VPC, following pagination
+func describeVpcCidrs(ctx context.Context, client EC2Client, vpcIDs []string) (map[string]vpcCidrs, error) {
+	result := make(map[string]vpcCidrs, len(vpcIDs))
+	for start := 0; start < len(vpcIDs); start += maxFilterValues {
+		end := min(start+maxFilterValues, len(vpcIDs))
+		input := &ec2.DescribeVpcsInput{
+			Filters: []types.Filter{{Name: aws.String("vpc-id"), Values: vpcIDs[start:end]}},
+		}
+		for {
+			page, err := client.DescribeVpcs(ctx, input)
+			if err != nil {
+				return nil, err
+			}
+			for _, vpc := range page.Vpcs {
+				result[aws.ToString(vpc.VpcId)] = vpcCidrsOf(vpc)
+			}
+			if page.NextToken == nil {
+				break
+			}
+			input.NextToken = page.NextToken
+		}
+	}
+	return result, nil
+}
+
+// vpcCidrsOf collects the CIDR blocks currently associated with vpc
+func vpcCidrsOf(vpc types.Vpc) vpcCidrs {
+	var cidrs vpcCidrs
+	for _, assoc := range vpc.CidrBlockAssociationSet {
+		if assoc.CidrBlockState != nil && assoc.CidrBlockState.State != types.VpcCidrBlockStateCodeAssociated {
+			continue
+		}
+		cidrs.ipv4 = append(cidrs.ipv4, aws.ToString(assoc.CidrBlock))
+	}
+	for _, assoc := range vpc.Ipv6CidrBlockAssociationSet {
+		if assoc.Ipv6CidrBlockState != nil && assoc.Ipv6CidrBlockState.State != types.VpcCidrBlockStateCodeAssociated {
+			continue
+		}
+		cidrs.ipv6 = append(cidrs.ipv6, aws.ToString(assoc.Ipv6CidrBlock))
+	}
+	// Older responses only carry the primary block
+	if len(cidrs.ipv4) == 0 && vpc.CidrBlock != nil {
+		cidrs.ipv4 = []string{*vpc.CidrBlock}
+	}
+	return cidrs
+}
@@ -0,0 +1,177 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/eks/types"
+)
+
+// vpcsEC2 serves DescribeVpcs from vpcs, applying the vpc-id filter and
+// returning one VPC per page
+type vpcsEC2 struct {
+	EC2Client
+	vpcs  []ec2types.Vpc
+	calls int
+}
+
+func (c *vpcsEC2) DescribeVpcs(ctx context.Context, params *ec2.DescribeVpcsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeVpcsOutput, error) {
+	c.calls++
+	var matched []ec2types.Vpc
+	for _, vpc := range c.vpcs {
+		if slices.Contains(params.Filters[0].Values, aws.ToString(vpc.VpcId)) {
+			matched = append(matched, vpc)
+		}
+	}
+	out := &ec2.DescribeVpcsOutput{}
+	if i := pageToken(params.NextToken); i < len(matched) {
+		out.Vpcs = matched[i : i+1]
+		out.NextToken = nextToken(i, len(matched))
+	}
+	return out, nil
+}
+
+// fakeVpc returns a VPC with the associated ipv4 blocks
+func fakeVpc(id string, ipv4 ...string) ec2types.Vpc {
+	vpc := ec2types.Vpc{VpcId: aws.String(id)}
+	for _, block := range ipv4 {
+		vpc.CidrBlockAssociationSet = append(vpc.CidrBlockAssociationSet, ec2types.VpcCidrBlockAssociation{
+			CidrBlock:      aws.String(block),
+			CidrBlockState: &ec2types.VpcCidrBlockState{State: ec2types.VpcCidrBlockStateCodeAssociated},
+		})
+	}
+	return vpc
+}
+
+// vpcCluster returns a cluster in vpcID
+func vpcCluster(name, vpcID string) types.Cluster {
+	c := fakeCluster(name, "1.31")
+	c.ResourcesVpcConfig = &types.VpcConfigResponse{VpcId: aws.String(vpcID)}
+	return c
+}
+
+func TestVpcCidrsOf(t *testing.T) {
+	state := func(code ec2types.VpcCidrBlockStateCode) *ec2types.VpcCidrBlockState {
+		return &ec2types.VpcCidrBlockState{State: code}
+	}
+	tests := []struct {
+		name string
+		vpc  ec2types.Vpc
+		want vpcCidrs
+	}{
+		{name: "associated blocks", vpc: fakeVpc("vpc-1", "10.0.0.0/16", "10.1.0.0/16"), want: vpcCidrs{ipv4: []string{"10.0.0.0/16", "10.1.0.0/16"}}},
+		{
+			name: "disassociated blocks skipped",
+			vpc: ec2types.Vpc{
+				CidrBlockAssociationSet: []ec2types.VpcCidrBlockAssociation{
+					{CidrBlock: aws.String("10.0.0.0/16"), CidrBlockState: state(ec2types.VpcCidrBlockStateCodeAssociated)},
+					{CidrBlock: aws.String("10.1.0.0/16"), CidrBlockState: state(ec2types.VpcCidrBlockStateCodeDisassociated)},
+				},
+				Ipv6CidrBlockAssociationSet: []ec2types.VpcIpv6CidrBlockAssociation{
+					{Ipv6CidrBlock: aws.String("2600:1f18::/56"), Ipv6CidrBlockState: state(ec2types.VpcCidrBlockStateCodeAssociated)},
+					{Ipv6CidrBlock: aws.String("2600:1f19::/56"), Ipv6CidrBlockState: state(ec2types.VpcCidrBlockStateCodeDisassociating)},
+				},
+			},
+			want: vpcCidrs{ipv4: []string{"10.0.0.0/16"}, ipv6: []string{"2600:1f18::/56"}},
+		},
+		{name: "primary block only", vpc: ec2types.Vpc{CidrBlock: aws.String("172.16.0.0/16")}, want: vpcCidrs{ipv4: []string{"172.16.0.0/16"}}},
+		{name: "no blocks", vpc: ec2types.Vpc{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := vpcCidrsOf(tt.vpc); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("vpcCidrsOf() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDescribeVpcCidrs(t *testing.T) {
+	ids := make([]string, maxFilterValues+1)
+	var vpcs []ec2types.Vpc
+	for i := range ids {
+		ids[i] = fmt.Sprintf("vpc-%03d", i)
+		vpcs = append(vpcs, fakeVpc(ids[i], fmt.Sprintf("10.%d.0.0/16", i%256)))
+	}
+	tests := []struct {
+		name      string
+		ids       []string
+		vpcs      []ec2types.Vpc
+		wantCalls int
+	}{
+		{name: "paginated", ids: ids[:3], vpcs: vpcs, wantCalls: 3},
+		{name: "batches of filter values", ids: ids, vpcs: []ec2types.Vpc{vpcs[0], vpcs[maxFilterValues]}, wantCalls: 2},
+		{name: "unknown vpc", ids: []string{"vpc-missing"}, vpcs: vpcs, wantCalls: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &vpcsEC2{vpcs: tt.vpcs}
+			got, err := describeVpcCidrs(context.Background(), client, tt.ids)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, vpc := range client.vpcs {
+				id := aws.ToString(vpc.VpcId)
+				if !slices.Contains(tt.ids, id) {
+					continue
+				}
+				if want := vpcCidrsOf(vpc); !reflect.DeepEqual(got[id], want) {
+					t.Errorf("%s = %+v, want %+v", id, got[id], want)
+				}
+			}
+			if _, ok := got["vpc-missing"]; ok {
+				t.Error("unknown vpc has CIDR blocks")
+			}
+			if client.calls != tt.wantCalls {
+				t.Errorf("%d DescribeVpcs calls, want %d", client.calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestRunVpcs(t *testing.T) {
+	f := newFakeFactory(map[string][]types.Cluster{"us-east-1": {
+		vpcCluster("prod", "vpc-prod"), vpcCluster("prod-canary", "vpc-prod"), vpcCluster("dev", "vpc-dev"),
+	}})
+	client := &vpcsEC2{EC2Client: f.ec2, vpcs: []ec2types.Vpc{fakeVpc("vpc-prod", "10.0.0.0/16"), fakeVpc("vpc-dev", "10.1.0.0/16")}}
+	tests := []struct {
+		name      string
+		opts      []Option
+		want      map[string][]string
+		wantCalls int
+	}{
+		{name: "cidrs", opts: []Option{WithVpcCidr()}, want: map[string][]string{
+			"prod": {"10.0.0.0/16"}, "prod-canary": {"10.0.0.0/16"}, "dev": {"10.1.0.0/16"},
+		}, wantCalls: 2},
+		{name: "vpc filter", opts: []Option{WithVpcIDs("vpc-dev")}, want: map[string][]string{"dev": nil}},
+		{name: "vpc filter and cidrs", opts: []Option{WithVpcIDs("vpc-prod"), WithVpcCidr()}, want: map[string][]string{
+			"prod": {"10.0.0.0/16"}, "prod-canary": {"10.0.0.0/16"},
+		}, wantCalls: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client.calls = 0
+			opts := append([]Option{WithClientFactory(&ec2Factory{fakeFactory: f, client: client}), WithRegions("us-east-1")}, tt.opts...)
+			result, err := NewScanner(opts...).Run(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := make(map[string][]string)
+			for _, c := range result.Clusters {
+				got[c.Name] = c.VpcCidrs
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("clusters = %v, want %v", got, tt.want)
+			}
+			if client.calls != tt.wantCalls {
+				t.Errorf("%d DescribeVpcs calls, want %d", client.calls, tt.wantCalls)
+			}
+		})
+	}
+}
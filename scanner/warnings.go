@@ -0,0 +1,73 @@
+package scanner
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Warning codes, one per kind of soft finding
+const (
+	WarningEOL             = "eol"
+	WarningBelowMinVersion = "below-min-version"
+	WarningOpenEndpoint    = "open-endpoint"
+	WarningDisallowedCidrs = "disallowed-cidrs"
+	WarningMissingTags     = "missing-tags"
+	WarningHealthIssues    = "health-issues"
+	WarningBelowMinAZs     = "below-min-azs"
+	WarningStale           = "stale"
+	WarningUndescribed     = "undescribed"
+)
+
+// Warning is a soft finding about one cluster: something worth attention that
+// does not fail the scan. Code is one of the Warning* constants.
+type Warning struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Cluster string `json:"cluster"`
+	Region  string `json:"region"`
+}
+
+// CollectWarnings returns the warnings of clusters, grouped by cluster in
+// order and, for each cluster, in the order of the Warning* constants. It
+// reads fields set by the scan, so a finding whose option was not enabled
+// (such as WithHealth) is never reported.
+func CollectWarnings(clusters []Cluster) []Warning {
+	var warnings []Warning
+	for i := range clusters {
+		c := &clusters[i]
+		add := func(code, format string, args ...any) {
+			warnings = append(warnings, Warning{Code: code, Message: fmt.Sprintf(format, args...), Cluster: c.Name, Region: c.Region})
+		}
+		if c.EOL {
+			add(WarningEOL, "runs EOL Kubernetes %s", c.Version)
+		}
+		if c.BelowMinVersion {
+			add(WarningBelowMinVersion, "runs Kubernetes %s, below the minimum version", c.Version)
+		}
+		if c.OpenEndpoint() {
+			add(WarningOpenEndpoint, "has a public endpoint open to 0.0.0.0/0")
+		}
+		if len(c.DisallowedCidrs) > 0 {
+			add(WarningDisallowedCidrs, "allows public access from CIDRs outside the allowlist: %s", strings.Join(c.DisallowedCidrs, ", "))
+		}
+		if len(c.MissingTags) > 0 {
+			add(WarningMissingTags, "is missing tags: %s", strings.Join(c.MissingTags, ", "))
+		}
+		if len(c.HealthIssues) > 0 {
+			add(WarningHealthIssues, "reports %d health issues", len(c.HealthIssues))
+		}
+		if c.BelowMinAZs {
+			add(WarningBelowMinAZs, "has subnets in only %d availability zones", len(c.AvailabilityZones))
+		}
+		if c.Stale && c.CreatedAt != nil {
+			add(WarningStale, "is older than the maximum age, created %s", c.CreatedAt.UTC().Format(time.DateOnly))
+		} else if c.Stale {
+			add(WarningStale, "is older than the maximum age")
+		}
+		if c.DescribeError != "" && !c.ListedOnly {
+			add(WarningUndescribed, "could not be described: %s", c.DescribeError)
+		}
+	}
+	return warnings
+}
@@ -0,0 +1,68 @@
+package scanner
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCollectWarnings(t *testing.T) {
+	created := time.Date(2021, 6, 1, 9, 0, 0, 0, time.FixedZone("CEST", 2*60*60))
+	tests := []struct {
+		name    string
+		cluster Cluster
+		want    []Warning
+	}{
+		{name: "healthy", cluster: Cluster{Version: "1.31", EndpointPublicAccess: true, PublicAccessCidrs: []string{"10.0.0.0/8"}}},
+		{name: "eol", cluster: Cluster{Version: "1.24", EOL: true}, want: []Warning{{Code: WarningEOL, Message: "runs EOL Kubernetes 1.24"}}},
+		{name: "below min version", cluster: Cluster{Version: "1.28", BelowMinVersion: true}, want: []Warning{{Code: WarningBelowMinVersion, Message: "runs Kubernetes 1.28, below the minimum version"}}},
+		{name: "open endpoint", cluster: Cluster{EndpointPublicAccess: true, PublicAccessCidrs: []string{"0.0.0.0/0"}}, want: []Warning{{Code: WarningOpenEndpoint, Message: "has a public endpoint open to 0.0.0.0/0"}}},
+		{name: "private endpoint with open CIDR", cluster: Cluster{PublicAccessCidrs: []string{"0.0.0.0/0"}}},
+		{name: "disallowed cidrs", cluster: Cluster{DisallowedCidrs: []string{"203.0.113.0/24", "198.51.100.7/32"}}, want: []Warning{{Code: WarningDisallowedCidrs, Message: "allows public access from CIDRs outside the allowlist: 203.0.113.0/24, 198.51.100.7/32"}}},
+		{name: "missing tags", cluster: Cluster{MissingTags: []string{"owner", "cost-center"}}, want: []Warning{{Code: WarningMissingTags, Message: "is missing tags: owner, cost-center"}}},
+		{name: "health issues", cluster: Cluster{HealthIssues: []HealthIssue{{Code: "IamRoleNotFound"}, {Code: "SubnetNotFound"}}}, want: []Warning{{Code: WarningHealthIssues, Message: "reports 2 health issues"}}},
+		{name: "below min AZs", cluster: Cluster{BelowMinAZs: true, AvailabilityZones: []string{"us-east-1a"}}, want: []Warning{{Code: WarningBelowMinAZs, Message: "has subnets in only 1 availability zones"}}},
+		{name: "stale with creation date", cluster: Cluster{Stale: true, CreatedAt: &created}, want: []Warning{{Code: WarningStale, Message: "is older than the maximum age, created 2021-06-01"}}},
+		{name: "stale without creation date", cluster: Cluster{Stale: true}, want: []Warning{{Code: WarningStale, Message: "is older than the maximum age"}}},
+		{name: "undescribed", cluster: Cluster{DescribeError: "AccessDeniedException"}, want: []Warning{{Code: WarningUndescribed, Message: "could not be described: AccessDeniedException"}}},
+		{name: "listed only", cluster: Cluster{DescribeError: "describe skipped", ListedOnly: true}},
+		{
+			name:    "several in constant order",
+			cluster: Cluster{Version: "1.24", EOL: true, BelowMinVersion: true, MissingTags: []string{"owner"}, Stale: true},
+			want: []Warning{
+				{Code: WarningEOL, Message: "runs EOL Kubernetes 1.24"},
+				{Code: WarningBelowMinVersion, Message: "runs Kubernetes 1.24, below the minimum version"},
+				{Code: WarningMissingTags, Message: "is missing tags: owner"},
+				{Code: WarningStale, Message: "is older than the maximum age"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.cluster.Name, tt.cluster.Region = "prod", "us-east-1"
+			for i := range tt.want {
+				tt.want[i].Cluster, tt.want[i].Region = "prod", "us-east-1"
+			}
+			got := CollectWarnings([]Cluster{tt.cluster})
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("warnings = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCollectWarningsGroupsByCluster(t *testing.T) {
+	clusters := []Cluster{
+		{Name: "b", Region: "eu-west-1", EOL: true, Stale: true},
+		{Name: "quiet", Region: "eu-west-1"},
+		{Name: "a", Region: "us-east-1", Stale: true},
+	}
+	var got []string
+	for _, w := range CollectWarnings(clusters) {
+		got = append(got, w.Cluster+":"+w.Code)
+	}
+	want := []string{"b:" + WarningEOL, "b:" + WarningStale, "a:" + WarningStale}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("warnings = %q, want %q", got, want)
+	}
+}
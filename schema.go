@@ -0,0 +1,128 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+
+	"shift-left-shuffle/scanner"
+)
+
+// schemaDialect is the JSON Schema version emitted by "schema"
+const schemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// runSchema implements "schema": it prints the JSON Schema of the JSON output,
+// derived from the Go types so it cannot drift from the encoder
+func runSchema(args []string) error {
+	fs := flag.NewFlagSet("schema", flag.ExitOnError)
+	if err := parseSubcommand(fs, args); err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(resultSchema())
+}
+
+// resultSchema returns the schema document of ScanResult
+func resultSchema() map[string]any {
+	gen := &schemaGenerator{defs: make(map[string]any)}
+	root := gen.schemaOf(reflect.TypeOf(scanner.ScanResult{}))
+	root["$schema"] = schemaDialect
+	root["title"] = "shift-left-shuffle scan result"
+	root["$defs"] = gen.defs
+	return root
+}
+
+// schemaGenerator builds schemas for Go types, collecting named structs in defs
+type schemaGenerator struct {
+	defs map[string]any
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schemaOf returns the schema of t. Named structs other than the root are
+// emitted once under $defs and referenced.
+func (g *schemaGenerator) schemaOf(t reflect.Type) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case t.Kind() == reflect.Struct:
+		return g.structSchema(t)
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	// encoding/json writes nil slices and maps as null
+	case reflect.Slice:
+		return map[string]any{"type": []string{"array", "null"}, "items": g.schemaOf(t.Elem())}
+	case reflect.Array:
+		return map[string]any{"type": "array", "items": g.schemaOf(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": []string{"object", "null"}, "additionalProperties": g.schemaOf(t.Elem())}
+	default:
+		return map[string]any{}
+	}
+}
+
+// structSchema returns the object schema of t, or a $ref to it for nested structs
+func (g *schemaGenerator) structSchema(t reflect.Type) map[string]any {
+	if t != reflect.TypeOf(scanner.ScanResult{}) {
+		if _, ok := g.defs[t.Name()]; !ok {
+			g.defs[t.Name()] = nil // reserve the name so recursive types terminate
+			g.defs[t.Name()] = g.objectSchema(t)
+		}
+		return map[string]any{"$ref": "#/$defs/" + t.Name()}
+	}
+	return g.objectSchema(t)
+}
+
+// objectSchema lists the JSON properties of t; fields without omitempty are required
+func (g *schemaGenerator) objectSchema(t reflect.Type) map[string]any {
+	properties := make(map[string]any)
+	required := []string{}
+	g.addFields(t, properties, &required)
+	return map[string]any{
+		"type":                 "object",
+		"properties":           properties,
+		"required":             required,
+		"additionalProperties": false,
+	}
+}
+
+// addFields adds the exported fields of t, flattening embedded structs as encoding/json does
+func (g *schemaGenerator) addFields(t reflect.Type, properties map[string]any, required *[]string) {
+	for i := range t.NumField() {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" || !field.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			g.addFields(field.Type, properties, required)
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		properties[name] = g.schemaOf(field.Type)
+		if !strings.Contains(opts, "omitempty") {
+			*required = append(*required, name)
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"shift-left-shuffle/scanner"
+)
+
+// validate checks value against the subset of JSON Schema that resultSchema
+// emits, resolving $ref in root's $defs. Both are decoded from JSON.
+func validate(root, schema map[string]any, value any, path string) error {
+	if ref, ok := schema["$ref"].(string); ok {
+		def, ok := root["$defs"].(map[string]any)[strings.TrimPrefix(ref, "#/$defs/")].(map[string]any)
+		if !ok {
+			return fmt.Errorf("%s: unresolved %s", path, ref)
+		}
+		return validate(root, def, value, path)
+	}
+	var types []string
+	switch t := schema["type"].(type) {
+	case string:
+		types = []string{t}
+	case []any:
+		for _, v := range t {
+			types = append(types, v.(string))
+		}
+	case nil:
+		return nil
+	}
+	kind := jsonKind(value)
+	if !slices.Contains(types, kind) && !(kind == "integer" && slices.Contains(types, "number")) {
+		return fmt.Errorf("%s: %s, want %v", path, kind, types)
+	}
+	switch v := value.(type) {
+	case []any:
+		items, _ := schema["items"].(map[string]any)
+		for i, item := range v {
+			if err := validate(root, items, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	case map[string]any:
+		properties, _ := schema["properties"].(map[string]any)
+		required, _ := schema["required"].([]any)
+		for _, name := range required {
+			if _, ok := v[name.(string)]; !ok {
+				return fmt.Errorf("%s: missing required %s", path, name)
+			}
+		}
+		for name, field := range v {
+			switch sub := properties[name].(type) {
+			case map[string]any:
+				if err := validate(root, sub, field, path+"."+name); err != nil {
+					return err
+				}
+			default:
+				if additional, ok := schema["additionalProperties"].(map[string]any); ok {
+					if err := validate(root, additional, field, path+"."+name); err != nil {
+						return err
+					}
+				} else if schema["additionalProperties"] == false {
+					return fmt.Errorf("%s: property %s not in the schema", path, name)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// jsonKind returns the JSON Schema type of a decoded JSON value
+func jsonKind(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if v == math.Trunc(v) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+// fill sets every field reachable from v to a non-zero value, so no field is
+// left out by omitempty; depth bounds recursive types
+func fill(v reflect.Value, depth int) {
+	if depth > 5 {
+		return
+	}
+	switch v.Kind() {
+	case reflect.Pointer:
+		v.Set(reflect.New(v.Type().Elem()))
+		fill(v.Elem(), depth+1)
+	case reflect.Struct:
+		if v.Type() == reflect.TypeOf(time.Time{}) {
+			v.Set(reflect.ValueOf(time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)))
+			return
+		}
+		for i := range v.NumField() {
+			if v.Type().Field(i).IsExported() {
+				fill(v.Field(i), depth+1)
+			}
+		}
+	case reflect.Slice:
+		v.Set(reflect.MakeSlice(v.Type(), 1, 1))
+		fill(v.Index(0), depth+1)
+	case reflect.Map:
+		v.Set(reflect.MakeMap(v.Type()))
+		key := reflect.New(v.Type().Key()).Elem()
+		fill(key, depth+1)
+		elem := reflect.New(v.Type().Elem()).Elem()
+		fill(elem, depth+1)
+		v.SetMapIndex(key, elem)
+	case reflect.String:
+		v.SetString("x")
+	case reflect.Bool:
+		v.SetBool(true)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(1)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v.SetUint(1)
+	case reflect.Float32, reflect.Float64:
+		v.SetFloat(1.5)
+	}
+}
+
+func TestResultSchemaValidates(t *testing.T) {
+	var full scanner.ScanResult
+	fill(reflect.ValueOf(&full).Elem(), 0)
+	tests := []struct {
+		name   string
+		result *scanner.ScanResult
+	}{
+		{name: "empty", result: &scanner.ScanResult{}},
+		{name: "sample", result: sampleResult()},
+		{name: "every field set", result: &full},
+	}
+	// Round trip the schema through JSON so it is checked as a reader sees it
+	data, err := json.Marshal(resultSchema())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var schema map[string]any
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(tt.result)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var value any
+			if err := json.Unmarshal(data, &value); err != nil {
+				t.Fatal(err)
+			}
+			if err := validate(schema, schema, value, "$"); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestResultSchemaRejects(t *testing.T) {
+	data, err := json.Marshal(resultSchema())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var schema map[string]any
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatal(err)
+	}
+	if schema["$schema"] != schemaDialect {
+		t.Errorf("$schema = %v, want %s", schema["$schema"], schemaDialect)
+	}
+	valid, err := json.Marshal(sampleResult())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name   string
+		mutate func(doc map[string]any)
+	}{
+		{name: "unknown property", mutate: func(doc map[string]any) { doc["extra"] = 1 }},
+		{name: "missing required", mutate: func(doc map[string]any) { delete(doc, "clusters") }},
+		{name: "wrong type", mutate: func(doc map[string]any) { doc["schemaVersion"] = "1" }},
+		{name: "bad cluster", mutate: func(doc map[string]any) { doc["clusters"].([]any)[0].(map[string]any)["eol"] = "yes" }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var doc map[string]any
+			if err := json.Unmarshal(valid, &doc); err != nil {
+				t.Fatal(err)
+			}
+			tt.mutate(doc)
+			if err := validate(schema, schema, doc, "$"); err == nil {
+				t.Error("validate accepted an invalid document")
+			}
+		})
+	}
+}
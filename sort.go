@@ -0,0 +1,64 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+
+	"shift-left-shuffle/scanner"
+)
+
+// sortKeys lists the values accepted by --sort
+var sortKeys = []string{"name", "region", "version", "created"}
+
+// validSortKey checks a --sort value; empty keeps the scan order
+func validSortKey(key string) error {
+	if key != "" && !slices.Contains(sortKeys, key) {
+		return fmt.Errorf("unsupported --sort key %q: must be one of %s", key, strings.Join(sortKeys, ", "))
+	}
+	return nil
+}
+
+// sortClusters orders the clusters of every result by key, descending with
+// reverse. Ties are broken by name and then region; clusters without a
+// version or creation time sort before the others.
+func sortClusters(results []*scanner.ScanResult, key string, reverse bool) {
+	compare := func(a, b scanner.Cluster) int {
+		var c int
+		switch key {
+		case "region":
+			c = strings.Compare(a.Region, b.Region)
+		case "version":
+			c = scanner.CompareVersions(a.Version, b.Version)
+		case "created":
+			c = compareCreated(a, b)
+		}
+		c = cmp.Or(c, strings.Compare(a.Name, b.Name), strings.Compare(a.Region, b.Region))
+		if reverse {
+			return -c
+		}
+		return c
+	}
+	for _, result := range results {
+		slices.SortStableFunc(result.Clusters, compare)
+		// Warnings are listed in cluster order
+		if result.Warnings != nil {
+			result.Warnings = scanner.CollectWarnings(result.Clusters)
+		}
+	}
+}
+
+// compareCreated orders clusters by creation time, unknown times first
+func compareCreated(a, b scanner.Cluster) int {
+	switch {
+	case a.CreatedAt == nil && b.CreatedAt == nil:
+		return 0
+	case a.CreatedAt == nil:
+		return -1
+	case b.CreatedAt == nil:
+		return 1
+	default:
+		return a.CreatedAt.Compare(*b.CreatedAt)
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"shift-left-shuffle/scanner"
+)
+
+func TestSortClusters(t *testing.T) {
+	at := func(day int) *time.Time {
+		ts := time.Date(2025, 1, day, 0, 0, 0, 0, time.UTC)
+		return &ts
+	}
+	clusters := []scanner.Cluster{
+		{Name: "web", Region: "us-east-1", Version: "1.29", CreatedAt: at(3)},
+		{Name: "api", Region: "eu-west-1", Version: "1.31", CreatedAt: at(1)},
+		{Name: "batch", Region: "us-east-1", Version: "1.9"},
+		{Name: "api", Region: "ap-south-1", Version: "1.10", CreatedAt: at(2)},
+	}
+	tests := []struct {
+		key     string
+		reverse bool
+		want    []string
+	}{
+		{"name", false, []string{"api/ap-south-1", "api/eu-west-1", "batch/us-east-1", "web/us-east-1"}},
+		{"name", true, []string{"web/us-east-1", "batch/us-east-1", "api/eu-west-1", "api/ap-south-1"}},
+		{"region", false, []string{"api/ap-south-1", "api/eu-west-1", "batch/us-east-1", "web/us-east-1"}},
+		{"version", false, []string{"batch/us-east-1", "api/ap-south-1", "web/us-east-1", "api/eu-west-1"}},
+		{"created", false, []string{"batch/us-east-1", "api/eu-west-1", "api/ap-south-1", "web/us-east-1"}},
+		{"created", true, []string{"web/us-east-1", "api/ap-south-1", "api/eu-west-1", "batch/us-east-1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			result := &scanner.ScanResult{Clusters: slices.Clone(clusters)}
+			sortClusters([]*scanner.ScanResult{result}, tt.key, tt.reverse)
+			var got []string
+			for _, c := range result.Clusters {
+				got = append(got, c.Name+"/"+c.Region)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("sort by %s (reverse %t) = %v, want %v", tt.key, tt.reverse, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidSortKey(t *testing.T) {
+	for _, key := range append([]string{""}, sortKeys...) {
+		if err := validSortKey(key); err != nil {
+			t.Errorf("validSortKey(%q) = %v", key, err)
+		}
+	}
+	if err := validSortKey("age"); err == nil {
+		t.Error("validSortKey(age) accepted an unknown key")
+	}
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+
+	"shift-left-shuffle/scanner"
+)
+
+// printSummary writes only the aggregates of the scans, as text or JSON
+func printSummary(w io.Writer, format string, results []*scanner.ScanResult, compact bool) error {
+	sum := scanner.Summarize(results...)
+	if format == "json" {
+		return encodeJSON(w, sum, compact)
+	}
+
+	fmt.Fprintf(w, "Clusters: %d across %d regions in %d accounts\n", sum.TotalClusters, sum.RegionsScanned, sum.Accounts)
+	fmt.Fprintln(w, "Clusters per region:")
+	for _, region := range slices.Sorted(maps.Keys(sum.ClustersByRegion)) {
+		fmt.Fprintf(w, "* %s: %d\n", region, sum.ClustersByRegion[region])
+	}
+	fmt.Fprintln(w, "Kubernetes versions:")
+	for _, version := range sum.SortedVersions() {
+		fmt.Fprintf(w, "* %s: %d\n", version, sum.Versions[version])
+	}
+	fmt.Fprintf(w, "EOL clusters: %d\n", sum.EOL)
+	if sum.TotalNodes != nil {
+		fmt.Fprintf(w, "Managed node group nodes (desired): %d\n", *sum.TotalNodes)
+	}
+	_, err := fmt.Fprintf(w, "Errors: %d regions failed to list, %d clusters undescribed\n", sum.RegionErrors, sum.DescribeErrors)
+	return err
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"shift-left-shuffle/scanner"
+)
+
+func TestPrintSummary(t *testing.T) {
+	result := sampleResult()
+	result.RegionErrors = []scanner.RegionError{{Region: "ap-south-1", Error: "AccessDenied"}}
+
+	t.Run("text", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := printSummary(&buf, "text", []*scanner.ScanResult{result}, false); err != nil {
+			t.Fatal(err)
+		}
+		want := `Clusters: 2 across 2 regions in 1 accounts
+Clusters per region:
+* eu-west-1: 1
+* us-east-1: 1
+Kubernetes versions:
+* 1.24: 1
+* 1.31: 1
+EOL clusters: 1
+Errors: 1 regions failed to list, 0 clusters undescribed
+`
+		if buf.String() != want {
+			t.Errorf("summary:\n%s\nwant:\n%s", buf.String(), want)
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := printSummary(&buf, "json", []*scanner.ScanResult{result}, true); err != nil {
+			t.Fatal(err)
+		}
+		var sum scanner.Summary
+		if err := json.Unmarshal(buf.Bytes(), &sum); err != nil {
+			t.Fatal(err)
+		}
+		if sum.TotalClusters != 2 || sum.RegionErrors != 1 || sum.EOL != 1 || sum.TotalNodes != nil {
+			t.Errorf("summary = %+v", sum)
+		}
+		if bytes.Count(buf.Bytes(), []byte("\n")) != 1 {
+			t.Errorf("compact summary spans several lines:\n%s", buf.String())
+		}
+	})
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"shift-left-shuffle/scanner"
+)
+
+// syslogTag identifies the tool's messages in syslog
+const syslogTag = "shift-left-shuffle"
+
+// findingsLogger receives the summary and per-cluster findings of a scan.
+// *syslog.Writer implements it.
+type findingsLogger interface {
+	Info(msg string) error
+	Warning(msg string) error
+}
+
+// streamLogger writes findings to a stream, prefixed by severity; it is the
+// fallback when syslog cannot be reached
+type streamLogger struct {
+	w io.Writer
+}
+
+func (l streamLogger) Info(msg string) error {
+	_, err := fmt.Fprintf(l.w, "INFO: %s\n", msg)
+	return err
+}
+
+func (l streamLogger) Warning(msg string) error {
+	_, err := fmt.Fprintf(l.w, "WARNING: %s\n", msg)
+	return err
+}
+
+// parseSyslogAddr splits a --syslog-addr value of the form [udp://|tcp://]host:port.
+// An empty address means the local syslog daemon.
+func parseSyslogAddr(addr string) (network, raddr string, err error) {
+	if addr == "" {
+		return "", "", nil
+	}
+	network, raddr, found := strings.Cut(addr, "://")
+	if !found {
+		network, raddr = "udp", addr
+	}
+	if network != "udp" && network != "tcp" {
+		return "", "", fmt.Errorf("unsupported --syslog-addr network %q: must be udp or tcp", network)
+	}
+	if !strings.Contains(raddr, ":") {
+		return "", "", fmt.Errorf("invalid --syslog-addr %q: expected host:port", addr)
+	}
+	return network, raddr, nil
+}
+
+// openFindingsLogger connects to syslog at addr, falling back to fallback
+// with a warning when the connection fails
+func openFindingsLogger(addr string, fallback io.Writer) findingsLogger {
+	network, raddr, _ := parseSyslogAddr(addr) // validated with the flags
+	logger, err := dialSyslog(network, raddr, syslogTag)
+	if err != nil {
+		fmt.Fprintf(fallback, "Warning: cannot connect to syslog (%v); writing findings to stderr\n", err)
+		return streamLogger{fallback}
+	}
+	return logger
+}
+
+// logFindings sends one info message per scan summarizing it, and one warning
+// per finding of scanner.CollectWarnings
+func logFindings(logger findingsLogger, results []*scanner.ScanResult) error {
+	for _, result := range results {
+		msg := fmt.Sprintf("account %s: %d clusters in %d regions, %d regions failed to list", result.Account, len(result.Clusters), len(result.Regions), len(result.RegionErrors))
+		if err := logger.Info(msg); err != nil {
+			return err
+		}
+		for _, warning := range scanner.CollectWarnings(result.Clusters) {
+			msg := fmt.Sprintf("account %s: cluster %s in region %s %s", result.Account, warning.Cluster, warning.Region, warning.Message)
+			if err := logger.Warning(msg); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+
+	"shift-left-shuffle/scanner"
+)
+
+func TestParseSyslogAddr(t *testing.T) {
+	tests := []struct {
+		addr        string
+		wantNetwork string
+		wantRaddr   string
+		wantErr     string
+	}{
+		{addr: ""},
+		{addr: "logs.example.com:514", wantNetwork: "udp", wantRaddr: "logs.example.com:514"},
+		{addr: "tcp://10.0.0.1:6514", wantNetwork: "tcp", wantRaddr: "10.0.0.1:6514"},
+		{addr: "udp://[::1]:514", wantNetwork: "udp", wantRaddr: "[::1]:514"},
+		{addr: "unix:///dev/log", wantErr: `unsupported --syslog-addr network "unix": must be udp or tcp`},
+		{addr: "logs.example.com", wantErr: `invalid --syslog-addr "logs.example.com": expected host:port`},
+	}
+	for _, tt := range tests {
+		network, raddr, err := parseSyslogAddr(tt.addr)
+		if tt.wantErr != "" {
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("parseSyslogAddr(%q) err = %v, want %q", tt.addr, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || network != tt.wantNetwork || raddr != tt.wantRaddr {
+			t.Errorf("parseSyslogAddr(%q) = %q, %q, %v; want %q, %q", tt.addr, network, raddr, err, tt.wantNetwork, tt.wantRaddr)
+		}
+	}
+}
+
+// recordingLogger keeps the messages it is sent, prefixed by severity, and
+// fails once it holds failAfter of them when that is positive
+type recordingLogger struct {
+	messages  []string
+	failAfter int
+}
+
+func (l *recordingLogger) log(severity, msg string) error {
+	if l.failAfter > 0 && len(l.messages) >= l.failAfter {
+		return errors.New("connection closed")
+	}
+	l.messages = append(l.messages, severity+" "+msg)
+	return nil
+}
+
+func (l *recordingLogger) Info(msg string) error    { return l.log("info", msg) }
+func (l *recordingLogger) Warning(msg string) error { return l.log("warning", msg) }
+
+func TestLogFindings(t *testing.T) {
+	open := sampleResult()
+	open.Clusters[0].EndpointPublicAccess = true
+	open.Clusters[0].PublicAccessCidrs = []string{"0.0.0.0/0"}
+	open.RegionErrors = []scanner.RegionError{{Region: "ap-south-1", Error: "throttled"}}
+	tests := []struct {
+		name      string
+		results   []*scanner.ScanResult
+		failAfter int
+		want      []string
+		wantErr   bool
+	}{
+		{name: "no scans"},
+		{
+			name:    "clean account",
+			results: []*scanner.ScanResult{{Account: "210987654321", Regions: []string{"us-east-1"}}},
+			want:    []string{"info account 210987654321: 0 clusters in 1 regions, 0 regions failed to list"},
+		},
+		{
+			name:    "eol and open endpoint",
+			results: []*scanner.ScanResult{open},
+			want: []string{
+				"info account 123456789012: 2 clusters in 2 regions, 1 regions failed to list",
+				"warning account 123456789012: cluster prod in region us-east-1 has a public endpoint open to 0.0.0.0/0",
+				"warning account 123456789012: cluster legacy in region eu-west-1 runs EOL Kubernetes 1.24",
+			},
+		},
+		{
+			name:      "write failure stops logging",
+			results:   []*scanner.ScanResult{open},
+			failAfter: 1,
+			want:      []string{"info account 123456789012: 2 clusters in 2 regions, 1 regions failed to list"},
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := &recordingLogger{failAfter: tt.failAfter}
+			err := logFindings(logger, tt.results)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %t", err, tt.wantErr)
+			}
+			if strings.Join(logger.messages, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("messages =\n%s\nwant\n%s", strings.Join(logger.messages, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestOpenFindingsLoggerFallback(t *testing.T) {
+	// A TCP port that was just closed refuses the connection
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+
+	var stderr bytes.Buffer
+	logger := openFindingsLogger("tcp://"+addr, &stderr)
+	if _, ok := logger.(streamLogger); !ok {
+		t.Fatalf("logger = %T, want the stderr fallback", logger)
+	}
+	if err := logFindings(logger, []*scanner.ScanResult{sampleResult()}); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"Warning: cannot connect to syslog (",
+		"INFO: account 123456789012: 2 clusters in 2 regions, 0 regions failed to list\n",
+		"WARNING: account 123456789012: cluster legacy in region eu-west-1 runs EOL Kubernetes 1.24\n",
+	} {
+		if !strings.Contains(stderr.String(), want) {
+			t.Errorf("stderr lacks %q:\n%s", want, stderr.String())
+		}
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !windows && !plan9
+
+package main
+
+import "log/syslog"
+
+// dialSyslog connects to the syslog daemon at raddr, or the local one when network is empty
+func dialSyslog(network, raddr, tag string) (findingsLogger, error) {
+	return syslog.Dial(network, raddr, syslog.LOG_INFO|syslog.LOG_DAEMON, tag)
+}
@@ -0,0 +1,56 @@
+//go:build !windows && !plan9
+
+package main
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"shift-left-shuffle/scanner"
+)
+
+func TestOpenFindingsLoggerSyslog(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	logger := openFindingsLogger("udp://"+conn.LocalAddr().String(), new(strings.Builder))
+	if _, ok := logger.(streamLogger); ok {
+		t.Fatal("logger fell back to stderr")
+	}
+	if err := logFindings(logger, []*scanner.ScanResult{sampleResult()}); err != nil {
+		t.Fatal(err)
+	}
+
+	// LOG_DAEMON is facility 3: priority 30 is info and 28 a warning
+	want := []string{
+		"<30>",
+		"shift-left-shuffle[",
+		"account 123456789012: 2 clusters in 2 regions, 0 regions failed to list",
+		"<28>",
+		"account 123456789012: cluster legacy in region eu-west-1 runs EOL Kubernetes 1.24",
+	}
+	var received []string
+	buf := make([]byte, 4096)
+	for range 2 {
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		received = append(received, string(buf[:n]))
+	}
+	messages := strings.Join(received, "\n")
+	for _, w := range want {
+		if !strings.Contains(messages, w) {
+			t.Errorf("syslog messages lack %q:\n%s", w, messages)
+		}
+	}
+	if !strings.HasPrefix(received[0], "<30>") || !strings.HasPrefix(received[1], "<28>") {
+		t.Errorf("priorities of\n%s\nwant info then warning", messages)
+	}
+}
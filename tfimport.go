@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"shift-left-shuffle/scanner"
+)
+
+// printTFImport writes a Terraform import block per EKS cluster across
+// results, each preceded by a comment with its region and account, since
+// the block imports through whichever aws provider serves that region.
+// Connected clusters are not aws_eks_cluster resources and are only noted.
+func printTFImport(w io.Writer, results []*scanner.ScanResult) error {
+	var b strings.Builder
+	used := make(map[string]bool)
+	for _, result := range results {
+		for _, c := range result.Clusters {
+			if c.Connected() {
+				fmt.Fprintf(&b, "# Skipped connected cluster %s in %s (account %s): not an aws_eks_cluster\n\n", c.Name, c.Region, result.Account)
+				continue
+			}
+			fmt.Fprintf(&b, "# Cluster %s in %s (account %s); import with an aws provider for that region\n", c.Name, c.Region, result.Account)
+			fmt.Fprintf(&b, "import {\n  to = aws_eks_cluster.%s\n  id = %s\n}\n\n", tfResourceName(c.Name, c.Region, used), strconv.Quote(c.Name))
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// tfResourceName turns a cluster name into a Terraform identifier not in
+// used and marks it used. Characters other than letters, digits and
+// underscores become underscores, and a leading digit gets a "cluster_"
+// prefix. The same name in several regions is qualified with the region,
+// then numbered if it still collides.
+func tfResourceName(name, region string, used map[string]bool) string {
+	base := tfIdentifier(name)
+	candidate := base
+	if used[candidate] {
+		candidate = base + "_" + tfIdentifier(region)
+	}
+	for n := 2; used[candidate]; n++ {
+		candidate = base + "_" + tfIdentifier(region) + "_" + strconv.Itoa(n)
+	}
+	used[candidate] = true
+	return candidate
+}
+
+// tfIdentifier replaces the characters of s that are not letters, digits or
+// underscores with underscores and makes sure it does not start with a digit
+func tfIdentifier(s string) string {
+	id := strings.Map(func(r rune) rune {
+		if r == '_' || ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') || ('0' <= r && r <= '9') {
+			return r
+		}
+		return '_'
+	}, s)
+	if id == "" || ('0' <= id[0] && id[0] <= '9') {
+		id = "cluster_" + id
+	}
+	return id
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"shift-left-shuffle/scanner"
+)
+
+func TestTFResourceName(t *testing.T) {
+	tests := []struct {
+		name   string
+		used   []string
+		region string
+		want   string
+	}{
+		{name: "prod", region: "us-east-1", want: "prod"},
+		{name: "web-api.v2", region: "us-east-1", want: "web_api_v2"},
+		{name: "2024-cluster", region: "us-east-1", want: "cluster_2024_cluster"},
+		{name: "", region: "us-east-1", want: "cluster_"},
+		{name: "prod", used: []string{"prod"}, region: "eu-west-1", want: "prod_eu_west_1"},
+		{name: "prod", used: []string{"prod", "prod_eu_west_1"}, region: "eu-west-1", want: "prod_eu_west_1_2"},
+		{name: "prod", used: []string{"prod", "prod_eu_west_1", "prod_eu_west_1_2"}, region: "eu-west-1", want: "prod_eu_west_1_3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			used := make(map[string]bool)
+			for _, name := range tt.used {
+				used[name] = true
+			}
+			if got := tfResourceName(tt.name, tt.region, used); got != tt.want {
+				t.Errorf("tfResourceName(%q, %q) = %q, want %q", tt.name, tt.region, got, tt.want)
+			}
+			if !used[tt.want] {
+				t.Errorf("%q not marked used", tt.want)
+			}
+		})
+	}
+}
+
+func TestPrintTFImport(t *testing.T) {
+	dev := &scanner.ScanResult{Account: "123456789012", Clusters: []scanner.Cluster{
+		{Name: "prod", Region: "us-east-1"},
+		{Name: "onprem", Region: "us-east-1", Connector: &scanner.Connector{Provider: "OTHER"}},
+	}}
+	prod := &scanner.ScanResult{Account: "210987654321", Clusters: []scanner.Cluster{{Name: "prod", Region: "eu-west-1"}}}
+
+	var buf bytes.Buffer
+	if err := printTFImport(&buf, []*scanner.ScanResult{dev, prod}); err != nil {
+		t.Fatal(err)
+	}
+	want := `# Cluster prod in us-east-1 (account 123456789012); import with an aws provider for that region
+import {
+  to = aws_eks_cluster.prod
+  id = "prod"
+}
+
+# Skipped connected cluster onprem in us-east-1 (account 123456789012): not an aws_eks_cluster
+
+# Cluster prod in eu-west-1 (account 210987654321); import with an aws provider for that region
+import {
+  to = aws_eks_cluster.prod_eu_west_1
+  id = "prod"
+}
+
+`
+	if buf.String() != want {
+		t.Errorf("tf-import output:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadLocation(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    string
+		wantErr bool
+	}{
+		{name: "local", want: "Local"},
+		{name: "UTC", want: "UTC"},
+		{name: "Europe/Paris", want: "Europe/Paris"},
+		{name: "Mars/Olympus", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loc, err := loadLocation(tt.name)
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "invalid --timezone") {
+					t.Fatalf("err = %v, want an invalid --timezone error", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if loc.String() != tt.want {
+				t.Errorf("location = %s, want %s", loc, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatTime(t *testing.T) {
+	at := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Skip(err)
+	}
+	if got := formatTime(at, tokyo); got != "2024-03-01T21:00:00+09:00" {
+		t.Errorf("formatTime in Tokyo = %s", got)
+	}
+	if got := formatTime(at, nil); got != at.In(time.Local).Format(time.RFC3339) {
+		t.Errorf("formatTime with no zone = %s, want local time", got)
+	}
+}
+
+func TestRenderTimezone(t *testing.T) {
+	path := writeScan(t, sampleResult())
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{format: "markdown", want: "2024-03-01T21:00:00+09:00"},
+		{format: "json", want: `"createdAt": "2024-03-01T12:00:00Z"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := runRender(&buf, []string{"--output", tt.format, "--timezone", "Asia/Tokyo", path}); err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(buf.String(), tt.want) {
+				t.Errorf("%s output lacks %s:\n%s", tt.format, tt.want, buf.String())
+			}
+		})
+	}
+}
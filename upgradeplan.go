@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"shift-left-shuffle/scanner"
+)
+
+// printUpgradePlan writes the clusters of all results bucketed by Kubernetes
+// minor version, oldest first, with the size of each bucket
+func printUpgradePlan(w io.Writer, results []*scanner.ScanResult) error {
+	var b strings.Builder
+	for _, wave := range scanner.PlanUpgrades(results...) {
+		title := "Kubernetes " + wave.Version
+		if wave.Version == scanner.UnknownVersion {
+			title = "Unknown version"
+		}
+		noun := "clusters"
+		if len(wave.Clusters) == 1 {
+			noun = "cluster"
+		}
+		eol := ""
+		if wave.EOL {
+			eol = ", EOL"
+		}
+		fmt.Fprintf(&b, "%s (%d %s%s):\n", title, len(wave.Clusters), noun, eol)
+		for _, ref := range wave.Clusters {
+			fmt.Fprintf(&b, "* %s (%s, account %s)\n", ref.Name, ref.Region, ref.Account)
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"shift-left-shuffle/scanner"
+)
+
+func TestPrintUpgradePlan(t *testing.T) {
+	result := &scanner.ScanResult{Account: "123456789012", Clusters: []scanner.Cluster{
+		{Name: "prod", Region: "us-east-1", Version: "1.31"},
+		{Name: "legacy", Region: "eu-west-1", Version: "1.24", EOL: true},
+		{Name: "staging", Region: "us-east-1", Version: "1.31"},
+		{Name: "hidden", Region: "us-east-1", DescribeError: "AccessDenied"},
+	}}
+	var buf bytes.Buffer
+	if err := printUpgradePlan(&buf, []*scanner.ScanResult{result}); err != nil {
+		t.Fatal(err)
+	}
+	want := `Kubernetes 1.24 (1 cluster, EOL):
+* legacy (eu-west-1, account 123456789012)
+Kubernetes 1.31 (2 clusters):
+* prod (us-east-1, account 123456789012)
+* staging (us-east-1, account 123456789012)
+Unknown version (1 cluster):
+* hidden (us-east-1, account 123456789012)
+`
+	if buf.String() != want {
+		t.Errorf("upgrade plan:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"time"
+
+	"shift-left-shuffle/scanner"
+)
+
+// watchEvent is the JSON record of a --changes-only cycle; an event with no
+// changes is the heartbeat
+type watchEvent struct {
+	Time time.Time `json:"time"`
+	*scanner.Diff
+}
+
+// scanFunc runs one scan configured by f, like scan
+type scanFunc func(ctx context.Context, f *cliFlags, progress io.Writer) ([]*scanner.ScanResult, *scanner.ProfilesResult, error)
+
+// runWatch rescans with scan every f.watch until interrupted and renders each
+// cycle to w. With --changes-only, cycles after the first only print the
+// clusters that changed since the previous complete cycle, or a heartbeat when
+// none did.
+// Failed scans are logged and retried on the next cycle.
+func runWatch(ctx context.Context, f *cliFlags, scan scanFunc, progress, w io.Writer) error {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+
+	var prev []scanner.AccountCluster
+	emitted := false
+	for cycle := 0; ; cycle++ {
+		if cycle > 0 {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(f.watch):
+			}
+		}
+
+		results, profilesResult, err := scan(ctx, f, progress)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			log.Printf("Watch: scan failed: %v", err)
+			if results == nil || f.changesOnly {
+				// Diffing partial results would report missing clusters as removed
+				continue
+			}
+		}
+
+		var cur []scanner.AccountCluster
+		for _, result := range results {
+			cur = append(cur, result.Flatten()...)
+		}
+		if !f.changesOnly || !emitted {
+			if profilesResult != nil {
+				err = renderProfiles(w, f.output, profilesResult, f.renderOptions())
+			} else {
+				err = render(w, f.output, results[0], f.renderOptions())
+			}
+		} else {
+			err = printChanges(w, f, scanner.DiffClusters(prev, cur))
+		}
+		if err != nil {
+			return err
+		}
+		prev, emitted = cur, true
+	}
+}
+
+// printChanges writes the changes of one --changes-only cycle: a compact JSON
+// watchEvent for json and ndjson output, diff lines or a heartbeat for text
+func printChanges(w io.Writer, f *cliFlags, diff *scanner.Diff) error {
+	now := time.Now()
+	if f.output == "json" || f.output == "ndjson" {
+		return encodeJSON(w, watchEvent{Time: now.UTC(), Diff: diff}, true)
+	}
+	if diff.Empty() {
+		_, err := fmt.Fprintf(w, "No changes at %s\n", formatTime(now, f.location))
+		return err
+	}
+	return printDiff(w, diff)
+}
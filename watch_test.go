@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"shift-left-shuffle/scanner"
+)
+
+// scriptedScans returns a scanFunc serving cycles in turn, each a result or
+// an error, and canceling the watch once they are exhausted
+func scriptedScans(cancel context.CancelFunc, cycles []func() (*scanner.ScanResult, error)) scanFunc {
+	i := 0
+	return func(ctx context.Context, f *cliFlags, progress io.Writer) ([]*scanner.ScanResult, *scanner.ProfilesResult, error) {
+		if i == len(cycles) {
+			cancel()
+			return nil, nil, ctx.Err()
+		}
+		result, err := cycles[i]()
+		i++
+		if result == nil {
+			return nil, nil, err
+		}
+		return []*scanner.ScanResult{result}, nil, err
+	}
+}
+
+func TestRunWatchChangesOnly(t *testing.T) {
+	changed := func() (*scanner.ScanResult, error) {
+		result := sampleResult()
+		result.Clusters[1].Version = "1.25"
+		result.Clusters = append(result.Clusters, scanner.Cluster{Name: "shadow", Region: "us-east-1", Version: "1.31", Endpoint: "https://shadow.example.com"})
+		return result, nil
+	}
+	cycles := []func() (*scanner.ScanResult, error){
+		func() (*scanner.ScanResult, error) { return sampleResult(), nil },
+		// A partial scan is skipped rather than reporting removals
+		func() (*scanner.ScanResult, error) {
+			result := sampleResult()
+			result.Clusters = result.Clusters[:1]
+			return result, errors.New("throttled")
+		},
+		changed,
+		changed,
+	}
+	tests := []struct {
+		name  string
+		args  []string
+		check func(t *testing.T, out string)
+	}{
+		{
+			name: "text",
+			args: []string{"--watch", "1m", "--changes-only"},
+			check: func(t *testing.T, out string) {
+				full, changes, found := strings.Cut(out, "+ 123456789012/us-east-1/shadow\n")
+				if !found || !strings.Contains(full, "prod") || !strings.Contains(full, "legacy") {
+					t.Fatalf("output =\n%s\nwant the full first cycle, then the added cluster", out)
+				}
+				if !strings.HasPrefix(changes, "~ 123456789012/eu-west-1/legacy: [version") {
+					t.Errorf("changes =\n%s\nwant legacy changed", changes)
+				}
+				if n := strings.Count(changes, "No changes at "); n != 1 || strings.Contains(changes, "- ") {
+					t.Errorf("changes =\n%s\nwant one heartbeat and no removals", changes)
+				}
+			},
+		},
+		{
+			name: "json",
+			args: []string{"--watch", "1m", "--changes-only", "--output", "ndjson"},
+			check: func(t *testing.T, out string) {
+				lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+				if len(lines) != 4 {
+					t.Fatalf("output =\n%s\nwant 2 cluster records and 2 events", out)
+				}
+				var events []watchEvent
+				for _, line := range lines[2:] {
+					var event watchEvent
+					if err := json.Unmarshal([]byte(line), &event); err != nil {
+						t.Fatal(err)
+					}
+					events = append(events, event)
+				}
+				if len(events[0].Added) != 1 || events[0].Added[0].Name != "shadow" || len(events[0].Changed) != 1 || len(events[0].Removed) != 0 {
+					t.Errorf("second event = %+v, want shadow added and legacy changed", events[0].Diff)
+				}
+				if !events[1].Empty() || events[1].Time.IsZero() {
+					t.Errorf("third event = %+v, want a heartbeat", events[1])
+				}
+			},
+		},
+		{
+			name: "every cycle in full",
+			args: []string{"--watch", "1m"},
+			check: func(t *testing.T, out string) {
+				if n := strings.Count(out, "shadow"); n != 2 || strings.Contains(out, "No changes") {
+					t.Errorf("output =\n%s\nwant both later cycles rendered in full", out)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := testFlags(t, tt.args...)
+			f.watch = time.Millisecond
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			var out bytes.Buffer
+			if err := runWatch(ctx, f, scriptedScans(cancel, cycles), io.Discard, &out); err != nil {
+				t.Fatal(err)
+			}
+			tt.check(t, out.String())
+		})
+	}
+}
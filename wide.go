@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"shift-left-shuffle/scanner"
+)
+
+// printWide writes one table row per cluster with the extended columns,
+// followed by the describe errors of undescribed clusters. Ages are measured
+// at the scan time so saved scans render the same way.
+func printWide(w io.Writer, result *scanner.ScanResult) error {
+	now := result.GeneratedAt
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tREGION\tVERSION\tPLATFORM\tPUBLIC\tOIDC\tNODEGROUPS\tAGE")
+	for _, c := range result.Clusters {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			c.Name, c.Region, orDash(c.Version), orDash(c.PlatformVersion), publicAccess(&c),
+			orDash(strings.TrimPrefix(c.OIDCIssuer, "https://")), countOrDash(c.NodegroupCount), clusterAge(c.CreatedAt, now))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	for _, c := range result.Clusters {
+		if c.DescribeError != "" {
+			if _, err := fmt.Fprintf(w, "%s (%s): undescribed: %s\n", c.Name, c.Region, c.DescribeError); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// publicAccess summarizes endpoint exposure: open (0.0.0.0/0), yes (public but
+// restricted) or no
+func publicAccess(c *scanner.Cluster) string {
+	switch {
+	case c.OpenEndpoint():
+		return "open"
+	case c.EndpointPublicAccess:
+		return "yes"
+	default:
+		return "no"
+	}
+}
+
+// clusterAge formats the time since created in whole days, or hours for
+// clusters younger than a day
+func clusterAge(created *time.Time, now time.Time) string {
+	if created == nil {
+		return "-"
+	}
+	age := now.Sub(*created)
+	if age < 24*time.Hour {
+		return strconv.Itoa(max(int(age.Hours()), 0)) + "h"
+	}
+	return strconv.Itoa(int(age.Hours()/24)) + "d"
+}
+
+// orDash returns s, or "-" when it is empty
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// countOrDash formats an optional count
+func countOrDash(n *int) string {
+	if n == nil {
+		return "-"
+	}
+	return strconv.Itoa(*n)
+}
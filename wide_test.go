@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"shift-left-shuffle/scanner"
+)
+
+func TestClusterAge(t *testing.T) {
+	now := time.Date(2025, 1, 10, 12, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) *time.Time {
+		created := now.Add(-d)
+		return &created
+	}
+	tests := []struct {
+		name    string
+		created *time.Time
+		want    string
+	}{
+		{name: "unknown", want: "-"},
+		{name: "hours", created: at(5*time.Hour + 30*time.Minute), want: "5h"},
+		{name: "clock skew", created: at(-time.Hour), want: "0h"},
+		{name: "one day", created: at(24 * time.Hour), want: "1d"},
+		{name: "days", created: at(40*24*time.Hour + 23*time.Hour), want: "40d"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := clusterAge(tt.created, now); got != tt.want {
+				t.Errorf("clusterAge = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPublicAccess(t *testing.T) {
+	tests := []struct {
+		cluster scanner.Cluster
+		want    string
+	}{
+		{cluster: scanner.Cluster{}, want: "no"},
+		{cluster: scanner.Cluster{EndpointPublicAccess: true, PublicAccessCidrs: []string{"203.0.113.0/24"}}, want: "yes"},
+		{cluster: scanner.Cluster{EndpointPublicAccess: true, PublicAccessCidrs: []string{"203.0.113.0/24", "0.0.0.0/0"}}, want: "open"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := publicAccess(&tt.cluster); got != tt.want {
+				t.Errorf("publicAccess(%+v) = %q, want %q", tt.cluster, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrintWide(t *testing.T) {
+	result := sampleResult()
+	nodegroups := 3
+	result.Clusters[0].PlatformVersion = "eks.12"
+	result.Clusters[0].OIDCIssuer = "https://oidc.eks.us-east-1.amazonaws.com/id/ABC"
+	result.Clusters[0].EndpointPublicAccess = true
+	result.Clusters[0].PublicAccessCidrs = []string{"0.0.0.0/0"}
+	result.Clusters[0].NodegroupCount = &nodegroups
+	result.Clusters = append(result.Clusters, scanner.Cluster{Name: "hidden", Region: "us-east-1", DescribeError: "AccessDenied"})
+
+	var buf bytes.Buffer
+	if err := printWide(&buf, result); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	want := [][]string{
+		{"NAME", "REGION", "VERSION", "PLATFORM", "PUBLIC", "OIDC", "NODEGROUPS", "AGE"},
+		{"prod", "us-east-1", "1.31", "eks.12", "open", "oidc.eks.us-east-1.amazonaws.com/id/ABC", "3", "306d"},
+		{"legacy", "eu-west-1", "1.24", "-", "no", "-", "-", "306d"},
+		{"hidden", "us-east-1", "-", "-", "no", "-", "-", "-"},
+	}
+	if len(lines) != len(want)+1 {
+		t.Fatalf("wide output has %d lines, want %d:\n%s", len(lines), len(want)+1, buf.String())
+	}
+	for i, row := range want {
+		if got := strings.Fields(lines[i]); strings.Join(got, " ") != strings.Join(row, " ") {
+			t.Errorf("row %d = %q, want %q", i, got, row)
+		}
+	}
+	if got := lines[len(want)]; got != "hidden (us-east-1): undescribed: AccessDenied" {
+		t.Errorf("describe error line = %q", got)
+	}
+}